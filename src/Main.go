@@ -1,13 +1,28 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"java/diagnostics"
+	"java/evaluator"
+	"java/lexer"
+	"java/object"
+	"java/parser"
 	"java/repl"
+	"java/typecheck"
 	"os"
 	"os/user"
+	"path/filepath"
 )
 
 func main() {
+	format := flag.String("diagnostics", "text", "how to print diagnostics: text or json")
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		os.Exit(runFile(flag.Arg(0), *format))
+	}
+
 	logo := `
      ____.                    
     |    |____ ___  _______   
@@ -26,3 +41,58 @@ func main() {
 	fmt.Printf("Feel free to type in commands\n")
 	repl.Start(os.Stdin, os.Stdout)
 }
+
+// runFile runs the program in path and returns the process exit code.
+func runFile(path string, format string) int {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	src := diagnostics.NewSource(path, string(text))
+
+	p := parser.New(lexer.New(src.Text))
+	program := p.ParseProgram()
+
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		printDiagnostics(diags, src, format)
+		return 1
+	}
+	if diags := typecheck.New().Check(program); len(diags) != 0 {
+		printDiagnostics(diags, src, format)
+		return 1
+	}
+
+	evaluator.SourceFile = filepath.Base(path)
+	result := evaluator.Eval(program, object.NewEnvironment())
+	if result, ok := result.(*object.Error); ok {
+		if result.Exception != nil {
+			fmt.Fprint(os.Stderr, "Exception in thread \"main\" "+evaluator.StackTrace(result.Exception))
+		} else {
+			fmt.Fprintln(os.Stderr, result.Message)
+		}
+		return 1
+	}
+	return 0
+}
+
+func printDiagnostics(diags []*diagnostics.Diagnostic, src *diagnostics.Source, format string) {
+	if format == "json" {
+		out, err := diagnostics.JSON(diags, src)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		fmt.Println(string(out))
+		return
+	}
+
+	for _, d := range diags {
+		fmt.Fprint(os.Stderr, diagnostics.Render(d, src))
+	}
+	if len(diags) == 1 {
+		fmt.Fprintln(os.Stderr, "1 error")
+	} else {
+		fmt.Fprintf(os.Stderr, "%d errors\n", len(diags))
+	}
+}
@@ -41,42 +41,26 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
-type IfExpression struct {
+// IfStatement is `if (Condition) Consequence else Alternative`. An
+// `else if` chain is an IfStatement whose Alternative is another IfStatement.
+type IfStatement struct {
 	Token       tokens.Token // The 'if' token
 	Condition   Expression
-	Branches    []ElseIfExpression
-	Consequence *BlockStatement
-	Alternative *BlockStatement
+	Consequence Statement
+	Alternative Statement // nil when there is no else branch
 }
 
-type ElseIfExpression struct {
-	Token       tokens.Token // The else if token
-	Condition   Expression
-	Consequence *BlockStatement
-}
-
-func (eif *ElseIfExpression) expressionNode()      {}
-func (eif *ElseIfExpression) TokenLiteral() string { return eif.Token.Literal }
-func (eif *ElseIfExpression) String() string {
-	var out bytes.Buffer
-	out.WriteString("else if")
-	out.WriteString(eif.Condition.String())
-	out.WriteString(" ")
-	out.WriteString(eif.Consequence.String())
-	return out.String()
-}
-
-func (ie *IfExpression) expressionNode()      {}
-func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
-func (ie *IfExpression) String() string {
+func (is *IfStatement) statementNode()       {}
+func (is *IfStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *IfStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("if")
-	out.WriteString(ie.Condition.String())
+	out.WriteString(is.Condition.String())
 	out.WriteString(" ")
-	out.WriteString(ie.Consequence.String())
-	if ie.Alternative != nil {
+	out.WriteString(is.Consequence.String())
+	if is.Alternative != nil {
 		out.WriteString("else ")
-		out.WriteString(ie.Alternative.String())
+		out.WriteString(is.Alternative.String())
 	}
 	return out.String()
 }
@@ -119,10 +103,27 @@ func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
 
+type NullLiteral struct {
+	Token tokens.Token
+}
+
+func (nl *NullLiteral) expressionNode()      {}
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) String() string       { return nl.Token.Literal }
+
 type CallExpression struct {
 	Token     tokens.Token // The '(' token
-	Function  Expression   // Identifier or FunctionLiteral
+	Function  Expression   // Identifier, MemberExpression or FunctionLiteral
 	Arguments []Expression
+
+	// Signature is the parameter list of the method chosen by overload
+	// resolution, e.g. "(int,String)". It is set by the type checker and
+	// empty if the call was not resolved.
+	Signature string
+	// Receiver is the static type of the object the method is called on,
+	// or of the class the call is in for an unqualified call. It is set
+	// by the type checker and empty if the type is not known.
+	Receiver string
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -141,26 +142,128 @@ func (ce *CallExpression) String() string {
 }
 
 type Parameter struct {
-	DataType      tokens.Token
+	Modifiers     []tokens.Token // only final is allowed
+	DataType      *Type          // nil for a lambda parameter whose type is inferred
+	Variadic      bool           // declared with ..., as in `int... xs`
 	ParameterName *Identifier
 }
 
-func (p *Parameter) expressionNode()      {}
-func (p *Parameter) TokenLiteral() string { return p.DataType.Literal }
+func (p *Parameter) expressionNode() {}
+func (p *Parameter) TokenLiteral() string {
+	if p.DataType == nil {
+		return p.ParameterName.TokenLiteral()
+	}
+	return p.DataType.TokenLiteral()
+}
 func (p *Parameter) String() string {
+	if p.DataType == nil {
+		return p.ParameterName.Value
+	}
 	var out bytes.Buffer
-	out.WriteString(p.DataType.Literal + " ")
+	for _, m := range p.Modifiers {
+		out.WriteString(m.Literal + " ")
+	}
+	out.WriteString(p.TypeString() + " ")
 	out.WriteString(p.ParameterName.Value)
 	return out.String()
 }
 
+// TypeString is the declared type of the parameter, e.g. "int...".
+func (p *Parameter) TypeString() string {
+	if p.Variadic {
+		return p.DataType.String() + "..."
+	}
+	return p.DataType.String()
+}
+
+// IsVariadic reports whether params ends with a variable arity parameter.
+func IsVariadic(params []*Parameter) bool {
+	return len(params) > 0 && params[len(params)-1].Variadic
+}
+
+// Type is a type as written in a declaration, e.g. `int`, `Point`,
+// `String[]` or `Map<String, List<? extends Number>>`.
+type Type struct {
+	Token      tokens.Token // the first token of the type
+	Name       string       // "?" for a wildcard
+	Arguments  []*Type      // empty but not nil for the diamond, as in `new Box<>()`
+	Dimensions int          // the number of [] pairs
+
+	// Bound is the bound of a wildcard, if any: `? extends Bound`, or
+	// `? super Bound` when Super is set.
+	Bound *Type
+	Super bool
+}
+
+func (t *Type) TokenLiteral() string { return t.Token.Literal }
+func (t *Type) String() string {
+	var out bytes.Buffer
+	out.WriteString(t.Name)
+	if t.Bound != nil {
+		if t.Super {
+			out.WriteString(" super ")
+		} else {
+			out.WriteString(" extends ")
+		}
+		out.WriteString(t.Bound.String())
+	}
+	if t.Arguments != nil {
+		out.WriteString(typesString("<", t.Arguments, ">"))
+	}
+	out.WriteString(strings.Repeat("[]", t.Dimensions))
+	return out.String()
+}
+
+// TypeParameter is a type parameter of a generic class or method, e.g.
+// `T extends Comparable<T>`.
+type TypeParameter struct {
+	Name   *Identifier
+	Bounds []*Type // separated by '&' in the source
+}
+
+func (tp *TypeParameter) TokenLiteral() string { return tp.Name.Token.Literal }
+func (tp *TypeParameter) String() string {
+	if len(tp.Bounds) == 0 {
+		return tp.Name.Value
+	}
+	bounds := []string{}
+	for _, b := range tp.Bounds {
+		bounds = append(bounds, b.String())
+	}
+	return tp.Name.Value + " extends " + strings.Join(bounds, " & ")
+}
+
+// typeParametersString is the type parameters of a declaration, e.g.
+// "<T, U extends T>", or "" if it has none.
+func typeParametersString(params []*TypeParameter) string {
+	if len(params) == 0 {
+		return ""
+	}
+	names := []string{}
+	for _, p := range params {
+		names = append(names, p.String())
+	}
+	return "<" + strings.Join(names, ", ") + ">"
+}
+
+func typesString(open string, types []*Type, close string) string {
+	names := []string{}
+	for _, t := range types {
+		names = append(names, t.String())
+	}
+	return open + strings.Join(names, ", ") + close
+}
+
 type FunctionLiteral struct {
-	Name       *Identifier
-	Accessor   tokens.Token // e.g PUBLIC/PRIVATE
-	ReturnType tokens.Token // e.g String, int,...
-	Token      tokens.Token // The Accessor token
-	Parameters []*Parameter
-	Body       *BlockStatement
+	Name           *Identifier
+	Accessor       tokens.Token   // e.g PUBLIC/PRIVATE
+	Modifiers      []tokens.Token // all modifiers, including the accessor
+	TypeParameters []*TypeParameter
+	ReturnType     *Type        // e.g String, int, void
+	Token          tokens.Token // the first token of the declaration
+	Parameters     []*Parameter
+	Throws         []*Type         // the exceptions in the throws clause
+	Body           *BlockStatement // nil for abstract and native methods
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
@@ -171,32 +274,62 @@ func (fl *FunctionLiteral) String() string {
 	for _, p := range fl.Parameters {
 		params = append(params, p.String())
 	}
-	out.WriteString(fl.TokenLiteral() + " ")
-	out.WriteString(fl.ReturnType.Literal + " ")
+	out.WriteString(modifiersString(fl.Modifiers))
+	if len(fl.TypeParameters) > 0 {
+		out.WriteString(typeParametersString(fl.TypeParameters) + " ")
+	}
+	out.WriteString(fl.ReturnType.String() + " ")
 	out.WriteString(fl.Name.Value)
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") ")
-	out.WriteString(fl.Body.String())
+	out.WriteString(")")
+	out.WriteString(throwsString(fl.Throws))
+	if fl.Body == nil {
+		out.WriteString(";")
+	} else {
+		out.WriteString(" " + fl.Body.String())
+	}
 	return out.String()
 }
 
-type IntegerAssignmentStatement struct {
-	Token tokens.Token // the token.INT token
-	Name  *Identifier
-	Value Expression
+// HasModifier reports whether modifiers contains a modifier of type t.
+func HasModifier(modifiers []tokens.Token, t tokens.TokenType) bool {
+	for _, m := range modifiers {
+		if m.Type == t {
+			return true
+		}
+	}
+	return false
 }
 
-type BooleanAssignmentStatement struct {
-	Token tokens.Token // token.TRUE
-	Name  *Identifier
-	Value Expression
+func throwsString(throws []*Type) string {
+	if len(throws) == 0 {
+		return ""
+	}
+	return typesString(" throws ", throws, "")
 }
 
-type StringAssignmentStatement struct {
-	Token tokens.Token // the token.STRING token
+func modifiersString(modifiers []tokens.Token) string {
+	var out bytes.Buffer
+	for _, m := range modifiers {
+		out.WriteString(m.Literal + " ")
+	}
+	return out.String()
+}
+
+// VariableDeclaration declares local variables of one type, each with an
+// optional initializer, as in `final int a = 1, b;`.
+type VariableDeclaration struct {
+	Token       tokens.Token // the first token of the declaration
+	Modifiers   []tokens.Token
+	Type        *Type
+	Declarators []*Declarator
+}
+
+// Declarator declares one of the variables of a VariableDeclaration.
+type Declarator struct {
 	Name  *Identifier
-	Value Expression
+	Value Expression // nil when the variable has no initializer
 }
 
 type ReturnStatement struct {
@@ -289,6 +422,15 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+type DoubleLiteral struct {
+	Token tokens.Token
+	Value float64
+}
+
+func (dl *DoubleLiteral) expressionNode()      {}
+func (dl *DoubleLiteral) TokenLiteral() string { return dl.Token.Literal }
+func (dl *DoubleLiteral) String() string       { return dl.Token.Literal }
+
 type IntegerLiteral struct {
 	Token tokens.Token
 	Value int64
@@ -298,74 +440,626 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
-func (bs *BooleanAssignmentStatement) String() string {
+func (vd *VariableDeclaration) String() string {
 	var out bytes.Buffer
-	out.WriteString(bs.TokenLiteral() + " ")
-	out.WriteString(bs.Name.String())
-	out.WriteString(" = ")
-
-	if bs.Value != nil {
-		out.WriteString(bs.Value.String())
+	out.WriteString(modifiersString(vd.Modifiers))
+	out.WriteString(vd.Type.String() + " ")
+	for i, d := range vd.Declarators {
+		if i > 0 {
+			out.WriteString(", ")
+		}
+		out.WriteString(d.Name.String())
+		if d.Value != nil {
+			out.WriteString(" = " + d.Value.String())
+		}
 	}
+	out.WriteString(";")
+	return out.String()
+}
 
+func (rs *ReturnStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(rs.TokenLiteral() + " ")
+	if rs.ReturnValue != nil {
+		out.WriteString(rs.ReturnValue.String())
+	}
 	out.WriteString(";")
 	return out.String()
 }
 
-func (is *IntegerAssignmentStatement) String() string {
+func (i *Identifier) String() string { return i.Value }
+
+func (rs *ReturnStatement) statementNode()       {}
+func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+
+func (vd *VariableDeclaration) statementNode()       {}
+func (vd *VariableDeclaration) TokenLiteral() string { return vd.Token.Literal }
+
+type Identifier struct {
+	Token tokens.Token // the token.IDENT token
+	Value string
+}
+
+func (i *Identifier) expressionNode()      {}
+func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+
+// ClassDeclaration is `class Name { members }` or, when Token is the
+// 'interface', 'enum' or 'record' token, `interface Name { members }`,
+// `enum Name { constants; members }` or `record Name(components) {
+// members }`. The parser declares the members an enum has implicitly: a
+// field for each constant, whose value is the EnumConstant, and the
+// methods values() and valueOf(String), whose token is the 'enum' token.
+// The members a record has implicitly, a field and an accessor for each
+// component and the canonical constructor, have the 'record' token.
+type ClassDeclaration struct {
+	Token          tokens.Token // the 'class', 'interface', 'enum' or 'record' token
+	Modifiers      []tokens.Token
+	Name           *Identifier
+	TypeParameters []*TypeParameter
+	Components     []*Parameter // of a record
+	SuperClass     *Type        // nil when the class does not extend another
+	Interfaces     []*Type      // implemented by a class or extended by an interface
+	Permits        []*Type      // the permitted subtypes of a sealed class
+	Fields         []*FieldDeclaration
+	Constructors   []*ConstructorDeclaration
+	Methods        []*FunctionLiteral
+	Initializers   []*BlockStatement   // instance initializer blocks
+	Classes        []*ClassDeclaration // the member classes and interfaces
+
+	StaticInitializers []*BlockStatement
+}
+
+func (cd *ClassDeclaration) statementNode()       {}
+func (cd *ClassDeclaration) TokenLiteral() string { return cd.Token.Literal }
+func (cd *ClassDeclaration) IsInterface() bool    { return cd.Token.Type == tokens.INTERFACE }
+func (cd *ClassDeclaration) IsEnum() bool         { return cd.Token.Type == tokens.ENUM }
+func (cd *ClassDeclaration) IsRecord() bool       { return cd.Token.Type == tokens.RECORD }
+func (cd *ClassDeclaration) IsSealed() bool       { return HasModifier(cd.Modifiers, tokens.SEALED) }
+
+// IsFinal reports whether the class may not be extended: it is declared
+// final, or it is an enum or a record, which are implicitly final.
+func (cd *ClassDeclaration) IsFinal() bool {
+	return HasModifier(cd.Modifiers, tokens.FINAL) || cd.IsEnum() || cd.IsRecord()
+}
+
+func (cd *ClassDeclaration) String() string {
+	var out bytes.Buffer
+	out.WriteString(modifiersString(cd.Modifiers))
+	out.WriteString(cd.TokenLiteral() + " " + cd.Name.Value + typeParametersString(cd.TypeParameters))
+	if cd.IsRecord() {
+		components := []string{}
+		for _, c := range cd.Components {
+			components = append(components, c.String())
+		}
+		out.WriteString("(" + strings.Join(components, ", ") + ")")
+	}
+	out.WriteString(" ")
+	if cd.SuperClass != nil && !cd.IsEnum() && !cd.IsRecord() {
+		out.WriteString("extends " + cd.SuperClass.String() + " ")
+	}
+	if len(cd.Interfaces) > 0 {
+		names := []string{}
+		for _, i := range cd.Interfaces {
+			names = append(names, i.String())
+		}
+		if cd.IsInterface() {
+			out.WriteString("extends ")
+		} else {
+			out.WriteString("implements ")
+		}
+		out.WriteString(strings.Join(names, ", ") + " ")
+	}
+	if len(cd.Permits) > 0 {
+		out.WriteString(typesString("permits ", cd.Permits, " "))
+	}
+	out.WriteString(cd.body())
+	return out.String()
+}
+
+// body is the members of the class between braces.
+func (cd *ClassDeclaration) body() string {
 	var out bytes.Buffer
-	out.WriteString(is.TokenLiteral() + " ")
-	out.WriteString(is.Name.String())
-	out.WriteString(" = ")
-	if is.Value != nil {
-		out.WriteString(is.Value.String())
+	out.WriteString("{ ")
+	if cd.IsEnum() {
+		constants := []string{}
+		for _, f := range cd.Fields {
+			if constant, ok := f.Value.(*EnumConstant); ok {
+				constants = append(constants, constant.String())
+			}
+		}
+		out.WriteString(strings.Join(constants, ", ") + "; ")
+	}
+	for _, f := range cd.Fields {
+		if _, ok := f.Value.(*EnumConstant); !ok && f.Token.Type != tokens.RECORD {
+			out.WriteString(f.String() + " ")
+		}
+	}
+	for _, b := range cd.StaticInitializers {
+		out.WriteString("static {" + b.String() + "} ")
+	}
+	for _, b := range cd.Initializers {
+		out.WriteString("{" + b.String() + "} ")
+	}
+	for _, c := range cd.Constructors {
+		if c.Token.Type != tokens.RECORD {
+			out.WriteString(c.String() + " ")
+		}
+	}
+	for _, m := range cd.Methods {
+		if m.Token.Type != tokens.ENUM && m.Token.Type != tokens.RECORD {
+			out.WriteString(m.String() + " ")
+		}
+	}
+	for _, c := range cd.Classes {
+		out.WriteString(c.String() + " ")
 	}
+	out.WriteString("}")
+	return out.String()
+}
+
+type FieldDeclaration struct {
+	Token     tokens.Token // the first token of the declaration
+	Modifiers []tokens.Token
+	Type      *Type
+	Name      *Identifier
+	Value     Expression // nil when the field has no initializer
+}
 
+func (fd *FieldDeclaration) statementNode()       {}
+func (fd *FieldDeclaration) TokenLiteral() string { return fd.Token.Literal }
+func (fd *FieldDeclaration) String() string {
+	var out bytes.Buffer
+	out.WriteString(modifiersString(fd.Modifiers))
+	out.WriteString(fd.Type.String() + " " + fd.Name.Value)
+	if fd.Value != nil {
+		out.WriteString(" = " + fd.Value.String())
+	}
 	out.WriteString(";")
 	return out.String()
 }
 
-func (ss *StringAssignmentStatement) String() string {
+type ConstructorDeclaration struct {
+	Token          tokens.Token // the first token of the declaration
+	Modifiers      []tokens.Token
+	TypeParameters []*TypeParameter
+	Name           *Identifier
+	Parameters     []*Parameter
+	Throws         []*Type
+	Body           *BlockStatement
+
+	// Compact is set for the compact canonical constructor of a record,
+	// `Name { ... }`. The parser gives it the components as parameters
+	// and a body that runs the block written and then assigns the fields.
+	Compact bool
+}
+
+func (cd *ConstructorDeclaration) statementNode()       {}
+func (cd *ConstructorDeclaration) TokenLiteral() string { return cd.Token.Literal }
+func (cd *ConstructorDeclaration) String() string {
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range cd.Parameters {
+		params = append(params, p.String())
+	}
+	out.WriteString(modifiersString(cd.Modifiers))
+	if len(cd.TypeParameters) > 0 {
+		out.WriteString(typeParametersString(cd.TypeParameters) + " ")
+	}
+	out.WriteString(cd.Name.Value)
+	if cd.Compact {
+		out.WriteString(" " + cd.Body.Statements[0].String())
+		return out.String()
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(")")
+	out.WriteString(throwsString(cd.Throws) + " ")
+	out.WriteString(cd.Body.String())
+	return out.String()
+}
+
+// NewExpression is `new Type(Arguments)`, or `Outer.new Type(Arguments)`
+// for an inner class, whose instances belong to the instance Outer. With a
+// Body, it creates an instance of an anonymous class extending or
+// implementing Type.
+type NewExpression struct {
+	Token     tokens.Token // the 'new' token
+	Outer     Expression   // nil when the instance is not qualified
+	Type      *Type
+	Arguments []Expression
+	Body      *ClassDeclaration
+	Signature string // of the constructor chosen, as in CallExpression
+}
+
+func (ne *NewExpression) expressionNode()      {}
+func (ne *NewExpression) TokenLiteral() string { return ne.Token.Literal }
+func (ne *NewExpression) String() string {
+	args := []string{}
+	for _, a := range ne.Arguments {
+		args = append(args, a.String())
+	}
 	var out bytes.Buffer
-	out.WriteString(ss.TokenLiteral() + " ")
-	out.WriteString(ss.Name.String())
-	out.WriteString(" = ")
-	if ss.Value != nil {
-		out.WriteString(ss.Value.String())
+	if ne.Outer != nil {
+		out.WriteString(ne.Outer.String() + ".")
 	}
+	out.WriteString("new " + ne.Type.String() + "(" + strings.Join(args, ", ") + ")")
+	if ne.Body != nil {
+		out.WriteString(" " + ne.Body.body())
+	}
+	return out.String()
+}
 
-	out.WriteString(";")
+// ThisExpression is `this`, or `Qualifier.this` for the instance of an
+// enclosing class that an instance of an inner class belongs to.
+type ThisExpression struct {
+	Token     tokens.Token // the 'this' token
+	Qualifier *Identifier  // nil for an unqualified this
+}
+
+func (te *ThisExpression) expressionNode()      {}
+func (te *ThisExpression) TokenLiteral() string { return te.Token.Literal }
+func (te *ThisExpression) String() string {
+	if te.Qualifier != nil {
+		return te.Qualifier.Value + "." + te.Token.Literal
+	}
+	return te.Token.Literal
+}
+
+// SuperExpression is `super` in `super.method()`, `super.field` and
+// `super(...)`, or `Qualifier.super` in `Interface.super.method()`.
+type SuperExpression struct {
+	Token     tokens.Token // the 'super' token
+	Qualifier *Identifier  // nil for an unqualified super
+}
+
+func (se *SuperExpression) expressionNode()      {}
+func (se *SuperExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SuperExpression) String() string {
+	if se.Qualifier != nil {
+		return se.Qualifier.Value + "." + se.Token.Literal
+	}
+	return se.Token.Literal
+}
+
+// MemberExpression is `Object.Property`, a field access or, as the
+// Function of a CallExpression, a method call.
+type MemberExpression struct {
+	Token    tokens.Token // the '.' token
+	Object   Expression
+	Property *Identifier
+	Receiver string // the static type of Object, as in CallExpression
+
+	// TypeArguments are the explicit type arguments of a generic method
+	// call, as in `Util.<String>first(xs)`.
+	TypeArguments []*Type
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	if me.TypeArguments != nil {
+		return me.Object.String() + "." + typesString("<", me.TypeArguments, ">") + me.Property.Value
+	}
+	return me.Object.String() + "." + me.Property.Value
+}
+
+// IndexExpression is `Left[Index]`, an array access.
+type IndexExpression struct {
+	Token tokens.Token // the '[' token
+	Left  Expression
+	Index Expression
+	Array string // the static type of Left, e.g. "int[]", as in CallExpression
+}
+
+func (ie *IndexExpression) expressionNode()      {}
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) String() string {
+	return ie.Left.String() + "[" + ie.Index.String() + "]"
+}
+
+// AssignmentExpression is `Target = Value`, where Target is an Identifier,
+// a MemberExpression or an IndexExpression.
+type AssignmentExpression struct {
+	Token  tokens.Token // the '=' token
+	Target Expression
+	Value  Expression
+}
+
+func (ae *AssignmentExpression) expressionNode()      {}
+func (ae *AssignmentExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignmentExpression) String() string {
+	return ae.Target.String() + " = " + ae.Value.String()
+}
+
+// LambdaExpression is `Parameters -> Body`, as in `x -> x * 2` or
+// `(int a, int b) -> { return a + b; }`.
+type LambdaExpression struct {
+	Token      tokens.Token // the first token, a name or the '('
+	Parameters []*Parameter
+	Body       Node // an Expression or a *BlockStatement
+
+	// Interface is the functional interface the lambda implements. It is
+	// set by the type checker and empty if the interface is not known.
+	Interface string
+}
+
+func (le *LambdaExpression) expressionNode()      {}
+func (le *LambdaExpression) TokenLiteral() string { return le.Token.Literal }
+func (le *LambdaExpression) String() string {
+	params := []string{}
+	for _, p := range le.Parameters {
+		params = append(params, p.String())
+	}
+	body := le.Body.String()
+	if _, ok := le.Body.(*BlockStatement); ok {
+		body = "{" + body + "}"
+	}
+	return "(" + strings.Join(params, ", ") + ") -> " + body
+}
+
+// MethodReference is `Target::Name`, as in `String::length`, `this::f`
+// or, where Name is "new", the constructor reference `Point::new`.
+type MethodReference struct {
+	Token     tokens.Token // the '::' token
+	Target    Expression
+	Name      *Identifier
+	Interface string // as in LambdaExpression
+}
+
+func (mr *MethodReference) expressionNode()      {}
+func (mr *MethodReference) TokenLiteral() string { return mr.Token.Literal }
+func (mr *MethodReference) String() string {
+	return mr.Target.String() + "::" + mr.Name.Value
+}
+
+// EnumConstant is a constant of an enum, `NAME(Arguments) { Body }`, where
+// the arguments and the body are optional. It is the value of the field
+// the constant is declared as.
+type EnumConstant struct {
+	Token     tokens.Token // the name
+	Name      *Identifier
+	Ordinal   int
+	Arguments []Expression
+	Signature string // of the constructor chosen, as in CallExpression
+
+	// Body is the class of a constant with a class body, which extends
+	// the enum, or nil.
+	Body *ClassDeclaration
+}
+
+func (ec *EnumConstant) expressionNode()      {}
+func (ec *EnumConstant) TokenLiteral() string { return ec.Token.Literal }
+func (ec *EnumConstant) String() string {
+	var out bytes.Buffer
+	out.WriteString(ec.Name.Value)
+	if len(ec.Arguments) > 0 {
+		args := []string{}
+		for _, a := range ec.Arguments {
+			args = append(args, a.String())
+		}
+		out.WriteString("(" + strings.Join(args, ", ") + ")")
+	}
+	if ec.Body != nil {
+		body := ec.Body.String()
+		out.WriteString(" " + body[strings.Index(body, "{"):])
+	}
 	return out.String()
 }
 
-func (rs *ReturnStatement) String() string {
+// SwitchStatement is `switch (Value) { Cases }`.
+type SwitchStatement struct {
+	Token tokens.Token // the 'switch' token
+	Value Expression
+	Cases []*SwitchCase
+}
+
+func (ss *SwitchStatement) statementNode()       {}
+func (ss *SwitchStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SwitchStatement) String() string       { return switchString(ss.Value, ss.Cases) }
+
+// SwitchExpression is a switch used as an expression, whose value is that
+// of the case selected.
+type SwitchExpression struct {
+	Token tokens.Token // the 'switch' token
+	Value Expression
+	Cases []*SwitchCase
+}
+
+func (se *SwitchExpression) expressionNode()      {}
+func (se *SwitchExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SwitchExpression) String() string       { return switchString(se.Value, se.Cases) }
+
+// SwitchCase is a rule of a switch, `case A, B -> Body` or, without
+// labels, `default -> Body`.
+type SwitchCase struct {
+	Token  tokens.Token // the 'case' or 'default' token
+	Labels []Expression // empty for default
+	Guard  Expression   // the condition after 'when', or nil
+	Body   Node         // an Expression, a *BlockStatement or a *ThrowStatement
+}
+
+func (sc *SwitchCase) String() string {
 	var out bytes.Buffer
-	out.WriteString(rs.TokenLiteral() + " ")
-	if rs.ReturnValue != nil {
-		out.WriteString(rs.ReturnValue.String())
+	if len(sc.Labels) == 0 {
+		out.WriteString("default")
+	} else {
+		labels := []string{}
+		for _, l := range sc.Labels {
+			labels = append(labels, l.String())
+		}
+		out.WriteString("case " + strings.Join(labels, ", "))
+	}
+	if sc.Guard != nil {
+		out.WriteString(" when " + sc.Guard.String())
+	}
+	out.WriteString(" -> ")
+	switch body := sc.Body.(type) {
+	case *BlockStatement:
+		out.WriteString("{" + body.String() + "}")
+	case Expression:
+		out.WriteString(body.String() + ";")
+	default:
+		out.WriteString(body.String())
 	}
-	out.WriteString(";")
 	return out.String()
 }
 
-func (i *Identifier) String() string { return i.Value }
+func switchString(value Expression, cases []*SwitchCase) string {
+	var out bytes.Buffer
+	out.WriteString("switch (" + value.String() + ") { ")
+	for _, c := range cases {
+		out.WriteString(c.String() + " ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
 
-func (rs *ReturnStatement) statementNode()       {}
-func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+// InstanceofExpression is `Left instanceof Pattern`, which tests whether
+// the value of Left matches the pattern.
+type InstanceofExpression struct {
+	Token   tokens.Token // the 'instanceof' token
+	Left    Expression
+	Pattern Expression // a *TypePattern or a *RecordPattern
+}
 
-func (bs *BooleanAssignmentStatement) statementNode()       {}
-func (bs *BooleanAssignmentStatement) TokenLiteral() string { return bs.Token.Literal }
+func (ie *InstanceofExpression) expressionNode()      {}
+func (ie *InstanceofExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InstanceofExpression) String() string {
+	return "(" + ie.Left.String() + " instanceof " + ie.Pattern.String() + ")"
+}
+
+// TypePattern is `Type Name`, which matches a value of the type and binds
+// it to a new variable. Without a name, as in `o instanceof String`, it
+// only tests the type. In a record pattern the type may be `var`, the type
+// of the record component.
+type TypePattern struct {
+	Modifiers []tokens.Token // only final is allowed
+	Type      *Type
+	Name      *Identifier // nil when the pattern binds no variable
+}
 
-func (ls *IntegerAssignmentStatement) statementNode()       {}
-func (ls *IntegerAssignmentStatement) TokenLiteral() string { return ls.Token.Literal }
+func (tp *TypePattern) expressionNode()      {}
+func (tp *TypePattern) TokenLiteral() string { return tp.Type.TokenLiteral() }
+func (tp *TypePattern) String() string {
+	if tp.Name == nil {
+		return modifiersString(tp.Modifiers) + tp.Type.String()
+	}
+	return modifiersString(tp.Modifiers) + tp.Type.String() + " " + tp.Name.Value
+}
 
-func (ls *StringAssignmentStatement) statementNode()       {}
-func (ls *StringAssignmentStatement) TokenLiteral() string { return ls.Token.Literal }
+// RecordPattern is `Type(Patterns)`, which matches a record of the type
+// whose components match the patterns.
+type RecordPattern struct {
+	Type     *Type
+	Patterns []Expression
+}
 
-type Identifier struct {
-	Token tokens.Token // the token.IDENT token
-	Value string
+func (rp *RecordPattern) expressionNode()      {}
+func (rp *RecordPattern) TokenLiteral() string { return rp.Type.TokenLiteral() }
+func (rp *RecordPattern) String() string {
+	patterns := []string{}
+	for _, p := range rp.Patterns {
+		patterns = append(patterns, p.String())
+	}
+	return rp.Type.String() + "(" + strings.Join(patterns, ", ") + ")"
 }
 
-func (i *Identifier) expressionNode()      {}
-func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+// IsPattern reports whether e is a type pattern or a record pattern.
+func IsPattern(e Expression) bool {
+	switch e.(type) {
+	case *TypePattern, *RecordPattern:
+		return true
+	}
+	return false
+}
+
+// YieldStatement is `yield Value;`, which gives the value of the switch
+// expression whose case it is in.
+type YieldStatement struct {
+	Token tokens.Token // the 'yield' identifier
+	Value Expression
+}
+
+func (ys *YieldStatement) statementNode()       {}
+func (ys *YieldStatement) TokenLiteral() string { return ys.Token.Literal }
+func (ys *YieldStatement) String() string       { return "yield " + ys.Value.String() + ";" }
+
+// ThrowStatement is `throw Value;`.
+type ThrowStatement struct {
+	Token tokens.Token // the 'throw' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *ThrowStatement) String() string {
+	return "throw " + ts.Value.String() + ";"
+}
+
+// TryStatement is `try Block`, followed by any catch clauses and an
+// optional finally block. A try-with-resources statement,
+// `try (Resources) Block`, may have neither.
+type TryStatement struct {
+	Token     tokens.Token // the 'try' token
+	Resources []*Resource
+	Block     *BlockStatement
+	Catches   []*CatchClause
+	Finally   *BlockStatement // nil when there is no finally block
+}
+
+func (ts *TryStatement) statementNode()       {}
+func (ts *TryStatement) TokenLiteral() string { return ts.Token.Literal }
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("try ")
+	if len(ts.Resources) > 0 {
+		resources := []string{}
+		for _, r := range ts.Resources {
+			resources = append(resources, r.String())
+		}
+		out.WriteString("(" + strings.Join(resources, "; ") + ") ")
+	}
+	out.WriteString("{" + ts.Block.String() + "}")
+	for _, c := range ts.Catches {
+		out.WriteString(" " + c.String())
+	}
+	if ts.Finally != nil {
+		out.WriteString(" finally {" + ts.Finally.String() + "}")
+	}
+	return out.String()
+}
+
+// Resource is a resource of a try-with-resources statement: either the
+// declaration of a new variable, `Type Name = Value`, or a variable
+// declared before, in which case Type and Name are nil.
+type Resource struct {
+	Type  *Type
+	Name  *Identifier
+	Value Expression
+}
+
+func (r *Resource) TokenLiteral() string { return r.Value.TokenLiteral() }
+func (r *Resource) String() string {
+	if r.Type == nil {
+		return r.Value.String()
+	}
+	return r.Type.String() + " " + r.Name.Value + " = " + r.Value.String()
+}
+
+// CatchClause is `catch (Types Parameter) Body`. A multi-catch clause,
+// `catch (A | B e)`, has more than one type.
+type CatchClause struct {
+	Token     tokens.Token // the 'catch' token
+	Types     []*Type
+	Parameter *Identifier
+	Body      *BlockStatement
+}
+
+func (cc *CatchClause) TokenLiteral() string { return cc.Token.Literal }
+func (cc *CatchClause) String() string {
+	types := []string{}
+	for _, t := range cc.Types {
+		types = append(types, t.String())
+	}
+	return "catch (" + strings.Join(types, " | ") + " " + cc.Parameter.Value + ") {" + cc.Body.String() + "}"
+}
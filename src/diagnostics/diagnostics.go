@@ -0,0 +1,238 @@
+package diagnostics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"java/tokens"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Note:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Span is a range of source text, optionally labelled with a short
+// explanation that is printed next to its underline.
+type Span struct {
+	Start tokens.Position `json:"start"`
+	End   tokens.Position `json:"end"`
+	Label string          `json:"label,omitempty"`
+}
+
+// TokenSpan returns the span covering tok.
+func TokenSpan(tok tokens.Token, label string) Span {
+	return Span{Start: tok.Start, End: tok.End, Label: label}
+}
+
+// After returns an empty span just past tok, which is where something
+// that is missing after tok, such as a ';', should have been.
+func After(tok tokens.Token, label string) Span {
+	return Span{Start: tok.End, End: tok.End, Label: label}
+}
+
+type Diagnostic struct {
+	Severity  Severity `json:"severity"`
+	Code      string   `json:"code"`
+	Message   string   `json:"message"`
+	Primary   Span     `json:"primary"`
+	Secondary []Span   `json:"secondary,omitempty"`
+	Notes     []string `json:"notes,omitempty"`
+	Hint      string   `json:"hint,omitempty"`
+}
+
+func Errorf(code string, primary Span, format string, a ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		Severity: Error,
+		Code:     code,
+		Message:  fmt.Sprintf(format, a...),
+		Primary:  primary,
+	}
+}
+
+func (d *Diagnostic) WithSecondary(span Span) *Diagnostic {
+	d.Secondary = append(d.Secondary, span)
+	return d
+}
+
+func (d *Diagnostic) WithNote(format string, a ...interface{}) *Diagnostic {
+	d.Notes = append(d.Notes, fmt.Sprintf(format, a...))
+	return d
+}
+
+func (d *Diagnostic) WithHint(format string, a ...interface{}) *Diagnostic {
+	d.Hint = fmt.Sprintf(format, a...)
+	return d
+}
+
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Primary.Start.Line, d.Primary.Start.Column, d.Severity, d.Message)
+}
+
+// Source is the text diagnostics refer to, along with the name it is shown
+// under, e.g. "Main.java".
+type Source struct {
+	Name  string
+	Text  string
+	lines []string
+}
+
+func NewSource(name string, text string) *Source {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return &Source{
+		Name:  name,
+		Text:  text,
+		lines: strings.Split(strings.ReplaceAll(text, "\r", "\n"), "\n"),
+	}
+}
+
+// Line returns line n, starting at 1, without its line terminator.
+func (s *Source) Line(n int) string {
+	if n < 1 || n > len(s.lines) {
+		return ""
+	}
+	return s.lines[n-1]
+}
+
+// Render formats d the way javac and rustc do:
+//
+//	error[E0001]: expected ';', found 'int'
+//	 --> Main.java:1:10
+//	  |
+//	1 | int x = 5
+//	  |          ^ expected ';'
+//	  |
+//	  = hint: add ';' at the end of the statement
+//
+// The primary span is underlined with carets and secondary spans with
+// dashes. A diagnostic whose primary span has no line only gets the
+// header, and secondary spans without one are left out.
+func Render(d *Diagnostic, src *Source) string {
+	var out bytes.Buffer
+
+	out.WriteString(d.Severity.String())
+	if d.Code != "" {
+		out.WriteString("[" + d.Code + "]")
+	}
+	out.WriteString(": " + d.Message + "\n")
+	if d.Primary.Start.Line < 1 {
+		return out.String()
+	}
+
+	type underline struct {
+		span Span
+		mark string
+	}
+	spans := []underline{{d.Primary, "^"}}
+	for _, s := range d.Secondary {
+		if s.Start.Line >= 1 {
+			spans = append(spans, underline{s, "-"})
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].span.Start.Offset < spans[j].span.Start.Offset
+	})
+
+	width := 1
+	for _, u := range spans {
+		if w := len(fmt.Sprint(u.span.Start.Line)); w > width {
+			width = w
+		}
+	}
+	gutter := strings.Repeat(" ", width)
+
+	fmt.Fprintf(&out, "%s--> %s:%d:%d\n", gutter, src.Name, d.Primary.Start.Line, d.Primary.Start.Column)
+	fmt.Fprintf(&out, "%s |\n", gutter)
+
+	lastLine := 0
+	for _, u := range spans {
+		line := src.Line(u.span.Start.Line)
+		if u.span.Start.Line != lastLine {
+			fmt.Fprintf(&out, "%*d | %s\n", width, u.span.Start.Line, line)
+			lastLine = u.span.Start.Line
+		}
+
+		start := u.span.Start.Column
+		end := u.span.End.Column
+		if u.span.End.Line != u.span.Start.Line {
+			end = utf8.RuneCountInString(line) + 1
+		}
+		length := end - start
+		if length < 1 {
+			length = 1
+		}
+
+		marker := indentation(line, start-1) + strings.Repeat(u.mark, length)
+		if u.span.Label != "" {
+			marker += " " + u.span.Label
+		}
+		fmt.Fprintf(&out, "%s | %s\n", gutter, marker)
+	}
+
+	if len(d.Notes) > 0 || d.Hint != "" {
+		fmt.Fprintf(&out, "%s |\n", gutter)
+	}
+	for _, note := range d.Notes {
+		fmt.Fprintf(&out, "%s = note: %s\n", gutter, note)
+	}
+	if d.Hint != "" {
+		fmt.Fprintf(&out, "%s = hint: %s\n", gutter, d.Hint)
+	}
+	return out.String()
+}
+
+// indentation returns blanks as wide as the first n characters of line,
+// keeping tabs so the underline lines up with the source. A negative n
+// gives none.
+func indentation(line string, n int) string {
+	n = max(n, 0)
+	var out strings.Builder
+	for _, c := range line {
+		if n == 0 {
+			break
+		}
+		if c == '\t' {
+			out.WriteRune('\t')
+		} else {
+			out.WriteRune(' ')
+		}
+		n--
+	}
+	out.WriteString(strings.Repeat(" ", n))
+	return out.String()
+}
+
+// JSON encodes diags as a JSON array for consumption by editors and other
+// tools. Each diagnostic carries the name of the file it refers to.
+func JSON(diags []*Diagnostic, src *Source) ([]byte, error) {
+	type located struct {
+		File string `json:"file"`
+		*Diagnostic
+	}
+	out := make([]located, len(diags))
+	for i, d := range diags {
+		out[i] = located{File: src.Name, Diagnostic: d}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,123 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"java/tokens"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	src := NewSource("Main.java", "int x = 5;\n\tadd(1, 2;\nint y = 6;\n")
+	d := Errorf("E0001", Span{
+		Start: tokens.Position{Offset: 19, Line: 2, Column: 10},
+		End:   tokens.Position{Offset: 20, Line: 2, Column: 11},
+		Label: "expected ')'",
+	}, "expected ')', found ';'").
+		WithSecondary(Span{
+			Start: tokens.Position{Offset: 14, Line: 2, Column: 5},
+			End:   tokens.Position{Offset: 15, Line: 2, Column: 6},
+			Label: "unclosed delimiter",
+		}).
+		WithNote("arguments are separated by ','").
+		WithHint("add ')' before ';'")
+
+	expected := "error[E0001]: expected ')', found ';'\n" +
+		" --> Main.java:2:10\n" +
+		"  |\n" +
+		"2 | \tadd(1, 2;\n" +
+		"  | \t   - unclosed delimiter\n" +
+		"  | \t        ^ expected ')'\n" +
+		"  |\n" +
+		"  = note: arguments are separated by ','\n" +
+		"  = hint: add ')' before ';'\n"
+
+	if actual := Render(d, src); actual != expected {
+		t.Errorf("Render() wrong.\nexpected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestRenderSpansOnSeveralLines(t *testing.T) {
+	src := NewSource("Main.java", "{\n  int x = 5;\n  return x\n")
+	d := Errorf("E0007", Span{
+		Start: tokens.Position{Offset: 25, Line: 3, Column: 11},
+		End:   tokens.Position{Offset: 25, Line: 3, Column: 11},
+	}, "reached end of file while parsing").
+		WithSecondary(Span{
+			Start: tokens.Position{Offset: 0, Line: 1, Column: 1},
+			End:   tokens.Position{Offset: 1, Line: 1, Column: 2},
+			Label: "this block is never closed",
+		})
+
+	expected := "error[E0007]: reached end of file while parsing\n" +
+		" --> Main.java:3:11\n" +
+		"  |\n" +
+		"1 | {\n" +
+		"  | - this block is never closed\n" +
+		"3 |   return x\n" +
+		"  |           ^\n"
+
+	if actual := Render(d, src); actual != expected {
+		t.Errorf("Render() wrong.\nexpected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestRenderWithoutPosition(t *testing.T) {
+	src := NewSource("Main.java", "int x = null;\n")
+	d := Errorf("E0101", Span{}, "incompatible types: <null> cannot be converted to int").
+		WithSecondary(Span{Label: "nowhere"})
+
+	expected := "error[E0101]: incompatible types: <null> cannot be converted to int\n"
+	if actual := Render(d, src); actual != expected {
+		t.Errorf("Render() wrong.\nexpected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	// A column before the start of the line underlines its first character.
+	d = Errorf("E0101", Span{Start: tokens.Position{Line: 1}, End: tokens.Position{Line: 1}}, "bad span")
+	expected = "error[E0101]: bad span\n" +
+		" --> Main.java:1:0\n" +
+		"  |\n" +
+		"1 | int x = null;\n" +
+		"  | ^\n"
+	if actual := Render(d, src); actual != expected {
+		t.Errorf("Render() wrong.\nexpected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	src := NewSource("Main.java", "int class = 5;")
+	d := Errorf("E0005", Span{
+		Start: tokens.Position{Offset: 4, Line: 1, Column: 5},
+		End:   tokens.Position{Offset: 9, Line: 1, Column: 10},
+		Label: "reserved word",
+	}, "'class' is a reserved word and cannot be used as an identifier")
+
+	out, err := JSON([]*Diagnostic{d}, src)
+	if err != nil {
+		t.Fatalf("JSON() returned error: %s", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("JSON() output is not valid JSON: %s", err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(decoded))
+	}
+
+	got := decoded[0]
+	for key, expected := range map[string]interface{}{
+		"file":     "Main.java",
+		"severity": "error",
+		"code":     "E0005",
+		"message":  "'class' is a reserved word and cannot be used as an identifier",
+	} {
+		if got[key] != expected {
+			t.Errorf("%s wrong. expected=%v, got=%v", key, expected, got[key])
+		}
+	}
+
+	start := got["primary"].(map[string]interface{})["start"].(map[string]interface{})
+	if start["line"] != float64(1) || start["column"] != float64(5) {
+		t.Errorf("primary start wrong. got=%v", start)
+	}
+}
@@ -0,0 +1,894 @@
+package evaluator
+
+import (
+	"java/ast"
+	"java/object"
+	"java/tokens"
+	"sort"
+	"strings"
+)
+
+// declareClasses declares the classes among stmts in env, along with
+// their member classes. The classes are all declared before their
+// superclasses are resolved, so a class may extend one declared after it.
+// A class declared in a method is a local class, which javac names after
+// the class of the method.
+func declareClasses(stmts []ast.Statement, env *object.Environment) {
+	var classes []*object.Class
+	for _, s := range stmts {
+		if decl, ok := s.(*ast.ClassDeclaration); ok {
+			name := decl.Name.Value
+			if enclosing := env.Class(); enclosing != nil {
+				name = enclosing.Name + "$1" + name
+			}
+			class := newClass(name, decl, env)
+			env.Set(decl.Name.Value, class)
+			classes = append(classes, declareMembers(class)...)
+		}
+	}
+
+	for _, class := range classes {
+		resolveSuperclass(class, class.Env)
+	}
+}
+
+// newClass returns the class decl, named name, declared in env. Its
+// static fields start out with their default values.
+func newClass(name string, decl *ast.ClassDeclaration, env *object.Environment) *object.Class {
+	class := &object.Class{Name: name, Declaration: decl, Env: env}
+	class.Statics = make(map[string]object.Object)
+	for _, f := range decl.Fields {
+		switch {
+		case isConstantVariable(class, f):
+			// Constant variables hold their values before the class is
+			// initialized, as the JVM gives them when it prepares the class.
+			class.Statics[f.Name.Value] = coerce(f.Type, Eval(f.Value, env))
+		case isStaticField(class, f):
+			class.Statics[f.Name.Value] = defaultValue(f.Type)
+		}
+	}
+	return class
+}
+
+// declareMembers declares the member classes of class, and theirs in
+// turn, and returns them after class. Their code runs in an environment
+// where the static members of class are in scope, and so are those of its
+// instance that an inner class belongs to.
+func declareMembers(class *object.Class) []*object.Class {
+	classes := []*object.Class{class}
+	class.Classes = make(map[string]*object.Class)
+	for _, decl := range class.Declaration.Classes {
+		member := newClass(class.Name+"$"+decl.Name.Value, decl, object.NewMethodEnvironment(class.Env, class, nil))
+		member.Outer = class
+		class.Classes[decl.Name.Value] = member
+		classes = append(classes, declareMembers(member)...)
+	}
+	return classes
+}
+
+func resolveSuperclass(class *object.Class, env *object.Environment) {
+	for _, t := range class.Declaration.Interfaces {
+		if i, ok := lookupClass(t.Name, env); ok {
+			class.Interfaces = append(class.Interfaces, i)
+		}
+	}
+	if superclass := class.Declaration.SuperClass; superclass != nil && superclass.Name != "Object" {
+		class.Super, _ = lookupClass(superclass.Name, env)
+	}
+}
+
+// lookupClass finds the class name in env, where a member class may be
+// qualified by the classes it is declared in, as in Outer.Inner.
+func lookupClass(name string, env *object.Environment) (*object.Class, bool) {
+	names := strings.Split(name, ".")
+	val, _ := env.Get(names[0])
+	class, ok := val.(*object.Class)
+	for _, member := range names[1:] {
+		if !ok {
+			break
+		}
+		class = class.MemberClass(member)
+		ok = class != nil
+	}
+	return class, ok
+}
+
+// isInner reports whether class is an inner class, a member class whose
+// instances belong to an instance of the class it is declared in. Member
+// interfaces, enums and records are implicitly static, and so are the
+// member classes of an interface.
+func isInner(class *object.Class) bool {
+	decl := class.Declaration
+	return class.Outer != nil && !class.Outer.Declaration.IsInterface() && !ast.HasModifier(decl.Modifiers, tokens.STATIC) &&
+		!decl.IsInterface() && !decl.IsEnum() && !decl.IsRecord()
+}
+
+// scope is the environment the code of class runs in on instance, which
+// is nil in a static context.
+func scope(class *object.Class, instance *object.Instance) *object.Environment {
+	if instance != nil {
+		if env, ok := instance.Enclosing[class]; ok {
+			return env
+		}
+	}
+	return class.Env
+}
+
+// belongTo makes instance, of the inner class or a subclass of it, belong
+// to outer, an instance of the class enclosing it.
+func belongTo(instance *object.Instance, class *object.Class, outer *object.Instance) {
+	if instance.Enclosing == nil {
+		instance.Enclosing = make(map[*object.Class]*object.Environment)
+	}
+	instance.Enclosing[class] = object.NewMethodEnvironment(scope(class.Outer, outer), class.Outer, outer)
+}
+
+// enclosingInstance returns the innermost instance of class, or of a
+// subclass of it, that code in env is in a method of, or nil if there is
+// none.
+func enclosingInstance(env *object.Environment, class *object.Class) *object.Instance {
+	for frame := env.Frame(); frame != nil; frame = frame.Outer().Frame() {
+		if this := frame.This(); this != nil && frame.Class().IsSubtypeOf(class) {
+			return this
+		}
+	}
+	return nil
+}
+
+// isStaticField reports whether f is a static field of class. The fields
+// of an interface are implicitly static.
+func isStaticField(class *object.Class, f *ast.FieldDeclaration) bool {
+	return class.Declaration.IsInterface() || ast.HasModifier(f.Modifiers, tokens.STATIC)
+}
+
+// isConstantVariable reports whether the field f of class is a static
+// constant variable: a final field of a primitive type or String whose
+// initializer is a constant expression (JLS 4.12.4). Reading it is not an
+// active use of the class.
+func isConstantVariable(class *object.Class, f *ast.FieldDeclaration) bool {
+	if !isStaticField(class, f) || f.Value == nil || f.Type.Dimensions > 0 {
+		return false
+	}
+	if !class.Declaration.IsInterface() && !ast.HasModifier(f.Modifiers, tokens.FINAL) {
+		return false
+	}
+	switch f.Type.Name {
+	case "byte", "short", "char", "int", "long", "float", "double", "boolean", "String":
+		return isConstantExpression(f.Value)
+	}
+	return false
+}
+
+// isConstantExpression reports whether e is made of literals and the
+// operators on them. Constant expressions may also name constant
+// variables, but those are not recognized here.
+func isConstantExpression(e ast.Expression) bool {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral, *ast.DoubleLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	case *ast.PrefixExpression:
+		return isConstantExpression(e.Right)
+	case *ast.InfixExpression:
+		return isConstantExpression(e.Left) && isConstantExpression(e.Right)
+	}
+	return false
+}
+
+// initializeClass initializes class on its first active use: its
+// superclass is initialized first, then its static field initializers and
+// static initializer blocks run in the order they are written. A class
+// that is used again while it is being initialized, by its own
+// initializers, is seen as it is so far. Unlike in Java, superinterfaces
+// are initialized along with the class, as the constants they declare are
+// looked up without going through initializeClass.
+//
+// If an initializer throws an exception other than an Error, it is
+// wrapped in an ExceptionInInitializerError. The class is then erroneous,
+// and any later use of it throws NoClassDefFoundError (JLS 12.4.2).
+func initializeClass(class *object.Class) object.Object {
+	switch class.State {
+	case object.Uninitialized:
+	case object.Erroneous:
+		return newException("NoClassDefFoundError", "Could not initialize class %s", qualifiedName(class))
+	default:
+		return nil
+	}
+	class.State = object.BeingInitialized
+	result := runStaticInitializers(class)
+	if !isError(result) {
+		class.State = object.Initialized
+		return nil
+	}
+	class.State = object.Erroneous
+	if err := result.(*object.Error); err.Exception != nil && !err.Exception.Class.IsSubtypeOf(libraryClasses()["Error"]) {
+		wrapper := instantiate(libraryClasses()["ExceptionInInitializerError"], []object.Object{err.Exception}, "(Throwable)")
+		if isError(wrapper) {
+			return wrapper
+		}
+		return throw(wrapper.(*object.Instance))
+	}
+	return result
+}
+
+// runStaticInitializers initializes the superclass and superinterfaces of
+// class, then runs the static initializers of class.
+func runStaticInitializers(class *object.Class) object.Object {
+	if err := pushFrame(qualifiedName(class) + ".<clinit>"); err != nil {
+		return err
+	}
+	defer popFrame()
+
+	if class.Super != nil {
+		if result := initializeClass(class.Super); isError(result) {
+			return result
+		}
+	}
+	for _, i := range class.Interfaces {
+		if result := initializeClass(i); isError(result) {
+			return result
+		}
+	}
+
+	frame := object.NewMethodEnvironment(class.Env, class, nil)
+	for _, init := range initializers(class, true) {
+		var result object.Object
+		switch init := init.(type) {
+		case *ast.FieldDeclaration:
+			result = evalOperand(init.Value, frame)
+			if !isError(result) {
+				class.Statics[init.Name.Value] = result
+			}
+		case *ast.BlockStatement:
+			result = Eval(init, frame)
+		}
+		if isError(result) {
+			return result
+		}
+	}
+	return nil
+}
+
+// isAbstract reports whether class is an interface or an abstract class.
+func isAbstract(class *object.Class) bool {
+	return class.Declaration.IsInterface() || ast.HasModifier(class.Declaration.Modifiers, tokens.ABSTRACT)
+}
+
+// evalNewExpression creates an instance. One of an inner class belongs to
+// the instance its creation is qualified with, as in `outer.new Inner()`,
+// or else to the innermost enclosing instance of its enclosing class.
+func evalNewExpression(node *ast.NewExpression, env *object.Environment) object.Object {
+	var outer *object.Instance
+	var class *object.Class
+	if node.Outer != nil {
+		obj := evalOperand(node.Outer, env)
+		if isError(obj) {
+			return obj
+		}
+		if obj == NULL {
+			// javac checks the outer instance with Objects.requireNonNull,
+			// whose exception has no message.
+			at(node.Token)
+			return newException("NullPointerException", "")
+		}
+		if instance, ok := obj.(*object.Instance); ok {
+			outer, class = instance, instance.Class.MemberClass(node.Type.Name)
+		}
+	} else {
+		class, _ = lookupClass(node.Type.Name, env)
+	}
+	switch {
+	case class == nil && node.Body != nil && node.Type.Name == "Object":
+		// An anonymous class that only extends Object.
+	case class == nil:
+		return unchecked(node.String())
+	case isInner(class) && outer == nil:
+		outer = enclosingInstance(env, class.Outer)
+	}
+
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+	at(node.Token)
+	if node.Body != nil {
+		return instantiateAnonymous(node, class, outer, args, env)
+	}
+	if result := initializeClass(class); isError(result) {
+		return result
+	}
+	instance := allocate(class)
+	if outer != nil {
+		belongTo(instance, class, outer)
+	}
+	if result := construct(class, instance, args, node.Signature); isError(result) {
+		return result
+	}
+	return instance
+}
+
+// anonymousClasses holds the class made for each anonymous class body.
+var anonymousClasses = map[*ast.ClassDeclaration]*object.Class{}
+
+// instantiateAnonymous creates an instance of the anonymous class that
+// node declares, which extends or implements class. The constructor of
+// the superclass that matches args runs first, then the initializers of
+// the anonymous class, whose code runs in env. A nil class stands for
+// Object. An anonymous class outside of any class is named after the main
+// class.
+func instantiateAnonymous(node *ast.NewExpression, class *object.Class, outer *object.Instance, args []object.Object, env *object.Environment) object.Object {
+	anonymous, ok := anonymousClasses[node.Body]
+	if !ok {
+		name := node.Body.Name.Value
+		if strings.HasPrefix(name, "$") {
+			name = mainClass() + name
+		}
+		anonymous = newClass(name, node.Body, env)
+		switch {
+		case class == nil:
+		case class.Declaration.IsInterface():
+			anonymous.Interfaces = []*object.Class{class}
+		default:
+			anonymous.Super = class
+		}
+		members := declareMembers(anonymous)
+		for _, member := range members[1:] {
+			resolveSuperclass(member, member.Env)
+		}
+		anonymousClasses[node.Body] = anonymous
+	}
+	if result := initializeClass(anonymous); isError(result) {
+		return result
+	}
+
+	instance := allocate(anonymous)
+	instance.Enclosing = map[*object.Class]*object.Environment{anonymous: env}
+	if outer != nil {
+		belongTo(instance, class, outer)
+	}
+	if anonymous.Super != nil {
+		if result := construct(anonymous.Super, instance, args, node.Signature); isError(result) {
+			return result
+		}
+	}
+	if result := initialize(anonymous, instance); isError(result) {
+		return result
+	}
+	return instance
+}
+
+// instantiate creates an instance of class and runs the constructor that
+// matches args on it.
+func instantiate(class *object.Class, args []object.Object, sig string) object.Object {
+	instance := allocate(class)
+	if result := construct(class, instance, args, sig); isError(result) {
+		return result
+	}
+	return instance
+}
+
+// allocate creates an instance of class. All of its fields start out with
+// their default values before any constructor runs. A Throwable records
+// the calls in progress when it is created.
+func allocate(class *object.Class) *object.Instance {
+	instance := object.NewInstance(class)
+	if isThrowable(class) {
+		instance.StackTrace = captureStackTrace()
+	}
+	for c := class; c != nil; c = c.Super {
+		for _, f := range c.Declaration.Fields {
+			if !isStaticField(c, f) {
+				instance.Fields[c][f.Name.Value] = defaultValue(f.Type)
+			}
+		}
+	}
+	return instance
+}
+
+// construct runs the constructor of class that matches args on instance,
+// in the order the JLS prescribes: a constructor beginning with this(...)
+// first delegates to another constructor of the class. Any other first
+// runs the superclass constructor, named by super(...) or else the one
+// without parameters, then the field initializers and initializer blocks
+// of class in the order they are written. The rest of the constructor
+// body runs last.
+func construct(class *object.Class, instance *object.Instance, args []object.Object, sig string) object.Object {
+	var ctor *ast.ConstructorDeclaration
+	if len(class.Declaration.Constructors) > 0 || len(args) > 0 {
+		if ctor = findConstructor(class, args, sig); ctor == nil {
+			return unchecked("new " + class.Name + sig)
+		}
+	}
+
+	if err := pushFrame(qualifiedName(class) + ".<init>"); err != nil {
+		return err
+	}
+	defer popFrame()
+
+	frame := object.NewMethodEnvironment(scope(class, instance), class, instance)
+	var body []ast.Statement
+	var explicit *ast.CallExpression
+	if ctor != nil {
+		bindParameters(frame, ctor.Parameters, args)
+		body = ctor.Body.Statements
+		if explicit = explicitConstructorCall(ctor); explicit != nil {
+			body = body[1:]
+		}
+	}
+
+	var explicitArgs []object.Object
+	var explicitSig string
+	if explicit != nil {
+		explicitSig = explicit.Signature
+		explicitArgs = evalExpressions(explicit.Arguments, frame)
+		if len(explicitArgs) == 1 && isError(explicitArgs[0]) {
+			return explicitArgs[0]
+		}
+	}
+
+	if explicit != nil && isThisCall(explicit) {
+		if result := construct(class, instance, explicitArgs, explicitSig); isError(result) {
+			return result
+		}
+	} else {
+		if super := class.Super; super != nil && isInner(super) && instance.Enclosing[super] == nil {
+			belongTo(instance, super, enclosingInstance(frame.Outer(), super.Outer))
+		}
+		if class.Super != nil {
+			if result := construct(class.Super, instance, explicitArgs, explicitSig); isError(result) {
+				return result
+			}
+		}
+		if result := initialize(class, instance); isError(result) {
+			return result
+		}
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+	return evalBlockStatement(&ast.BlockStatement{Token: ctor.Body.Token, Statements: body}, frame)
+}
+
+// initialize runs the field initializers and initializer blocks of class
+// on instance.
+func initialize(class *object.Class, instance *object.Instance) object.Object {
+	frame := object.NewMethodEnvironment(scope(class, instance), class, instance)
+	for _, init := range initializers(class, false) {
+		var result object.Object
+		switch init := init.(type) {
+		case *ast.FieldDeclaration:
+			result = evalOperand(init.Value, frame)
+			if !isError(result) {
+				instance.Fields[class][init.Name.Value] = result
+			}
+		case *ast.BlockStatement:
+			result = Eval(init, frame)
+		}
+		if isError(result) {
+			return result
+		}
+	}
+	return nil
+}
+
+// explicitConstructorCall returns the this(...) or super(...) call that
+// begins the body of ctor, if there is one.
+func explicitConstructorCall(ctor *ast.ConstructorDeclaration) *ast.CallExpression {
+	if len(ctor.Body.Statements) == 0 {
+		return nil
+	}
+	stmt, ok := ctor.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		return nil
+	}
+	switch call.Function.(type) {
+	case *ast.ThisExpression, *ast.SuperExpression:
+		return call
+	}
+	return nil
+}
+
+func isThisCall(call *ast.CallExpression) bool {
+	_, ok := call.Function.(*ast.ThisExpression)
+	return ok
+}
+
+// initializers returns the field declarations with an initializer and
+// the initializer blocks of class in source order, either the static or
+// the instance ones.
+func initializers(class *object.Class, static bool) []ast.Statement {
+	var inits []ast.Statement
+	for _, f := range class.Declaration.Fields {
+		if f.Value != nil && isStaticField(class, f) == static {
+			inits = append(inits, f)
+		}
+	}
+	blocks := class.Declaration.Initializers
+	if static {
+		blocks = class.Declaration.StaticInitializers
+	}
+	for _, b := range blocks {
+		inits = append(inits, b)
+	}
+	sort.SliceStable(inits, func(i, j int) bool {
+		return startOf(inits[i]) < startOf(inits[j])
+	})
+	return inits
+}
+
+func startOf(s ast.Statement) int {
+	switch s := s.(type) {
+	case *ast.FieldDeclaration:
+		return s.Token.Start.Offset
+	case *ast.BlockStatement:
+		return s.Token.Start.Offset
+	}
+	return 0
+}
+
+// defaultValue is the value of a field of type t before it is assigned.
+func defaultValue(t *ast.Type) object.Object {
+	if t.Dimensions > 0 {
+		return NULL
+	}
+	switch t.Name {
+	case "byte", "short", "int", "long":
+		return &object.Integer{Value: 0}
+	case "float", "double":
+		return &object.Double{Value: 0}
+	case "boolean":
+		return FALSE
+	}
+	return NULL
+}
+
+// evalReceiver evaluates the object of a field access or method call. It
+// also returns the class the member is looked up from: the class of the
+// object, except for this and super, where javac looks in the class of the
+// enclosing method and its superclass.
+func evalReceiver(node ast.Expression, env *object.Environment) (object.Object, *object.Class) {
+	switch node := node.(type) {
+	case *ast.ThisExpression:
+		return evalThis(node, env)
+	case *ast.SuperExpression:
+		this := env.This()
+		switch {
+		case this == nil:
+		case node.Qualifier == nil:
+			return this, env.Class().Super
+		default:
+			if i, ok := lookupClass(node.Qualifier.Value, env); ok && i.Declaration.IsInterface() {
+				return this, i
+			}
+		}
+		return unchecked(node.String()), nil
+	}
+
+	obj := evalOperand(node, env)
+	if instance, ok := obj.(*object.Instance); ok {
+		return obj, instance.Class
+	}
+	return obj, nil
+}
+
+// evalThis evaluates this, the receiver of the innermost method call, or
+// Qualifier.this, the receiver of the call of a method of the enclosing
+// class named, along with the class whose members are looked up.
+func evalThis(node *ast.ThisExpression, env *object.Environment) (object.Object, *object.Class) {
+	if node.Qualifier == nil {
+		if this := env.This(); this != nil {
+			return this, env.Class()
+		}
+	} else if class, ok := lookupClass(node.Qualifier.Value, env); ok {
+		for frame := env.Frame(); frame != nil; frame = frame.Outer().Frame() {
+			if this := frame.This(); frame.Class() == class && this != nil {
+				return this, class
+			}
+		}
+	}
+	return unchecked(node.String()), nil
+}
+
+func evalMemberExpression(node *ast.MemberExpression, env *object.Environment) object.Object {
+	obj, from := evalReceiver(node.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	at(node.Token)
+	switch obj := obj.(type) {
+	case *object.Class:
+		// A member class, as in Outer.Inner, is used without initializing
+		// the class it is declared in.
+		if _, ok := obj.StaticField(node.Property.Value); !ok {
+			if member := obj.MemberClass(node.Property.Value); member != nil {
+				return member
+			}
+		}
+		return evalStaticField(obj, node.Property.Value)
+	case *object.Array:
+		if node.Property.Value == "length" {
+			return &object.Integer{Value: int64(len(obj.Elements))}
+		}
+	case *object.Instance:
+		if val, ok := obj.Field(from, node.Property.Value); ok {
+			return val
+		}
+		if val, ok := from.StaticField(node.Property.Value); ok {
+			return val
+		}
+		return unchecked(node.String())
+	}
+	return fieldAccessError(obj, node, "read", env)
+}
+
+// evalStaticField reads the static field name through class. This
+// initializes the class that declares the field, which may be a
+// superclass of class, unless the field is a constant variable (JLS
+// 12.4.1).
+func evalStaticField(class *object.Class, name string) object.Object {
+	owner := class.StaticOwner(name)
+	if owner == nil {
+		return unchecked(class.Name + "." + name)
+	}
+	if !isConstant(owner, name) {
+		if result := initializeClass(owner); isError(result) {
+			return result
+		}
+	}
+	return owner.Statics[name]
+}
+
+// isConstant reports whether the static field name of class is a constant
+// variable.
+func isConstant(class *object.Class, name string) bool {
+	for _, f := range class.Declaration.Fields {
+		if f.Name.Value == name {
+			return isConstantVariable(class, f)
+		}
+	}
+	return false
+}
+
+func evalFieldAssignment(target *ast.MemberExpression, value ast.Expression, env *object.Environment) object.Object {
+	obj, from := evalReceiver(target.Object, env)
+	if isError(obj) {
+		return obj
+	}
+	at(target.Token)
+	name := target.Property.Value
+
+	var class *object.Class
+	switch obj := obj.(type) {
+	case *object.Class:
+		// Assigning a static field initializes the class declaring it.
+		if owner := obj.StaticOwner(name); owner != nil {
+			if result := initializeClass(owner); isError(result) {
+				return result
+			}
+		}
+		class = obj
+	case *object.Instance:
+		if _, ok := obj.Field(from, name); !ok {
+			class = from
+		}
+	default:
+		return fieldAccessError(obj, target, "assign", env)
+	}
+	if class != nil {
+		if _, ok := class.StaticField(name); !ok {
+			return unchecked(target.String())
+		}
+	}
+
+	val := evalOperand(value, env)
+	if isError(val) {
+		return val
+	}
+	if class != nil {
+		class.SetStaticField(name, val)
+	} else {
+		obj.(*object.Instance).SetField(from, name, val)
+	}
+	return val
+}
+
+// fieldAccessError is the error for the field access node to read or
+// assign a field of obj, which is not an object with fields.
+func fieldAccessError(obj object.Object, node *ast.MemberExpression, access string, env *object.Environment) object.Object {
+	if obj == NULL {
+		if access == "read" && node.Property.Value == "length" && strings.HasSuffix(node.Receiver, "[]") {
+			return nullPointerException(node.Object, env, "Cannot read the array length")
+		}
+		return nullPointerException(node.Object, env, "Cannot %s field \"%s\"", access, node.Property.Value)
+	}
+	return unchecked(node.String())
+}
+
+func evalCallExpression(node *ast.CallExpression, env *object.Environment) object.Object {
+	switch function := node.Function.(type) {
+	case *ast.MemberExpression:
+		receiver, from := evalReceiver(function.Object, env)
+		if isError(receiver) {
+			return receiver
+		}
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		at(node.Token)
+		if _, ok := function.Object.(*ast.SuperExpression); ok {
+			// super.method() calls the superclass method even if the
+			// class of the receiver overrides it.
+			method, declaring := findMethod(from, function.Property.Value, args, node.Signature)
+			if method == nil {
+				// The methods of Object that the superclass does not override.
+				if method, ok := valueMethods["Object."+function.Property.Value]; ok && method.arity == len(args) {
+					return method.fn(receiver, args)
+				}
+				return unchecked(node.String())
+			}
+			return callMethod(declaring, receiver.(*object.Instance), method, args)
+		}
+		if class, ok := receiver.(*object.Class); ok {
+			return invokeStaticMethod(class, function.Property.Value, args, node.Signature)
+		}
+		if receiver == NULL {
+			return nullPointerException(function.Object, env, `Cannot invoke "%s"`, describeMethod(node, args))
+		}
+		if class := env.Class(); class != nil {
+			// A private method of the calling class is not overridden by
+			// subclasses of it, so it runs whatever the receiver's class.
+			method, declaring := findDeclaredMethod(class, function.Property.Value, args, node.Signature)
+			if instance, ok := receiver.(*object.Instance); ok && method != nil && declaring == class &&
+				ast.HasModifier(method.Modifiers, tokens.PRIVATE) && instance.Class.IsSubtypeOf(class) {
+				return callMethod(declaring, instance, method, args)
+			}
+		}
+		return invokeMethod(receiver, function.Property.Value, args, node.Signature)
+
+	case *ast.Identifier:
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isError(args[0]) {
+			return args[0]
+		}
+		at(node.Token)
+		// The method is looked up in the class of the innermost method
+		// being called, then in the classes enclosing it in turn, where
+		// it is called on their instance.
+		for frame := env.Frame(); frame != nil; frame = frame.Outer().Frame() {
+			this := frame.This()
+			if method, declaring := findDeclaredMethod(frame.Class(), function.Value, args, node.Signature); method != nil {
+				// Private and static methods are not overridden, so they are
+				// called without dispatching on the receiver.
+				switch {
+				case ast.HasModifier(method.Modifiers, tokens.STATIC):
+					return callMethod(declaring, nil, method, args)
+				case this != nil && !ast.HasModifier(method.Modifiers, tokens.PRIVATE):
+					return invokeMethod(this, function.Value, args, signature(method.Parameters))
+				}
+				return callMethod(declaring, this, method, args)
+			}
+			// The methods of Object that the class does not override.
+			if _, ok := valueMethods["Object."+function.Value]; ok && this != nil {
+				return invokeMethod(this, function.Value, args, node.Signature)
+			}
+		}
+		if val, ok := env.Get(function.Value); ok {
+			if fn, ok := val.(*object.Function); ok {
+				return applyFunction(fn, args, node.Signature)
+			}
+		}
+	}
+	return unchecked(node.String())
+}
+
+// invokeStaticMethod calls the static method name of class, as in
+// Counter.increment(), which initializes the class.
+func invokeStaticMethod(class *object.Class, name string, args []object.Object, sig string) object.Object {
+	method, declaring := findDeclaredMethod(class, name, args, sig)
+	if method == nil {
+		return unchecked(class.Name + "." + name)
+	}
+	if result := initializeClass(declaring); isError(result) {
+		return result
+	}
+	return callMethod(declaring, nil, method, args)
+}
+
+// invokeMethod calls the method name of receiver. The method is looked up
+// from the class of the receiver, so the most specific override runs.
+func invokeMethod(receiver object.Object, name string, args []object.Object, sig string) object.Object {
+	switch receiver := receiver.(type) {
+	case *object.Instance:
+		method, declaring := findMethod(receiver.Class, name, args, sig)
+		if method == nil {
+			if receiver.Lambda != nil {
+				if method, ok := functionalMethod(receiver, name, args, sig); ok {
+					return callLambda(receiver.Lambda, method, args)
+				}
+			}
+			if method, ok := valueMethods["Object."+name]; ok && method.arity == len(args) {
+				return method.fn(receiver, args)
+			}
+			return unchecked(receiver.Class.Name + "." + name)
+		}
+		return callMethod(declaring, receiver, method, args)
+	case *object.Null:
+		return newException("NullPointerException", "Cannot invoke \"%s()\"", name)
+	}
+	if method, ok := valueMethods[boxName(receiver)+"."+name]; ok && method.arity == len(args) {
+		return method.fn(receiver, args)
+	}
+	return unchecked(typeName(receiver) + "." + name)
+}
+
+func callMethod(class *object.Class, this *object.Instance, method *ast.FunctionLiteral, args []object.Object) object.Object {
+	if method.Body == nil {
+		return callNative(class, this, method, args)
+	}
+	if err := pushFrame(qualifiedName(class) + "." + method.Name.Value); err != nil {
+		return err
+	}
+	defer popFrame()
+
+	frame := object.NewMethodEnvironment(scope(class, this), class, this)
+	bindParameters(frame, method.Parameters, args)
+	return returnValue(method, evalBlockStatement(method.Body, frame))
+}
+
+func applyFunction(fn *object.Function, args []object.Object, sig string) object.Object {
+	name := fn.Literal.Name.Value
+	if fn = findFunction(fn, args, sig); fn == nil {
+		return unchecked(name)
+	}
+	if err := pushFrame(mainClass() + "." + name); err != nil {
+		return err
+	}
+	defer popFrame()
+
+	env := object.NewEnclosedEnvironment(fn.Env)
+	bindParameters(env, fn.Literal.Parameters, args)
+	return returnValue(fn.Literal, evalBlockStatement(fn.Literal.Body, env))
+}
+
+// returnValue returns the value a call of method evaluates to, given the
+// result of its body, which is nil for a void method.
+func returnValue(method *ast.FunctionLiteral, obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.ReturnValue:
+		if obj.Value == nil {
+			return nil
+		}
+		return coerce(method.ReturnType, obj.Value)
+	case *object.Error:
+		return obj
+	}
+	return nil
+}
+
+// interfacesOf returns the interfaces class implements, directly or
+// indirectly, including class itself if it is an interface.
+func interfacesOf(class *object.Class) []*object.Class {
+	var result []*object.Class
+	seen := map[*object.Class]bool{}
+	var visit func(c *object.Class)
+	visit = func(c *object.Class) {
+		if c == nil || seen[c] {
+			return
+		}
+		seen[c] = true
+		if c.Declaration.IsInterface() {
+			result = append(result, c)
+		}
+		visit(c.Super)
+		for _, i := range c.Interfaces {
+			visit(i)
+		}
+	}
+	visit(class)
+	return result
+}
@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"java/ast"
+	"java/object"
+)
+
+// constantClasses holds the class made for each enum constant with a
+// body, a subclass of its enum.
+var constantClasses = map[*ast.EnumConstant]*object.Class{}
+
+// evalEnumConstant creates the enum constant node while the static fields
+// of its enum, the class of env, are initialized. Its name and ordinal are
+// set before the enum constructor runs. A constant with a body is an
+// instance of a class of its own, whose field initializers run after the
+// enum constructor.
+func evalEnumConstant(node *ast.EnumConstant, env *object.Environment) object.Object {
+	enum := env.Class()
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	class := enum
+	if node.Body != nil {
+		class = constantClass(node, enum)
+	}
+	instance := allocate(class)
+	base := libraryClasses()["Enum"]
+	instance.SetField(base, "name", intern(node.Name.Value))
+	instance.SetField(base, "ordinal", &object.Integer{Value: int64(node.Ordinal)})
+
+	at(node.Token)
+	if result := construct(enum, instance, args, node.Signature); isError(result) {
+		return result
+	}
+	if class != enum {
+		if result := initialize(class, instance); isError(result) {
+			return result
+		}
+	}
+	return instance
+}
+
+func constantClass(node *ast.EnumConstant, enum *object.Class) *object.Class {
+	class, ok := constantClasses[node]
+	if !ok {
+		class = &object.Class{
+			Name:        node.Body.Name.Value,
+			Declaration: node.Body,
+			Super:       enum,
+			Env:         enum.Env,
+			Statics:     map[string]object.Object{},
+			State:       object.Initialized,
+		}
+		constantClasses[node] = class
+	}
+	return class
+}
+
+// enumOf returns the enum class is or extends, the class of a constant
+// with a body, or nil if it is not an enum.
+func enumOf(class *object.Class) *object.Class {
+	for c := class; c != nil; c = c.Super {
+		if c.Declaration.IsEnum() {
+			return c
+		}
+	}
+	return nil
+}
+
+// enumConstants returns the constants of enum in the order they are
+// declared.
+func enumConstants(enum *object.Class) []object.Object {
+	var constants []object.Object
+	for _, f := range enum.Declaration.Fields {
+		if _, ok := f.Value.(*ast.EnumConstant); ok {
+			constants = append(constants, enum.Statics[f.Name.Value])
+		}
+	}
+	return constants
+}
+
+// callEnumMethod runs the method that every enum has implicitly.
+func callEnumMethod(enum *object.Class, method *ast.FunctionLiteral, args []object.Object) object.Object {
+	switch method.Name.Value {
+	case "values":
+		return object.NewArray(&ast.Type{Name: enum.Name}, enumConstants(enum))
+	case "valueOf":
+		name, ok := args[0].(*object.String)
+		if !ok {
+			return newException("NullPointerException", "Name is null")
+		}
+		for _, f := range enum.Declaration.Fields {
+			if _, ok := f.Value.(*ast.EnumConstant); ok && f.Name.Value == name.Value {
+				return enum.Statics[f.Name.Value]
+			}
+		}
+		return newException("IllegalArgumentException", "No enum constant %s.%s", qualifiedName(enum), name.Value)
+	}
+	return newError("native method %s.%s is not implemented", enum.Name, method.Name.Value)
+}
@@ -1,8 +1,10 @@
 package evaluator
 
 import (
+	"fmt"
 	"java/ast"
 	"java/object"
+	"java/tokens"
 )
 
 var (
@@ -11,19 +13,107 @@ var (
 	FALSE = &object.Boolean{Value: false}
 )
 
-func Eval(node ast.Node) object.Object {
+func Eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// Statements
 	case *ast.Program:
-		return evalStatements(node.Statements)
+		return evalProgram(node, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression)
+		return Eval(node.Expression, env)
+	case *ast.BlockStatement:
+		return evalBlockStatement(node, object.NewEnclosedEnvironment(env))
+	case *ast.IfStatement:
+		return evalIfStatement(node, env)
+	case *ast.ReturnStatement:
+		if node.ReturnValue == nil {
+			return &object.ReturnValue{}
+		}
+		val := evalOperand(node.ReturnValue, env)
+		if isError(val) {
+			return val
+		}
+		return &object.ReturnValue{Value: val}
+	case *ast.VariableDeclaration:
+		return evalDeclaration(node, env)
+	case *ast.IncrementStatement:
+		return evalIncrement(node.Operand, 1, env)
+	case *ast.DecrementStatement:
+		return evalIncrement(node.Operand, -1, env)
+	case *ast.ClassDeclaration:
+		declareClasses([]ast.Statement{node}, env)
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+	case *ast.TryStatement:
+		return evalTryStatement(node, env)
+	case *ast.SwitchStatement:
+		return evalSwitchStatement(node, env)
+	case *ast.YieldStatement:
+		val := evalOperand(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		return &object.YieldValue{Value: val}
 
 	// Expressions
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.DoubleLiteral:
+		return &object.Double{Value: node.Value}
+	case *ast.StringLiteral:
+		return intern(node.Value)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
+	case *ast.NullLiteral:
+		return NULL
+	case *ast.Identifier:
+		return evalIdentifier(node, env)
+	case *ast.ThisExpression:
+		this, _ := evalThis(node, env)
+		return this
+	case *ast.PrefixExpression:
+		right := evalOperand(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		return evalPrefixExpression(node.Operator, right)
+	case *ast.InfixExpression:
+		left := evalOperand(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		right := evalOperand(node.Right, env)
+		if isError(right) {
+			return right
+		}
+		at(node.Token)
+		return evalInfixExpression(node.Operator, left, right)
+	case *ast.AssignmentExpression:
+		return evalAssignmentExpression(node, env)
+	case *ast.FunctionLiteral:
+		declareFunction(node, env)
+		return nil
+	case *ast.CallExpression:
+		return evalCallExpression(node, env)
+	case *ast.NewExpression:
+		return evalNewExpression(node, env)
+	case *ast.MemberExpression:
+		return evalMemberExpression(node, env)
+	case *ast.LambdaExpression:
+		return evalLambdaExpression(node, env)
+	case *ast.MethodReference:
+		return evalMethodReference(node, env)
+	case *ast.SwitchExpression:
+		return evalSwitchExpression(node, env)
+	case *ast.EnumConstant:
+		return evalEnumConstant(node, env)
+	case *ast.InstanceofExpression:
+		return evalInstanceofExpression(node, env)
+	case *ast.IndexExpression:
+		array, index := evalIndex(node, "load from", env)
+		if isError(array) {
+			return array
+		}
+		return array.(*object.Array).Elements[index]
 	}
 	return nil
 }
@@ -35,10 +125,494 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	return FALSE
 }
 
-func evalStatements(stmts []ast.Statement) object.Object {
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
+
+// unchecked is the error for code that the evaluator cannot run, as it
+// breaks a rule of the language that the type checker enforces before
+// the program runs.
+func unchecked(code string) *object.Error {
+	return newError("cannot evaluate %s", code)
+}
+
+func isError(obj object.Object) bool {
+	if obj != nil {
+		return obj.Type() == object.ERROR_OBJ
+	}
+	return false
+}
+
+func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+	declareLibrary(env)
+	if err := pushFrame(mainClass() + ".main"); err != nil {
+		return err
+	}
+	defer popFrame()
+
+	// Classes and methods may be used before they are declared.
+	declareClasses(program.Statements, env)
+	for _, statement := range program.Statements {
+		if es, ok := statement.(*ast.ExpressionStatement); ok && isDeclaration(es) {
+			Eval(statement, env)
+		}
+	}
+
 	var result object.Object
-	for _, statement := range stmts {
-		result = Eval(statement)
+	for _, statement := range program.Statements {
+		if isDeclaration(statement) {
+			continue
+		}
+		result = Eval(statement, env)
+
+		switch result := result.(type) {
+		case *object.ReturnValue:
+			return result.Value
+		case *object.Error:
+			return result
+		}
+	}
+	return result
+}
+
+func isDeclaration(statement ast.Statement) bool {
+	switch statement := statement.(type) {
+	case *ast.ClassDeclaration:
+		return true
+	case *ast.ExpressionStatement:
+		_, ok := statement.Expression.(*ast.FunctionLiteral)
+		return ok
+	}
+	return false
+}
+
+func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+	for _, statement := range block.Statements {
+		result = Eval(statement, env)
+
+		if result != nil {
+			rt := result.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.YIELD_VALUE_OBJ || rt == object.ERROR_OBJ {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+func evalIfStatement(is *ast.IfStatement, env *object.Environment) object.Object {
+	condition := evalCondition(is.Condition, env)
+	if isError(condition) {
+		return condition
+	}
+
+	if condition == TRUE {
+		return Eval(is.Consequence, env)
+	} else if is.Alternative != nil {
+		return Eval(is.Alternative, env)
+	}
+	return nil
+}
+
+func evalSwitchStatement(node *ast.SwitchStatement, env *object.Environment) object.Object {
+	c, env, err := selectCase(node.Token, node.Value, node.Cases, env)
+	if err != nil || c == nil {
+		return err
+	}
+	result := Eval(c.Body, env)
+	if _, ok := c.Body.(ast.Expression); ok && !isError(result) {
+		return nil
 	}
 	return result
 }
+
+// evalSwitchExpression evaluates to the value of the case that matches,
+// which is either the value of its expression or the value its block
+// yields.
+func evalSwitchExpression(node *ast.SwitchExpression, env *object.Environment) object.Object {
+	c, env, err := selectCase(node.Token, node.Value, node.Cases, env)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return newError("the switch expression does not cover all possible input values")
+	}
+	if body, ok := c.Body.(ast.Expression); ok {
+		return evalOperand(body, env)
+	}
+	switch result := Eval(c.Body, env).(type) {
+	case *object.YieldValue:
+		return result.Value
+	case *object.Error:
+		return result
+	}
+	return unchecked(node.String())
+}
+
+// selectCase returns the case of a switch on value with a label equal to
+// it or a pattern it matches, whose guard holds, or else the default case.
+// It returns nil if there is neither. The labels of a switch on an enum
+// are the names of its constants. The case is run in the environment it
+// returns, where the variables of its pattern are bound.
+func selectCase(tok tokens.Token, value ast.Expression, cases []*ast.SwitchCase, env *object.Environment) (*ast.SwitchCase, *object.Environment, object.Object) {
+	val := evalOperand(value, env)
+	if isError(val) {
+		return nil, env, val
+	}
+	if val == NULL && !hasNullLabel(cases) {
+		at(tok)
+		return nil, env, newException("NullPointerException", "")
+	}
+
+	var enum *object.Class
+	if instance, ok := val.(*object.Instance); ok {
+		enum = enumOf(instance.Class)
+	}
+	var fallback *ast.SwitchCase
+	for _, c := range cases {
+		if len(c.Labels) == 0 {
+			fallback = c
+		}
+		for _, label := range c.Labels {
+			if ast.IsPattern(label) {
+				scope := object.NewEnclosedEnvironment(env)
+				matched, err := matchPattern(label, val, scope)
+				if err != nil {
+					return nil, env, err
+				}
+				if matched && c.Guard != nil {
+					guard := evalCondition(c.Guard, scope)
+					if isError(guard) {
+						return nil, env, guard
+					}
+					matched = guard == TRUE
+				}
+				if matched {
+					return c, scope, nil
+				}
+				continue
+			}
+			var constant object.Object
+			if name, ok := label.(*ast.Identifier); ok && enum != nil {
+				constant, _ = enum.StaticField(name.Value)
+			} else if constant = evalOperand(label, env); isError(constant) {
+				return nil, env, constant
+			}
+			if matches(constant, val) {
+				return c, env, nil
+			}
+		}
+	}
+	return fallback, env, nil
+}
+
+// matches reports whether the value of a case label is equal to the
+// value of a switch, as numbers, as strings, or as the same object.
+func matches(label, val object.Object) bool {
+	if l, ok := label.(*object.String); ok {
+		s, ok := val.(*object.String)
+		return ok && l.Value == s.Value
+	}
+	if isNumeric(label) && isNumeric(val) {
+		return evalInfixExpression("==", label, val) == TRUE
+	}
+	return label == val
+}
+
+// evalCondition evaluates the condition of an if statement or a loop,
+// which must be a boolean.
+func evalCondition(node ast.Expression, env *object.Environment) object.Object {
+	condition := evalOperand(node, env)
+	if isError(condition) {
+		return condition
+	}
+	if _, ok := condition.(*object.Boolean); !ok {
+		return unchecked(node.String())
+	}
+	return condition
+}
+
+// evalOperand evaluates node for its value, which a call of a void method
+// does not have.
+func evalOperand(node ast.Expression, env *object.Environment) object.Object {
+	val := Eval(node, env)
+	if val == nil {
+		return unchecked(node.String())
+	}
+	return val
+}
+
+// evalDeclaration declares local variables in env. A variable without an
+// initializer holds the default value of its type until it is assigned.
+func evalDeclaration(node *ast.VariableDeclaration, env *object.Environment) object.Object {
+	for _, d := range node.Declarators {
+		if d.Value == nil {
+			env.Set(d.Name.Value, defaultValue(node.Type))
+			continue
+		}
+		val := evalOperand(d.Value, env)
+		if isError(val) {
+			return val
+		}
+		env.Set(d.Name.Value, coerce(node.Type, val))
+	}
+	return nil
+}
+
+func evalIncrement(operand *ast.Identifier, delta int64, env *object.Environment) object.Object {
+	val, _ := env.Get(operand.Value)
+	integer, ok := val.(*object.Integer)
+	if !ok {
+		return unchecked(operand.String())
+	}
+	env.Assign(operand.Value, &object.Integer{Value: integer.Value + delta})
+	return nil
+}
+
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	return unchecked(node.Value)
+}
+
+func evalPrefixExpression(operator string, right object.Object) object.Object {
+	switch operator {
+	case "!":
+		if right, ok := right.(*object.Boolean); ok {
+			return nativeBoolToBooleanObject(!right.Value)
+		}
+	case "-":
+		switch right := right.(type) {
+		case *object.Integer:
+			return &object.Integer{Value: -right.Value}
+		case *object.Double:
+			return &object.Double{Value: -right.Value}
+		}
+	}
+	return unchecked("operator " + operator)
+}
+
+func evalInfixExpression(operator string, left, right object.Object) object.Object {
+	switch {
+	case operator == "+" && (left.Type() == object.STRING_OBJ || right.Type() == object.STRING_OBJ):
+		return evalStringConcatenation(left, right)
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return evalIntegerInfixExpression(operator, left.(*object.Integer).Value, right.(*object.Integer).Value)
+	case isNumeric(left) && isNumeric(right):
+		// Binary numeric promotion: an int operand is widened to double.
+		return evalDoubleInfixExpression(operator, toDouble(left), toDouble(right))
+	case operator == "==":
+		return nativeBoolToBooleanObject(equal(left, right))
+	case operator == "!=":
+		return nativeBoolToBooleanObject(!equal(left, right))
+	}
+	return unchecked("operator " + operator)
+}
+
+func evalIntegerInfixExpression(operator string, left, right int64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Integer{Value: left + right}
+	case "-":
+		return &object.Integer{Value: left - right}
+	case "*":
+		return &object.Integer{Value: left * right}
+	case "/":
+		if right == 0 {
+			return newException("ArithmeticException", "/ by zero")
+		}
+		return &object.Integer{Value: left / right}
+	case "<":
+		return nativeBoolToBooleanObject(left < right)
+	case ">":
+		return nativeBoolToBooleanObject(left > right)
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		return nativeBoolToBooleanObject(left != right)
+	}
+	return unchecked("operator " + operator)
+}
+
+func evalDoubleInfixExpression(operator string, left, right float64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Double{Value: left + right}
+	case "-":
+		return &object.Double{Value: left - right}
+	case "*":
+		return &object.Double{Value: left * right}
+	case "/":
+		return &object.Double{Value: left / right}
+	case "<":
+		return nativeBoolToBooleanObject(left < right)
+	case ">":
+		return nativeBoolToBooleanObject(left > right)
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		return nativeBoolToBooleanObject(left != right)
+	}
+	return unchecked("operator " + operator)
+}
+
+func isNumeric(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.DOUBLE_OBJ
+}
+
+func toDouble(obj object.Object) float64 {
+	if integer, ok := obj.(*object.Integer); ok {
+		return float64(integer.Value)
+	}
+	return obj.(*object.Double).Value
+}
+
+// evalIndex evaluates the array and the index of an array access, which
+// is to load from or store to the array. It returns an error instead of
+// the array if the array is null or the index is out of bounds.
+func evalIndex(node *ast.IndexExpression, access string, env *object.Environment) (object.Object, int) {
+	left := evalOperand(node.Left, env)
+	if isError(left) {
+		return left, 0
+	}
+	index := evalOperand(node.Index, env)
+	if isError(index) {
+		return index, 0
+	}
+	at(node.Token)
+	array, ok := left.(*object.Array)
+	if !ok {
+		if left == NULL {
+			return nullPointerException(node.Left, env, "Cannot %s %s array", access, arrayKind(node.Array)), 0
+		}
+		return unchecked(node.String()), 0
+	}
+	i, ok := index.(*object.Integer)
+	if !ok {
+		return unchecked(node.String()), 0
+	}
+	if i.Value < 0 || i.Value >= int64(len(array.Elements)) {
+		return newException("ArrayIndexOutOfBoundsException", "Index %d out of bounds for length %d",
+			i.Value, len(array.Elements)), 0
+	}
+	return array, int(i.Value)
+}
+
+func evalStringConcatenation(left, right object.Object) object.Object {
+	l := stringOf(left)
+	if isError(l) {
+		return l
+	}
+	r := stringOf(right)
+	if isError(r) {
+		return r
+	}
+	return &object.String{Value: l.(*object.String).Value + r.(*object.String).Value}
+}
+
+// stringOf converts obj to a String the way string concatenation does,
+// calling toString() on instances of classes that declare it.
+func stringOf(obj object.Object) object.Object {
+	switch obj := obj.(type) {
+	case *object.String:
+		return obj
+	case *object.Instance:
+		if method, declaring := findMethod(obj.Class, "toString", nil, "()"); method != nil {
+			result := callMethod(declaring, obj, method, nil)
+			if isError(result) {
+				return result
+			}
+			if result, ok := result.(*object.String); ok {
+				return result
+			}
+			return stringOf(result)
+		}
+	}
+	return &object.String{Value: obj.Inspect()}
+}
+
+// equal compares two values with ==. Numbers are compared by value before
+// this, boxed ones too, unlike in Java, where two boxes of a value outside
+// of -128 to 127 are distinct objects. So it compares booleans, which are
+// shared, and references, which are equal if they are null or refer to
+// the same object.
+func equal(left, right object.Object) bool {
+	return left == right
+}
+
+var interned = map[string]*object.String{}
+
+// intern returns the one String for the string literal value, so that
+// literals with the same value are the same object, as in Java.
+func intern(value string) *object.String {
+	s, ok := interned[value]
+	if !ok {
+		s = &object.String{Value: value}
+		interned[value] = s
+	}
+	return s
+}
+
+func evalAssignmentExpression(node *ast.AssignmentExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		val := evalOperand(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if !env.Assign(target.Value, val) {
+			return unchecked(node.String())
+		}
+		return val
+	case *ast.MemberExpression:
+		return evalFieldAssignment(target, node.Value, env)
+	case *ast.IndexExpression:
+		array, index := evalIndex(target, "store to", env)
+		if isError(array) {
+			return array
+		}
+		val := evalOperand(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		val = coerce(array.(*object.Array).ElementType, val)
+		array.(*object.Array).Elements[index] = val
+		return val
+	}
+	return unchecked(node.String())
+}
+
+func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+	var result []object.Object
+	for _, e := range exps {
+		evaluated := evalOperand(e, env)
+		if isError(evaluated) {
+			return []object.Object{evaluated}
+		}
+		result = append(result, evaluated)
+	}
+	return result
+}
+
+// typeName is the Java name of the type of obj, for error messages.
+func typeName(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return "int"
+	case *object.Double:
+		return "double"
+	case *object.Array:
+		return obj.ElementType.String() + "[]"
+	case *object.Boolean:
+		return "boolean"
+	case *object.String:
+		return "String"
+	case *object.Null:
+		return "<null>"
+	case *object.Instance:
+		return obj.Class.Name
+	}
+	return string(obj.Type())
+}
@@ -1,9 +1,12 @@
 package evaluator
 
 import (
+	"bytes"
 	"java/lexer"
 	"java/object"
 	"java/parser"
+	"java/typecheck"
+	"os"
 	"testing"
 )
 
@@ -25,7 +28,39 @@ func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	return Eval(program)
+	env := object.NewEnvironment()
+	return Eval(program, env)
+}
+
+// testCheckedEval type checks input before evaluating it, as the
+// interpreter does, so that overloaded calls are resolved.
+func testCheckedEval(t *testing.T, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+	if diags := typecheck.New().Check(program); len(diags) != 0 {
+		t.Fatalf("type errors for %q: %s", input, diags[0].Message)
+	}
+	return Eval(program, object.NewEnvironment())
+}
+
+// testTypeError returns the message of the first error the type checker
+// reports for input, which the interpreter then does not evaluate.
+func testTypeError(t *testing.T, input string) string {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+	diags := typecheck.New().Check(program)
+	if len(diags) == 0 {
+		return ""
+	}
+	return diags[0].Message
 }
 
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
@@ -75,3 +110,1471 @@ func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 
 	return true
 }
+
+const pointClass = `
+class Point {
+	int x;
+	int y;
+	String label = "p";
+	boolean seen;
+
+	Point() {}
+
+	Point(int x, int y) {
+		this.x = x;
+		this.y = y;
+	}
+
+	Point(String label) {
+		this.label = label;
+	}
+
+	int sum() {
+		return x + y;
+	}
+
+	Point move(int dx, int dy) {
+		x = x + dx;
+		this.y = this.y + dy;
+		return this;
+	}
+
+	String toString() {
+		return label + "(" + x + ", " + y + ")";
+	}
+}
+`
+
+func TestObjects(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"new Point(1, 2).x", 1},
+		{"new Point(1, 2).sum()", 3},
+		{"new Point(1, 2).move(3, 4).sum()", 10},
+		{"new Point().x", 0},
+		{"new Point().seen", false},
+		{"new Point().label", "p"},
+		{`new Point("q").label`, "q"},
+		{`new Point("q").x`, 0},
+		{`"at " + new Point(1, 2)`, "at p(1, 2)"},
+		{"new Point(1, 2).x = 5", 5},
+		{"new Point() == new Point()", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(pointClass + tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestInstancesHaveTheirOwnFields(t *testing.T) {
+	input := `
+class Counter {
+	int count;
+	Counter other;
+
+	void tick() {
+		count++;
+	}
+}
+
+class Pair {
+	Counter a = new Counter();
+	Counter b = new Counter();
+
+	Pair() {
+		a.tick();
+		a.tick();
+		b.tick();
+		a.other = b;
+	}
+}
+
+public int check(int a, int b) {
+	return a * 10 + b;
+}
+
+check(new Pair().a.count, new Pair().b.count) + new Pair().a.other.count * 100
+`
+	testIntegerObject(t, testEval(input), 121)
+}
+
+func TestInitializationOrder(t *testing.T) {
+	input := `
+class Log {
+	String text = "a";
+	{ text = text + "b"; }
+	String more = text + "c";
+
+	Log() {
+		text = more + "d";
+	}
+}
+
+new Log().text
+`
+	testObject(t, input, testEval(input), "abcd")
+}
+
+func TestLocalVariableDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"Point p = new Point(1, 2); p.x", 1},
+		{"Point p; p = new Point(3, 4); p.y", 4},
+		{"int a = 1, b, c = a + 2; b = 10; a + b + c", 14},
+		{"double d = 1; d", 1.0},
+		{`final String s = "x"; s + s`, "xx"},
+		{"Point[] ps = null; ps == null", true},
+		{`Point p = null; "" + p`, "null"},
+		{"boolean b; b = 1 < 2; b", true},
+		{"var p = new Point(5, 6); p.x + p.y", 11},
+		{`var s = "a"; s + 1`, "a1"},
+		{"var d = 1.5; d * 2", 3.0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, pointClass+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestFinalFields(t *testing.T) {
+	input := `
+class Range {
+	static final int LIMIT;
+	static { LIMIT = 10; }
+	final int low;
+	final int high;
+	Range(int low, int high) {
+		this.low = low;
+		if (high > LIMIT) {
+			this.high = LIMIT;
+		} else {
+			this.high = high;
+		}
+	}
+	Range(final int high) { this(0, high); }
+	public int size() { return high - low; }
+}
+new Range(3, 20).size() + new Range(4).size()`
+	testObject(t, input, testCheckedEval(t, input), 11)
+}
+
+func TestObjectErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"new Missing()", "cannot find symbol: class Missing"},
+		{"new Point(true)", "no suitable constructor found for Point(boolean)"},
+		{"new Point().z", "cannot find symbol: variable z"},
+		{"new Point().nope()", "cannot find symbol: method nope()"},
+		{"new Point().label.x", "cannot find symbol: variable x"},
+		{"this", "non-static variable this cannot be referenced from a static context"},
+		{"class E {}\nnew E(1)", "constructor E in class E cannot be applied to given types"},
+		{"class V { void f() {} }\n1 + new V().f()", "'void' type not allowed here"},
+	}
+
+	for _, tt := range tests {
+		if message := testTypeError(t, pointClass+tt.input); message != tt.message {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.message, message)
+		}
+	}
+}
+
+func testObject(t *testing.T, input string, obj object.Object, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case int:
+		return testIntegerObject(t, obj, int64(expected))
+	case bool:
+		return testBooleanObject(t, obj, expected)
+	case float64:
+		d, ok := obj.(*object.Double)
+		if !ok {
+			t.Errorf("%q: object is not Double. got=%T (%+v)", input, obj, obj)
+			return false
+		}
+		if d.Value != expected {
+			t.Errorf("%q: object has wrong value. got=%v, want=%v", input, d.Value, expected)
+			return false
+		}
+	case string:
+		str, ok := obj.(*object.String)
+		if !ok {
+			t.Errorf("%q: object is not String. got=%T (%+v)", input, obj, obj)
+			return false
+		}
+		if str.Value != expected {
+			t.Errorf("%q: object has wrong value. got=%q, want=%q", input, str.Value, expected)
+			return false
+		}
+	}
+	return true
+}
+
+const animalClasses = `
+class Dog extends Animal {
+	String name = "dog";
+	String log = "";
+
+	Dog() {
+		this("rex");
+		log = log + "Dog() ";
+	}
+
+	Dog(String n) {
+		super(n);
+		log = log + "Dog(String) ";
+	}
+
+	String speak() {
+		return "woof from " + name + "/" + super.name;
+	}
+
+	String parentSpeak() {
+		return super.speak();
+	}
+
+	String sound() {
+		return "bark";
+	}
+}
+
+class Animal {
+	String name = "animal";
+	String log = "";
+	String seen = describe();
+
+	Animal(String n) {
+		log = "Animal(" + n + ") ";
+		name = n;
+	}
+
+	String speak() {
+		return "..." + name;
+	}
+
+	String describe() {
+		return "I say " + sound();
+	}
+
+	String sound() {
+		return "?";
+	}
+}
+
+class Puppy extends Dog {
+	String sound() {
+		return "yip";
+	}
+}
+`
+
+func TestInheritance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// Dispatch selects the override of the runtime class.
+		{"new Dog().speak()", "woof from dog/rex"},
+		{"new Dog().describe()", "I say bark"},
+		{"new Puppy().describe()", "I say yip"},
+		{`new Animal("cat").describe()`, "I say ?"},
+		// super.method() does not dispatch.
+		{"new Dog().parentSpeak()", "...rex"},
+		// Fields hide rather than override.
+		{"new Dog().name", "dog"},
+		{`new Dog("max").speak()`, "woof from dog/max"},
+		// The super constructor runs before the field initializers and
+		// the body of the subclass constructor, and methods it calls
+		// already dispatch to the subclass.
+		{"new Dog().log", "Dog(String) Dog() "},
+		{"new Dog().seen", "I say bark"},
+		{"new Puppy().seen", "I say yip"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(animalClasses + tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestConstructorOrder(t *testing.T) {
+	input := `
+class Base {
+	String trace = "base-field ";
+
+	Base() {
+		trace = trace + "base ";
+	}
+}
+
+class Derived extends Base {
+	String mine = record("derived-field ");
+
+	Derived() {
+		this(1);
+		trace = trace + "derived() ";
+	}
+
+	Derived(int x) {
+		trace = trace + "derived(int) ";
+	}
+
+	String record(String s) {
+		trace = trace + s;
+		return s;
+	}
+}
+
+new Derived().trace
+`
+	testObject(t, input, testEval(input), "base-field base derived-field derived(int) derived() ")
+}
+
+func TestInheritanceErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"class A extends B {}", "cannot find symbol: class B"},
+		{"class A extends B {}\nclass B extends A {}", "cyclic inheritance involving A"},
+		{"final class A {}\nclass B extends A {}", "cannot inherit from final A"},
+		{"class A { A(int x) {} }\nclass B extends A {}\nnew B()", "constructor A in class A cannot be applied to given types"},
+		{"class A { A() { this(); } }\nnew A()", "recursive constructor invocation"},
+		{"class A { A() { int x = 1; super(); } }\nnew A()", "call to super must be first statement in constructor"},
+		{"class A { void f() { super.f(); } }\nnew A().f()", "cannot find symbol: method f()"},
+	}
+
+	for _, tt := range tests {
+		if message := testTypeError(t, tt.input); message != tt.message {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.message, message)
+		}
+	}
+}
+
+func TestInterfacesAndDefaultMethods(t *testing.T) {
+	classes := `
+interface Shape {
+	int area();
+	default String describe() { return name() + " with area " + area(); }
+	private String name() { return "shape"; }
+}
+
+interface Named {
+	default String name() { return "named"; }
+}
+
+interface Loud extends Named {
+	default String name() { return "LOUD"; }
+}
+
+abstract class Base implements Shape {
+	abstract int side();
+	public int area() { return side() * side(); }
+}
+
+class Square extends Base implements Loud, Named {
+	int s;
+	Square(int s) { this.s = s; }
+	int side() { return s; }
+}
+
+interface Left { default String hi() { return "left"; } }
+interface Right { default String hi() { return "right"; } }
+
+class Both implements Left, Right {
+	public String hi() { return Left.super.hi() + "+" + Right.super.hi(); }
+}
+
+class Named2 extends Square {
+	Named2() { super(1); }
+	public String name() { return "class wins"; }
+}
+
+public String check(Shape s) {
+	return s.describe();
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"new Square(3).area()", 9},
+		{"new Square(3).describe()", "shape with area 9"},
+		{"new Square(2).name()", "LOUD"},
+		{"new Both().hi()", "left+right"},
+		{"new Named2().name()", "class wins"},
+		{"check(new Square(4))", "shape with area 16"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(classes + tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+
+	if message := testTypeError(t, classes+"new Base()"); message != "Base is abstract; cannot be instantiated" {
+		t.Errorf("expected instantiation error. got=%q", message)
+	}
+}
+
+func TestPrivateAndStaticMethodsAreNotOverridden(t *testing.T) {
+	classes := `
+class A {
+	private String who() { return "A"; }
+	static String kind() { return "static A"; }
+	String call(A other) { return who() + other.who(); }
+	String callStatic() { return kind(); }
+}
+
+class B extends A {
+	String who() { return "B"; }
+	static String kind() { return "static B"; }
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"new B().call(new B())", "AA"},
+		{"new B().who()", "B"},
+		{"new B().callStatic()", "static A"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(classes + tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestStaticMembers(t *testing.T) {
+	classes := `
+class Counter {
+	static int count;
+	int id;
+	Counter() { count++; id = count; }
+	static int increment() { count++; return count; }
+	static int twice() { increment(); return increment(); }
+	int total() { return count; }
+}
+
+interface Limits { int MAX = 10; }
+
+class Bounded implements Limits {
+	static int clamp(int n) { if (n > MAX) { return MAX; } return n; }
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"Counter.count", 0},
+		{"Counter.increment(); Counter.increment()", 2},
+		{"Counter.twice()", 2},
+		{"new Counter(); new Counter(); Counter.count", 2},
+		{"new Counter(); new Counter().id", 2},
+		{"new Counter().total() + new Counter().total()", 3},
+		{"Counter.count = 5; Counter.increment()", 6},
+		{"new Counter().count", 1},
+		{"Limits.MAX", 10},
+		{"Bounded.clamp(12)", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(classes + tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestClassInitialization(t *testing.T) {
+	classes := `
+class Log {
+	static String text = "";
+	static void add(String s) { text = text + s; }
+}
+
+class A {
+	static { Log.add("A"); }
+	static int x = f();
+	static int f() { Log.add("x"); return 1; }
+}
+
+class B extends A {
+	static { Log.add("B"); }
+	{ Log.add("i"); }
+	B() { Log.add("c"); }
+}
+
+class Lazy {
+	static int value = 1;
+	static final int LIMIT = 5 * 2;
+	static final String NAME = "lazy";
+	static { Log.add("lazy"); }
+}
+
+class Eager extends Lazy {
+	static { Log.add("eager"); }
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// Classes are only initialized when they are first used.
+		{"Log.text", ""},
+		{"Log.add(\"start\"); new B(); new B(); Log.text", "startAxBicic"},
+		{"A.x; Log.text", "Ax"},
+		// Calling a static method through a subclass only initializes the
+		// class declaring it.
+		{"A.f(); B.f(); Log.text", "Axxx"},
+		{"Log.add(\"-\"); Lazy.value = 2; Log.text", "-lazy"},
+		// Reading a static field through a subclass only initializes the
+		// class declaring it.
+		{"int v = Eager.value; Log.text", "lazy"},
+		{"Eager.value = 2; Log.text", "lazy"},
+		// Reading a constant variable initializes no class.
+		{"int n = Lazy.LIMIT + Eager.LIMIT; Log.text + n", "20"},
+		{"Lazy.NAME + Log.text", "lazy"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(classes + tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestFailedClassInitialization(t *testing.T) {
+	classes := `
+class Broken {
+	static int k = 1 / 0;
+	static int j = 2;
+}
+
+class Sub extends Broken {
+	static int m = 3;
+}
+
+class Asserting {
+	static int k = fail();
+	static int fail() { throw new AssertionError("broken"); }
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`String s = ""; try { int k = Broken.k; } catch (ExceptionInInitializerError e) { s = e.getCause().getMessage(); } s`,
+			"/ by zero"},
+		// The class is erroneous once its initialization fails.
+		{`String s = ""; try { int k = Broken.k; } catch (ExceptionInInitializerError e) {}
+		  try { int j = Broken.j; } catch (NoClassDefFoundError e) { s = e.getMessage(); } s`,
+			"Could not initialize class Broken"},
+		// So is a subclass, whose superclass fails to initialize.
+		{`String s = ""; try { int m = Sub.m; } catch (ExceptionInInitializerError e) { s = "EIIE"; }
+		  try { int m = Sub.m; } catch (NoClassDefFoundError e) { s = s + " " + e.getMessage(); } s`,
+			"EIIE Could not initialize class Sub"},
+		// Errors are rethrown as they are.
+		{`String s = ""; try { int k = Asserting.k; } catch (AssertionError e) { s = e.getMessage(); } s`,
+			"broken"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+
+	evaluated := testCheckedEval(t, classes+"Broken.j")
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "java.lang.ExceptionInInitializerError" {
+		t.Errorf("expected an ExceptionInInitializerError. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestOverloading(t *testing.T) {
+	classes := `
+class Animal {
+	String meet(Animal a) { return "animal"; }
+	String meet(Dog d) { return "dog"; }
+	String greet(Animal a) { return meet(a); }
+}
+
+class Dog extends Animal {}
+
+class Printer {
+	String print(int x) { return "int"; }
+	String print(double x) { return "double " + x; }
+	String print(String s) { return "String"; }
+	String print(Object o) { return "Object"; }
+	String box(Integer i) { return "Integer"; }
+	String box(long l) { return "long"; }
+	String widen(double d) { return "double " + d; }
+	String count(String label, int... xs) { return label + xs.length; }
+	int sum(int... xs) { return xs[0] + xs[1]; }
+	String varargs(int... xs) { return "varargs"; }
+	String varargs(int x) { return "fixed"; }
+}
+
+public String f(int x) { return "f(int)"; }
+public String f(String s) { return "f(String)"; }
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"new Printer().print(1)", "int"},
+		{"new Printer().print(null)", "String"},
+		{"new Printer().print(1.5)", "double 1.5"},
+		{"new Printer().print(\"s\")", "String"},
+		{"new Printer().print(new Dog())", "Object"},
+		// Widening is preferred to boxing.
+		{"new Printer().box(1)", "long"},
+		{"new Printer().widen(2)", "double 2.0"},
+		{"new Printer().count(\"n\", 1, 2, 3)", "n3"},
+		{"new Printer().count(\"n\")", "n0"},
+		{"new Printer().sum(1, 2)", 3},
+		// Variable arity is the last resort.
+		{"new Printer().varargs(1)", "fixed"},
+		{"new Printer().varargs(1, 2)", "varargs"},
+		// Overloads are chosen by the static type of the arguments.
+		{"new Animal().meet(new Dog())", "dog"},
+		{"new Animal().greet(new Dog())", "animal"},
+		{"f(1) + \" \" + f(\"s\")", "f(int) f(String)"},
+		{"1.5 * 2", 3.0},
+		{"7 / 2 + 0.5", 3.5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	classes := `
+class Box<T> {
+	private T value;
+	Box(T value) { this.value = value; }
+	T get() { return value; }
+	<R> R with(R other) { return other; }
+}
+
+class Pair<A, B extends Comparable<B>> {
+	A first;
+	B second;
+	Pair(A first, B second) { this.first = first; this.second = second; }
+	boolean secondIsLess(B other) { return second.compareTo(other) < 0; }
+}
+
+class Version implements Comparable<Version> {
+	int number;
+	Version(int number) { this.number = number; }
+	public int compareTo(Version other) { return number - other.number; }
+}
+
+class Holder {
+	static Box<String> text = new Box<>("boxed");
+	static Box<Box<Integer>> nested = new Box<Box<Integer>>(new Box<>(7));
+	static Pair<String, Integer> pair = new Pair<>("one", 1);
+}
+
+public static <T extends Comparable<T>> T max(T a, T b) {
+	if (a.compareTo(b) > 0) {
+		return a;
+	}
+	return b;
+}
+
+public int count(Box<? extends Number> box, Box<? super Integer>... boxes) { return boxes.length; }
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Holder.text.get()`, "boxed"},
+		{`Holder.nested.get().get()`, 7},
+		{`Holder.text.<Integer>with(3)`, 3},
+		{`Holder.pair.first`, "one"},
+		{`Holder.pair.secondIsLess(2)`, true},
+		{`max(3, 7)`, 7},
+		{`max("pear", "apple")`, "pear"},
+		{`max(2.5, 1.5)`, 2.5},
+		{`max(new Version(2), new Version(1)).number`, 2},
+		{`max(max(1, 5), 3)`, 5},
+		{`count(new Box<>(1), new Box<>(2), new Box<>(3))`, 2},
+		{`"b".compareTo("abc")`, 1},
+		{`"abc".compareTo("abcde")`, -2},
+		{`"abc".length()`, 3},
+		{`"x".equals("x")`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestLambdas(t *testing.T) {
+	classes := `
+interface Op { double apply(int a); }
+
+interface Shape {
+	double area();
+	default double twice() { return 2 * area(); }
+}
+
+class Counter {
+	int count;
+	Counter(int count) { this.count = count; }
+	int get() { return count; }
+	static int twice(int x) { return 2 * x; }
+	Supplier<Integer> getter() { return this::get; }
+	Runnable incrementer() { return () -> { count++; }; }
+}
+
+public int apply(Function<Integer, Integer> f, int x) { return f.apply(x); }
+
+public Supplier<Integer> adder(int a, int b) {
+	int sum = a + b;
+	return () -> sum;
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Function<Integer, Integer> f = x -> x * 2; f.apply(21)`, 42},
+		{`Supplier<String> s = () -> "hi"; s.get()`, "hi"},
+		{`BiFunction<Integer, Integer, Integer> add = (a, b) -> { return a + b; }; add.apply(3, 4)`, 7},
+		{`Predicate<String> p = s -> s.length() > 3; p.test("hello")`, true},
+		{`Consumer<Counter> reset = c -> c.count = 0; Counter c = new Counter(9); reset.accept(c); c.count`, 0},
+		{`Comparator<String> c = (a, b) -> a.length() - b.length(); c.compare("aa", "b")`, 1},
+		{`Op half = a -> a / 2; half.apply(5)`, 2.0},
+		{`Op same = (int a) -> a; same.apply(5)`, 5.0},
+		{`Shape s = () -> 3; s.twice()`, 6.0},
+		{`apply(x -> x + 1, 1)`, 2},
+		{`apply(x -> apply(y -> x * y, 3), 4)`, 12},
+		{`adder(2, 3).get()`, 5},
+		{`Counter c = new Counter(1); Runnable r = c.incrementer(); r.run(); r.run(); c.count`, 3},
+		{`Function<Integer, Counter> make = Counter::new; make.apply(4).count`, 4},
+		{`Function<Counter, Integer> get = Counter::get; get.apply(new Counter(5))`, 5},
+		{`Function<Integer, Integer> twice = Counter::twice; twice.apply(6)`, 12},
+		{`new Counter(7).getter().get()`, 7},
+		{`Function<String, Integer> length = String::length; length.apply("abcd")`, 4},
+		{`String s = "xyz"; Supplier<Integer> length = s::length; length.get()`, 3},
+		{`Runnable r = () -> {}; r.equals(r)`, true},
+		{`Supplier<Integer> s = () -> 1; Object o = s; o == s`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+
+	// Without the type checker, the functional interface is not known.
+	input := `public int apply(Function<Integer, Integer> f, int x) { return f.apply(x); }
+apply(x -> x * 3, 3)`
+	testObject(t, input, testEval(input), 9)
+
+	input = `Counter c = null; Supplier<Integer> s = c::get;`
+	evaluated := testCheckedEval(t, classes+input)
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "java.lang.NullPointerException" {
+		t.Errorf("expected a NullPointerException for %q. got=%T (%+v)", input, evaluated, evaluated)
+	}
+}
+
+func TestEnums(t *testing.T) {
+	classes := `
+enum Color { RED, GREEN, BLUE }
+
+enum Op {
+	PLUS("+") {
+		int apply(int a, int b) { return a + b; }
+	},
+	TIMES("*") {
+		int apply(int a, int b) { return a * b; }
+		public String toString() { return "times"; }
+	};
+
+	private final String symbol;
+	Op(String symbol) { this.symbol = symbol; }
+	abstract int apply(int a, int b);
+	String symbol() { return symbol; }
+}
+
+public String describe(Color c) {
+	return switch (c) {
+		case RED -> "warm";
+		case GREEN, BLUE -> {
+			String s = "cool";
+			yield s + "!";
+		}
+	};
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Color.values().length`, 3},
+		{`Color.values()[1] == Color.GREEN`, true},
+		{`Color.valueOf("BLUE") == Color.BLUE`, true},
+		{`Color.BLUE.ordinal()`, 2},
+		{`Color.GREEN.name()`, "GREEN"},
+		{`"" + Color.RED`, "RED"},
+		{`Color.RED.compareTo(Color.BLUE)`, -2},
+		{`Color.RED.equals(Color.RED)`, true},
+		{`Op.PLUS.apply(2, 3) + Op.TIMES.apply(2, 3)`, 11},
+		{`Op.TIMES.symbol() + Op.TIMES + Op.PLUS`, "*timesPLUS"},
+		{`Op.valueOf("TIMES").ordinal()`, 1},
+		{`describe(Color.RED) + describe(Color.BLUE)`, "warmcool!"},
+		{`String s = "none";
+		  switch (Color.GREEN) {
+			case RED -> s = "red";
+			case GREEN -> { s = "green"; }
+			default -> throw new IllegalStateException();
+		  }
+		  s`, "green"},
+		{`int n = 3; (switch (n) { case 1, 2 -> "small"; case 3 -> "three"; default -> "big"; })`, "three"},
+		{`(switch ("b") { case "a" -> 1; case "b" -> 2; default -> 3; })`, 2},
+		{`double d = switch (7) { case 1 -> 1.5; default -> 2; }; d`, 2.0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+
+	errors := []struct {
+		input   string
+		message string
+	}{
+		{`Color.valueOf("PURPLE")`, "java.lang.IllegalArgumentException: No enum constant Color.PURPLE"},
+		{`Color c = null; describe(c)`, "java.lang.NullPointerException"},
+		{`int n = switch (1) { case 2 -> 0; default -> throw new IllegalStateException("no"); };`, "java.lang.IllegalStateException: no"},
+	}
+	for _, tt := range errors {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != tt.message {
+			t.Errorf("expected %q for %q. got=%T (%+v)", tt.message, tt.input, evaluated, evaluated)
+		}
+	}
+}
+
+func TestRecordsAndPatterns(t *testing.T) {
+	classes := `
+record Point(int x, int y) {
+	Point {
+		if (x < 0) { throw new IllegalArgumentException("negative x"); }
+	}
+	Point(int x) { this(x, x); }
+	int sum() { return x + y; }
+}
+
+record Line(Point start, Point end) {}
+
+sealed interface Shape permits Circle, Square, Group {}
+record Circle(int r) implements Shape {}
+record Square(int side) implements Shape {}
+final class Group implements Shape {}
+
+public int area(Shape s) {
+	return switch (s) {
+		case Circle c when c.r() == 0 -> -1;
+		case Circle(int r) -> 3 * r * r;
+		case Square(var side) -> side * side;
+		case Group g -> 0;
+	};
+}
+
+public String describe(Object o) {
+	return switch (o) {
+		case null -> "null";
+		case Line(Point(var x1, var y1), Point end) when x1 == y1 -> "diagonal to " + end;
+		case Line l -> "line";
+		case Integer i when i > 9 -> "big " + i;
+		case Integer i -> "int " + i;
+		case String s -> "string " + s.length();
+		default -> "other";
+	};
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`new Point(1, 2).x() + new Point(1, 2).y()`, 3},
+		{`new Point(4).sum()`, 8},
+		{`"" + new Point(1, 2)`, "Point[x=1, y=2]"},
+		{`"" + new Line(new Point(1), null)`, "Line[start=Point[x=1, y=1], end=null]"},
+		{`new Point(1, 2).equals(new Point(1, 2))`, true},
+		{`new Point(1, 2).equals(new Point(2, 1))`, false},
+		{`new Point(1, 2) == new Point(1, 2)`, false},
+		{`new Point(1, 2).hashCode() == new Point(1, 2).hashCode()`, true},
+		{`new Point(1, 2).hashCode()`, 33},
+		{`new Line(new Point(1), null).equals(new Line(new Point(1), null))`, true},
+		{`area(new Circle(2)) + area(new Square(3)) + area(new Circle(0))`, 20},
+		{`describe(new Line(new Point(2), new Point(3, 4)))`, "diagonal to Point[x=3, y=4]"},
+		{`describe(new Line(new Point(2, 3), null))`, "line"},
+		{`describe(12) + ", " + describe(2) + ", " + describe("abc")`, "big 12, int 2, string 3"},
+		{`describe(null) + ", " + describe(2.5)`, "null, other"},
+		{`Object o = "text"; o instanceof String`, true},
+		{`Object o = 1; o instanceof String s`, false},
+		{`Object o = null; o instanceof Object`, false},
+		{`Object o = "text"; int n = 0; if (o instanceof String s) { n = s.length(); } n`, 4},
+		{`Object o = new Point(1, 2);
+		  if (!(o instanceof Point(int a, int b))) { throw new IllegalStateException(); }
+		  a * 10 + b`, 12},
+		{`Object o = new Line(new Point(1), new Point(2));
+		  int n = 0;
+		  if (o instanceof Line(Point(var a, var b), Point(int c, int d))) { n = a + d; }
+		  n`, 3},
+		{`String s = null; (switch (s) { case null -> "none"; case "a" -> "a"; default -> "other"; })`, "none"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+
+	errors := []struct {
+		input   string
+		message string
+	}{
+		{`new Point(-1, 0)`, "java.lang.IllegalArgumentException: negative x"},
+		{`Object o = null; (switch (o) { case String s -> 1; default -> 2; })`, "java.lang.NullPointerException"},
+	}
+	for _, tt := range errors {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != tt.message {
+			t.Errorf("expected %q for %q. got=%T (%+v)", tt.message, tt.input, evaluated, evaluated)
+		}
+	}
+}
+
+func TestNestedClasses(t *testing.T) {
+	classes := `
+interface Counter { int next(); }
+
+class Outer {
+	private int x = 10;
+	static int count = 0;
+
+	class Inner {
+		int y = 5;
+		int sum() { return x + y; }
+		int outerX() { return Outer.this.x; }
+		void bump() { x = x + 1; count = count + 1; }
+	}
+
+	static class Nested {
+		String hello() { return "nested " + count; }
+	}
+
+	class Deeper extends Inner {
+		int sum() { return super.sum() * 2; }
+	}
+
+	Inner make() { return new Inner(); }
+
+	Counter counter(int start) {
+		int step = 2;
+		class Local implements Counter {
+			int n = start;
+			public int next() { n = n + step; return n + x; }
+		}
+		return new Local();
+	}
+
+	Counter anonymous(int start) {
+		return new Counter() {
+			int n = start;
+			public int next() { n = n + 1; return n + x; }
+		};
+	}
+}
+
+class Shadow {
+	int v = 1;
+	int f() {
+		int v = 2;
+		class L {
+			int v = 3;
+			int g() { return v * 100 + Shadow.this.v * 10; }
+		}
+		return new L().g() + v;
+	}
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`Outer o = new Outer(); Outer.Inner i = o.new Inner(); i.sum()`, 15},
+		{`Outer o = new Outer(); Outer.Inner i = o.make(); i.bump(); i.outerX() + Outer.count`, 12},
+		{`Outer o = new Outer(); Outer.Inner a = o.new Inner(); Outer.Inner b = o.new Inner(); a.bump(); b.sum()`, 16},
+		{`new Outer.Nested().hello()`, "nested 0"},
+		{`Outer o = new Outer(); o.new Deeper().sum()`, 30},
+		{`Counter c = new Outer().counter(1); c.next(); c.next()`, 15},
+		{`Counter c = new Outer().anonymous(1); c.next(); c.next()`, 13},
+		{`new Shadow().f()`, 312},
+		{`Object o = new Object() { public String toString() { return "anonymous"; } }; "" + o`, "anonymous"},
+		{`Comparator<String> byLength = new Comparator<>() {
+			public int compare(String a, String b) { return a.length() - b.length(); }
+		  };
+		  byLength.compare("abc", "a")`, 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+
+	errors := []struct {
+		input   string
+		message string
+	}{
+		{`Outer o = null; o.new Inner()`, "java.lang.NullPointerException"},
+	}
+	for _, tt := range errors {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != tt.message {
+			t.Errorf("expected %q for %q. got=%T (%+v)", tt.message, tt.input, evaluated, evaluated)
+		}
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	classes := `
+class InsufficientFunds extends Exception {
+	int missing;
+	InsufficientFunds(int missing) {
+		super("missing " + missing);
+		this.missing = missing;
+	}
+}
+
+class Account {
+	int balance = 10;
+	int[] history;
+
+	void withdraw(int amount) throws InsufficientFunds {
+		if (amount > balance) {
+			throw new InsufficientFunds(amount - balance);
+		}
+		balance = balance - amount;
+	}
+
+	String tryWithdraw(int amount) {
+		try {
+			withdraw(amount);
+			return "ok";
+		} catch (InsufficientFunds e) {
+			return e.getMessage();
+		} finally {
+			balance = balance + 1;
+		}
+	}
+
+	int afterFailure() {
+		tryWithdraw(15);
+		return balance;
+	}
+
+	int overridden() {
+		try {
+			return 1;
+		} finally {
+			return 2;
+		}
+	}
+}
+
+public String divide(int a, int b) {
+	try {
+		return "" + a / b;
+	} catch (IllegalStateException | ArithmeticException e) {
+		return e.toString();
+	}
+}
+
+public <T> T id(T value) {
+	return value;
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"new Account().tryWithdraw(5)", "ok"},
+		{"new Account().tryWithdraw(15)", "missing 5"},
+		{"new Account().afterFailure()", 11},
+		{"new Account().overridden()", 2},
+		{"divide(6, 3)", "2"},
+		{"divide(1, 0)", "java.lang.ArithmeticException: / by zero"},
+		// A catch clause for a superclass catches subclasses too.
+		{`String s = "";
+		  try { new Account().history[0]; } catch (RuntimeException e) { s = e.getMessage(); }
+		  s`, "Cannot load from int array"},
+		{`String s = "";
+		  try { throw new IllegalStateException("bad", new Error("cause")); }
+		  catch (Exception e) { s = e.getCause().getMessage(); }
+		  finally { s = s + "!"; }
+		  s`, "cause!"},
+		{`String s = "";
+		  try { try { throw new RuntimeException("inner"); } finally { s = "finally "; } }
+		  catch (RuntimeException e) { s = s + e.getMessage(); }
+		  s`, "finally inner"},
+		{`String s = "";
+		  try { throw new RuntimeException(new IllegalArgumentException("x")); }
+		  catch (RuntimeException e) { s = e.getMessage(); }
+		  s`, "java.lang.IllegalArgumentException: x"},
+		// Unboxing null throws a NullPointerException.
+		{`Integer a = null; int b = 1;
+		  try { b = a + 1; } catch (NullPointerException e) { b = 2; }
+		  b`, 2},
+		{`Integer a = 3; int b = a; b + a`, 6},
+		{`Integer a = null; int b = 1;
+		  try { b = id(a); } catch (NullPointerException e) { b = 2; }
+		  b`, 2},
+		{`int b = id(3); b + id(1)`, 4},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestTryWithResources(t *testing.T) {
+	classes := `
+class Log {
+	static String text = "";
+}
+
+class Resource implements AutoCloseable {
+	String name;
+	boolean fails;
+	Resource(String name, boolean fails) {
+		this.name = name;
+		this.fails = fails;
+		Log.text = Log.text + "open " + name + ";";
+	}
+	public void close() {
+		Log.text = Log.text + "close " + name + ";";
+		if (fails) {
+			throw new IllegalStateException(name);
+		}
+	}
+}
+
+public String use(boolean fails) {
+	try (Resource a = new Resource("a", fails); Resource b = new Resource("b", fails)) {
+		Log.text = Log.text + "body;";
+		return Log.text;
+	}
+}
+
+public String suppressed() {
+	try (Resource a = new Resource("a", true); Resource b = new Resource("b", true)) {
+		throw new RuntimeException("body");
+	} catch (RuntimeException e) {
+		return e.getMessage() + " " + e.getSuppressed().length + " " +
+			e.getSuppressed()[0].getMessage() + e.getSuppressed()[1].getMessage();
+	}
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// Resources are closed in reverse order, after the block.
+		{`use(false) + Log.text`, "open a;open b;body;open a;open b;body;close b;close a;"},
+		{`suppressed()`, "body 2 ba"},
+		// An exception thrown by close propagates if the block completes
+		// normally, and the other resources are still closed.
+		{`String s = "";
+		  try { use(true); } catch (IllegalStateException e) { s = e.getMessage() + e.getSuppressed().length; }
+		  s + " " + Log.text`, "b1 open a;open b;body;close b;close a;"},
+		// A resource that fails to initialize is not closed, but the
+		// ones before it are.
+		{`String s = "";
+		  try (Resource a = new Resource("a", false); Resource b = new Resource("b" + 1 / 0, false)) {}
+		  catch (RuntimeException e) { s = e.getMessage(); }
+		  Log.text`, "open a;close a;"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestNull(t *testing.T) {
+	classes := `
+class Node {
+	int value;
+	int[] values;
+	Node next;
+	static Node head;
+	Node getNext() { return next; }
+	int nextValue() { return next.value; }
+}
+
+public int value(Node n) { return n.value; }
+public Throwable none() { return null; }
+public int unboxed(Integer n) { return n; }
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`String s = null; s == null`, true},
+		{`new Node().next == null`, true},
+		{`new Node().values != null`, false},
+		{`Node.head = new Node(); Node.head == Node.head`, true},
+		{`new Node() == new Node()`, false},
+		{`"null " + new Node().next`, "null null"},
+		// String literals are interned, but other strings are new objects.
+		{`String a = "ab"; String b = "ab"; a == b`, true},
+		{`String a = "ab"; String b = "a"; String c = b + "b"; a == c`, false},
+		{`new IllegalStateException(none()).getMessage() == null`, true},
+		// Boxed values are not objects of their own: == compares them by
+		// value. Java boxes only the values from -128 to 127 into shared
+		// objects, so a == b is false there for 1000.
+		{`Integer a = 100; Integer b = 100; a == b`, true},
+		{`Integer a = 1000; Integer b = 1000; a == b`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestObjectMethods(t *testing.T) {
+	classes := `
+class Point {
+	int x;
+	Point(int x) { this.x = x; }
+	public boolean equals(Point other) { return x == other.x; }
+}
+class Plain {
+	boolean self() { return equals(this); }
+	int rehash() { return hashCode() - this.hashCode(); }
+	boolean shown() { return toString().equals("" + this); }
+	public String toString() { return "plain"; }
+	boolean inherited() { return super.equals(this) == !super.toString().equals(toString()); }
+}
+`
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`new Point(1).equals(new Point(1))`, true},
+		{`new Point(1).equals("p")`, false},
+		{`new Plain().self()`, true},
+		{`new Plain().equals(new Plain())`, false},
+		{`new Plain().rehash()`, 0},
+		{`new Plain().shown()`, true},
+		{`new Plain().inherited()`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		testObject(t, tt.input, evaluated, tt.expected)
+	}
+}
+
+func TestNullPointerExceptions(t *testing.T) {
+	classes := `
+class Node {
+	int value;
+	int[] values;
+	boolean[] flags;
+	Node[] children;
+	Node next;
+	static Node head;
+	Node getNext() { return next; }
+	int nextValue() { return next.value; }
+	Node find(int value, String name) { return null; }
+	Node[] of(Node... nodes) { return nodes; }
+}
+
+public int value(Node n) { return n.value; }
+public Throwable none() { return null; }
+public int unboxed(Integer n) { return n; }
+`
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`String s = null; s.length()`, `Cannot invoke "String.length()" because "s" is null`},
+		{`value(null)`, `Cannot read field "value" because "n" is null`},
+		{`new Node().nextValue()`, `Cannot read field "value" because "this.next" is null`},
+		{`Node.head.value`, `Cannot read field "value" because "Node.head" is null`},
+		{`Node.head.next = null`, `Cannot assign field "next" because "Node.head" is null`},
+		{`new Node().getNext().getNext()`, `Cannot invoke "Node.getNext()" because the return value of "Node.getNext()" is null`},
+		{`new Node().find(1, "a").value`, `Cannot read field "value" because the return value of "Node.find(int, String)" is null`},
+		{`Node.head = new Node(); Node.head.values.length`, `Cannot read the array length because "Node.head.values" is null`},
+		{`Node.head = new Node(); Node.head.values[0]`, `Cannot load from int array because "Node.head.values" is null`},
+		{`Node.head = new Node(); Node.head.flags[0] = true`, `Cannot store to byte/boolean array because "Node.head.flags" is null`},
+		{`Node.head = new Node(); Node.head.children = Node.head.of(Node.head, null); int i = 1; Node.head.children[i].value`,
+			`Cannot read field "value" because "Node.head.children[i]" is null`},
+		{`throw none();`, `Cannot throw exception because the return value of "Main.none()" is null`},
+		{`Integer a = null; int b = a;`, `Cannot invoke "java.lang.Integer.intValue()" because "a" is null`},
+		{`Integer a = null; a + 1`, `Cannot invoke "java.lang.Integer.intValue()" because "a" is null`},
+		{`Integer a = null; a == 1`, `Cannot invoke "java.lang.Integer.intValue()" because "a" is null`},
+		{`Double d = null; -d`, `Cannot invoke "java.lang.Double.doubleValue()" because "d" is null`},
+		{`Boolean b = null; if (b) {}`, `Cannot invoke "java.lang.Boolean.booleanValue()" because "b" is null`},
+		{`Integer a = null; new Node().find(a, "a")`, `Cannot invoke "java.lang.Integer.intValue()" because "a" is null`},
+		{`unboxed(null)`, `Cannot invoke "java.lang.Integer.intValue()" because "n" is null`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, classes+tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		expected := "java.lang.NullPointerException: " + tt.message
+		if errObj.Exception == nil || errObj.Message != expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, expected, errObj.Message)
+		}
+	}
+}
+
+func TestUncaughtExceptions(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"1 / 0", "java.lang.ArithmeticException: / by zero"},
+		{"class E extends RuntimeException {}\nthrow new E();", "E"},
+		{`try { 1 / 0; } catch (IllegalStateException e) {}`, "java.lang.ArithmeticException: / by zero"},
+		{`try { 1 / 0; } catch (ArithmeticException e) { throw new IllegalStateException("again", e); }`,
+			"java.lang.IllegalStateException: again"},
+		{"public int f() { return f(); }\nf()", "java.lang.StackOverflowError"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testCheckedEval(t, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Exception == nil {
+			t.Errorf("error for %q is not an exception: %q", tt.input, errObj.Message)
+		}
+		if errObj.Message != tt.message {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.message, errObj.Message)
+		}
+	}
+}
+
+func TestPrintStackTrace(t *testing.T) {
+	input := `public void check(int x) {
+	if (x > 1) {
+		throw new IllegalArgumentException("too big");
+	}
+}
+
+public void run() {
+	try {
+		check(2);
+	} catch (IllegalArgumentException e) {
+		throw new RuntimeException("run failed", e);
+	}
+}
+
+try {
+	run();
+} catch (RuntimeException e) {
+	e.printStackTrace();
+}
+`
+	expected := `java.lang.RuntimeException: run failed
+	at Main.run(Main.java:11)
+	at Main.main(Main.java:16)
+Caused by: java.lang.IllegalArgumentException: too big
+	at Main.check(Main.java:3)
+	at Main.run(Main.java:9)
+	... 1 more
+`
+	var out bytes.Buffer
+	Stderr = &out
+	defer func() { Stderr = os.Stderr }()
+
+	if result := testCheckedEval(t, input); isError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+	if out.String() != expected {
+		t.Errorf("wrong stack trace. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestPrintLambdaStackTrace(t *testing.T) {
+	input := `class Job {
+	Runnable task() {
+		return () -> {
+			throw new IllegalStateException("failed");
+		};
+	}
+}
+
+try {
+	new Job().task().run();
+} catch (IllegalStateException e) {
+	e.printStackTrace();
+}
+`
+	expected := `java.lang.IllegalStateException: failed
+	at Job.lambda$task$0(Main.java:4)
+	at Main.main(Main.java:10)
+`
+	var out bytes.Buffer
+	Stderr = &out
+	defer func() { Stderr = os.Stderr }()
+
+	if result := testCheckedEval(t, input); isError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+	if out.String() != expected {
+		t.Errorf("wrong stack trace. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
+
+func TestPrintSuppressedStackTrace(t *testing.T) {
+	input := `class Resource implements AutoCloseable {
+	public void close() {
+		throw new IllegalStateException("close");
+	}
+}
+
+try (Resource r = new Resource()) {
+	throw new RuntimeException("body");
+} catch (RuntimeException e) {
+	e.printStackTrace();
+}
+`
+	expected := `java.lang.RuntimeException: body
+	at Main.main(Main.java:8)
+	Suppressed: java.lang.IllegalStateException: close
+		at Resource.close(Main.java:3)
+		at Main.main(Main.java:7)
+`
+	var out bytes.Buffer
+	Stderr = &out
+	defer func() { Stderr = os.Stderr }()
+
+	if result := testCheckedEval(t, input); isError(result) {
+		t.Fatalf("unexpected error: %s", result.Inspect())
+	}
+	if out.String() != expected {
+		t.Errorf("wrong stack trace. expected=\n%s\ngot=\n%s", expected, out.String())
+	}
+}
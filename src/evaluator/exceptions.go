@@ -0,0 +1,421 @@
+package evaluator
+
+import (
+	"fmt"
+	"io"
+	"java/ast"
+	"java/lang"
+	"java/object"
+	"java/tokens"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SourceFile is the name of the file being run, as shown in stack traces.
+// Methods declared outside of any class belong to the class it names.
+var SourceFile = "Main.java"
+
+// Stderr is where printStackTrace prints to.
+var Stderr io.Writer = os.Stderr
+
+const (
+	// maxDepth is the number of nested calls at which a StackOverflowError
+	// is thrown.
+	maxDepth = 2000
+	// maxTraceDepth is the number of calls a stack trace records at most,
+	// as in the JVM.
+	maxTraceDepth = 1024
+)
+
+// frame is a method or constructor call in progress.
+type frame struct {
+	method string // the class and name of the method, e.g. "Point.<init>"
+	line   int    // the line being run
+}
+
+var (
+	stack []*frame
+	// overflowing is set while the StackOverflowError is created, as its
+	// constructors need a few more frames.
+	overflowing bool
+)
+
+// pushFrame records the start of a call of method. It returns a
+// StackOverflowError instead if the calls are nested too deeply.
+func pushFrame(method string) object.Object {
+	if len(stack) >= maxDepth && !overflowing {
+		overflowing = true
+		defer func() { overflowing = false }()
+		return newException("StackOverflowError", "")
+	}
+	stack = append(stack, &frame{method: method})
+	return nil
+}
+
+func popFrame() {
+	stack = stack[:len(stack)-1]
+}
+
+// at records that the innermost call is running the code at tok.
+func at(tok tokens.Token) {
+	if len(stack) > 0 {
+		stack[len(stack)-1].line = tok.Start.Line
+	}
+}
+
+// mainClass is the class of the methods declared outside of any class.
+func mainClass() string {
+	return strings.TrimSuffix(SourceFile, ".java")
+}
+
+// captureStackTrace returns the calls in progress, innermost first, in the
+// format of a stack trace.
+func captureStackTrace() []string {
+	var trace []string
+	for i := len(stack) - 1; i >= 0 && len(trace) < maxTraceDepth; i-- {
+		trace = append(trace, fmt.Sprintf("%s(%s:%d)", stack[i].method, SourceFile, stack[i].line))
+	}
+	return trace
+}
+
+var (
+	libraryOnce sync.Once
+	library     map[string]*object.Class
+)
+
+// libraryClasses returns the library classes by name. They are declared
+// once, in an environment of their own, and shared by all programs.
+func libraryClasses() map[string]*object.Class {
+	libraryOnce.Do(func() {
+		env := object.NewEnvironment()
+		var stmts []ast.Statement
+		for _, decl := range lang.Classes() {
+			stmts = append(stmts, decl)
+		}
+		declareClasses(stmts, env)
+		library = make(map[string]*object.Class)
+		for _, decl := range lang.Classes() {
+			val, _ := env.Get(decl.Name.Value)
+			library[decl.Name.Value] = val.(*object.Class)
+		}
+	})
+	return library
+}
+
+// declareLibrary makes the library classes visible in env, unless the
+// program declares classes of the same names.
+func declareLibrary(env *object.Environment) {
+	for name, class := range libraryClasses() {
+		if _, ok := env.Get(name); !ok {
+			env.Set(name, class)
+		}
+	}
+}
+
+// qualifiedName is the name of class as Class.getName returns it, which
+// includes the package of a library class.
+func qualifiedName(class *object.Class) string {
+	if pkg := lang.Package(class.Declaration); pkg != "" {
+		return pkg + "." + class.Name
+	}
+	return class.Name
+}
+
+func isThrowable(class *object.Class) bool {
+	return class.IsSubtypeOf(libraryClasses()["Throwable"])
+}
+
+// throw returns the error that propagates exception, which carries the
+// exception's description in case nothing catches it.
+func throw(exception *object.Instance) object.Object {
+	description := stringOf(exception)
+	if isError(description) {
+		return description
+	}
+	return &object.Error{Message: description.(*object.String).Value, Exception: exception}
+}
+
+// newException creates and throws the library exception name, with the
+// message given unless it is empty. It is how runtime faults such as a
+// division by zero surface in the program.
+func newException(name string, format string, a ...interface{}) object.Object {
+	var args []object.Object
+	sig := "()"
+	if format != "" {
+		args = []object.Object{&object.String{Value: fmt.Sprintf(format, a...)}}
+		sig = "(String)"
+	}
+	exception := instantiate(libraryClasses()[name], args, sig)
+	if isError(exception) {
+		return exception
+	}
+	return throw(exception.(*object.Instance))
+}
+
+func evalThrowStatement(node *ast.ThrowStatement, env *object.Environment) object.Object {
+	val := evalOperand(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	at(node.Token)
+	exception, ok := val.(*object.Instance)
+	if !ok || !isThrowable(exception.Class) {
+		if val == NULL {
+			return nullPointerException(node.Value, env, "Cannot throw exception")
+		}
+		return unchecked(node.String())
+	}
+	return throw(exception)
+}
+
+// nullPointerException throws the NullPointerException for using the null
+// value of node, with a message like the helpful ones of the JVM, e.g.
+// `Cannot invoke "Point.sum()" because "p" is null`.
+func nullPointerException(node ast.Expression, env *object.Environment, format string, a ...interface{}) object.Object {
+	message := fmt.Sprintf(format, a...)
+	if description := describeNull(node, env); description != "" {
+		message += " because " + description + " is null"
+	}
+	return newException("NullPointerException", "%s", message)
+}
+
+// describeNull is how the JVM refers to the expression node whose value
+// is null: a variable, such as "this.items[i]", or the return value of a
+// method. It is "" if the JVM would not describe it.
+func describeNull(node ast.Expression, env *object.Environment) string {
+	if call, ok := node.(*ast.CallExpression); ok {
+		return `the return value of "` + describeMethod(call, nil) + `"`
+	}
+	if path := describeVariable(node, env); path != "" {
+		return `"` + path + `"`
+	}
+	return ""
+}
+
+func describeVariable(node ast.Expression, env *object.Environment) string {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		if val, ok := env.Get(node.Value); ok {
+			if class, ok := val.(*object.Class); ok {
+				return qualifiedName(class)
+			}
+		}
+		return env.Describe(node.Value)
+	case *ast.ThisExpression:
+		return "this"
+	case *ast.MemberExpression:
+		if object := describeVariable(node.Object, env); object != "" {
+			return object + "." + node.Property.Value
+		}
+	case *ast.IndexExpression:
+		array := describeVariable(node.Left, env)
+		if array == "" {
+			return ""
+		}
+		switch index := node.Index.(type) {
+		case *ast.IntegerLiteral:
+			return array + "[" + index.String() + "]"
+		case *ast.Identifier:
+			return array + "[" + env.Describe(index.Value) + "]"
+		}
+		return array + "[...]"
+	}
+	return ""
+}
+
+// describeMethod is how the JVM names the method node calls, e.g.
+// "Point.move(int, int)". The parameter types are those overload
+// resolution chose, or else those of the arguments args.
+func describeMethod(node *ast.CallExpression, args []object.Object) string {
+	var name string
+	switch function := node.Function.(type) {
+	case *ast.Identifier:
+		name = function.Value
+	case *ast.MemberExpression:
+		name = function.Property.Value
+	}
+	receiver := node.Receiver
+	if _, ok := node.Function.(*ast.Identifier); ok && receiver == "" {
+		receiver = mainClass()
+	}
+	if receiver != "" {
+		name = receiver + "." + name
+	}
+
+	var types []string
+	if node.Signature != "" {
+		for _, t := range strings.Split(strings.Trim(node.Signature, "()"), ",") {
+			if t != "" {
+				types = append(types, qualifiedTypeName(t))
+			}
+		}
+	} else {
+		for _, arg := range args {
+			types = append(types, qualifiedTypeName(typeName(arg)))
+		}
+	}
+	return name + "(" + strings.Join(types, ", ") + ")"
+}
+
+// qualifiedTypeName is the type named t with the package of a library
+// class, e.g. "java.lang.Throwable[]" for "Throwable[]".
+func qualifiedTypeName(t string) string {
+	if t == "<null>" {
+		return "Object"
+	}
+	name := strings.TrimRight(t, "[]")
+	if class, ok := libraryClasses()[name]; ok {
+		return qualifiedName(class) + t[len(name):]
+	}
+	return t
+}
+
+// arrayKind is how the JVM describes arrays of type t, such as "int[]",
+// in the message of a NullPointerException.
+func arrayKind(t string) string {
+	switch element := strings.TrimSuffix(t, "[]"); element {
+	case "boolean", "byte":
+		return "byte/boolean"
+	case "char", "short", "int", "long", "float", "double":
+		return element
+	}
+	return "object"
+}
+
+// evalTryStatement runs the try block, then the first catch clause that
+// catches the exception it threw, if any. The finally block always runs
+// last. If it completes abruptly, by a return or an exception, that
+// replaces the outcome of the try block and the catch clauses.
+func evalTryStatement(node *ast.TryStatement, env *object.Environment) object.Object {
+	var result object.Object
+	if len(node.Resources) > 0 {
+		result = evalResources(node, node.Resources, object.NewEnclosedEnvironment(env))
+	} else {
+		result = Eval(node.Block, env)
+	}
+
+	if err, ok := result.(*object.Error); ok && err.Exception != nil {
+		for _, clause := range node.Catches {
+			if catches(clause, err.Exception, env) {
+				scope := object.NewEnclosedEnvironment(env)
+				scope.Set(clause.Parameter.Value, err.Exception)
+				result = evalBlockStatement(clause.Body, scope)
+				break
+			}
+		}
+	}
+
+	if node.Finally != nil {
+		switch finally := Eval(node.Finally, env).(type) {
+		case *object.ReturnValue, *object.YieldValue, *object.Error:
+			return finally
+		}
+	}
+	return result
+}
+
+// evalResources initializes the first of resources, runs the rest of the
+// try-with-resources statement node with it in scope, and then closes it,
+// so that resources are closed in the reverse order of their
+// initialization. As in the translation the JLS gives, an exception thrown
+// by close is added to the suppressed exceptions of the one the block
+// threw, if any, and a null resource is not closed.
+func evalResources(node *ast.TryStatement, resources []*ast.Resource, env *object.Environment) object.Object {
+	if len(resources) == 0 {
+		return Eval(node.Block, env)
+	}
+	r := resources[0]
+	val := evalOperand(r.Value, env)
+	if isError(val) {
+		return val
+	}
+	if r.Name != nil {
+		env.Set(r.Name.Value, val)
+	}
+
+	result := evalResources(node, resources[1:], env)
+	err, failed := result.(*object.Error)
+	if failed && err.Exception == nil {
+		return result
+	}
+	resource, ok := val.(*object.Instance)
+	if !ok {
+		return result
+	}
+	at(node.Token)
+	closed := invokeMethod(resource, "close", nil, "()")
+	if !isError(closed) {
+		return result
+	}
+	suppressed, ok := closed.(*object.Error)
+	if !failed || !ok || suppressed.Exception == nil {
+		return closed
+	}
+	if added := throwableAddSuppressed(err.Exception, []object.Object{suppressed.Exception}); isError(added) {
+		return added
+	}
+	return result
+}
+
+// catches reports whether clause catches exception, which it does if the
+// exception is an instance of one of the types the clause names.
+func catches(clause *ast.CatchClause, exception *object.Instance, env *object.Environment) bool {
+	for _, t := range clause.Types {
+		if class, ok := lookupClass(t.Name, env); ok && exception.Class.IsSubtypeOf(class) {
+			return true
+		}
+	}
+	return false
+}
+
+// StackTrace formats exception the way Throwable.printStackTrace prints
+// it: its description and where it was created, followed by the same for
+// its suppressed exceptions and its chain of causes. The calls these have
+// in common with the exception that encloses them are left out.
+func StackTrace(exception *object.Instance) string {
+	var out strings.Builder
+	printStackTrace(&out, exception, nil, "", "", map[*object.Instance]bool{})
+	return out.String()
+}
+
+// printStackTrace prints the stack trace of exception, indented by prefix.
+// Its suppressed exceptions are printed indented one more level.
+func printStackTrace(out *strings.Builder, exception *object.Instance, enclosing []string, caption, prefix string, seen map[*object.Instance]bool) {
+	if seen[exception] {
+		fmt.Fprintf(out, "%s%s[CIRCULAR REFERENCE: %s]\n", prefix, caption, describeException(exception))
+		return
+	}
+	seen[exception] = true
+	fmt.Fprintf(out, "%s%s%s\n", prefix, caption, describeException(exception))
+
+	trace := exception.StackTrace
+	m, n := len(trace)-1, len(enclosing)-1
+	for m >= 0 && n >= 0 && trace[m] == enclosing[n] {
+		m--
+		n--
+	}
+	for _, call := range trace[:m+1] {
+		fmt.Fprintf(out, "%s\tat %s\n", prefix, call)
+	}
+	if common := len(trace) - 1 - m; common > 0 {
+		fmt.Fprintf(out, "%s\t... %d more\n", prefix, common)
+	}
+
+	for _, suppressed := range exception.Suppressed {
+		printStackTrace(out, suppressed, trace, "Suppressed: ", prefix+"\t", seen)
+	}
+	cause := invokeMethod(exception, "getCause", nil, "()")
+	if cause, ok := cause.(*object.Instance); ok {
+		printStackTrace(out, cause, trace, "Caused by: ", prefix, seen)
+	}
+}
+
+// describeException is the result of exception.toString().
+func describeException(exception *object.Instance) string {
+	description := stringOf(exception)
+	if err, ok := description.(*object.Error); ok {
+		return err.Message
+	}
+	return description.(*object.String).Value
+}
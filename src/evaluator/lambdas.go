@@ -0,0 +1,198 @@
+package evaluator
+
+import (
+	"fmt"
+	"java/ast"
+	"java/object"
+	"java/tokens"
+	"strings"
+)
+
+// A lambda expression or method reference evaluates to an instance of a
+// class made for it, as the JVM makes, which implements the functional
+// interface the type checker recorded for it. Without a type checker the
+// interface is not known, and the instance stands for any interface.
+
+var (
+	// lambdaClasses holds the class made for each lambda expression and
+	// method reference.
+	lambdaClasses = map[ast.Expression]*object.Class{}
+	// lambdaMethods holds the name of the method the body of each lambda
+	// expression compiles to, and lambdaCounts the number of such methods
+	// in each class.
+	lambdaMethods = map[*ast.LambdaExpression]string{}
+	lambdaCounts  = map[string]int{}
+)
+
+func evalLambdaExpression(node *ast.LambdaExpression, env *object.Environment) object.Object {
+	fn := &object.Lambda{Expression: node, Env: env, Method: lambdaMethod(node)}
+	return newLambda(node, node.Interface, fn, env)
+}
+
+// lambdaMethod is the name javac gives the method the body of node
+// compiles to, e.g. "Main.lambda$main$0", after the method it is in.
+func lambdaMethod(node *ast.LambdaExpression) string {
+	if name, ok := lambdaMethods[node]; ok {
+		return name
+	}
+	class, method := mainClass(), "main"
+	if len(stack) > 0 {
+		caller := stack[len(stack)-1].method
+		i := strings.LastIndex(caller, ".")
+		class, method = caller[:i], caller[i+1:]
+	}
+	switch method {
+	case "<init>":
+		method = "new"
+	case "<clinit>":
+		method = "static"
+	}
+	name := fmt.Sprintf("%s.lambda$%s$%d", class, method, lambdaCounts[class])
+	lambdaCounts[class]++
+	lambdaMethods[node] = name
+	return name
+}
+
+// evalMethodReference evaluates the target of node. A bound reference
+// calls the method on the object the target evaluates to, which must not
+// be null.
+func evalMethodReference(node *ast.MethodReference, env *object.Environment) object.Object {
+	fn := &object.Lambda{Reference: node}
+	if name, ok := node.Target.(*ast.Identifier); ok {
+		// A name that is not found is a library type, such as String.
+		fn.Receiver, _ = env.Get(name.Value)
+	} else {
+		receiver, _ := evalReceiver(node.Target, env)
+		if isError(receiver) {
+			return receiver
+		}
+		fn.Receiver = receiver
+	}
+	if fn.Receiver == NULL {
+		at(node.Token)
+		return newException("NullPointerException", "")
+	}
+	return newLambda(node, node.Interface, fn, env)
+}
+
+// newLambda returns an instance of the class made for node that runs fn
+// for the abstract method of the interface named iface.
+func newLambda(node ast.Expression, iface string, fn *object.Lambda, env *object.Environment) *object.Instance {
+	class, ok := lambdaClasses[node]
+	if !ok {
+		name := mainClass()
+		if c := env.Class(); c != nil {
+			name = c.Name
+		}
+		decl := &ast.ClassDeclaration{
+			Token: tokens.Token{Type: tokens.CLASS, Literal: "class"},
+			Name:  &ast.Identifier{Value: name + "$$Lambda"},
+		}
+		class = &object.Class{Name: decl.Name.Value, Declaration: decl, Env: env, Statics: map[string]object.Object{}, State: object.Initialized}
+		if i, ok := lookupClass(iface, env); ok && i.Declaration.IsInterface() {
+			class.Interfaces = []*object.Class{i}
+		}
+		lambdaClasses[node] = class
+	}
+	instance := object.NewInstance(class)
+	instance.Lambda = fn
+	return instance
+}
+
+// functionalMethod returns the abstract method of the interface instance
+// implements that a call of name with args or sig selects, and reports
+// whether it is one. If the interface is not known, any method but those
+// of Object is taken to be it.
+func functionalMethod(instance *object.Instance, name string, args []object.Object, sig string) (*ast.FunctionLiteral, bool) {
+	if len(instance.Class.Interfaces) == 0 {
+		_, isObjectMethod := valueMethods["Object."+name]
+		return nil, !isObjectMethod
+	}
+	method, _ := findDeclaredMethod(instance.Class, name, args, sig)
+	if method == nil || method.Body != nil || ast.HasModifier(method.Modifiers, tokens.STATIC) {
+		return nil, false
+	}
+	return method, true
+}
+
+// callLambda runs fn for a call of the functional method with args. The
+// parameters of a lambda expression without types take those of method,
+// and its result is converted to the result type of method. method is nil
+// if the interface is not known.
+func callLambda(fn *object.Lambda, method *ast.FunctionLiteral, args []object.Object) object.Object {
+	var result object.Object
+	if fn.Reference != nil {
+		result = callMethodReference(fn, args)
+	} else {
+		lambda := fn.Expression
+		if len(args) != len(lambda.Parameters) {
+			return unchecked(lambda.String())
+		}
+		if err := pushFrame(fn.Method); err != nil {
+			return err
+		}
+		defer popFrame()
+
+		env := object.NewEnclosedEnvironment(fn.Env)
+		if len(lambda.Parameters) > 0 && lambda.Parameters[0].DataType != nil {
+			bindParameters(env, lambda.Parameters, args)
+		} else {
+			for i, p := range lambda.Parameters {
+				arg := args[i]
+				if method != nil {
+					arg = coerce(method.Parameters[i].DataType, arg)
+				}
+				env.Set(p.ParameterName.Value, arg)
+			}
+		}
+
+		switch body := lambda.Body.(type) {
+		case *ast.BlockStatement:
+			result = evalBlockStatement(body, env)
+			if rv, ok := result.(*object.ReturnValue); ok {
+				result = rv.Value
+			} else if !isError(result) {
+				result = nil
+			}
+		case ast.Expression:
+			result = Eval(body, env)
+		}
+	}
+
+	if isError(result) || method == nil || result == nil {
+		return result
+	}
+	if method.ReturnType.Name == "void" && method.ReturnType.Dimensions == 0 {
+		return nil
+	}
+	return coerce(method.ReturnType, result)
+}
+
+// callMethodReference calls the method or constructor the method
+// reference fn refers to with args. An instance method referred to through
+// a class name is called on the first argument.
+func callMethodReference(fn *object.Lambda, args []object.Object) object.Object {
+	name := fn.Reference.Name.Value
+	switch receiver := fn.Receiver.(type) {
+	case *object.Class:
+		if name == "new" {
+			if result := initializeClass(receiver); isError(result) {
+				return result
+			}
+			return instantiate(receiver, args, "")
+		}
+		if method, declaring := findDeclaredMethod(receiver, name, args, ""); method != nil && ast.HasModifier(method.Modifiers, tokens.STATIC) {
+			if result := initializeClass(declaring); isError(result) {
+				return result
+			}
+			return callMethod(declaring, nil, method, args)
+		}
+	case nil:
+	default:
+		return invokeMethod(receiver, name, args, "")
+	}
+	if len(args) == 0 {
+		return unchecked(fn.Reference.String())
+	}
+	return invokeMethod(args[0], name, args[1:], "")
+}
@@ -0,0 +1,351 @@
+package evaluator
+
+import (
+	"java/ast"
+	"java/object"
+	"java/tokens"
+	"strings"
+)
+
+// The phases of overload resolution. A call selects a method applicable
+// in the earliest phase possible: first by subtyping and primitive
+// widening alone, then also by boxing and unboxing, and last by variable
+// arity.
+const (
+	strictPhase = iota
+	loosePhase
+	variadicPhase
+)
+
+// The type checker resolves overloaded calls by the static types of their
+// arguments and records the signature chosen in the call. The methods
+// below look methods up by that signature, which is the one overriding
+// methods share, or else by the runtime values of the arguments, taking
+// the first method applicable in the earliest phase.
+
+// findMethod finds the method name with signature sig, or else that can
+// be called with args, in class or the nearest superclass declaring one,
+// and returns it along with the class declaring it. Methods declared in
+// classes take precedence over default methods inherited from interfaces.
+func findMethod(class *object.Class, name string, args []object.Object, sig string) (*ast.FunctionLiteral, *object.Class) {
+	for phase := strictPhase; phase <= variadicPhase; phase++ {
+		for c := class; c != nil; c = c.Super {
+			for _, m := range c.Declaration.Methods {
+				if m.Name.Value == name && isImplemented(m) && selects(m.Parameters, typeParameters(m.TypeParameters, c), args, sig, phase, c.Env) {
+					return m, c
+				}
+			}
+		}
+		if m, i := findDefaultMethod(class, name, args, sig, phase); m != nil {
+			return m, i
+		}
+	}
+	return nil, nil
+}
+
+// isImplemented reports whether m has a body or is a native method, which
+// the evaluator implements.
+func isImplemented(m *ast.FunctionLiteral) bool {
+	return m.Body != nil || ast.HasModifier(m.Modifiers, tokens.NATIVE)
+}
+
+// findDeclaredMethod finds the method name that a call with args or sig
+// selects among the methods class declares or inherits, abstract or not.
+// It is how javac resolves an unqualified method call.
+func findDeclaredMethod(class *object.Class, name string, args []object.Object, sig string) (*ast.FunctionLiteral, *object.Class) {
+	for phase := strictPhase; phase <= variadicPhase; phase++ {
+		for c := class; c != nil; c = c.Super {
+			for _, m := range c.Declaration.Methods {
+				if m.Name.Value == name && selects(m.Parameters, typeParameters(m.TypeParameters, c), args, sig, phase, c.Env) {
+					return m, c
+				}
+			}
+		}
+		for _, i := range interfacesOf(class) {
+			for _, m := range i.Declaration.Methods {
+				if m.Name.Value == name && selects(m.Parameters, typeParameters(m.TypeParameters, i), args, sig, phase, i.Env) {
+					return m, i
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findDefaultMethod finds the default method name in the interfaces of
+// class. If several interfaces declare it, the one declared in the most
+// specific interface is chosen.
+func findDefaultMethod(class *object.Class, name string, args []object.Object, sig string, phase int) (*ast.FunctionLiteral, *object.Class) {
+	type candidate struct {
+		method    *ast.FunctionLiteral
+		declaring *object.Class
+	}
+	var candidates []candidate
+	for _, i := range interfacesOf(class) {
+		for _, m := range i.Declaration.Methods {
+			if m.Name.Value == name && ast.HasModifier(m.Modifiers, tokens.DEFAULT) &&
+				selects(m.Parameters, typeParameters(m.TypeParameters, i), args, sig, phase, i.Env) {
+				candidates = append(candidates, candidate{m, i})
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		mostSpecific := true
+		for _, other := range candidates {
+			if other.declaring != c.declaring && other.declaring.IsSubtypeOf(c.declaring) {
+				mostSpecific = false
+			}
+		}
+		if mostSpecific {
+			return c.method, c.declaring
+		}
+	}
+	return nil, nil
+}
+
+func findConstructor(class *object.Class, args []object.Object, sig string) *ast.ConstructorDeclaration {
+	for phase := strictPhase; phase <= variadicPhase; phase++ {
+		for _, c := range class.Declaration.Constructors {
+			if selects(c.Parameters, typeParameters(c.TypeParameters, class), args, sig, phase, class.Env) {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// findFunction finds the overload of the script method fn that a call
+// with args or sig selects.
+func findFunction(fn *object.Function, args []object.Object, sig string) *object.Function {
+	for phase := strictPhase; phase <= variadicPhase; phase++ {
+		for f := fn; f != nil; f = f.Next {
+			if selects(f.Literal.Parameters, f.Literal.TypeParameters, args, sig, phase, f.Env) {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// declareFunction declares the script method fn in env, as an overload of
+// the methods with the same name unless it redeclares one of them.
+func declareFunction(fn *ast.FunctionLiteral, env *object.Environment) {
+	var overloads []*object.Function
+	if val, ok := env.Get(fn.Name.Value); ok {
+		if existing, ok := val.(*object.Function); ok {
+			for f := existing; f != nil; f = f.Next {
+				if signature(f.Literal.Parameters) != signature(fn.Parameters) {
+					overloads = append(overloads, f)
+				}
+			}
+		}
+	}
+
+	var next *object.Function
+	for i := len(overloads) - 1; i >= 0; i-- {
+		next = &object.Function{Literal: overloads[i].Literal, Env: overloads[i].Env, Next: next}
+	}
+	env.Set(fn.Name.Value, &object.Function{Literal: fn, Env: env, Next: next})
+}
+
+// selects reports whether a call selects the method or constructor with
+// params in phase: by the signature sig when the type checker resolved
+// the call, or else by the values of its arguments. Parameter types are
+// resolved in env, where the type parameters typeParams are in scope.
+func selects(params []*ast.Parameter, typeParams []*ast.TypeParameter, args []object.Object, sig string, phase int, env *object.Environment) bool {
+	if sig != "" {
+		return phase == strictPhase && signature(params) == sig
+	}
+	return applicable(params, typeParams, args, phase, env)
+}
+
+// applicable reports whether a method or constructor with params can be
+// called with args in phase.
+func applicable(params []*ast.Parameter, typeParams []*ast.TypeParameter, args []object.Object, phase int, env *object.Environment) bool {
+	if phase == variadicPhase {
+		if !ast.IsVariadic(params) || len(args) < len(params)-1 {
+			return false
+		}
+		last := len(params) - 1
+		for i, arg := range args {
+			t := params[last].DataType
+			if i < last {
+				t = params[i].DataType
+			}
+			if !convertible(erasure(t, typeParams), arg, loosePhase, env) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(params) != len(args) {
+		return false
+	}
+	for i, param := range params {
+		if !convertible(erasure(parameterType(param), typeParams), args[i], phase, env) {
+			return false
+		}
+	}
+	return true
+}
+
+// convertible reports whether val may be passed for a parameter of type
+// t, whose name is resolved in env. Boxing and unboxing are only allowed
+// after the strict phase.
+func convertible(t *ast.Type, val object.Object, phase int, env *object.Environment) bool {
+	loose := phase != strictPhase
+	if t.Dimensions > 0 {
+		array, ok := val.(*object.Array)
+		if !ok {
+			return val == NULL
+		}
+		element := *t
+		element.Dimensions--
+		return element.String() == array.ElementType.String() ||
+			(!isPrimitive(element.Name) && array.ElementType.Dimensions == element.Dimensions && isSubclass(array.ElementType.Name, element.Name, env))
+	}
+
+	switch val := val.(type) {
+	case *object.Integer:
+		switch t.Name {
+		case "byte", "short", "int", "long", "float", "double":
+			return true
+		case "Integer", "Number", "Comparable", "Object":
+			return loose
+		}
+	case *object.Double:
+		switch t.Name {
+		case "double":
+			return true
+		case "Double", "Number", "Comparable", "Object":
+			return loose
+		}
+	case *object.Boolean:
+		switch t.Name {
+		case "boolean":
+			return true
+		case "Boolean", "Comparable", "Object":
+			return loose
+		}
+	case *object.String:
+		return t.Name == "String" || t.Name == "CharSequence" || t.Name == "Comparable" || t.Name == "Object"
+	case *object.Null:
+		return !isPrimitive(t.Name)
+	case *object.Array:
+		return t.Name == "Object"
+	case *object.Instance:
+		if t.Name == "Object" {
+			return true
+		}
+		class, ok := lookupClass(t.Name, env)
+		if val.Lambda != nil && len(val.Class.Interfaces) == 0 {
+			// The functional interface of the lambda is not known.
+			return ok && class.Declaration.IsInterface()
+		}
+		return ok && val.Class.IsSubtypeOf(class)
+	}
+	return false
+}
+
+// typeParameters returns the type parameters in scope in a method or
+// constructor with the type parameters own, declared in class.
+func typeParameters(own []*ast.TypeParameter, class *object.Class) []*ast.TypeParameter {
+	return append(append([]*ast.TypeParameter{}, own...), class.Declaration.TypeParameters...)
+}
+
+// erasure is the type t stands for at run time where the type parameters
+// params are in scope: a type variable stands for its first bound, or
+// Object if it has none.
+func erasure(t *ast.Type, params []*ast.TypeParameter) *ast.Type {
+	for i, p := range params {
+		if p.Name.Value != t.Name {
+			continue
+		}
+		erased := &ast.Type{Token: t.Token, Name: "Object"}
+		if len(p.Bounds) > 0 {
+			// A bound may be another type variable, but not this one.
+			others := append(append([]*ast.TypeParameter{}, params[:i]...), params[i+1:]...)
+			erased = erasure(p.Bounds[0], others)
+		}
+		return &ast.Type{Token: t.Token, Name: erased.Name, Dimensions: erased.Dimensions + t.Dimensions}
+	}
+	return t
+}
+
+// isSubclass reports whether the class named sub is a subtype of the one
+// named super, both looked up in env.
+func isSubclass(sub, super string, env *object.Environment) bool {
+	if super == "Object" {
+		return true
+	}
+	subclass, ok := lookupClass(sub, env)
+	class, isClass := lookupClass(super, env)
+	return ok && isClass && subclass.IsSubtypeOf(class)
+}
+
+func isPrimitive(name string) bool {
+	switch name {
+	case "byte", "short", "int", "long", "float", "double", "char", "boolean":
+		return true
+	}
+	return false
+}
+
+// parameterType is the type of param inside the method, which is an array
+// for a variable arity parameter.
+func parameterType(param *ast.Parameter) *ast.Type {
+	if !param.Variadic {
+		return param.DataType
+	}
+	t := *param.DataType
+	t.Dimensions++
+	return &t
+}
+
+// bindParameters declares params in env with the values of args. The
+// trailing arguments of a call with variable arity are collected into an
+// array, unless an array is passed for the last parameter.
+func bindParameters(env *object.Environment, params []*ast.Parameter, args []object.Object) {
+	if ast.IsVariadic(params) {
+		last := len(params) - 1
+		passesArray := len(args) == len(params) && (args[last] == NULL || args[last].Type() == object.ARRAY_OBJ)
+		if !passesArray {
+			element := params[last].DataType
+			var rest []object.Object
+			for _, arg := range args[last:] {
+				rest = append(rest, coerce(element, arg))
+			}
+			args = append(args[:last:last], object.NewArray(element, rest))
+		}
+	}
+	for i, param := range params {
+		env.Set(param.ParameterName.Value, coerce(param.DataType, args[i]))
+	}
+}
+
+// coerce converts val to the type t of the variable it is stored in, which
+// widens an int to a double.
+func coerce(t *ast.Type, val object.Object) object.Object {
+	if integer, ok := val.(*object.Integer); ok && t.Dimensions == 0 && (t.Name == "double" || t.Name == "float") {
+		return &object.Double{Value: float64(integer.Value)}
+	}
+	return val
+}
+
+// signature is the parameter list of a method or constructor as javac
+// prints it, e.g. "(int,String...)".
+func signature(params []*ast.Parameter) string {
+	return "(" + parameterTypes(params) + ")"
+}
+
+// parameterTypes lists the types of params the way javac prints them in
+// a method signature, e.g. "int,String".
+func parameterTypes(params []*ast.Parameter) string {
+	types := []string{}
+	for _, p := range params {
+		types = append(types, p.TypeString())
+	}
+	return strings.Join(types, ",")
+}
@@ -0,0 +1,248 @@
+package evaluator
+
+import (
+	"fmt"
+	"java/ast"
+	"java/object"
+	"java/tokens"
+	"math"
+	"unicode/utf16"
+)
+
+// native is the implementation of a native method of a library class.
+type native func(this *object.Instance, args []object.Object) object.Object
+
+// natives maps the class and name of each native method to its
+// implementation.
+var natives map[string]native
+
+func init() {
+	natives = map[string]native{
+		"Throwable.addSuppressed":   throwableAddSuppressed,
+		"Throwable.getSuppressed":   throwableGetSuppressed,
+		"Throwable.toString":        throwableToString,
+		"Throwable.printStackTrace": throwablePrintStackTrace,
+		"Record.equals":             recordEquals,
+		"Record.hashCode":           recordHashCode,
+		"Record.toString":           recordToString,
+	}
+}
+
+func callNative(class *object.Class, this *object.Instance, method *ast.FunctionLiteral, args []object.Object) object.Object {
+	if class.Declaration.IsEnum() && method.Token.Type == tokens.ENUM {
+		return callEnumMethod(class, method, args)
+	}
+	name := class.Name + "." + method.Name.Value
+	fn, ok := natives[name]
+	if !ok {
+		return newError("native method %s is not implemented", name)
+	}
+	return fn(this, args)
+}
+
+func throwableAddSuppressed(this *object.Instance, args []object.Object) object.Object {
+	if args[0] == this {
+		message := &object.String{Value: "Self-suppression not permitted"}
+		exception := instantiate(libraryClasses()["IllegalArgumentException"], []object.Object{message, this}, "(String,Throwable)")
+		if isError(exception) {
+			return exception
+		}
+		return throw(exception.(*object.Instance))
+	}
+	exception, ok := args[0].(*object.Instance)
+	if !ok {
+		return newException("NullPointerException", "Cannot suppress a null exception.")
+	}
+	this.Suppressed = append(this.Suppressed, exception)
+	return nil
+}
+
+func throwableGetSuppressed(this *object.Instance, args []object.Object) object.Object {
+	elements := make([]object.Object, len(this.Suppressed))
+	for i, exception := range this.Suppressed {
+		elements[i] = exception
+	}
+	return object.NewArray(&ast.Type{Name: "Throwable"}, elements)
+}
+
+// throwableToString is the name of the class of this and, if there is
+// one, its message.
+func throwableToString(this *object.Instance, args []object.Object) object.Object {
+	message := invokeMethod(this, "getLocalizedMessage", nil, "()")
+	if isError(message) {
+		return message
+	}
+	name := qualifiedName(this.Class)
+	if message == NULL {
+		return &object.String{Value: name}
+	}
+	return &object.String{Value: name + ": " + message.Inspect()}
+}
+
+func throwablePrintStackTrace(this *object.Instance, args []object.Object) object.Object {
+	fmt.Fprint(Stderr, StackTrace(this))
+	return nil
+}
+
+// valueMethod is a method of a string or a boxed primitive value, which
+// the evaluator represents as the value itself rather than an instance.
+type valueMethod struct {
+	arity int
+	fn    func(this object.Object, args []object.Object) object.Object
+}
+
+// valueMethods maps the class and name of each method of those values to
+// its implementation.
+var valueMethods = map[string]valueMethod{
+	"String.length":  {0, stringLength},
+	"String.isEmpty": {0, stringIsEmpty},
+
+	// The methods that unbox a value, which the type checker makes the
+	// unboxing conversions of the program call.
+	"Integer.intValue":     {0, unboxValue},
+	"Double.doubleValue":   {0, unboxValue},
+	"Boolean.booleanValue": {0, unboxValue},
+
+	// The methods of Object, for instances of classes that do not
+	// override them.
+	"Object.equals":   {1, objectEquals},
+	"Object.hashCode": {0, objectHashCode},
+	"Object.toString": {0, valueToString},
+}
+
+func init() {
+	for _, class := range []string{"Integer", "Double", "Boolean", "String"} {
+		valueMethods[class+".compareTo"] = valueMethod{1, valueCompareTo}
+		valueMethods[class+".equals"] = valueMethod{1, valueEquals}
+		valueMethods[class+".hashCode"] = valueMethod{0, valueHashCode}
+		valueMethods[class+".toString"] = valueMethod{0, valueToString}
+	}
+}
+
+// boxName is the class of the value obj, e.g. "Integer" for an int, or ""
+// if it has no value methods.
+func boxName(obj object.Object) string {
+	switch obj.(type) {
+	case *object.Integer:
+		return "Integer"
+	case *object.Double:
+		return "Double"
+	case *object.Boolean:
+		return "Boolean"
+	case *object.String:
+		return "String"
+	}
+	return ""
+}
+
+// compareToParameters names the parameter of each compareTo method, which
+// the message of the NullPointerException for a null argument refers to.
+var compareToParameters = map[string]string{
+	"Integer": "anotherInteger",
+	"Double":  "anotherDouble",
+	"Boolean": "b",
+	"String":  "anotherString",
+}
+
+func valueCompareTo(this object.Object, args []object.Object) object.Object {
+	class := boxName(this)
+	if args[0] == NULL {
+		return newException("NullPointerException", `Cannot read field "value" because "%s" is null`, compareToParameters[class])
+	}
+	if other := boxName(args[0]); other != class {
+		return newException("ClassCastException",
+			"class java.lang.%s cannot be cast to class java.lang.%s (java.lang.%s and java.lang.%s are in module java.base of loader 'bootstrap')",
+			other, class, other, class)
+	}
+
+	var result int64
+	switch this := this.(type) {
+	case *object.Integer:
+		result = compare(this.Value < args[0].(*object.Integer).Value, this.Value > args[0].(*object.Integer).Value)
+	case *object.Double:
+		result = compare(this.Value < args[0].(*object.Double).Value, this.Value > args[0].(*object.Double).Value)
+	case *object.Boolean:
+		result = compare(!this.Value && args[0].(*object.Boolean).Value, this.Value && !args[0].(*object.Boolean).Value)
+	case *object.String:
+		result = compareStrings(this.Value, args[0].(*object.String).Value)
+	}
+	return &object.Integer{Value: result}
+}
+
+func compare(less, greater bool) int64 {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// compareStrings compares a and b lexicographically by their UTF-16 code
+// units, as String.compareTo does: it returns the difference of the first
+// units that differ, or else of the lengths.
+func compareStrings(a, b string) int64 {
+	x, y := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			return int64(x[i]) - int64(y[i])
+		}
+	}
+	return int64(len(x)) - int64(len(y))
+}
+
+func valueEquals(this object.Object, args []object.Object) object.Object {
+	if boxName(args[0]) != boxName(this) {
+		return FALSE
+	}
+	return nativeBoolToBooleanObject(this.Inspect() == args[0].Inspect())
+}
+
+// valueHashCode is the hash code Java gives a string or a boxed value.
+func valueHashCode(this object.Object, args []object.Object) object.Object {
+	var h int32
+	switch this := this.(type) {
+	case *object.Integer:
+		h = int32(this.Value)
+	case *object.Double:
+		bits := math.Float64bits(this.Value)
+		h = int32(bits ^ bits>>32)
+	case *object.Boolean:
+		h = 1237
+		if this.Value {
+			h = 1231
+		}
+	case *object.String:
+		for _, u := range utf16.Encode([]rune(this.Value)) {
+			h = 31*h + int32(u)
+		}
+	}
+	return &object.Integer{Value: int64(h)}
+}
+
+func valueToString(this object.Object, args []object.Object) object.Object {
+	return &object.String{Value: this.Inspect()}
+}
+
+func objectEquals(this object.Object, args []object.Object) object.Object {
+	return nativeBoolToBooleanObject(this == args[0])
+}
+
+func objectHashCode(this object.Object, args []object.Object) object.Object {
+	return &object.Integer{Value: int64(this.(*object.Instance).HashCode())}
+}
+
+// unboxValue returns the boxed value itself, which the evaluator does not
+// distinguish from the primitive value.
+func unboxValue(this object.Object, args []object.Object) object.Object {
+	return this
+}
+
+func stringLength(this object.Object, args []object.Object) object.Object {
+	return &object.Integer{Value: int64(len(utf16.Encode([]rune(this.(*object.String).Value))))}
+}
+
+func stringIsEmpty(this object.Object, args []object.Object) object.Object {
+	return nativeBoolToBooleanObject(this.(*object.String).Value == "")
+}
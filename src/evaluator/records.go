@@ -0,0 +1,179 @@
+package evaluator
+
+import (
+	"java/ast"
+	"java/object"
+	"strings"
+)
+
+// recordComponents returns the values of the components of the record
+// this, in the order they are declared. A record class is final, so it is
+// the class of this.
+func recordComponents(this *object.Instance) []object.Object {
+	var values []object.Object
+	for _, c := range this.Class.Declaration.Components {
+		val, _ := this.Field(this.Class, c.ParameterName.Value)
+		values = append(values, val)
+	}
+	return values
+}
+
+// recordEquals reports whether other is a record of the same class as
+// this whose components are equal to those of this.
+func recordEquals(this *object.Instance, args []object.Object) object.Object {
+	other, ok := args[0].(*object.Instance)
+	if !ok || other.Class != this.Class {
+		return FALSE
+	}
+	values := recordComponents(other)
+	for i, val := range recordComponents(this) {
+		if val == NULL {
+			if values[i] != NULL {
+				return FALSE
+			}
+			continue
+		}
+		if result := invokeMethod(val, "equals", []object.Object{values[i]}, "(Object)"); result != TRUE {
+			if isError(result) {
+				return result
+			}
+			return FALSE
+		}
+	}
+	return TRUE
+}
+
+// recordHashCode combines the hash codes of the components of this.
+func recordHashCode(this *object.Instance, args []object.Object) object.Object {
+	var h int32
+	for _, val := range recordComponents(this) {
+		var hash int32
+		if val != NULL {
+			result := invokeMethod(val, "hashCode", nil, "()")
+			if isError(result) {
+				return result
+			}
+			hash = int32(result.(*object.Integer).Value)
+		}
+		h = 31*h + hash
+	}
+	return &object.Integer{Value: int64(h)}
+}
+
+// recordToString is the simple name of the class of this and its
+// components, as in "Point[x=1, y=2]".
+func recordToString(this *object.Instance, args []object.Object) object.Object {
+	var components []string
+	values := recordComponents(this)
+	for i, c := range this.Class.Declaration.Components {
+		s := stringOf(values[i])
+		if isError(s) {
+			return s
+		}
+		components = append(components, c.ParameterName.Value+"="+s.Inspect())
+	}
+	return &object.String{Value: this.Class.Declaration.Name.Value + "[" + strings.Join(components, ", ") + "]"}
+}
+
+// evalInstanceofExpression tests whether the value of node.Left matches
+// its pattern, binding the variables of the pattern in env if it does.
+func evalInstanceofExpression(node *ast.InstanceofExpression, env *object.Environment) object.Object {
+	val := evalOperand(node.Left, env)
+	if isError(val) {
+		return val
+	}
+	matched, err := matchPattern(node.Pattern, val, env)
+	if err != nil {
+		return err
+	}
+	return nativeBoolToBooleanObject(matched)
+}
+
+// matchPattern reports whether val matches pattern, and binds the
+// variables of the pattern in env if it does. A type pattern matches a
+// value of its type, and a record pattern a record of its type whose
+// components, read through their accessors, match the nested patterns.
+// null matches neither.
+func matchPattern(pattern ast.Expression, val object.Object, env *object.Environment) (bool, object.Object) {
+	if val == NULL {
+		return false, nil
+	}
+	switch pattern := pattern.(type) {
+	case *ast.TypePattern:
+		if pattern.Type.Name != "var" && !convertible(pattern.Type, val, loosePhase, env) {
+			return false, nil
+		}
+		bindPattern(pattern, val, env)
+		return true, nil
+	case *ast.RecordPattern:
+		record, ok := lookupClass(pattern.Type.Name, env)
+		if !ok || !record.Declaration.IsRecord() || len(record.Declaration.Components) != len(pattern.Patterns) {
+			return false, unchecked(pattern.String())
+		}
+		components := record.Declaration.Components
+		instance, ok := val.(*object.Instance)
+		if !ok || !instance.Class.IsSubtypeOf(record) {
+			return false, nil
+		}
+		for i, c := range components {
+			component := invokeMethod(instance, c.ParameterName.Value, nil, "()")
+			if isError(component) {
+				return false, component
+			}
+			nested := pattern.Patterns[i]
+			if component == NULL {
+				// A null component only matches a pattern that every value
+				// of the type of the component matches.
+				t, ok := nested.(*ast.TypePattern)
+				if !ok || !unconditional(t, parameterType(c), env) {
+					return false, nil
+				}
+				bindPattern(t, NULL, env)
+				continue
+			}
+			if matched, err := matchPattern(nested, component, env); !matched || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func bindPattern(pattern *ast.TypePattern, val object.Object, env *object.Environment) {
+	if pattern.Name == nil {
+		return
+	}
+	if pattern.Type.Name != "var" {
+		val = coerce(pattern.Type, val)
+	}
+	env.Set(pattern.Name.Value, val)
+}
+
+// unconditional reports whether the type pattern matches every value of
+// type t: it has the type var or a supertype of t.
+func unconditional(pattern *ast.TypePattern, t *ast.Type, env *object.Environment) bool {
+	p := pattern.Type
+	switch {
+	case p.Name == "var":
+		return true
+	case p.Dimensions != t.Dimensions:
+		return p.Name == "Object" && p.Dimensions == 0
+	case p.Name == t.Name:
+		return true
+	}
+	return !isPrimitive(t.Name) && isSubclass(t.Name, p.Name, env)
+}
+
+// hasNullLabel reports whether a case of a switch has the label null, so
+// the switch does not throw a NullPointerException for a null value.
+func hasNullLabel(cases []*ast.SwitchCase) bool {
+	for _, c := range cases {
+		for _, label := range c.Labels {
+			if _, ok := label.(*ast.NullLiteral); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,3 @@
+public interface Closeable extends AutoCloseable {
+    void close() throws IOException;
+}
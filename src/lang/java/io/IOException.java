@@ -0,0 +1,16 @@
+public class IOException extends Exception {
+    public IOException() {}
+    public IOException(String message) { super(message); }
+    public IOException(String message, Throwable cause) { super(message, cause); }
+    public IOException(Throwable cause) { super(cause); }
+}
+
+public class UncheckedIOException extends RuntimeException {
+    public UncheckedIOException(String message, IOException cause) { super(message, cause); }
+    public UncheckedIOException(IOException cause) { super(cause); }
+}
+
+public class FileNotFoundException extends IOException {
+    public FileNotFoundException() {}
+    public FileNotFoundException(String message) { super(message); }
+}
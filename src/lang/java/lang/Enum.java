@@ -0,0 +1,12 @@
+public abstract class Enum<E extends Enum<E>> implements Comparable<E> {
+    // The evaluator sets the name and ordinal of each enum constant before
+    // its constructor runs.
+    private String name;
+    private int ordinal;
+
+    public final String name() { return name; }
+    public final int ordinal() { return ordinal; }
+    public String toString() { return name; }
+    public final boolean equals(Object other) { return this == other; }
+    public final int compareTo(E o) { return ordinal - o.ordinal(); }
+}
@@ -0,0 +1,7 @@
+public abstract class Record {
+    // The evaluator compares, hashes and prints the components of a record.
+    protected Record() {}
+    public native boolean equals(Object obj);
+    public native int hashCode();
+    public native String toString();
+}
@@ -0,0 +1,139 @@
+public class Throwable {
+    private String message;
+    private Throwable cause;
+
+    public Throwable() {}
+    public Throwable(String message) { this.message = message; }
+    public Throwable(String message, Throwable cause) { this.message = message; this.cause = cause; }
+    public Throwable(Throwable cause) {
+        if (cause != null) {
+            message = cause.toString();
+        }
+        this.cause = cause;
+    }
+
+    public String getMessage() { return message; }
+    public String getLocalizedMessage() { return getMessage(); }
+    public Throwable getCause() { return cause; }
+    public Throwable initCause(Throwable cause) { this.cause = cause; return this; }
+    public final native void addSuppressed(Throwable exception);
+    public final native Throwable[] getSuppressed();
+    public native String toString();
+    public native void printStackTrace();
+}
+
+public class Exception extends Throwable {
+    public Exception() {}
+    public Exception(String message) { super(message); }
+    public Exception(String message, Throwable cause) { super(message, cause); }
+    public Exception(Throwable cause) { super(cause); }
+}
+
+public class RuntimeException extends Exception {
+    public RuntimeException() {}
+    public RuntimeException(String message) { super(message); }
+    public RuntimeException(String message, Throwable cause) { super(message, cause); }
+    public RuntimeException(Throwable cause) { super(cause); }
+}
+
+public class Error extends Throwable {
+    public Error() {}
+    public Error(String message) { super(message); }
+    public Error(String message, Throwable cause) { super(message, cause); }
+    public Error(Throwable cause) { super(cause); }
+}
+
+public class ArithmeticException extends RuntimeException {
+    public ArithmeticException() {}
+    public ArithmeticException(String message) { super(message); }
+}
+
+public class ClassCastException extends RuntimeException {
+    public ClassCastException() {}
+    public ClassCastException(String message) { super(message); }
+}
+
+public class IllegalArgumentException extends RuntimeException {
+    public IllegalArgumentException() {}
+    public IllegalArgumentException(String message) { super(message); }
+    public IllegalArgumentException(String message, Throwable cause) { super(message, cause); }
+    public IllegalArgumentException(Throwable cause) { super(cause); }
+}
+
+public class NumberFormatException extends IllegalArgumentException {
+    public NumberFormatException() {}
+    public NumberFormatException(String message) { super(message); }
+}
+
+public class IllegalStateException extends RuntimeException {
+    public IllegalStateException() {}
+    public IllegalStateException(String message) { super(message); }
+    public IllegalStateException(String message, Throwable cause) { super(message, cause); }
+    public IllegalStateException(Throwable cause) { super(cause); }
+}
+
+public class IndexOutOfBoundsException extends RuntimeException {
+    public IndexOutOfBoundsException() {}
+    public IndexOutOfBoundsException(String message) { super(message); }
+}
+
+public class ArrayIndexOutOfBoundsException extends IndexOutOfBoundsException {
+    public ArrayIndexOutOfBoundsException() {}
+    public ArrayIndexOutOfBoundsException(String message) { super(message); }
+}
+
+public class NullPointerException extends RuntimeException {
+    public NullPointerException() {}
+    public NullPointerException(String message) { super(message); }
+}
+
+public class UnsupportedOperationException extends RuntimeException {
+    public UnsupportedOperationException() {}
+    public UnsupportedOperationException(String message) { super(message); }
+    public UnsupportedOperationException(String message, Throwable cause) { super(message, cause); }
+    public UnsupportedOperationException(Throwable cause) { super(cause); }
+}
+
+public class CloneNotSupportedException extends Exception {
+    public CloneNotSupportedException() {}
+    public CloneNotSupportedException(String message) { super(message); }
+}
+
+public class InterruptedException extends Exception {
+    public InterruptedException() {}
+    public InterruptedException(String message) { super(message); }
+}
+
+public class AssertionError extends Error {
+    public AssertionError() {}
+    public AssertionError(String message) { super(message); }
+    public AssertionError(String message, Throwable cause) { super(message, cause); }
+}
+
+public class LinkageError extends Error {
+    public LinkageError() {}
+    public LinkageError(String message) { super(message); }
+    public LinkageError(String message, Throwable cause) { super(message, cause); }
+}
+
+public class ExceptionInInitializerError extends LinkageError {
+    public ExceptionInInitializerError() {}
+    public ExceptionInInitializerError(String message) { super(message); }
+    public ExceptionInInitializerError(Throwable thrown) { super(null, thrown); }
+    public Throwable getException() { return getCause(); }
+}
+
+public class NoClassDefFoundError extends LinkageError {
+    public NoClassDefFoundError() {}
+    public NoClassDefFoundError(String message) { super(message); }
+}
+
+public class VirtualMachineError extends Error {
+    public VirtualMachineError() {}
+    public VirtualMachineError(String message) { super(message); }
+}
+
+public class StackOverflowError extends VirtualMachineError {
+    public StackOverflowError() {}
+    public StackOverflowError(String message) { super(message); }
+}
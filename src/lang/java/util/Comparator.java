@@ -0,0 +1,3 @@
+public interface Comparator<T> {
+    int compare(T o1, T o2);
+}
@@ -0,0 +1,3 @@
+public interface BiFunction<T, U, R> {
+    R apply(T t, U u);
+}
@@ -0,0 +1,3 @@
+public interface Consumer<T> {
+    void accept(T t);
+}
@@ -0,0 +1,3 @@
+public interface Function<T, R> {
+    R apply(T t);
+}
@@ -0,0 +1,3 @@
+public interface Predicate<T> {
+    boolean test(T t);
+}
@@ -0,0 +1,68 @@
+// Package lang declares the library classes that programs use without
+// declaring them, such as the Throwable hierarchy. The classes are written
+// in Java, in source files grouped by package, and their native methods are
+// implemented by the evaluator.
+package lang
+
+import (
+	"embed"
+	"java/ast"
+	"java/lexer"
+	"java/parser"
+	"path"
+	"strings"
+	"sync"
+)
+
+//go:embed java
+var sources embed.FS
+
+var (
+	once     sync.Once
+	classes  []*ast.ClassDeclaration
+	packages map[*ast.ClassDeclaration]string
+)
+
+// Classes returns the declarations of the library classes. They are
+// parsed once and shared by all callers.
+func Classes() []*ast.ClassDeclaration {
+	once.Do(load)
+	return classes
+}
+
+// Package returns the package a library class is declared in, e.g.
+// "java.lang", or "" if decl is not one of the library classes.
+func Package(decl *ast.ClassDeclaration) string {
+	once.Do(load)
+	return packages[decl]
+}
+
+func load() {
+	packages = make(map[*ast.ClassDeclaration]string)
+	for _, dir := range []string{"java/lang", "java/io", "java/util", "java/util/function"} {
+		entries, err := sources.ReadDir(dir)
+		if err != nil {
+			panic(err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			text, err := sources.ReadFile(path.Join(dir, entry.Name()))
+			if err != nil {
+				panic(err)
+			}
+			p := parser.New(lexer.New(string(text)))
+			program := p.ParseProgram()
+			if len(p.Errors()) != 0 {
+				panic(dir + "/" + entry.Name() + ": " + strings.Join(p.Errors(), "; "))
+			}
+			for _, s := range program.Statements {
+				if decl, ok := s.(*ast.ClassDeclaration); ok {
+					classes = append(classes, decl)
+					packages[decl] = strings.ReplaceAll(dir, "/", ".")
+				}
+			}
+		}
+	}
+}
@@ -2,37 +2,156 @@ package lexer
 
 import (
 	"bytes"
+	"fmt"
 	"java/tokens"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type Lexer struct {
 	value        string
-	position     int
-	readPosition int
-	ch           byte
+	position     int // byte offset of ch in value
+	readPosition int // byte offset of the character after ch
+	ch           rune
+
+	// backslashes counts the raw (untranslated) backslashes ending at ch.
+	// A backslash only starts a unicode escape when it is preceded by an
+	// even number of them, so `\\u0041` is left untranslated.
+	backslashes int
+
+	line      int // line of ch, starting at 1
+	lineStart int // byte offset of the first character on that line
+
+	// problems explains why the ILLEGAL token starting at an offset was
+	// rejected, e.g. "unclosed string literal".
+	problems map[int]string
 }
 
 func New(input string) *Lexer {
 	l := &Lexer{
-		value: input,
+		value:    input,
+		line:     1,
+		problems: map[int]string{},
 	}
 	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' || (l.ch == '\r' && l.peekRawByte() != '\n') {
+		l.line++
+		l.lineStart = l.readPosition
+	}
+	l.position = l.readPosition
 	if l.readPosition >= len(l.value) {
 		l.ch = 0
+		l.readPosition = len(l.value) + 1
+		return
+	}
+
+	ch, width := utf8.DecodeRuneInString(l.value[l.readPosition:])
+	if ch == '\\' && l.backslashes%2 == 0 {
+		if escaped, n, ok := l.unicodeEscape(l.readPosition); ok {
+			l.ch = escaped
+			l.readPosition += n
+			l.backslashes = 0
+			return
+		}
+	}
+
+	l.ch = ch
+	l.readPosition += width
+	if ch == '\\' {
+		l.backslashes++
 	} else {
-		l.ch = l.value[l.readPosition]
+		l.backslashes = 0
 	}
-	l.position = l.readPosition
-	l.readPosition += 1
 }
 
-func (l *Lexer) NextToken() (tok tokens.Token) {
+// unicodeEscape decodes a `\uXXXX` escape starting at offset, as done by
+// Java before any other lexing takes place. Any number of `u`s may follow
+// the backslash, and an escaped surrogate pair is combined into one rune.
+func (l *Lexer) unicodeEscape(offset int) (rune, int, bool) {
+	i := offset + 1
+	if i >= len(l.value) || l.value[i] != 'u' {
+		return 0, 0, false
+	}
+	for i < len(l.value) && l.value[i] == 'u' {
+		i++
+	}
+	if i+4 > len(l.value) {
+		return 0, 0, false
+	}
+	var ch rune
+	for _, c := range l.value[i : i+4] {
+		digit, ok := hexDigit(c)
+		if !ok {
+			return 0, 0, false
+		}
+		ch = ch*16 + digit
+	}
+	i += 4
+
+	if ch >= 0xD800 && ch < 0xDC00 && i < len(l.value) && l.value[i] == '\\' {
+		if low, n, ok := l.unicodeEscape(i); ok && low >= 0xDC00 && low <= 0xDFFF {
+			return (ch-0xD800)<<10 + (low - 0xDC00) + 0x10000, i + n - offset, true
+		}
+	}
+	return ch, i - offset, true
+}
+
+func hexDigit(c rune) (rune, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (l *Lexer) NextToken() tokens.Token {
 	l.skipWhitespace()
 
+	start := l.pos()
+	tok := l.scanToken()
+	tok.Start = start
+	tok.End = l.pos()
+	return tok
+}
+
+// Problem describes why tok, an ILLEGAL token returned by this lexer, is
+// not valid Java.
+func (l *Lexer) Problem(tok tokens.Token) string {
+	if problem, ok := l.problems[tok.Start.Offset]; ok {
+		return problem
+	}
+	return fmt.Sprintf("illegal character: '%s'", tok.Literal)
+}
+
+func (l *Lexer) pos() tokens.Position {
+	offset := l.position
+	if offset > len(l.value) {
+		offset = len(l.value)
+	}
+	return tokens.Position{
+		Offset: offset,
+		Line:   l.line,
+		Column: utf8.RuneCountInString(l.value[l.lineStart:offset]) + 1,
+	}
+}
+
+func (l *Lexer) peekRawByte() byte {
+	if l.readPosition >= len(l.value) {
+		return 0
+	}
+	return l.value[l.readPosition]
+}
+
+func (l *Lexer) scanToken() (tok tokens.Token) {
 	switch l.ch {
 	case '<':
 		tok = tokens.Token{Type: tokens.LT, Literal: "<"}
@@ -49,11 +168,35 @@ func (l *Lexer) NextToken() (tok tokens.Token) {
 		if l.peekChar() == '-' {
 			tok = tokens.Token{Type: tokens.DECREMENT, Literal: "--"}
 			l.readChar()
+		} else if l.peekChar() == '>' {
+			tok = tokens.Token{Type: tokens.ARROW, Literal: "->"}
+			l.readChar()
 		} else {
 			tok = tokens.Token{Type: tokens.MINUS, Literal: "-"}
 		}
+	case ':':
+		// A single ':' is not an operator of the language yet.
+		if l.peekChar() == ':' {
+			tok = tokens.Token{Type: tokens.DOUBLE_COLON, Literal: "::"}
+			l.readChar()
+		} else {
+			tok = tokens.Token{Type: tokens.ILLEGAL, Literal: ":"}
+		}
 	case '"':
-		str := l.readString()
+		start := l.position
+		if l.peekChar() == '"' && l.peekCharAt(2) == '"' {
+			str, problem := l.readTextBlock()
+			if problem != "" {
+				l.problems[start] = problem
+				return tokens.Token{Type: tokens.ILLEGAL, Literal: str}
+			}
+			return tokens.Token{Type: tokens.STRING, Literal: str}
+		}
+		str, problem := l.readString()
+		if problem != "" {
+			l.problems[start] = problem
+			return tokens.Token{Type: tokens.ILLEGAL, Literal: str}
+		}
 		tok = tokens.Token{Type: tokens.STRING, Literal: str}
 	case '=':
 		if l.peekChar() == '=' { // Was an `==`
@@ -70,9 +213,21 @@ func (l *Lexer) NextToken() (tok tokens.Token) {
 			tok = tokens.Token{Type: tokens.BANG, Literal: "!"}
 		}
 	case '.':
-		tok = tokens.Token{Type: tokens.PERIOD, Literal: "."}
+		if l.peekChar() == '.' && l.peekCharAt(2) == '.' {
+			l.readChar()
+			l.readChar()
+			tok = tokens.Token{Type: tokens.ELLIPSIS, Literal: "..."}
+		} else {
+			tok = tokens.Token{Type: tokens.PERIOD, Literal: "."}
+		}
 	case '*':
 		tok = tokens.Token{Type: tokens.ASTERISK, Literal: "*"}
+	case '|':
+		tok = tokens.Token{Type: tokens.BAR, Literal: "|"}
+	case '&':
+		tok = tokens.Token{Type: tokens.AMPERSAND, Literal: "&"}
+	case '?':
+		tok = tokens.Token{Type: tokens.QUESTION, Literal: "?"}
 	case '/':
 		tok = tokens.Token{Type: tokens.SLASH, Literal: "/"}
 	case ',':
@@ -94,29 +249,17 @@ func (l *Lexer) NextToken() (tok tokens.Token) {
 	case 0:
 		tok = tokens.Token{Type: tokens.EOF, Literal: ""}
 	default:
-		if isLetter(l.ch) {
+		if isIdentifierStart(l.ch) {
 			literal := l.readIdentifier()
 			tokenType := tokens.LookupIdentifier(literal)
 			tok = tokens.Token{Type: tokenType, Literal: literal}
-
-			// Check for "else if" token
-			if tok.Type == tokens.ELSE {
-				if l.peekIdentifier() == "if" {
-					tok = tokens.Token{Type: tokens.ELSE_IF, Literal: "else if"}
-					l.position += 3
-					l.readPosition = l.position + 1
-				} else {
-					tok = tokens.Token{Type: tokens.ELSE, Literal: "else"}
-				}
-			}
 			return tok
 		} else if isNumber(l.ch) {
-			literal := l.readNumber()
-			tok = tokens.Token{Type: tokens.INT, Literal: literal}
+			literal, tokenType := l.readNumber()
+			tok = tokens.Token{Type: tokenType, Literal: literal}
 			return tok
 		} else {
 			tok = tokens.Token{Type: tokens.ILLEGAL, Literal: string(l.ch)}
-			return tok
 		}
 	}
 
@@ -124,68 +267,280 @@ func (l *Lexer) NextToken() (tok tokens.Token) {
 	return
 }
 
-func (l *Lexer) peekIdentifier() string {
-	temp := l.position
-
-	l.readIdentifier()
+// readIdentifier reads a Java identifier. Identifier-ignorable characters
+// are accepted but, as in javac, are not part of the name.
+func (l *Lexer) readIdentifier() string {
+	var out bytes.Buffer
+	for isIdentifierPart(l.ch) {
+		if !isIdentifierIgnorable(l.ch) {
+			out.WriteRune(l.ch)
+		}
+		l.readChar()
+	}
+	return out.String()
+}
 
-	l.skipWhitespace()
+func (l *Lexer) readString() (string, string) {
+	var out bytes.Buffer
+	out.WriteRune(l.ch)
+	l.readChar() // Move pointer forward so we are not looking at `"`
+	for l.ch != '"' {
+		if l.ch == 0 || l.ch == '\n' || l.ch == '\r' {
+			return out.String(), "unclosed string literal"
+		}
+		if l.ch == '\\' {
+			out.WriteRune(l.ch)
+			l.readChar()
+		}
+		out.WriteRune(l.ch)
+		l.readChar()
+	}
+	str, ok := unescape(out.String()[1:])
+	if !ok {
+		return str, "illegal escape character in string literal"
+	}
+	return str, ""
+}
 
+// readTextBlock reads a `"""` delimited text block and returns its content
+// after incidental whitespace has been stripped and escapes interpreted.
+// The lexer is left on the character following the closing delimiter.
+func (l *Lexer) readTextBlock() (string, string) {
 	var out bytes.Buffer
-	for isLetter(l.ch) {
-		out.WriteByte(l.ch)
+	out.WriteString(`"""`)
+	l.readChar()
+	l.readChar()
+	l.readChar()
+
+	// The opening delimiter may only be followed by whitespace and a line
+	// terminator; the content starts on the next line.
+	for l.ch == ' ' || l.ch == '\t' || l.ch == '\f' {
+		out.WriteRune(l.ch)
+		l.readChar()
+	}
+	switch l.ch {
+	case '\r':
+		l.readChar()
+		if l.ch == '\n' {
+			l.readChar()
+		}
+	case '\n':
 		l.readChar()
+	default:
+		return out.String(), "illegal text block open delimiter sequence, missing line terminator"
 	}
 
-	l.position = temp
-	l.readPosition = l.position + 1
-	return out.String()
+	out.Reset()
+	for !(l.ch == '"' && l.peekChar() == '"' && l.peekCharAt(2) == '"') {
+		if l.ch == 0 {
+			return `"""` + out.String(), "unclosed text block"
+		}
+		if l.ch == '\\' {
+			out.WriteRune(l.ch)
+			l.readChar()
+		}
+		out.WriteRune(l.ch)
+		l.readChar()
+	}
+	content := out.String()
+	l.readChar()
+	l.readChar()
+	l.readChar()
+
+	str, ok := unescape(stripIndent(content))
+	if !ok {
+		return str, "illegal escape character in text block"
+	}
+	return str, ""
 }
 
-func (l *Lexer) readIdentifier() string {
-	position := l.position
-	for isLetter(l.ch) {
-		l.readChar()
+// stripIndent removes incidental whitespace from the raw content of a text
+// block as described in JLS 3.10.6: the smallest indentation of the
+// non-blank lines (and of the closing delimiter line) is removed from every
+// line, and trailing whitespace is dropped.
+func stripIndent(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = strings.ReplaceAll(content, "\r", "\n")
+	lines := strings.Split(content, "\n")
+
+	last := len(lines) - 1
+	indent := -1
+	for i, line := range lines {
+		if isBlank(line) && i != last {
+			continue
+		}
+		n := leadingWhitespace(line)
+		if indent == -1 || n < indent {
+			indent = n
+		}
 	}
-	return l.value[position:l.position]
 
+	for i, line := range lines {
+		if isBlank(line) {
+			lines[i] = ""
+			continue
+		}
+		lines[i] = strings.TrimRight(line[indent:], " \t\f")
+	}
+	return strings.Join(lines, "\n")
 }
 
-func (l *Lexer) readString() string {
-	position := l.position
-	l.readChar() // Move pointer forward so we are not looking at `"`
-	for l.ch != '"' {
-		l.readChar()
+func isBlank(line string) bool {
+	return leadingWhitespace(line) == len(line)
+}
+
+func leadingWhitespace(line string) int {
+	n := 0
+	for n < len(line) && (line[n] == ' ' || line[n] == '\t' || line[n] == '\f') {
+		n++
+	}
+	return n
+}
+
+// unescape interprets the escape sequences of a string literal or text
+// block. A backslash followed by a line terminator joins the two lines.
+func unescape(s string) (string, bool) {
+	if !strings.Contains(s, "\\") {
+		return s, true
+	}
+
+	var out bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			out.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return s, false
+		}
+		switch c := s[i]; c {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'b':
+			out.WriteByte('\b')
+		case 'r':
+			out.WriteByte('\r')
+		case 'f':
+			out.WriteByte('\f')
+		case 's':
+			out.WriteByte(' ')
+		case '"', '\'', '\\':
+			out.WriteByte(c)
+		case '\n':
+			// line continuation
+		default:
+			if c < '0' || c > '7' {
+				return s, false
+			}
+			// Octal escapes take up to three digits, the first of which
+			// may only be 0-3 when all three are used.
+			value := int(c - '0')
+			max := 2
+			if c > '3' {
+				max = 1
+			}
+			for j := 0; j < max && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '7'; j++ {
+				i++
+				value = value*8 + int(s[i]-'0')
+			}
+			out.WriteRune(rune(value))
+		}
 	}
-	return l.value[position+1 : l.position]
+	return out.String(), true
 }
 
-func (l *Lexer) readNumber() string {
-	position := l.position
+// readNumber reads an integer literal or a floating-point literal with a
+// fraction, such as 2.5, optionally followed by a d or D suffix.
+func (l *Lexer) readNumber() (string, tokens.TokenType) {
+	var out bytes.Buffer
 	for isNumber(l.ch) {
+		out.WriteRune(l.ch)
+		l.readChar()
+	}
+	if l.ch != '.' || !isNumber(l.peekChar()) {
+		return out.String(), tokens.INT
+	}
+	out.WriteRune(l.ch)
+	l.readChar()
+	for isNumber(l.ch) {
+		out.WriteRune(l.ch)
+		l.readChar()
+	}
+	if l.ch == 'd' || l.ch == 'D' {
+		out.WriteRune(l.ch)
 		l.readChar()
 	}
-	return l.value[position:l.position]
+	return out.String(), tokens.DOUBLE
 }
 
-func isLetter(c byte) bool {
-	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') && c != ' '
+// isIdentifierStart mirrors Character.isJavaIdentifierStart.
+func isIdentifierStart(c rune) bool {
+	return unicode.IsLetter(c) ||
+		unicode.Is(unicode.Nl, c) ||
+		unicode.Is(unicode.Sc, c) ||
+		unicode.Is(unicode.Pc, c)
 }
 
-func isNumber(c byte) bool {
+// isIdentifierPart mirrors Character.isJavaIdentifierPart.
+func isIdentifierPart(c rune) bool {
+	return isIdentifierStart(c) ||
+		unicode.IsDigit(c) ||
+		unicode.Is(unicode.Mn, c) ||
+		unicode.Is(unicode.Mc, c) ||
+		isIdentifierIgnorable(c)
+}
+
+// isIdentifierIgnorable mirrors Character.isIdentifierIgnorable.
+func isIdentifierIgnorable(c rune) bool {
+	return (c >= 0x00 && c <= 0x08 && c != 0) ||
+		(c >= 0x0E && c <= 0x1B) ||
+		(c >= 0x7F && c <= 0x9F) ||
+		unicode.Is(unicode.Cf, c)
+}
+
+func isNumber(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
+// skipWhitespace skips whitespace as well as `//` and `/* */` comments.
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
-		l.readChar()
+	for {
+		switch {
+		case l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' || l.ch == '\f':
+			l.readChar()
+		case l.ch == '/' && l.peekChar() == '/':
+			for l.ch != '\n' && l.ch != '\r' && l.ch != 0 {
+				l.readChar()
+			}
+		case l.ch == '/' && l.peekChar() == '*':
+			l.readChar()
+			l.readChar()
+			for !(l.ch == '*' && l.peekChar() == '/') && l.ch != 0 {
+				l.readChar()
+			}
+			l.readChar()
+			l.readChar()
+		default:
+			return
+		}
 	}
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.position >= len(l.value) {
-		return 0
-	} else {
-		return l.value[l.readPosition]
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt returns the character n positions after ch, with unicode
+// escapes translated, without moving the lexer.
+func (l *Lexer) peekCharAt(n int) rune {
+	saved := *l
+	for i := 0; i < n; i++ {
+		l.readChar()
 	}
+	ch := l.ch
+	*l = saved
+	return ch
 }
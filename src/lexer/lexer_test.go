@@ -33,7 +33,7 @@ func TestLexerString(t *testing.T) {
 	const javaStringCode = `String str = "Hello world";`
 	lexer := New(javaStringCode)
 	expectedResult := []tokens.Token{
-		tokens.Token{Type: tokens.STRING_DT, Literal: "String"},
+		tokens.Token{Type: tokens.IDENT, Literal: "String"},
 		tokens.Token{Type: tokens.IDENT, Literal: "str"},
 		tokens.Token{Type: tokens.ASSIGN, Literal: "="},
 		tokens.Token{Type: tokens.STRING, Literal: "Hello world"},
@@ -79,7 +79,7 @@ func TestLexerLarge(t *testing.T) {
 		tokens.Token{Type: tokens.VOID, Literal: "void"},
 		tokens.Token{Type: tokens.IDENT, Literal: "main"},
 		tokens.Token{Type: tokens.LPAREN, Literal: "("},
-		tokens.Token{Type: tokens.STRING_DT, Literal: "String"},
+		tokens.Token{Type: tokens.IDENT, Literal: "String"},
 		tokens.Token{Type: tokens.LSPAREN, Literal: "["},
 		tokens.Token{Type: tokens.RSPAREN, Literal: "]"},
 		tokens.Token{Type: tokens.IDENT, Literal: "args"},
@@ -95,25 +95,25 @@ func TestLexerLarge(t *testing.T) {
 		tokens.Token{Type: tokens.ASSIGN, Literal: "="},
 		tokens.Token{Type: tokens.INT, Literal: "10"},
 		tokens.Token{Type: tokens.SEMICOLON, Literal: ";"},
-		tokens.Token{Type: tokens.STRING_DT, Literal: "String"},
+		tokens.Token{Type: tokens.IDENT, Literal: "String"},
 		tokens.Token{Type: tokens.IDENT, Literal: "test"},
 		tokens.Token{Type: tokens.ASSIGN, Literal: "="},
 		tokens.Token{Type: tokens.STRING, Literal: "Hello world"},
 		tokens.Token{Type: tokens.SEMICOLON, Literal: ";"},
-		tokens.Token{Type: tokens.SYSTEM, Literal: "System"},
+		tokens.Token{Type: tokens.IDENT, Literal: "System"},
 		tokens.Token{Type: tokens.PERIOD, Literal: "."},
-		tokens.Token{Type: tokens.OUT, Literal: "out"},
+		tokens.Token{Type: tokens.IDENT, Literal: "out"},
 		tokens.Token{Type: tokens.PERIOD, Literal: "."},
-		tokens.Token{Type: tokens.PRINTLN, Literal: "println"},
+		tokens.Token{Type: tokens.IDENT, Literal: "println"},
 		tokens.Token{Type: tokens.LPAREN, Literal: "("},
 		tokens.Token{Type: tokens.IDENT, Literal: "test"},
 		tokens.Token{Type: tokens.RPAREN, Literal: ")"},
 		tokens.Token{Type: tokens.SEMICOLON, Literal: ";"},
-		tokens.Token{Type: tokens.SYSTEM, Literal: "System"},
+		tokens.Token{Type: tokens.IDENT, Literal: "System"},
 		tokens.Token{Type: tokens.PERIOD, Literal: "."},
-		tokens.Token{Type: tokens.OUT, Literal: "out"},
+		tokens.Token{Type: tokens.IDENT, Literal: "out"},
 		tokens.Token{Type: tokens.PERIOD, Literal: "."},
-		tokens.Token{Type: tokens.PRINTLN, Literal: "println"},
+		tokens.Token{Type: tokens.IDENT, Literal: "println"},
 		tokens.Token{Type: tokens.LPAREN, Literal: "("},
 		tokens.Token{Type: tokens.IDENT, Literal: "x"},
 		tokens.Token{Type: tokens.PLUS, Literal: "+"},
@@ -154,22 +154,53 @@ func TestLexerLarge(t *testing.T) {
 }
 
 func TestLexerElseIf(t *testing.T) {
-	input := `else if`
-	lexer := New(input)
-	expectedResult := tokens.Token{Type: tokens.ELSE_IF, Literal: "else if"}
+	inputs := []string{
+		`else if`,
+		`else  if`,
+		"else\n\tif",
+		`else /* comment */ if`,
+		"else // comment\nif",
+	}
 
-	if lexer.NextToken().Type != expectedResult.Type {
-		t.Fatalf("Type of token should've been %s, but was %s\n", lexer.NextToken().Type, expectedResult.Type)
+	for _, input := range inputs {
+		lexer := New(input)
+		expectedResult := []tokens.Token{
+			{Type: tokens.ELSE, Literal: "else"},
+			{Type: tokens.IF, Literal: "if"},
+			{Type: tokens.EOF, Literal: ""},
+		}
+		for _, tok := range expectedResult {
+			result := lexer.NextToken()
+			if result.Type != tok.Type || result.Literal != tok.Literal {
+				t.Errorf("For input %q: expected token %s %q, but got %s %q\n", input, tok.Type, tok.Literal, result.Type, result.Literal)
+			}
+		}
 	}
 }
 
-func TestLexerPeekIdentifier(t *testing.T) {
-	input := `else if`
+func TestLexerComments(t *testing.T) {
+	input := `int x = 5; // the answer
+	/* a block
+	   comment */ x / 2;`
 	lexer := New(input)
+	expectedResult := []tokens.Token{
+		{Type: tokens.INTEGER_DT, Literal: "int"},
+		{Type: tokens.IDENT, Literal: "x"},
+		{Type: tokens.ASSIGN, Literal: "="},
+		{Type: tokens.INT, Literal: "5"},
+		{Type: tokens.SEMICOLON, Literal: ";"},
+		{Type: tokens.IDENT, Literal: "x"},
+		{Type: tokens.SLASH, Literal: "/"},
+		{Type: tokens.INT, Literal: "2"},
+		{Type: tokens.SEMICOLON, Literal: ";"},
+		{Type: tokens.EOF, Literal: ""},
+	}
 
-	result := lexer.peekIdentifier()
-	if result != "if" {
-		t.Fatalf("Peek identifier was not 'if' but was %s\n", result)
+	for _, tok := range expectedResult {
+		result := lexer.NextToken()
+		if result.Type != tok.Type || result.Literal != tok.Literal {
+			t.Errorf("Expected token %s %q, but got %s %q\n", tok.Type, tok.Literal, result.Type, result.Literal)
+		}
 	}
 }
 
@@ -181,11 +212,105 @@ func TestLexerIncrement(t *testing.T) {
 
 	expectedToken := tokens.Token{Type: tokens.INCREMENT, Literal: "++"}
 
-	if expectedToken != tok {
+	if expectedToken.Type != tok.Type || expectedToken.Literal != tok.Literal {
 		t.Fatalf("Expected token should've been %s, but was %s\n", expectedToken.Literal, tok.Literal)
 	}
 }
 
+func TestLexerNumbersAndEllipsis(t *testing.T) {
+	input := `1.5 2.0d 3. int... xs`
+	expected := []tokens.Token{
+		{Type: tokens.DOUBLE, Literal: "1.5"},
+		{Type: tokens.DOUBLE, Literal: "2.0d"},
+		{Type: tokens.INT, Literal: "3"},
+		{Type: tokens.PERIOD, Literal: "."},
+		{Type: tokens.INTEGER_DT, Literal: "int"},
+		{Type: tokens.ELLIPSIS, Literal: "..."},
+		{Type: tokens.IDENT, Literal: "xs"},
+	}
+
+	lexer := New(input)
+	for i, want := range expected {
+		tok := lexer.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, want.Type, want.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestLexerMultiCatch(t *testing.T) {
+	input := `catch (A | B e)`
+	expected := []tokens.Token{
+		{Type: tokens.CATCH, Literal: "catch"},
+		{Type: tokens.LPAREN, Literal: "("},
+		{Type: tokens.IDENT, Literal: "A"},
+		{Type: tokens.BAR, Literal: "|"},
+		{Type: tokens.IDENT, Literal: "B"},
+		{Type: tokens.IDENT, Literal: "e"},
+		{Type: tokens.RPAREN, Literal: ")"},
+	}
+
+	lexer := New(input)
+	for i, want := range expected {
+		tok := lexer.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, want.Type, want.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestLexerGenerics(t *testing.T) {
+	input := `Map<? super T, List<A & B>>`
+	expected := []tokens.Token{
+		{Type: tokens.IDENT, Literal: "Map"},
+		{Type: tokens.LT, Literal: "<"},
+		{Type: tokens.QUESTION, Literal: "?"},
+		{Type: tokens.SUPER, Literal: "super"},
+		{Type: tokens.IDENT, Literal: "T"},
+		{Type: tokens.COMMA, Literal: ","},
+		{Type: tokens.IDENT, Literal: "List"},
+		{Type: tokens.LT, Literal: "<"},
+		{Type: tokens.IDENT, Literal: "A"},
+		{Type: tokens.AMPERSAND, Literal: "&"},
+		{Type: tokens.IDENT, Literal: "B"},
+		// Consecutive '>' are separate tokens, so that each closes a list
+		// of type arguments.
+		{Type: tokens.GT, Literal: ">"},
+		{Type: tokens.GT, Literal: ">"},
+	}
+
+	lexer := New(input)
+	for i, want := range expected {
+		tok := lexer.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, want.Type, want.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
+func TestLexerLambdas(t *testing.T) {
+	input := `x -> x - 1; String::length`
+	expected := []tokens.Token{
+		{Type: tokens.IDENT, Literal: "x"},
+		{Type: tokens.ARROW, Literal: "->"},
+		{Type: tokens.IDENT, Literal: "x"},
+		{Type: tokens.MINUS, Literal: "-"},
+		{Type: tokens.INT, Literal: "1"},
+		{Type: tokens.SEMICOLON, Literal: ";"},
+		{Type: tokens.IDENT, Literal: "String"},
+		{Type: tokens.DOUBLE_COLON, Literal: "::"},
+		{Type: tokens.IDENT, Literal: "length"},
+	}
+
+	lexer := New(input)
+	for i, want := range expected {
+		tok := lexer.NextToken()
+		if tok.Type != want.Type || tok.Literal != want.Literal {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q", i, want.Type, want.Literal, tok.Type, tok.Literal)
+		}
+	}
+}
+
 func TestLexerDecrement(t *testing.T) {
 	input := `x--;`
 	lexer := New(input)
@@ -194,7 +319,301 @@ func TestLexerDecrement(t *testing.T) {
 
 	expectedToken := tokens.Token{Type: tokens.DECREMENT, Literal: "--"}
 
-	if expectedToken != tok {
+	if expectedToken.Type != tok.Type || expectedToken.Literal != tok.Literal {
 		t.Fatalf("Expected token should've been %s, but was %s\n", expectedToken.Literal, tok.Literal)
 	}
 }
+
+func TestLexerTextBlock(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"\"\"\"\n    SELECT *\n      FROM users\n    \"\"\"",
+			"SELECT *\n  FROM users\n",
+		},
+		{
+			"\"\"\"\n    {\n      \"id\": 1\n    }\"\"\"",
+			"{\n  \"id\": 1\n}",
+		},
+		{
+			"\"\"\"\n      indented\n    \"\"\"",
+			"  indented\n",
+		},
+		{
+			"\"\"\"\n    trailing   \n    kept\\s\n    \"\"\"",
+			"trailing\nkept \n",
+		},
+		{
+			"\"\"\"\n    one \\\n    line\"\"\"",
+			"one line",
+		},
+		{
+			"\"\"\"\n    a\n\n    b\n    \"\"\"",
+			"a\n\nb\n",
+		},
+		{
+			"\"\"\"   \r\n    quote: \\\"\"\"\r\n    \"\"\"",
+			"quote: \"\"\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		lexer := New(tt.input)
+		tok := lexer.NextToken()
+		if tok.Type != tokens.STRING {
+			t.Fatalf("Token type should've been %s, but was %s (%q)\n", tokens.STRING, tok.Type, tok.Literal)
+		}
+		if tok.Literal != tt.expected {
+			t.Errorf("Text block literal should've been %q, but was %q\n", tt.expected, tok.Literal)
+		}
+		if next := lexer.NextToken(); next.Type != tokens.EOF {
+			t.Errorf("Expected EOF after text block, but got %s (%q)\n", next.Type, next.Literal)
+		}
+	}
+}
+
+func TestLexerTextBlockInStatement(t *testing.T) {
+	input := "String sql = \"\"\"\n\t\tSELECT 1\n\t\t\"\"\";"
+	lexer := New(input)
+	expectedResult := []tokens.Token{
+		{Type: tokens.IDENT, Literal: "String"},
+		{Type: tokens.IDENT, Literal: "sql"},
+		{Type: tokens.ASSIGN, Literal: "="},
+		{Type: tokens.STRING, Literal: "SELECT 1\n"},
+		{Type: tokens.SEMICOLON, Literal: ";"},
+		{Type: tokens.EOF, Literal: ""},
+	}
+
+	for _, tok := range expectedResult {
+		result := lexer.NextToken()
+		if result.Type != tok.Type {
+			t.Errorf("For token: %v. Invalid token type! Token type should've been %v, but was %v\n", tok.Literal, tok.Type, result.Type)
+		}
+		if tok.Literal != result.Literal {
+			t.Errorf("Invalid token literal! Token literal should've been %q, but was %q\n", tok.Literal, result.Literal)
+		}
+	}
+}
+
+func TestLexerIllegalTextBlock(t *testing.T) {
+	tests := []string{
+		`"""abc"""`,
+		"\"\"\"\n  never closed",
+		`"unterminated`,
+	}
+
+	for _, input := range tests {
+		tok := New(input).NextToken()
+		if tok.Type != tokens.ILLEGAL {
+			t.Errorf("Token type for %q should've been %s, but was %s\n", input, tokens.ILLEGAL, tok.Type)
+		}
+	}
+}
+
+func TestLexerStringEscapes(t *testing.T) {
+	input := `"say \"hi\"\tand\\or\s\101"`
+	tok := New(input).NextToken()
+
+	expected := "say \"hi\"\tand\\or A"
+	if tok.Type != tokens.STRING || tok.Literal != expected {
+		t.Fatalf("Expected string token %q, but got %s %q\n", expected, tok.Type, tok.Literal)
+	}
+}
+
+func TestLexerIdentifiers(t *testing.T) {
+	input := `x1 my_var $tmp _count café größe 变量 abc class soft­hyphen`
+	lexer := New(input)
+	expectedResult := []tokens.Token{
+		{Type: tokens.IDENT, Literal: "x1"},
+		{Type: tokens.IDENT, Literal: "my_var"},
+		{Type: tokens.IDENT, Literal: "$tmp"},
+		{Type: tokens.IDENT, Literal: "_count"},
+		{Type: tokens.IDENT, Literal: "café"},
+		{Type: tokens.IDENT, Literal: "größe"},
+		{Type: tokens.IDENT, Literal: "变量"},
+		{Type: tokens.IDENT, Literal: "abc"},
+		{Type: tokens.CLASS, Literal: "class"},
+		{Type: tokens.IDENT, Literal: "softhyphen"},
+		{Type: tokens.EOF, Literal: ""},
+	}
+
+	for _, tok := range expectedResult {
+		result := lexer.NextToken()
+		if result.Type != tok.Type {
+			t.Errorf("For token: %v. Invalid token type! Token type should've been %v, but was %v\n", tok.Literal, tok.Type, result.Type)
+		}
+		if tok.Literal != result.Literal {
+			t.Errorf("Invalid token literal! Token literal should've been %v, but was %v\n", tok.Literal, result.Literal)
+		}
+	}
+}
+
+func TestLexerUnicodeEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []tokens.Token
+	}{
+		{
+			`\u0069nt x \u003d 5;`,
+			[]tokens.Token{
+				{Type: tokens.INTEGER_DT, Literal: "int"},
+				{Type: tokens.IDENT, Literal: "x"},
+				{Type: tokens.ASSIGN, Literal: "="},
+				{Type: tokens.INT, Literal: "5"},
+				{Type: tokens.SEMICOLON, Literal: ";"},
+			},
+		},
+		{
+			`"\uuu0048i é"`,
+			[]tokens.Token{{Type: tokens.STRING, Literal: "Hi é"}},
+		},
+		{
+			// The second backslash is preceded by an odd number of raw
+			// backslashes, so it is an ordinary escape, not a unicode one.
+			`"\\u0041"`,
+			[]tokens.Token{{Type: tokens.STRING, Literal: `\u0041`}},
+		},
+		{
+			// \u005c is a backslash, which then escapes the following n.
+			`"a\u005cnb"`,
+			[]tokens.Token{{Type: tokens.STRING, Literal: "a\nb"}},
+		},
+		{
+			`"\uD83D\uDE00"`,
+			[]tokens.Token{{Type: tokens.STRING, Literal: "😀"}},
+		},
+		{
+			`"¿Qué tal? ☕"`,
+			[]tokens.Token{{Type: tokens.STRING, Literal: "¿Qué tal? ☕"}},
+		},
+	}
+
+	for _, tt := range tests {
+		lexer := New(tt.input)
+		for _, tok := range append(tt.expected, tokens.Token{Type: tokens.EOF}) {
+			result := lexer.NextToken()
+			if result.Type != tok.Type || result.Literal != tok.Literal {
+				t.Errorf("For input %s: expected token %s %q, but got %s %q\n", tt.input, tok.Type, tok.Literal, result.Type, result.Literal)
+			}
+		}
+	}
+}
+
+func TestLexerIllegalCharacter(t *testing.T) {
+	lexer := New(`x # y`)
+	lexer.NextToken()
+	tok := lexer.NextToken()
+
+	if tok.Type != tokens.ILLEGAL || tok.Literal != "#" {
+		t.Fatalf("Expected ILLEGAL token '#', but got %s %q\n", tok.Type, tok.Literal)
+	}
+	if next := lexer.NextToken(); next.Literal != "y" {
+		t.Fatalf("Expected lexing to continue after an illegal character, got %q\n", next.Literal)
+	}
+}
+
+func TestLexerKeywords(t *testing.T) {
+	input := `abstract assert boolean break byte case catch char class const
+	continue default do double else enum extends final finally float for goto
+	if implements import instanceof int interface long native new package
+	private protected public return short static strictfp super switch
+	synchronized this throw throws transient try void volatile while _
+	true false null`
+	expected := []tokens.TokenType{
+		tokens.ABSTRACT, tokens.ASSERT, tokens.BOOLEAN_DT, tokens.BREAK, tokens.BYTE_DT,
+		tokens.CASE, tokens.CATCH, tokens.CHARACTER_DT, tokens.CLASS, tokens.CONST,
+		tokens.CONTINUE, tokens.DEFAULT, tokens.DO, tokens.DOUBLE_DT, tokens.ELSE,
+		tokens.ENUM, tokens.EXTENDS, tokens.FINAL, tokens.FINALLY, tokens.FLOAT_DT,
+		tokens.FOR, tokens.GOTO, tokens.IF, tokens.IMPLEMENTS, tokens.IMPORT,
+		tokens.INSTANCEOF, tokens.INTEGER_DT, tokens.INTERFACE, tokens.LONG_DT, tokens.NATIVE,
+		tokens.NEW, tokens.PACKAGE, tokens.PRIVATE, tokens.PROTECTED, tokens.PUBLIC,
+		tokens.RETURN, tokens.SHORT_DT, tokens.STATIC, tokens.STRICTFP, tokens.SUPER,
+		tokens.SWITCH, tokens.SYNCHRONIZED, tokens.THIS, tokens.THROW, tokens.THROWS,
+		tokens.TRANSIENT, tokens.TRY, tokens.VOID, tokens.VOLATILE, tokens.WHILE,
+		tokens.UNDERSCORE, tokens.TRUE, tokens.FALSE, tokens.NULL, tokens.EOF,
+	}
+
+	lexer := New(input)
+	for _, tokenType := range expected {
+		result := lexer.NextToken()
+		if result.Type != tokenType {
+			t.Errorf("For token: %v. Invalid token type! Token type should've been %v, but was %v\n", result.Literal, tokenType, result.Type)
+		}
+		if tokenType != tokens.EOF && !tokens.IsKeyword(result.Type) {
+			t.Errorf("%v should be reserved\n", result.Literal)
+		}
+	}
+}
+
+func TestLexerContextualKeywords(t *testing.T) {
+	input := `var record yield sealed permits String System out println`
+	lexer := New(input)
+
+	for tok := lexer.NextToken(); tok.Type != tokens.EOF; tok = lexer.NextToken() {
+		if tok.Type != tokens.IDENT {
+			t.Errorf("%v should be lexed as an identifier, but was %v\n", tok.Literal, tok.Type)
+		}
+		if tokens.IsKeyword(tok.Type) {
+			t.Errorf("%v should not be reserved\n", tok.Literal)
+		}
+	}
+}
+
+func TestLexerPositions(t *testing.T) {
+	input := "int x = 5;\n\tString s = \"é\";\r\nx++;"
+	lexer := New(input)
+	expected := []struct {
+		literal string
+		start   tokens.Position
+		end     tokens.Position
+	}{
+		{"int", tokens.Position{Offset: 0, Line: 1, Column: 1}, tokens.Position{Offset: 3, Line: 1, Column: 4}},
+		{"x", tokens.Position{Offset: 4, Line: 1, Column: 5}, tokens.Position{Offset: 5, Line: 1, Column: 6}},
+		{"=", tokens.Position{Offset: 6, Line: 1, Column: 7}, tokens.Position{Offset: 7, Line: 1, Column: 8}},
+		{"5", tokens.Position{Offset: 8, Line: 1, Column: 9}, tokens.Position{Offset: 9, Line: 1, Column: 10}},
+		{";", tokens.Position{Offset: 9, Line: 1, Column: 10}, tokens.Position{Offset: 10, Line: 1, Column: 11}},
+		{"String", tokens.Position{Offset: 12, Line: 2, Column: 2}, tokens.Position{Offset: 18, Line: 2, Column: 8}},
+		{"s", tokens.Position{Offset: 19, Line: 2, Column: 9}, tokens.Position{Offset: 20, Line: 2, Column: 10}},
+		{"=", tokens.Position{Offset: 21, Line: 2, Column: 11}, tokens.Position{Offset: 22, Line: 2, Column: 12}},
+		{"é", tokens.Position{Offset: 23, Line: 2, Column: 13}, tokens.Position{Offset: 27, Line: 2, Column: 16}},
+		{";", tokens.Position{Offset: 27, Line: 2, Column: 16}, tokens.Position{Offset: 28, Line: 2, Column: 17}},
+		{"x", tokens.Position{Offset: 30, Line: 3, Column: 1}, tokens.Position{Offset: 31, Line: 3, Column: 2}},
+		{"++", tokens.Position{Offset: 31, Line: 3, Column: 2}, tokens.Position{Offset: 33, Line: 3, Column: 4}},
+	}
+
+	for _, tt := range expected {
+		tok := lexer.NextToken()
+		if tok.Literal != tt.literal {
+			t.Fatalf("Expected token %q, but got %q\n", tt.literal, tok.Literal)
+		}
+		if tok.Start != tt.start || tok.End != tt.end {
+			t.Errorf("Token %q should span %+v-%+v, but spans %+v-%+v\n", tok.Literal, tt.start, tt.end, tok.Start, tok.End)
+		}
+	}
+}
+
+func TestLexerProblems(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#`, "illegal character: '#'"},
+		{`"abc`, "unclosed string literal"},
+		{`"a\qc"`, "illegal escape character in string literal"},
+		{`"""abc"""`, "illegal text block open delimiter sequence, missing line terminator"},
+		{"\"\"\"\nabc", "unclosed text block"},
+	}
+
+	for _, tt := range tests {
+		lexer := New(tt.input)
+		tok := lexer.NextToken()
+		if tok.Type != tokens.ILLEGAL {
+			t.Fatalf("Expected ILLEGAL token for %q, but got %s\n", tt.input, tok.Type)
+		}
+		if problem := lexer.Problem(tok); problem != tt.expected {
+			t.Errorf("Wrong problem for %q. Expected %q, but got %q\n", tt.input, tt.expected, problem)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package object
+
+import (
+	"fmt"
+	"java/ast"
+)
+
+// Class is a class or an interface.
+type Class struct {
+	Name        string
+	Declaration *ast.ClassDeclaration
+	Super       *Class       // nil for interfaces and classes that only extend Object
+	Interfaces  []*Class     // the interfaces a class implements or an interface extends
+	Env         *Environment // the environment the class was declared in
+
+	// Outer is the class a member class is declared in, and Classes are
+	// the member classes of a class by their simple names.
+	Outer   *Class
+	Classes map[string]*Class
+
+	Statics map[string]Object // the static fields declared by the class
+	State   InitState
+}
+
+// InitState tracks the initialization of a class, which runs its static
+// initializers the first time the class is used. A class whose
+// initialization failed is erroneous, and cannot be used.
+type InitState int
+
+const (
+	Uninitialized InitState = iota
+	BeingInitialized
+	Initialized
+	Erroneous
+)
+
+func (c *Class) Type() ObjectType { return CLASS_OBJ }
+func (c *Class) Inspect() string  { return "class " + c.Name }
+
+// IsSubtypeOf reports whether c is other or extends or implements it,
+// directly or indirectly.
+func (c *Class) IsSubtypeOf(other *Class) bool {
+	if c == other {
+		return true
+	}
+	if c.Super != nil && c.Super.IsSubtypeOf(other) {
+		return true
+	}
+	for _, i := range c.Interfaces {
+		if i.IsSubtypeOf(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// StaticField returns the static field name declared by c or inherited
+// from its superclasses or interfaces.
+func (c *Class) StaticField(name string) (Object, bool) {
+	if owner := c.StaticOwner(name); owner != nil {
+		return owner.Statics[name], true
+	}
+	return nil, false
+}
+
+// SetStaticField assigns the static field that StaticField would return.
+// It reports false if there is no such field.
+func (c *Class) SetStaticField(name string, val Object) bool {
+	if owner := c.StaticOwner(name); owner != nil {
+		owner.Statics[name] = val
+		return true
+	}
+	return false
+}
+
+// MemberClass returns the member class name that c declares or inherits
+// from its superclasses or interfaces, or nil if there is none.
+func (c *Class) MemberClass(name string) *Class {
+	if member, ok := c.Classes[name]; ok {
+		return member
+	}
+	if c.Super != nil {
+		if member := c.Super.MemberClass(name); member != nil {
+			return member
+		}
+	}
+	for _, i := range c.Interfaces {
+		if member := i.MemberClass(name); member != nil {
+			return member
+		}
+	}
+	return nil
+}
+
+// StaticOwner returns the class that declares the static field name that
+// StaticField would return, or nil if there is none.
+func (c *Class) StaticOwner(name string) *Class {
+	if _, ok := c.Statics[name]; ok {
+		return c
+	}
+	if c.Super != nil {
+		if owner := c.Super.StaticOwner(name); owner != nil {
+			return owner
+		}
+	}
+	for _, i := range c.Interfaces {
+		if owner := i.StaticOwner(name); owner != nil {
+			return owner
+		}
+	}
+	return nil
+}
+
+// Instance is an object created with `new`. Each instance has its own
+// storage for the fields declared by its class and each superclass. A
+// field declared in a subclass hides, rather than replaces, a superclass
+// field with the same name.
+type Instance struct {
+	Class  *Class
+	Fields map[*Class]map[string]Object
+	id     int
+
+	// StackTrace holds the calls in progress when a Throwable was
+	// created, innermost first, e.g. "Main.main(Main.java:3)".
+	StackTrace []string
+	// Suppressed holds the exceptions added by Throwable.addSuppressed.
+	Suppressed []*Instance
+	// Lambda is the code run for the abstract method of a functional
+	// interface by an instance created by a lambda expression or a method
+	// reference.
+	Lambda *Lambda
+	// Enclosing holds the environment that the code of an inner or
+	// anonymous class among the classes of the instance runs in: for an
+	// inner class, one where this is the instance of the enclosing class
+	// that the instance belongs to, and for an anonymous class the one
+	// where the instance was created.
+	Enclosing map[*Class]*Environment
+}
+
+var instances int
+
+func NewInstance(class *Class) *Instance {
+	instances++
+	i := &Instance{Class: class, Fields: make(map[*Class]map[string]Object), id: instances}
+	for c := class; c != nil; c = c.Super {
+		i.Fields[c] = make(map[string]Object)
+	}
+	return i
+}
+
+// Field returns the field name as seen from code in class from: the one
+// declared there or else the one inherited from the nearest superclass.
+func (i *Instance) Field(from *Class, name string) (Object, bool) {
+	if fields := i.lookup(from, name); fields != nil {
+		return fields[name], true
+	}
+	return nil, false
+}
+
+// SetField assigns the field that Field would return. It reports false if
+// there is no such field.
+func (i *Instance) SetField(from *Class, name string, val Object) bool {
+	if fields := i.lookup(from, name); fields != nil {
+		fields[name] = val
+		return true
+	}
+	return false
+}
+
+func (i *Instance) lookup(from *Class, name string) map[string]Object {
+	for c := from; c != nil; c = c.Super {
+		if _, ok := i.Fields[c][name]; ok {
+			return i.Fields[c]
+		}
+	}
+	return nil
+}
+
+func (i *Instance) Type() ObjectType { return INSTANCE_OBJ }
+func (i *Instance) Inspect() string  { return fmt.Sprintf("%s@%x", i.Class.Name, i.id) }
+
+// HashCode is the identity hash code of i, which Inspect shows in hex.
+func (i *Instance) HashCode() int { return i.id }
+
+// Lambda is a lambda expression or a method reference, evaluated. The
+// instance of its functional interface belongs to a class made for it,
+// which implements the interface.
+type Lambda struct {
+	Expression *ast.LambdaExpression // nil for a method reference
+	Reference  *ast.MethodReference
+	Env        *Environment // the environment a lambda expression captures
+	Method     string       // the method its body compiles to, e.g. "Main.lambda$main$0"
+
+	// Receiver is what the target of a method reference refers to: a
+	// class, the object a bound reference calls the method on, or nil for
+	// a library type whose methods are called on the first argument.
+	Receiver Object
+}
@@ -0,0 +1,138 @@
+package object
+
+type Environment struct {
+	store map[string]Object
+	outer *Environment
+
+	// class and this are set on the environment of a method or constructor
+	// call: the class declaring it and the receiver of the call.
+	class *Class
+	this  *Instance
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{store: make(map[string]Object)}
+}
+
+func NewEnclosedEnvironment(outer *Environment) *Environment {
+	env := NewEnvironment()
+	env.outer = outer
+	return env
+}
+
+// NewMethodEnvironment returns the environment for a call of a method or
+// constructor of class on the receiver this.
+func NewMethodEnvironment(outer *Environment, class *Class, this *Instance) *Environment {
+	env := NewEnclosedEnvironment(outer)
+	env.class = class
+	env.this = this
+	return env
+}
+
+// Get looks name up in e and the environments enclosing it. Inside a
+// method the fields of the receiver, then the static fields and the member
+// classes of its class, are found after the method's locals.
+func (e *Environment) Get(name string) (Object, bool) {
+	if obj, ok := e.store[name]; ok {
+		return obj, true
+	}
+	if e.this != nil {
+		if obj, ok := e.this.Field(e.class, name); ok {
+			return obj, true
+		}
+	}
+	if e.class != nil {
+		if obj, ok := e.class.StaticField(name); ok {
+			return obj, true
+		}
+		if member := e.class.MemberClass(name); member != nil {
+			return member, true
+		}
+	}
+	if e.outer != nil {
+		return e.outer.Get(name)
+	}
+	return nil, false
+}
+
+// Set declares name in e.
+func (e *Environment) Set(name string, val Object) Object {
+	e.store[name] = val
+	return val
+}
+
+// Assign changes the value of the variable or field name that Get would
+// find. It reports false if there is no such variable.
+func (e *Environment) Assign(name string, val Object) bool {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = val
+		return true
+	}
+	if e.this != nil && e.this.SetField(e.class, name, val) {
+		return true
+	}
+	if e.class != nil && e.class.SetStaticField(name, val) {
+		return true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, val)
+	}
+	return false
+}
+
+// Describe is how the JVM refers to the variable name that Get would find
+// in the message of a NullPointerException: "name" for a local variable,
+// "this.name" for a field and "Class.name" for a static field.
+func (e *Environment) Describe(name string) string {
+	for ; e != nil; e = e.outer {
+		if _, ok := e.store[name]; ok {
+			return name
+		}
+		if e.this != nil {
+			if _, ok := e.this.Field(e.class, name); ok {
+				return "this." + name
+			}
+		}
+		if e.class != nil {
+			if _, ok := e.class.StaticField(name); ok {
+				return e.class.Name + "." + name
+			}
+		}
+	}
+	return name
+}
+
+// This returns the receiver of the innermost method call, or nil outside
+// of an instance method.
+func (e *Environment) This() *Instance {
+	if frame := e.Frame(); frame != nil {
+		return frame.this
+	}
+	return nil
+}
+
+// Class returns the class declaring the innermost method being called.
+func (e *Environment) Class() *Class {
+	if frame := e.Frame(); frame != nil {
+		return frame.class
+	}
+	return nil
+}
+
+// Frame returns the environment of the innermost method call that e is
+// in, or nil outside of any. In a method of an inner, local or anonymous
+// class, the environments of the calls of the enclosing classes follow
+// from the outer environment of the frame.
+func (e *Environment) Frame() *Environment {
+	for ; e != nil; e = e.outer {
+		if e.class != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// Outer returns the environment e is enclosed in.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
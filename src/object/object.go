@@ -1,13 +1,28 @@
 package object
 
-import "fmt"
+import (
+	"fmt"
+	"java/ast"
+	"math"
+	"strconv"
+	"strings"
+)
 
 type ObjectType string
 
 const (
-	INTEGER_OBJ = "INTEGER"
-	BOOLEAN_OBJ = "BOOLEAN"
-	NULL_OBJ    = "NULL"
+	INTEGER_OBJ      = "INTEGER"
+	DOUBLE_OBJ       = "DOUBLE"
+	BOOLEAN_OBJ      = "BOOLEAN"
+	NULL_OBJ         = "NULL"
+	STRING_OBJ       = "STRING"
+	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	YIELD_VALUE_OBJ  = "YIELD_VALUE"
+	ERROR_OBJ        = "ERROR"
+	FUNCTION_OBJ     = "FUNCTION"
+	CLASS_OBJ        = "CLASS"
+	INSTANCE_OBJ     = "INSTANCE"
+	ARRAY_OBJ        = "ARRAY"
 )
 
 type Object interface {
@@ -33,3 +48,128 @@ type Integer struct {
 
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
+
+type Double struct {
+	Value float64
+}
+
+func (d *Double) Type() ObjectType { return DOUBLE_OBJ }
+
+// Inspect formats d the way Double.toString does: in decimal notation,
+// with at least one digit after the point, for magnitudes from 10^-3 up
+// to 10^7 and in scientific notation, e.g. 1.0E10, otherwise.
+func (d *Double) Inspect() string {
+	v := d.Value
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "Infinity"
+	case math.IsInf(v, -1):
+		return "-Infinity"
+	}
+	if abs := math.Abs(v); abs == 0 || (abs >= 1e-3 && abs < 1e7) {
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+	s := strconv.FormatFloat(v, 'E', -1, 64)
+	mantissa, exponent, _ := strings.Cut(s, "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	n, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(n)
+}
+
+type String struct {
+	Value string
+}
+
+func (s *String) Type() ObjectType { return STRING_OBJ }
+func (s *String) Inspect() string  { return s.Value }
+
+type ReturnValue struct {
+	Value Object
+}
+
+func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
+func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
+
+// YieldValue carries the value of a switch expression out of the block of
+// the case that yields it.
+type YieldValue struct {
+	Value Object
+}
+
+func (yv *YieldValue) Type() ObjectType { return YIELD_VALUE_OBJ }
+func (yv *YieldValue) Inspect() string  { return yv.Value.Inspect() }
+
+// Error stops the evaluation of a program. It is either a Java exception
+// being thrown, which a try statement can catch, or an error javac would
+// have reported, which nothing catches.
+type Error struct {
+	Message   string
+	Exception *Instance // the Throwable thrown, if any
+}
+
+func (e *Error) Type() ObjectType { return ERROR_OBJ }
+func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+
+// Function is a method declared outside of any class, as in a script.
+type Function struct {
+	Literal *ast.FunctionLiteral
+	Env     *Environment
+	Next    *Function // another method with the same name, if overloaded
+}
+
+func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
+func (f *Function) Inspect() string  { return f.Literal.String() }
+
+// Array is a Java array. Arrays are created for variable arity
+// parameters.
+type Array struct {
+	ElementType *ast.Type
+	Elements    []Object
+	id          int
+}
+
+var arrays int
+
+func NewArray(elementType *ast.Type, elements []Object) *Array {
+	arrays++
+	return &Array{ElementType: elementType, Elements: elements, id: arrays}
+}
+
+func (a *Array) Type() ObjectType { return ARRAY_OBJ }
+
+// Inspect formats a the way Object.toString does for arrays, with the
+// JVM's descriptor of the array type, e.g. "[I@1" for an int[].
+func (a *Array) Inspect() string {
+	descriptor := strings.Repeat("[", a.ElementType.Dimensions+1)
+	switch a.ElementType.Name {
+	case "int":
+		descriptor += "I"
+	case "long":
+		descriptor += "J"
+	case "short":
+		descriptor += "S"
+	case "byte":
+		descriptor += "B"
+	case "char":
+		descriptor += "C"
+	case "boolean":
+		descriptor += "Z"
+	case "float":
+		descriptor += "F"
+	case "double":
+		descriptor += "D"
+	case "String", "Object", "Integer", "Double", "Boolean":
+		descriptor += "Ljava.lang." + a.ElementType.Name + ";"
+	default:
+		descriptor += "L" + a.ElementType.Name + ";"
+	}
+	return fmt.Sprintf("%s@%x", descriptor, a.id)
+}
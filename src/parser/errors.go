@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"fmt"
+	"java/ast"
+	"java/diagnostics"
+	"java/tokens"
+)
+
+// Diagnostic codes reported by the parser.
+const (
+	ErrUnexpectedToken    = "E0001"
+	ErrExpectedExpression = "E0002"
+	ErrIllegalToken       = "E0003"
+	ErrIntegerTooLarge    = "E0004"
+	ErrReservedWord       = "E0005"
+	ErrRestrictedName     = "E0006"
+	ErrUnexpectedEOF      = "E0007"
+	ErrInvalidAssignment  = "E0008"
+	ErrMisplacedClause    = "E0009"
+	ErrPrimitiveArgument  = "E0010"
+)
+
+func (p *Parser) Errors() []string {
+	errors := make([]string, len(p.diagnostics))
+	for i, d := range p.diagnostics {
+		errors[i] = d.Message
+	}
+	return errors
+}
+
+func (p *Parser) Diagnostics() []*diagnostics.Diagnostic {
+	return p.diagnostics
+}
+
+func (p *Parser) report(d *diagnostics.Diagnostic) {
+	if p.panicMode {
+		return
+	}
+	p.panicMode = true
+	p.errorOffset = d.Primary.Start.Offset
+	p.diagnostics = append(p.diagnostics, d)
+}
+
+// peekError reports that the next token is not the expected t. A missing
+// token is reported right after the current one when the next token is on
+// a later line or at the end of the input, as that is where it belongs.
+func (p *Parser) peekError(t tokens.TokenType) {
+	expected := tokens.Describe(t)
+	found := describe(p.peekToken)
+
+	var d *diagnostics.Diagnostic
+	if p.peekTokenIs(tokens.EOF) || p.peekToken.Start.Line > p.curToken.End.Line {
+		d = diagnostics.Errorf(ErrUnexpectedToken, diagnostics.After(p.curToken, "expected "+expected),
+			"expected %s, found %s", expected, found)
+	} else {
+		d = diagnostics.Errorf(ErrUnexpectedToken, diagnostics.TokenSpan(p.peekToken, "expected "+expected),
+			"expected %s, found %s", expected, found)
+	}
+	if t == tokens.SEMICOLON {
+		d.WithHint("add ';' to end the statement")
+	}
+	p.report(d)
+}
+
+// closingError reports a missing closing delimiter t, pointing out where
+// the delimiter was opened.
+func (p *Parser) closingError(t tokens.TokenType, open tokens.Token) {
+	if p.panicMode {
+		return
+	}
+	p.peekError(t)
+	p.diagnostics[len(p.diagnostics)-1].WithSecondary(diagnostics.TokenSpan(open, "unclosed delimiter"))
+}
+
+func (p *Parser) varargsNotLastError(param *ast.Parameter) {
+	p.report(diagnostics.Errorf(ErrUnexpectedToken, diagnostics.TokenSpan(param.ParameterName.Token, ""),
+		"varargs parameter must be the last parameter"))
+}
+
+func (p *Parser) noPrefixParseFnError(tok tokens.Token) {
+	if tok.Type == tokens.ILLEGAL {
+		p.report(diagnostics.Errorf(ErrIllegalToken, diagnostics.TokenSpan(tok, ""),
+			"%s", p.l.Problem(tok)))
+		return
+	}
+
+	span := diagnostics.TokenSpan(tok, "expected an expression")
+	if tok.Type == tokens.EOF {
+		span.Label = "expected an expression after this"
+	}
+	p.report(diagnostics.Errorf(ErrExpectedExpression, span,
+		"expected expression, found %s", describe(tok)))
+}
+
+func (p *Parser) expectedError(tok tokens.Token, what string) {
+	p.report(diagnostics.Errorf(ErrUnexpectedToken, diagnostics.TokenSpan(tok, "expected "+what),
+		"expected %s, found %s", what, describe(tok)))
+}
+
+func (p *Parser) reservedWordError(tok tokens.Token) {
+	p.report(diagnostics.Errorf(ErrReservedWord, diagnostics.TokenSpan(tok, "reserved word"),
+		"'%s' is a reserved word and cannot be used as an identifier", tok.Literal).
+		WithHint("choose a different name"))
+}
+
+func (p *Parser) restrictedNameError(tok tokens.Token, format string, a ...interface{}) *diagnostics.Diagnostic {
+	d := diagnostics.Errorf(ErrRestrictedName, diagnostics.TokenSpan(tok, ""), format, a...)
+	p.report(d)
+	return d
+}
+
+func (p *Parser) unexpectedEOFError(open tokens.Token) {
+	span := diagnostics.After(p.curToken, "")
+	p.report(diagnostics.Errorf(ErrUnexpectedEOF, span, "reached end of file while parsing").
+		WithSecondary(diagnostics.TokenSpan(open, "this block is never closed")).
+		WithHint("add the missing '}'"))
+}
+
+func (p *Parser) integerTooLargeError(tok tokens.Token) {
+	p.report(diagnostics.Errorf(ErrIntegerTooLarge, diagnostics.TokenSpan(tok, ""),
+		"integer number too large: %s", tok.Literal))
+}
+
+func (p *Parser) floatTooLargeError(tok tokens.Token) {
+	p.report(diagnostics.Errorf(ErrIntegerTooLarge, diagnostics.TokenSpan(tok, ""),
+		"floating-point number too large"))
+}
+
+func (p *Parser) invalidAssignmentError(tok tokens.Token) {
+	p.report(diagnostics.Errorf(ErrInvalidAssignment, diagnostics.TokenSpan(tok, "cannot assign to this expression"),
+		"unexpected type: required variable, found value"))
+}
+
+// primitiveArgumentError reports a primitive type used as a type argument
+// or bound, where only reference types are allowed.
+func (p *Parser) primitiveArgumentError(typ *ast.Type) {
+	p.report(diagnostics.Errorf(ErrPrimitiveArgument, diagnostics.TokenSpan(typ.Token, "primitive type"),
+		"unexpected type: required reference, found %s", typ).
+		WithHint("use the wrapper class, e.g. Integer for int"))
+}
+
+// misplacedClauseError reports a part of a try statement found where it
+// does not belong, e.g. "'catch' without 'try'".
+func (p *Parser) misplacedClauseError(tok tokens.Token, message string) {
+	p.report(diagnostics.Errorf(ErrMisplacedClause, diagnostics.TokenSpan(tok, ""), "%s", message))
+}
+
+func describe(tok tokens.Token) string {
+	if tok.Type == tokens.IDENT {
+		return fmt.Sprintf("identifier '%s'", tok.Literal)
+	}
+	return tokens.Describe(tok.Type)
+}
@@ -3,48 +3,81 @@ package parser
 import (
 	"fmt"
 	"java/ast"
+	"java/diagnostics"
 	"java/lexer"
 	"java/tokens"
 	"strconv"
+	"strings"
 )
 
 const (
-	int = iota
+	_ = iota
 	_
 	LOWEST
+	ASSIGN      // =
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
-	CALL        // myFunction(X)
+	CALL        // myFunction(X) or object.field
 )
 
 type Parser struct {
-	l         *lexer.Lexer
-	curToken  tokens.Token
-	peekToken tokens.Token
-	errors    []string
+	l           *lexer.Lexer
+	curToken    tokens.Token
+	peekToken   tokens.Token
+	diagnostics []*diagnostics.Diagnostic
+
+	// panicMode is set by the first error in a statement. Further errors
+	// are suppressed until the parser has synchronized, so one mistake
+	// does not cascade into a series of meaningless messages.
+	panicMode bool
+	// errorOffset is where the last reported error was found. A keyword
+	// found there caused the error, so it is not a synchronization point.
+	errorOffset int
+	// caseLabel is set while parsing the labels of a switch case, where a
+	// '->' ends the labels rather than starting a lambda body.
+	caseLabel bool
+	// classes are the classes being parsed, innermost last, which name
+	// the anonymous classes in them. anonymous counts those outside of
+	// any class.
+	classes   []*enclosingClass
+	anonymous int
 
 	prefixParseFns map[tokens.TokenType]prefixParseFn
 	infixParseFns  map[tokens.TokenType]infixParseFn
 }
 
+// enclosingClass is a class being parsed: its binary name, as javac
+// names its class file, and the number of anonymous classes in it so far.
+type enclosingClass struct {
+	name      string
+	anonymous int
+}
+
 type (
 	prefixParseFn func() ast.Expression
 	infixParseFn  func(ast.Expression) ast.Expression
 )
 
 var precedences = map[tokens.TokenType]int64{
-	tokens.EQ:       EQUALS,
-	tokens.NOT_EQ:   EQUALS,
-	tokens.LT:       LESSGREATER,
-	tokens.GT:       LESSGREATER,
-	tokens.PLUS:     SUM,
-	tokens.MINUS:    SUM,
-	tokens.SLASH:    PRODUCT,
-	tokens.ASTERISK: PRODUCT,
-	tokens.LPAREN:   CALL,
+	tokens.ASSIGN: ASSIGN,
+	tokens.EQ:     EQUALS,
+	tokens.NOT_EQ: EQUALS,
+	tokens.LT:     LESSGREATER,
+	tokens.GT:     LESSGREATER,
+
+	tokens.INSTANCEOF: LESSGREATER,
+	tokens.PLUS:       SUM,
+	tokens.MINUS:      SUM,
+	tokens.SLASH:      PRODUCT,
+	tokens.ASTERISK:   PRODUCT,
+	tokens.LPAREN:     CALL,
+	tokens.PERIOD:     CALL,
+	tokens.LSPAREN:    CALL,
+
+	tokens.DOUBLE_COLON: CALL,
 }
 
 // [...]
@@ -65,7 +98,7 @@ func (p *Parser) parseExpression(precedence int64) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 
 	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
+		p.noPrefixParseFnError(p.curToken)
 		return nil
 	}
 
@@ -86,8 +119,18 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.integerTooLargeError(p.curToken)
+		return nil
+	}
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseDoubleLiteral() ast.Expression {
+	lit := &ast.DoubleLiteral{Token: p.curToken}
+	value, err := strconv.ParseFloat(strings.TrimRight(p.curToken.Literal, "dD"), 64)
+	if err != nil {
+		p.floatTooLargeError(p.curToken)
 		return nil
 	}
 	lit.Value = value
@@ -102,7 +145,7 @@ func (p *Parser) registerInfix(tokenType tokens.TokenType, fn infixParseFn) {
 }
 
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, diagnostics: []*diagnostics.Diagnostic{}}
 	p.nextToken()
 	p.nextToken()
 
@@ -110,18 +153,27 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(tokens.IDENT, p.parseIdentifier)
 	p.registerPrefix(tokens.STRING, p.parseStringLiteral)
 	p.registerPrefix(tokens.INT, p.parseIntegerLiteral)
+	p.registerPrefix(tokens.DOUBLE, p.parseDoubleLiteral)
 	p.registerPrefix(tokens.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(tokens.TRUE, p.parseBoolean)
 	p.registerPrefix(tokens.FALSE, p.parseBoolean)
+	p.registerPrefix(tokens.NULL, p.parseNullLiteral)
 	p.registerPrefix(tokens.LPAREN, p.parseGroupedExpression)
-	p.registerPrefix(tokens.IF, p.parseIfExpression)
 	p.registerPrefix(tokens.PUBLIC, p.parseFunctionLiteral)
 	p.registerPrefix(tokens.PRIVATE, p.parseFunctionLiteral)
 	p.registerPrefix(tokens.VOID, p.parseFunctionLiteral)
 	p.registerPrefix(tokens.BANG, p.parsePrefixExpression)
+	p.registerPrefix(tokens.NEW, p.parseNewExpression)
+	p.registerPrefix(tokens.THIS, p.parseThisExpression)
+	p.registerPrefix(tokens.SUPER, p.parseSuperExpression)
+	p.registerPrefix(tokens.SWITCH, p.parseSwitchExpression)
 	p.infixParseFns = make(map[tokens.TokenType]infixParseFn)
 
 	p.registerInfix(tokens.LPAREN, p.parseCallExpression)
+	p.registerInfix(tokens.PERIOD, p.parseMemberExpression)
+	p.registerInfix(tokens.LSPAREN, p.parseIndexExpression)
+	p.registerInfix(tokens.DOUBLE_COLON, p.parseMethodReference)
+	p.registerInfix(tokens.ASSIGN, p.parseAssignmentExpression)
 	p.registerInfix(tokens.PLUS, p.parseInfixExpression)
 	p.registerInfix(tokens.MINUS, p.parseInfixExpression)
 	p.registerInfix(tokens.SLASH, p.parseInfixExpression)
@@ -130,6 +182,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(tokens.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(tokens.LT, p.parseInfixExpression)
 	p.registerInfix(tokens.GT, p.parseInfixExpression)
+	p.registerInfix(tokens.INSTANCEOF, p.parseInstanceofExpression)
 
 	return p
 }
@@ -143,12 +196,18 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	if ident, ok := function.(*ast.Identifier); ok && ident.Value == "yield" {
+		p.restrictedNameError(ident.Token, "invalid use of a restricted identifier 'yield'").
+			WithHint("qualify the method call, e.g. this.yield(...)")
+		return nil
+	}
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseCallArguments()
 	return exp
 }
 
 func (p *Parser) parseCallArguments() []ast.Expression {
+	open := p.curToken
 	args := []ast.Expression{}
 
 	if p.peekTokenIs(tokens.RPAREN) {
@@ -165,7 +224,7 @@ func (p *Parser) parseCallArguments() []ast.Expression {
 		args = append(args, p.parseExpression(LOWEST))
 	}
 
-	if !p.expectPeek(tokens.RPAREN) {
+	if !p.expectClosing(tokens.RPAREN, open) {
 		return nil
 	}
 	return args
@@ -177,361 +236,1940 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	// void getString()
 	// public String getString()
 	// public int getString()
+	first := p.curToken
+	modifiers := p.parseModifiers()
+	return p.parseMethodDeclaration(first, modifiers)
+}
 
-	lit := &ast.FunctionLiteral{Token: p.curToken}
-
-	if p.curToken.Type == tokens.PUBLIC || p.curToken.Type == tokens.PRIVATE {
-		lit.Accessor = p.curToken
+// parseMethodDeclaration parses the rest of a method declaration from its
+// type parameters or return type on. first is the first token of the declaration.
+func (p *Parser) parseMethodDeclaration(first tokens.Token, modifiers []tokens.Token) *ast.FunctionLiteral {
+	var typeParams []*ast.TypeParameter
+	if p.curTokenIs(tokens.LT) {
+		if typeParams = p.parseTypeParameters(); typeParams == nil {
+			return nil
+		}
 		p.nextToken()
 	}
+	if !p.curTokenIs(tokens.VOID) && !isTypeToken(p.curToken.Type) {
+		p.expectedError(p.curToken, "return type")
+		return nil
+	}
+	returnType := p.parseType()
+	if returnType == nil {
+		return nil
+	}
+	if !p.expectIdentifier() {
+		return nil
+	}
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(tokens.LPAREN) {
+		return nil
+	}
+	lit := p.parseMethodRest(first, modifiers, returnType, name)
+	if lit != nil {
+		lit.TypeParameters = typeParams
+	}
+	return lit
+}
 
-	if p.curToken.Type == tokens.VOID || p.curToken.Type == tokens.STRING_DT || p.curToken.Type == tokens.INTEGER_DT || p.curToken.Type == tokens.CHARACTER_DT {
-		lit.ReturnType = p.curToken
-		p.nextToken()
+// parseMethodRest parses the parameters and body of a method, starting at
+// the '(' after its name.
+func (p *Parser) parseMethodRest(first tokens.Token, modifiers []tokens.Token, returnType *ast.Type, name *ast.Identifier) *ast.FunctionLiteral {
+	lit := &ast.FunctionLiteral{Token: first, Modifiers: modifiers, ReturnType: returnType, Name: name}
+	for _, m := range modifiers {
+		if isAccessModifier(m.Type) {
+			lit.Accessor = m
+		}
 	}
 
-	lit.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	lit.Parameters = p.parseParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+	if !p.parseThrows(&lit.Throws) {
+		return nil
+	}
 
-	if !p.expectPeek(tokens.LPAREN) {
+	// Abstract and native methods have no body.
+	if p.peekTokenIs(tokens.SEMICOLON) {
+		p.nextToken()
+		return lit
+	}
+	if !p.expectPeek(tokens.LBRACE) {
+		return nil
+	}
+	lit.Body = p.parseBlockStatement()
+	if lit.Body == nil {
 		return nil
 	}
+	return lit
+}
 
+// parseThrows parses the throws clause of a method or constructor, if
+// there is one, into throws. It reports false on error.
+func (p *Parser) parseThrows(throws *[]*ast.Type) bool {
+	if !p.peekTokenIs(tokens.THROWS) {
+		return true
+	}
 	p.nextToken()
+	if !p.peekTokenIs(tokens.IDENT) {
+		// Report the missing type without consuming the method body.
+		p.expectedError(p.peekToken, "class name")
+		return false
+	}
+	*throws = p.parseTypeList()
+	return *throws != nil
+}
 
+// parseParameters parses a parenthesized parameter list, starting at the
+// '('. It returns nil on error.
+func (p *Parser) parseParameters() []*ast.Parameter {
+	open := p.curToken
 	parameters := []*ast.Parameter{}
 
-	for !p.curTokenIs(tokens.RPAREN) {
-		if p.curTokenIs(tokens.COMMA) {
-			p.nextToken()
-		}
-		param := &ast.Parameter{}
-		param.DataType = p.curToken
+	if p.peekTokenIs(tokens.RPAREN) {
 		p.nextToken()
-		param.ParameterName = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		return parameters
+	}
+
+	for {
+		p.nextToken()
+		param := p.parseParameter()
+		if param == nil {
+			return nil
+		}
 		parameters = append(parameters, param)
+
+		if !p.peekTokenIs(tokens.COMMA) {
+			break
+		}
 		p.nextToken()
+		if param.Variadic {
+			p.varargsNotLastError(param)
+		}
 	}
-
-	if !p.expectPeek(tokens.LBRACE) {
+	if !p.expectClosing(tokens.RPAREN, open) || p.panicMode {
 		return nil
 	}
+	return parameters
+}
 
-	lit.Parameters = parameters
+func (p *Parser) parseParameter() *ast.Parameter {
+	param := &ast.Parameter{}
+	for p.curTokenIs(tokens.FINAL) {
+		param.Modifiers = append(param.Modifiers, p.curToken)
+		p.nextToken()
+	}
+	if !isTypeToken(p.curToken.Type) {
+		p.expectedError(p.curToken, "parameter type")
+		return nil
+	}
+	if param.DataType = p.parseType(); param.DataType == nil {
+		return nil
+	}
+	if p.peekTokenIs(tokens.ELLIPSIS) {
+		p.nextToken()
+		param.Variadic = true
+	}
 
-	lit.Body = p.parseBlockStatement()
-	return lit
+	if !p.expectIdentifier() {
+		return nil
+	}
+	param.ParameterName = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return param
 }
 
-func (p *Parser) parseGroupedExpression() ast.Expression {
-	p.nextToken()
-	exp := p.parseExpression(LOWEST)
-
-	if !p.expectPeek(tokens.RPAREN) {
+// parseType parses a type in a declaration, starting at its first token.
+func (p *Parser) parseType() *ast.Type {
+	if !p.checkTypeName(p.curToken) {
 		return nil
 	}
+	typ := &ast.Type{Token: p.curToken, Name: p.curToken.Literal}
+	for p.curTokenIs(tokens.IDENT) && p.peekTokenIs(tokens.PERIOD) {
+		// A member class qualified by the class declaring it, as in
+		// `Map.Entry`.
+		l := *p.l
+		if l.NextToken().Type != tokens.IDENT {
+			break
+		}
+		p.nextToken()
+		p.nextToken()
+		typ.Name += "." + p.curToken.Literal
+	}
+	if p.curTokenIs(tokens.IDENT) && p.peekTokenIs(tokens.LT) {
+		p.nextToken()
+		if typ.Arguments = p.parseTypeArguments(false); typ.Arguments == nil {
+			return nil
+		}
+	}
+	for p.peekTokenIs(tokens.LSPAREN) {
+		open := p.peekToken
+		p.nextToken()
+		if !p.expectClosing(tokens.RSPAREN, open) {
+			return nil
+		}
+		typ.Dimensions++
+	}
+	return typ
+}
 
-	return exp
+// parseVarType parses the `var` of a local variable declaration whose
+// type is inferred from its initializer. It gives a Type named "var",
+// which is never the name of a class.
+func (p *Parser) parseVarType() *ast.Type {
+	if p.peekTokenIs(tokens.LSPAREN) {
+		p.restrictedNameError(p.curToken, "'var' is not allowed as an element type of an array")
+		return nil
+	}
+	return &ast.Type{Token: p.curToken, Name: p.curToken.Literal}
 }
 
-func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
-	expression := &ast.InfixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
-		Left:     left,
+// parseTypeArguments parses the type arguments of a generic type, such as
+// `<String, ? extends Number>`, starting at the '<'. The diamond `<>` is
+// only allowed if diamond is set and gives an empty list. The lexer never
+// joins '>' characters into a shift operator, so the '>' of nested lists,
+// as in `List<List<String>>`, each close one list. It returns nil on error.
+func (p *Parser) parseTypeArguments(diamond bool) []*ast.Type {
+	open := p.curToken
+	args := []*ast.Type{}
+	if diamond && p.peekTokenIs(tokens.GT) {
+		p.nextToken()
+		return args
 	}
 
-	precedence := p.curPrecedence()
-	p.nextToken()
-	expression.Right = p.parseExpression(precedence)
+	for {
+		p.nextToken()
+		arg := p.parseTypeArgument()
+		if arg == nil {
+			return nil
+		}
+		args = append(args, arg)
 
-	return expression
+		if !p.peekTokenIs(tokens.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectClosing(tokens.GT, open) {
+		return nil
+	}
+	return args
 }
 
-func (p *Parser) parseBoolean() ast.Expression {
-	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(tokens.TRUE)}
+// parseTypeArgument parses a type argument: a reference type or a
+// wildcard, `?`, `? extends T` or `? super T`.
+func (p *Parser) parseTypeArgument() *ast.Type {
+	if !p.curTokenIs(tokens.QUESTION) {
+		return p.parseReferenceType()
+	}
+	wildcard := &ast.Type{Token: p.curToken, Name: "?"}
+	if p.peekTokenIs(tokens.EXTENDS) || p.peekTokenIs(tokens.SUPER) {
+		p.nextToken()
+		wildcard.Super = p.curTokenIs(tokens.SUPER)
+		p.nextToken()
+		if wildcard.Bound = p.parseReferenceType(); wildcard.Bound == nil {
+			return nil
+		}
+	}
+	return wildcard
 }
 
-func (p *Parser) parsePrefixExpression() ast.Expression {
-	expression := &ast.PrefixExpression{
-		Token:    p.curToken,
-		Operator: p.curToken.Literal,
+// parseReferenceType parses a type that may not be primitive, although
+// an array of a primitive type is fine.
+func (p *Parser) parseReferenceType() *ast.Type {
+	if !isTypeToken(p.curToken.Type) {
+		p.expectedError(p.curToken, "type")
+		return nil
 	}
-	p.nextToken()
-	expression.Right = p.parseExpression(PREFIX)
-	return expression
+	typ := p.parseType()
+	if typ != nil && typ.Dimensions == 0 && typ.Token.Type != tokens.IDENT {
+		p.primitiveArgumentError(typ)
+		return nil
+	}
+	return typ
 }
 
-func (p *Parser) noPrefixParseFnError(t tokens.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
-}
+// parseTypeParameters parses the type parameters of a generic class or
+// method, `<T, U extends Comparable<U> & Serializable>`, starting at the
+// '<'. It returns nil on error.
+func (p *Parser) parseTypeParameters() []*ast.TypeParameter {
+	open := p.curToken
+	params := []*ast.TypeParameter{}
+	for {
+		if !p.expectIdentifier() || !p.checkTypeName(p.curToken) {
+			return nil
+		}
+		param := &ast.TypeParameter{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+		if p.peekTokenIs(tokens.EXTENDS) {
+			p.nextToken()
+			for {
+				p.nextToken()
+				bound := p.parseClassType()
+				if bound == nil {
+					return nil
+				}
+				param.Bounds = append(param.Bounds, bound)
+
+				if !p.peekTokenIs(tokens.AMPERSAND) {
+					break
+				}
+				p.nextToken()
+			}
+		}
+		params = append(params, param)
 
-func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.peekTokenIs(tokens.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectClosing(tokens.GT, open) {
+		return nil
+	}
+	return params
 }
 
-func (p *Parser) Errors() []string {
-	return p.errors
+func (p *Parser) parseModifiers() []tokens.Token {
+	modifiers := []tokens.Token{}
+	for {
+		switch {
+		case isModifier(p.curToken.Type):
+			modifiers = append(modifiers, p.curToken)
+		case p.startsContextualModifier():
+			modifiers = append(modifiers, p.parseContextualModifier())
+		default:
+			return modifiers
+		}
+		p.nextToken()
+	}
 }
 
-func (p *Parser) peekError(t tokens.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead",
-		t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+// startsContextualModifier reports whether the current token starts the
+// modifier sealed or non-sealed of a class or interface declaration. The
+// lexer gives `non-sealed` as three tokens, with no space between them.
+// It looks ahead without consuming any tokens.
+func (p *Parser) startsContextualModifier() bool {
+	if !p.curTokenIs(tokens.IDENT) {
+		return false
+	}
+	l := *p.l
+	next := p.peekToken
+	switch p.curToken.Literal {
+	case "sealed":
+	case "non":
+		sealed := l.NextToken()
+		if next.Type != tokens.MINUS || next.Start.Offset != p.curToken.End.Offset ||
+			sealed.Type != tokens.IDENT || sealed.Literal != "sealed" || sealed.Start.Offset != next.End.Offset {
+			return false
+		}
+		next = l.NextToken()
+	default:
+		return false
+	}
+	switch {
+	case next.Type == tokens.CLASS, next.Type == tokens.INTERFACE, isModifier(next.Type):
+		return true
+	}
+	return next.Type == tokens.IDENT && (next.Literal == "sealed" || next.Literal == "non")
 }
 
-func (p *Parser) nextToken() {
-	p.curToken = p.peekToken
-	p.peekToken = p.l.NextToken()
+// parseContextualModifier gives the modifier sealed or non-sealed at the
+// current token the type SEALED or NON_SEALED, leaving the current token
+// at its end.
+func (p *Parser) parseContextualModifier() tokens.Token {
+	tok := p.curToken
+	if tok.Literal == "sealed" {
+		tok.Type = tokens.SEALED
+		return tok
+	}
+	p.nextToken()
+	p.nextToken()
+	return tokens.Token{Type: tokens.NON_SEALED, Literal: "non-sealed", Start: tok.Start, End: p.curToken.End}
 }
 
-func (p *Parser) ParseProgram() *ast.Program {
-	program := &ast.Program{}
-	program.Statements = []ast.Statement{}
-
-	for p.curToken.Type != tokens.EOF {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
-		}
-		p.nextToken()
-	}
-	return program
+// startsRecord reports whether the current token is the 'record' of a
+// record declaration.
+func (p *Parser) startsRecord() bool {
+	return p.curTokenIs(tokens.IDENT) && p.curToken.Literal == "record" && p.peekTokenIs(tokens.IDENT)
 }
 
-func (p *Parser) parseIfExpression() ast.Expression {
-	expression := &ast.IfExpression{Token: p.curToken}
-	if !p.expectPeek(tokens.LPAREN) {
-		return nil
+func isModifier(t tokens.TokenType) bool {
+	switch t {
+	case tokens.PUBLIC, tokens.PRIVATE, tokens.PROTECTED, tokens.STATIC, tokens.FINAL,
+		tokens.ABSTRACT, tokens.NATIVE, tokens.SYNCHRONIZED, tokens.TRANSIENT,
+		tokens.VOLATILE, tokens.STRICTFP, tokens.DEFAULT:
+		return true
 	}
+	return false
+}
 
-	p.nextToken()
+func isAccessModifier(t tokens.TokenType) bool {
+	return t == tokens.PUBLIC || t == tokens.PRIVATE || t == tokens.PROTECTED
+}
 
-	expression.Condition = p.parseExpression(LOWEST)
+// parseDeclarationStatement parses a class, interface, method or variable
+// declaration starting with its modifiers.
+func (p *Parser) parseDeclarationStatement() ast.Statement {
+	first := p.curToken
+	modifiers := p.parseModifiers()
 
-	if !p.expectPeek(tokens.RPAREN) {
-		return nil
+	if p.curTokenIs(tokens.CLASS) || p.curTokenIs(tokens.INTERFACE) || p.curTokenIs(tokens.ENUM) || p.startsRecord() {
+		class := p.parseClassDeclaration(modifiers, false)
+		if class == nil {
+			return nil
+		}
+		return class
+	}
+	if isTypeToken(p.curToken.Type) {
+		return p.parseVariableDeclaration(first, modifiers)
 	}
 
-	if !p.expectPeek(tokens.LBRACE) {
+	method := p.parseMethodDeclaration(first, modifiers)
+	if method == nil {
 		return nil
 	}
+	return &ast.ExpressionStatement{Token: first, Expression: method}
+}
 
-	expression.Consequence = p.parseBlockStatement()
-
-	elseIfExpressions := []ast.ElseIfExpression{}
-
-	for p.peekTokenIs(tokens.ELSE_IF) {
-		elseIfExpression := &ast.ElseIfExpression{Token: p.curToken}
+// parseClassDeclaration parses a class, interface, enum or record, which
+// is a member of the class being parsed if member is set, or else a local
+// class if there is one.
+func (p *Parser) parseClassDeclaration(modifiers []tokens.Token, member bool) *ast.ClassDeclaration {
+	class := &ast.ClassDeclaration{Token: p.curToken, Modifiers: modifiers}
+	if p.curTokenIs(tokens.IDENT) {
+		class.Token.Type = tokens.RECORD
+	}
 
+	if !p.expectIdentifier() || !p.checkTypeName(p.curToken) {
+		return nil
+	}
+	class.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(tokens.LT) && !class.IsEnum() {
 		p.nextToken()
-
+		if class.TypeParameters = p.parseTypeParameters(); class.TypeParameters == nil {
+			return nil
+		}
+	}
+	if class.IsRecord() {
 		if !p.expectPeek(tokens.LPAREN) {
 			return nil
 		}
-
-		elseIfExpression.Condition = p.parseExpression(LOWEST)
-
-		if !p.expectPeek(tokens.LBRACE) {
+		if class.Components = p.parseParameters(); class.Components == nil {
 			return nil
 		}
-
-		elseIfExpression.Consequence = p.parseBlockStatement()
-
-		elseIfExpressions = append(elseIfExpressions, *elseIfExpression)
-
 	}
 
-	expression.Branches = elseIfExpressions
-
-	if p.peekTokenIs(tokens.ELSE) {
+	// An interface extends a list of interfaces, a class extends a single
+	// class and implements a list of interfaces. An enum or a record only
+	// implements interfaces, and cannot be sealed.
+	if p.peekTokenIs(tokens.EXTENDS) && !class.IsEnum() && !class.IsRecord() {
 		p.nextToken()
-		if !p.expectPeek(tokens.LBRACE) {
+		if class.IsInterface() {
+			if class.Interfaces = p.parseTypeList(); class.Interfaces == nil {
+				return nil
+			}
+		} else {
+			p.nextToken()
+			if class.SuperClass = p.parseClassType(); class.SuperClass == nil {
+				return nil
+			}
+		}
+	}
+	if !class.IsInterface() && p.peekTokenIs(tokens.IMPLEMENTS) {
+		p.nextToken()
+		if class.Interfaces = p.parseTypeList(); class.Interfaces == nil {
 			return nil
 		}
+	}
+	if p.peekTokenIs(tokens.IDENT) && p.peekToken.Literal == "permits" && !class.IsEnum() && !class.IsRecord() {
 		p.nextToken()
-		expression.Alternative = p.parseBlockStatement()
+		if class.Permits = p.parseTypeList(); class.Permits == nil {
+			return nil
+		}
 	}
 
-	return expression
+	name := class.Name.Value
+	if n := len(p.classes); n > 0 {
+		// javac numbers local classes like anonymous ones, but as they
+		// are only numbered apart when they share a name, the number is
+		// left at 1.
+		separator := "$1"
+		if member {
+			separator = "$"
+		}
+		name = p.classes[n-1].name + separator + name
+	}
+	if !p.expectPeek(tokens.LBRACE) || !p.parseClassBody(class, name) {
+		return nil
+	}
+	switch {
+	case class.IsEnum():
+		declareEnumMembers(class)
+	case class.IsRecord():
+		declareRecordMembers(class)
+	}
+	return class
 }
 
-func (p *Parser) parseBlockStatement() *ast.BlockStatement {
-	block := &ast.BlockStatement{Token: p.curToken}
-
-	block.Statements = []ast.Statement{}
+// parseClassBody parses the members of class, whose binary name is name,
+// from the '{' at the current token to the matching '}'. The body of an
+// enum starts with its constants.
+func (p *Parser) parseClassBody(class *ast.ClassDeclaration, name string) bool {
+	p.classes = append(p.classes, &enclosingClass{name: name})
+	defer func() { p.classes = p.classes[:len(p.classes)-1] }()
 
+	open := p.curToken
 	p.nextToken()
+	if class.IsEnum() && !p.parseEnumConstants(class) {
+		// Resume at the members after the constants.
+		p.synchronize()
+		if !p.curTokenIs(tokens.RBRACE) {
+			p.nextToken()
+		}
+	}
 
 	for !p.curTokenIs(tokens.RBRACE) && !p.curTokenIs(tokens.EOF) {
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		p.parseMember(class)
+		if p.panicMode {
+			p.synchronize()
+			if p.curTokenIs(tokens.RBRACE) {
+				continue
+			}
 		}
 		p.nextToken()
 	}
-	return block
-}
 
-func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	if p.curTokenIs(tokens.EOF) {
+		p.unexpectedEOFError(open)
+		return false
+	}
+	return true
+}
 
-	stmt.Expression = p.parseExpression(LOWEST)
+// parseEnumConstants parses the constants of the enum class, starting at
+// the current token, and declares each as a public static final field.
+// The constants end with a ';' before the other members, or with the '}'
+// closing the enum, where the current token is left.
+func (p *Parser) parseEnumConstants(class *ast.ClassDeclaration) bool {
+	enum := &ast.Type{Token: class.Name.Token, Name: class.Name.Value}
+	ordinal := 0
+	for p.curTokenIs(tokens.IDENT) {
+		constant := &ast.EnumConstant{Token: p.curToken, Ordinal: ordinal}
+		constant.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.peekTokenIs(tokens.LPAREN) {
+			p.nextToken()
+			if constant.Arguments = p.parseCallArguments(); constant.Arguments == nil {
+				return false
+			}
+		}
+		if p.peekTokenIs(tokens.LBRACE) {
+			// The body is an anonymous class extending the enum.
+			p.nextToken()
+			constant.Body = &ast.ClassDeclaration{
+				Token:      implicitToken(p.curToken, tokens.CLASS, "class"),
+				Name:       &ast.Identifier{Token: constant.Token, Value: p.anonymousName()},
+				SuperClass: enum,
+			}
+			if !p.parseClassBody(constant.Body, constant.Body.Name.Value) {
+				return false
+			}
+		}
+		class.Fields = append(class.Fields, &ast.FieldDeclaration{
+			Token:     constant.Token,
+			Modifiers: implicitModifiers(constant.Token, tokens.PUBLIC, tokens.STATIC, tokens.FINAL),
+			Type:      enum,
+			Name:      constant.Name,
+			Value:     constant,
+		})
+		ordinal++
 
-	if p.peekTokenIs(tokens.SEMICOLON) {
+		p.nextToken()
+		if !p.curTokenIs(tokens.COMMA) {
+			break
+		}
 		p.nextToken()
 	}
 
-	return stmt
-}
-
-func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case tokens.IDENT:
-		return p.parseIdentifierStatement()
-	case tokens.INCREMENT:
-		return p.parseIncrementStatement()
-	case tokens.DECREMENT:
-		return p.parseDecrementStatement()
-	case tokens.BOOLEAN_DT:
-		return p.parseBooleanStatement()
-	case tokens.INTEGER_DT:
-		return p.parseIntStatement()
-	case tokens.STRING_DT:
-		return p.parseStringStatement()
-	case tokens.RETURN:
-		return p.parseReturnStatement()
+	case tokens.SEMICOLON:
+		p.nextToken()
+	case tokens.RBRACE:
 	default:
-		return p.parseExpressionStatement()
+		p.expectedError(p.curToken, "',', '}', or ';'")
+		return false
 	}
+	return true
 }
 
-func (p *Parser) parseIdentifierStatement() ast.Statement {
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+// declareEnumMembers declares what an enum class has implicitly: it
+// extends Enum<E>, where E is the enum, and has the static methods
+// values() and valueOf(String), which the evaluator implements.
+func declareEnumMembers(class *ast.ClassDeclaration) {
+	tok := class.Token
+	enum := &ast.Type{Token: class.Name.Token, Name: class.Name.Value}
+	class.SuperClass = &ast.Type{Token: tok, Name: "Enum", Arguments: []*ast.Type{enum}}
+
+	modifiers := implicitModifiers(tok, tokens.PUBLIC, tokens.STATIC, tokens.NATIVE)
+	class.Methods = append(class.Methods,
+		&ast.FunctionLiteral{
+			Token:      tok,
+			Accessor:   modifiers[0],
+			Modifiers:  modifiers,
+			Name:       &ast.Identifier{Token: tok, Value: "values"},
+			ReturnType: &ast.Type{Token: tok, Name: enum.Name, Dimensions: 1},
+			Parameters: []*ast.Parameter{},
+		},
+		&ast.FunctionLiteral{
+			Token:      tok,
+			Accessor:   modifiers[0],
+			Modifiers:  modifiers,
+			Name:       &ast.Identifier{Token: tok, Value: "valueOf"},
+			ReturnType: enum,
+			Parameters: []*ast.Parameter{{
+				DataType:      &ast.Type{Token: tok, Name: "String"},
+				ParameterName: &ast.Identifier{Token: tok, Value: "name"},
+			}},
+		})
+}
+
+// declareRecordMembers declares what a record class has implicitly: it
+// extends Record, and has a private final field and a public accessor
+// method for each component, unless it declares the accessor itself, and
+// the canonical constructor, whose parameters are the components. A
+// compact canonical constructor is given those parameters, and assigns
+// the fields after the block written.
+func declareRecordMembers(class *ast.ClassDeclaration) {
+	tok := class.Token
+	class.SuperClass = &ast.Type{Token: tok, Name: "Record"}
+
+	var assignments []ast.Statement
+	for _, c := range class.Components {
+		name := c.ParameterName
+		typ := c.DataType
+		if c.Variadic {
+			array := *typ
+			array.Dimensions++
+			typ = &array
+		}
+		class.Fields = append(class.Fields, &ast.FieldDeclaration{
+			Token:     tok,
+			Modifiers: implicitModifiers(name.Token, tokens.PRIVATE, tokens.FINAL),
+			Type:      typ,
+			Name:      &ast.Identifier{Token: name.Token, Value: name.Value},
+		})
+
+		field := &ast.MemberExpression{
+			Token:    implicitToken(name.Token, tokens.PERIOD, "."),
+			Object:   &ast.ThisExpression{Token: implicitToken(name.Token, tokens.THIS, "this")},
+			Property: name,
+		}
+		assignments = append(assignments, &ast.ExpressionStatement{
+			Token: name.Token,
+			Expression: &ast.AssignmentExpression{
+				Token:  implicitToken(name.Token, tokens.ASSIGN, "="),
+				Target: field,
+				Value:  &ast.Identifier{Token: name.Token, Value: name.Value},
+			},
+		})
+		if !declaresAccessor(class, name.Value) {
+			modifiers := implicitModifiers(name.Token, tokens.PUBLIC)
+			class.Methods = append(class.Methods, &ast.FunctionLiteral{
+				Token:      tok,
+				Accessor:   modifiers[0],
+				Modifiers:  modifiers,
+				Name:       name,
+				ReturnType: typ,
+				Parameters: []*ast.Parameter{},
+				Body: &ast.BlockStatement{Token: tok, Statements: []ast.Statement{
+					&ast.ReturnStatement{Token: implicitToken(name.Token, tokens.RETURN, "return"), ReturnValue: field},
+				}},
+			})
+		}
+	}
+
+	for _, ctor := range class.Constructors {
+		if ctor.Compact {
+			ctor.Parameters = class.Components
+			ctor.Body.Statements = append(ctor.Body.Statements, assignments...)
+			return
+		}
+		if isCanonical(ctor, class) {
+			return
+		}
+	}
+	modifiers := implicitModifiers(class.Name.Token, tokens.PUBLIC)
+	class.Constructors = append(class.Constructors, &ast.ConstructorDeclaration{
+		Token:      tok,
+		Modifiers:  modifiers,
+		Name:       class.Name,
+		Parameters: class.Components,
+		Body:       &ast.BlockStatement{Token: tok, Statements: assignments},
+	})
+}
+
+// declaresAccessor reports whether the record class declares a method
+// name without parameters, the accessor of its component name.
+func declaresAccessor(class *ast.ClassDeclaration, name string) bool {
+	for _, m := range class.Methods {
+		if m.Name.Value == name && len(m.Parameters) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isCanonical reports whether ctor is the canonical constructor of the
+// record class, whose parameters have the types of its components.
+func isCanonical(ctor *ast.ConstructorDeclaration, class *ast.ClassDeclaration) bool {
+	if len(ctor.Parameters) != len(class.Components) {
+		return false
+	}
+	for i, p := range ctor.Parameters {
+		if p.TypeString() != class.Components[i].TypeString() {
+			return false
+		}
+	}
+	return true
+}
+
+// implicitToken returns a token of type t for code that is not written,
+// which is implied at tok.
+func implicitToken(tok tokens.Token, t tokens.TokenType, literal string) tokens.Token {
+	return tokens.Token{Type: t, Literal: literal, Start: tok.Start, End: tok.End}
+}
+
+// implicitModifiers returns the modifiers of the types given that a
+// member declared at tok has without them being written.
+func implicitModifiers(tok tokens.Token, types ...tokens.TokenType) []tokens.Token {
+	var modifiers []tokens.Token
+	for _, t := range types {
+		modifiers = append(modifiers, tokens.Token{Type: t, Literal: strings.ToLower(string(t)), Start: tok.Start, End: tok.End})
+	}
+	return modifiers
+}
+
+// parseTypeList parses the comma separated class or interface types after
+// an 'extends', 'implements' or 'throws'.
+func (p *Parser) parseTypeList() []*ast.Type {
+	types := []*ast.Type{}
+	for {
+		p.nextToken()
+		typ := p.parseClassType()
+		if typ == nil {
+			return nil
+		}
+		types = append(types, typ)
+
+		if !p.peekTokenIs(tokens.COMMA) {
+			return types
+		}
+		p.nextToken()
+	}
+}
+
+// parseClassType parses the name of a class or interface.
+func (p *Parser) parseClassType() *ast.Type {
+	if !p.curTokenIs(tokens.IDENT) {
+		p.expectedError(p.curToken, "class name")
+		return nil
+	}
+	return p.parseType()
+}
+
+// parseMember parses a field, constructor, method, initializer block or
+// member class and adds it to class.
+func (p *Parser) parseMember(class *ast.ClassDeclaration) {
+	switch p.curToken.Type {
+	case tokens.SEMICOLON:
+		return
+	case tokens.LBRACE:
+		if block := p.parseBlockStatement(); block != nil {
+			class.Initializers = append(class.Initializers, block)
+		}
+		return
+	}
+
+	first := p.curToken
+	modifiers := p.parseModifiers()
+
+	if p.curTokenIs(tokens.LBRACE) && len(modifiers) == 1 && modifiers[0].Type == tokens.STATIC {
+		if block := p.parseBlockStatement(); block != nil {
+			class.StaticInitializers = append(class.StaticInitializers, block)
+		}
+		return
+	}
+
+	if p.curTokenIs(tokens.CLASS) || p.curTokenIs(tokens.INTERFACE) || p.curTokenIs(tokens.ENUM) || p.startsRecord() {
+		if member := p.parseClassDeclaration(modifiers, true); member != nil {
+			class.Classes = append(class.Classes, member)
+		}
+		return
+	}
+
+	var typeParams []*ast.TypeParameter
+	if p.curTokenIs(tokens.LT) {
+		if typeParams = p.parseTypeParameters(); typeParams == nil {
+			return
+		}
+		p.nextToken()
+	}
+
+	if p.curTokenIs(tokens.IDENT) && p.curToken.Literal == class.Name.Value && p.peekTokenIs(tokens.LBRACE) && class.IsRecord() {
+		// The compact canonical constructor of a record.
+		ctor := &ast.ConstructorDeclaration{Token: first, Modifiers: modifiers, Compact: true}
+		ctor.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken()
+		if block := p.parseBlockStatement(); block != nil {
+			ctor.Body = &ast.BlockStatement{Token: block.Token, Statements: []ast.Statement{block}}
+			class.Constructors = append(class.Constructors, ctor)
+		}
+		return
+	}
+
+	if p.curTokenIs(tokens.IDENT) && p.curToken.Literal == class.Name.Value && p.peekTokenIs(tokens.LPAREN) {
+		ctor := &ast.ConstructorDeclaration{Token: first, Modifiers: modifiers, TypeParameters: typeParams}
+		ctor.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken()
+		if ctor.Parameters = p.parseParameters(); ctor.Parameters == nil {
+			return
+		}
+		if !p.parseThrows(&ctor.Throws) {
+			return
+		}
+		if !p.expectPeek(tokens.LBRACE) {
+			return
+		}
+		if ctor.Body = p.parseBlockStatement(); ctor.Body != nil {
+			class.Constructors = append(class.Constructors, ctor)
+		}
+		return
+	}
+
+	if !p.curTokenIs(tokens.VOID) && !isTypeToken(p.curToken.Type) {
+		p.expectedError(p.curToken, "a field, method or constructor declaration")
+		return
+	}
+	typ := p.parseType()
+	if typ == nil || !p.expectIdentifier() {
+		return
+	}
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	// Only methods have type parameters.
+	if p.peekTokenIs(tokens.LPAREN) || typ.Token.Type == tokens.VOID || typeParams != nil {
+		if !p.expectPeek(tokens.LPAREN) {
+			return
+		}
+		if method := p.parseMethodRest(first, modifiers, typ, name); method != nil {
+			method.TypeParameters = typeParams
+			class.Methods = append(class.Methods, method)
+		}
+		return
+	}
+
+	field := &ast.FieldDeclaration{Token: first, Modifiers: modifiers, Type: typ, Name: name}
+	if p.peekTokenIs(tokens.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+		field.Value = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeek(tokens.SEMICOLON) {
+		return
+	}
+	class.Fields = append(class.Fields, field)
+}
+
+// parseNewExpression parses the creation of an instance, with the body of
+// an anonymous class if a '{' follows the arguments.
+func (p *Parser) parseNewExpression() ast.Expression {
+	exp := &ast.NewExpression{Token: p.curToken}
+
+	p.nextToken()
+	if !p.checkIdentifier(p.curToken) {
+		return nil
+	}
+	exp.Type = &ast.Type{Token: p.curToken, Name: p.curToken.Literal}
+	for p.peekTokenIs(tokens.PERIOD) {
+		p.nextToken()
+		if !p.expectIdentifier() {
+			return nil
+		}
+		exp.Type.Name += "." + p.curToken.Literal
+	}
+	if p.peekTokenIs(tokens.LT) {
+		p.nextToken()
+		if exp.Type.Arguments = p.parseTypeArguments(true); exp.Type.Arguments == nil {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(tokens.LPAREN) {
+		return nil
+	}
+	exp.Arguments = p.parseCallArguments()
+
+	if exp.Arguments != nil && p.peekTokenIs(tokens.LBRACE) {
+		// Whether the anonymous class extends the type or implements it
+		// is only known once the type is.
+		p.nextToken()
+		exp.Body = &ast.ClassDeclaration{
+			Token: implicitToken(p.curToken, tokens.CLASS, "class"),
+			Name:  &ast.Identifier{Token: exp.Type.Token, Value: p.anonymousName()},
+		}
+		if !p.parseClassBody(exp.Body, exp.Body.Name.Value) {
+			return nil
+		}
+	}
+	return exp
+}
+
+// anonymousName is the name javac gives the next anonymous class in the
+// innermost class being parsed: the binary name of that class and a
+// number. Outside of any class the name only has the number, which the
+// evaluator prefixes with the name of the main class.
+func (p *Parser) anonymousName() string {
+	if len(p.classes) == 0 {
+		p.anonymous++
+		return fmt.Sprintf("$%d", p.anonymous)
+	}
+	class := p.classes[len(p.classes)-1]
+	class.anonymous++
+	return fmt.Sprintf("%s$%d", class.name, class.anonymous)
+}
+
+func (p *Parser) parseThisExpression() ast.Expression {
+	return &ast.ThisExpression{Token: p.curToken}
+}
+
+// parseSuperExpression parses `super`, which is only valid before a '.'
+// or, in a constructor, a '('. A qualified `Interface.super` is parsed by
+// parseMemberExpression.
+func (p *Parser) parseSuperExpression() ast.Expression {
+	if !p.peekTokenIs(tokens.PERIOD) && !p.peekTokenIs(tokens.LPAREN) {
+		p.peekError(tokens.PERIOD)
+		return nil
+	}
+	return &ast.SuperExpression{Token: p.curToken}
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: object}
+
+	if p.peekTokenIs(tokens.SUPER) {
+		qualifier, ok := object.(*ast.Identifier)
+		if !ok {
+			p.expectedError(p.peekToken, "identifier")
+			return nil
+		}
+		p.nextToken()
+		if !p.peekTokenIs(tokens.PERIOD) {
+			p.peekError(tokens.PERIOD)
+			return nil
+		}
+		return &ast.SuperExpression{Token: p.curToken, Qualifier: qualifier}
+	}
+	if p.peekTokenIs(tokens.THIS) {
+		qualifier, ok := object.(*ast.Identifier)
+		if !ok {
+			p.expectedError(p.peekToken, "identifier")
+			return nil
+		}
+		p.nextToken()
+		return &ast.ThisExpression{Token: p.curToken, Qualifier: qualifier}
+	}
+	if p.peekTokenIs(tokens.NEW) {
+		// The creation of an instance of an inner class of the object.
+		p.nextToken()
+		exp, ok := p.parseNewExpression().(*ast.NewExpression)
+		if !ok {
+			return nil
+		}
+		exp.Outer = object
+		return exp
+	}
+
+	if p.peekTokenIs(tokens.LT) {
+		p.nextToken()
+		if exp.TypeArguments = p.parseTypeArguments(false); exp.TypeArguments == nil {
+			return nil
+		}
+	}
+	if !p.expectIdentifier() {
+		return nil
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+	if exp.Index == nil || !p.expectClosing(tokens.RSPAREN, exp.Token) {
+		return nil
+	}
+	return exp
+}
+
+func (p *Parser) parseAssignmentExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignmentExpression{Token: p.curToken, Target: target}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.MemberExpression, *ast.IndexExpression:
+	default:
+		p.invalidAssignmentError(p.curToken)
+		return nil
+	}
+
+	// Assignment is right-associative: a = b = c is a = (b = c).
+	p.nextToken()
+	exp.Value = p.parseExpression(LOWEST)
+	return exp
+}
+
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	if !p.caseLabel && p.startsLambda() {
+		return p.parseLambdaExpression()
+	}
+	open := p.curToken
+	p.nextToken()
+	exp := p.parseExpression(LOWEST)
+
+	if !p.expectClosing(tokens.RPAREN, open) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+
+	return expression
+}
+
+// parseInstanceofExpression parses `Left instanceof Pattern`, where the
+// pattern may also be just a type.
+func (p *Parser) parseInstanceofExpression(left ast.Expression) ast.Expression {
+	exp := &ast.InstanceofExpression{Token: p.curToken, Left: left}
+	p.nextToken()
+	if exp.Pattern = p.parsePattern(false); exp.Pattern == nil {
+		return nil
+	}
+	return exp
+}
+
+// parsePattern parses a type pattern, `String s`, or a record pattern,
+// `Point(int x, var y)`, starting at its first token. A pattern nested in
+// a record pattern must bind a variable and may have the type var.
+func (p *Parser) parsePattern(nested bool) ast.Expression {
+	var modifiers []tokens.Token
+	for p.curTokenIs(tokens.FINAL) {
+		modifiers = append(modifiers, p.curToken)
+		p.nextToken()
+	}
+	if !isTypeToken(p.curToken.Type) {
+		p.expectedError(p.curToken, "type")
+		return nil
+	}
+	var typ *ast.Type
+	if nested && p.curTokenIs(tokens.IDENT) && p.curToken.Literal == "var" && !p.peekTokenIs(tokens.LT) {
+		typ = p.parseVarType()
+	} else {
+		typ = p.parseType()
+	}
+	if typ == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(tokens.LPAREN) && typ.Token.Type == tokens.IDENT && typ.Dimensions == 0 && modifiers == nil {
+		p.nextToken()
+		return p.parseRecordPattern(typ)
+	}
+	pattern := &ast.TypePattern{Modifiers: modifiers, Type: typ}
+	if nested || p.peekTokenIs(tokens.IDENT) || tokens.IsKeyword(p.peekToken.Type) && !p.peekTokenIs(tokens.INSTANCEOF) {
+		if !p.expectIdentifier() {
+			return nil
+		}
+		pattern.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	return pattern
+}
+
+// parseRecordPattern parses the nested patterns of a record pattern of
+// type typ, starting at the '('.
+func (p *Parser) parseRecordPattern(typ *ast.Type) ast.Expression {
+	open := p.curToken
+	pattern := &ast.RecordPattern{Type: typ, Patterns: []ast.Expression{}}
+	if p.peekTokenIs(tokens.RPAREN) {
+		p.nextToken()
+		return pattern
+	}
+	for {
+		p.nextToken()
+		nested := p.parsePattern(true)
+		if nested == nil {
+			return nil
+		}
+		pattern.Patterns = append(pattern.Patterns, nested)
+
+		if !p.peekTokenIs(tokens.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectClosing(tokens.RPAREN, open) {
+		return nil
+	}
+	return pattern
+}
+
+func (p *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(tokens.TRUE)}
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+func (p *Parser) parsePrefixExpression() ast.Expression {
+	expression := &ast.PrefixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+	}
+	p.nextToken()
+	expression.Right = p.parseExpression(PREFIX)
+	return expression
+}
+
+func (p *Parser) parseIdentifier() ast.Expression {
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(tokens.ARROW) && !p.caseLabel {
+		// The single parameter of a lambda expression, as in `x -> x * 2`.
+		lambda := &ast.LambdaExpression{Token: p.curToken, Parameters: []*ast.Parameter{{ParameterName: ident}}}
+		p.nextToken()
+		return p.parseLambdaBody(lambda)
+	}
+	return ident
+}
+
+// startsLambda reports whether the '(' at the current token begins the
+// parameters of a lambda expression rather than a parenthesized
+// expression: whether the matching ')' is followed by '->'. It looks
+// ahead without consuming any tokens.
+func (p *Parser) startsLambda() bool {
+	l := *p.l
+	for tok, depth := p.peekToken, 1; tok.Type != tokens.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case tokens.LPAREN:
+			depth++
+		case tokens.RPAREN:
+			if depth--; depth == 0 {
+				return l.NextToken().Type == tokens.ARROW
+			}
+		}
+	}
+	return false
+}
+
+// parseLambdaExpression parses a lambda expression with parenthesized
+// parameters, starting at the '('. The parameters are either all declared
+// as those of a method, as in `(int a, int b)`, or all names alone, as in
+// `(a, b)`, whose types are inferred.
+func (p *Parser) parseLambdaExpression() ast.Expression {
+	lambda := &ast.LambdaExpression{Token: p.curToken}
+	l := *p.l
+	if next := l.NextToken(); p.peekTokenIs(tokens.IDENT) && (next.Type == tokens.COMMA || next.Type == tokens.RPAREN) {
+		lambda.Parameters = p.parseInferredParameters()
+	} else {
+		lambda.Parameters = p.parseParameters()
+	}
+	if lambda.Parameters == nil || !p.expectPeek(tokens.ARROW) {
+		return nil
+	}
+	return p.parseLambdaBody(lambda)
+}
+
+// parseInferredParameters parses a parenthesized list of parameter names,
+// starting at the '('. It returns nil on error.
+func (p *Parser) parseInferredParameters() []*ast.Parameter {
+	open := p.curToken
+	params := []*ast.Parameter{}
+	for {
+		if !p.expectIdentifier() {
+			return nil
+		}
+		params = append(params, &ast.Parameter{ParameterName: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}})
+		if !p.peekTokenIs(tokens.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectClosing(tokens.RPAREN, open) {
+		return nil
+	}
+	return params
+}
+
+// parseLambdaBody parses the body of lambda after the '->', which is the
+// current token: a block or an expression.
+func (p *Parser) parseLambdaBody(lambda *ast.LambdaExpression) ast.Expression {
+	p.nextToken()
+	if p.curTokenIs(tokens.LBRACE) {
+		body := p.parseBlockStatement()
+		if body == nil {
+			return nil
+		}
+		lambda.Body = body
+		return lambda
+	}
+	body := p.parseExpression(LOWEST)
+	if body == nil {
+		return nil
+	}
+	lambda.Body = body
+	return lambda
+}
+
+// parseMethodReference parses the rest of a method reference after its
+// target, at the '::': a method name or `new`.
+func (p *Parser) parseMethodReference(target ast.Expression) ast.Expression {
+	ref := &ast.MethodReference{Token: p.curToken, Target: target}
+	if p.peekTokenIs(tokens.NEW) {
+		p.nextToken()
+	} else if !p.expectIdentifier() {
+		return nil
+	}
+	ref.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return ref
+}
+
+// synchronize discards tokens after an error until parsing can safely
+// resume: on a `;` or `}`, or just before a brace or a keyword that starts
+// a declaration or statement. The caller then advances past the current
+// token as it would after any statement. At the end of the input the
+// parser stays in panic mode, as the enclosing blocks are necessarily
+// unclosed too.
+func (p *Parser) synchronize() {
+	for !p.curTokenIs(tokens.EOF) {
+		if p.curTokenIs(tokens.SEMICOLON) || p.curTokenIs(tokens.RBRACE) ||
+			p.peekTokenIs(tokens.LBRACE) || p.peekTokenIs(tokens.RBRACE) ||
+			isSynchronizingToken(p.peekToken.Type) && p.peekToken.Start.Offset != p.errorOffset {
+			p.panicMode = false
+			return
+		}
+		p.nextToken()
+	}
+}
+
+func isSynchronizingToken(t tokens.TokenType) bool {
+	switch t {
+	case tokens.CLASS, tokens.INTERFACE, tokens.ENUM,
+		tokens.PUBLIC, tokens.PRIVATE, tokens.PROTECTED,
+		tokens.STATIC, tokens.FINAL, tokens.ABSTRACT, tokens.VOID,
+		tokens.BOOLEAN_DT, tokens.BYTE_DT, tokens.SHORT_DT, tokens.INTEGER_DT,
+		tokens.LONG_DT, tokens.FLOAT_DT, tokens.DOUBLE_DT, tokens.CHARACTER_DT,
+		tokens.IF, tokens.RETURN, tokens.WHILE, tokens.DO, tokens.FOR,
+		tokens.TRY, tokens.THROW, tokens.SWITCH, tokens.BREAK, tokens.CONTINUE:
+		return true
+	}
+	return false
+}
+
+func (p *Parser) nextToken() {
+	p.curToken = p.peekToken
+	p.peekToken = p.l.NextToken()
+}
+
+func (p *Parser) ParseProgram() *ast.Program {
+	program := &ast.Program{}
+	program.Statements = []ast.Statement{}
+
+	for p.curToken.Type != tokens.EOF {
+		stmt := p.parseStatement()
+		if p.panicMode {
+			p.synchronize()
+		} else if stmt != nil {
+			program.Statements = append(program.Statements, stmt)
+		}
+		p.nextToken()
+	}
+	return program
+}
+
+func (p *Parser) parseIfStatement() *ast.IfStatement {
+	stmt := &ast.IfStatement{Token: p.curToken}
+	if !p.expectPeek(tokens.LPAREN) {
+		return nil
+	}
+	open := p.curToken
+
+	p.nextToken()
+
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectClosing(tokens.RPAREN, open) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Consequence = p.parseStatement()
+
+	// An `else` binds to the nearest `if`, and `else if` is simply an else
+	// branch whose statement is another if statement.
+	if p.peekTokenIs(tokens.ELSE) {
+		p.nextToken()
+		p.nextToken()
+		stmt.Alternative = p.parseStatement()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseSwitchExpression() ast.Expression {
+	if e := p.parseSwitch(); e != nil {
+		return e
+	}
+	return nil
+}
+
+// parseSwitch parses `switch (Value) { Cases }`, which is a statement or
+// an expression depending on where it is.
+func (p *Parser) parseSwitch() *ast.SwitchExpression {
+	e := &ast.SwitchExpression{Token: p.curToken}
+	if !p.expectPeek(tokens.LPAREN) {
+		return nil
+	}
+	open := p.curToken
+	p.nextToken()
+	if e.Value = p.parseExpression(LOWEST); e.Value == nil {
+		return nil
+	}
+	if !p.expectClosing(tokens.RPAREN, open) || !p.expectPeek(tokens.LBRACE) {
+		return nil
+	}
+	brace := p.curToken
+	p.nextToken()
+
+	for !p.curTokenIs(tokens.RBRACE) {
+		if p.curTokenIs(tokens.EOF) {
+			p.unexpectedEOFError(brace)
+			return nil
+		}
+		if c := p.parseSwitchCase(); c != nil {
+			e.Cases = append(e.Cases, c)
+		}
+		if p.panicMode {
+			// Resume at the next case.
+			p.synchronize()
+			if p.curTokenIs(tokens.RBRACE) {
+				continue
+			}
+		}
+		p.nextToken()
+	}
+	return e
+}
+
+// parseSwitchCase parses a switch rule, `case A, B -> Body` or
+// `default -> Body`, whose body is an expression statement, a block or a
+// throw statement. The labels are constants or patterns, and a pattern
+// may be followed by a guard, `case String s when s.isEmpty() -> Body`.
+func (p *Parser) parseSwitchCase() *ast.SwitchCase {
+	c := &ast.SwitchCase{Token: p.curToken}
+	switch p.curToken.Type {
+	case tokens.CASE:
+		p.caseLabel = true
+		for {
+			p.nextToken()
+			var label ast.Expression
+			if p.startsPattern() {
+				label = p.parsePattern(false)
+			} else {
+				label = p.parseExpression(LOWEST)
+			}
+			if label == nil {
+				p.caseLabel = false
+				return nil
+			}
+			c.Labels = append(c.Labels, label)
+			if !p.peekTokenIs(tokens.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+		if p.peekTokenIs(tokens.IDENT) && p.peekToken.Literal == "when" {
+			p.nextToken()
+			p.nextToken()
+			if c.Guard = p.parseExpression(LOWEST); c.Guard == nil {
+				p.caseLabel = false
+				return nil
+			}
+		}
+		p.caseLabel = false
+	case tokens.DEFAULT:
+	default:
+		p.expectedError(p.curToken, "'case', 'default', or '}'")
+		return nil
+	}
+	if !p.expectPeek(tokens.ARROW) {
+		return nil
+	}
+
+	p.nextToken()
+	switch p.curToken.Type {
+	case tokens.LBRACE:
+		if block := p.parseBlockStatement(); block != nil {
+			c.Body = block
+		}
+	case tokens.THROW:
+		if throw := p.parseThrowStatement(); throw != nil {
+			c.Body = throw
+		}
+	default:
+		if value := p.parseExpression(LOWEST); value != nil && p.expectPeek(tokens.SEMICOLON) {
+			c.Body = value
+		}
+	}
+	if c.Body == nil {
+		return nil
+	}
+	return c
+}
+
+// startsPattern reports whether the case label starting at the current
+// token is a pattern rather than a constant: a type followed by a name, or
+// a record type followed by its nested patterns.
+func (p *Parser) startsPattern() bool {
+	switch {
+	case p.curTokenIs(tokens.FINAL):
+		return true
+	case p.curTokenIs(tokens.IDENT):
+		return p.peekTokenIs(tokens.LPAREN) || p.peekTokenIs(tokens.LT) || p.startsDeclaration()
+	}
+	return isTypeToken(p.curToken.Type) && p.peekTokenIs(tokens.IDENT)
+}
+
+// startsYield reports whether the statement starting at the current token
+// is a yield statement: whether it is the identifier yield followed by
+// something other than what would make it an expression statement, as in
+// `yield = 1;`.
+func (p *Parser) startsYield() bool {
+	if p.curToken.Literal != "yield" {
+		return false
+	}
+	switch p.peekToken.Type {
+	case tokens.ASSIGN, tokens.EQ, tokens.NOT_EQ, tokens.LT, tokens.GT, tokens.ASTERISK, tokens.SLASH,
+		tokens.PERIOD, tokens.LSPAREN, tokens.LPAREN, tokens.INCREMENT, tokens.DECREMENT,
+		tokens.SEMICOLON, tokens.DOUBLE_COLON, tokens.ARROW:
+		return false
+	}
+	return true
+}
+
+func (p *Parser) parseYieldStatement() *ast.YieldStatement {
+	stmt := &ast.YieldStatement{Token: p.curToken}
+	p.nextToken()
+	if stmt.Value = p.parseExpression(LOWEST); stmt.Value == nil {
+		return nil
+	}
+	if !p.expectPeek(tokens.SEMICOLON) {
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+
+	block.Statements = []ast.Statement{}
+
+	p.nextToken()
+
+	for !p.curTokenIs(tokens.RBRACE) && !p.curTokenIs(tokens.EOF) {
+		stmt := p.parseStatement()
+		if p.panicMode {
+			p.synchronize()
+			// The offending token closes this block.
+			if p.curTokenIs(tokens.RBRACE) {
+				continue
+			}
+		} else if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	if p.curTokenIs(tokens.EOF) {
+		p.unexpectedEOFError(block.Token)
+		return nil
+	}
+	return block
+}
+
+func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(tokens.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseStatement() ast.Statement {
+	switch p.curToken.Type {
+	case tokens.IDENT:
+		if p.startsRecord() || p.startsContextualModifier() {
+			return p.parseDeclarationStatement()
+		}
+		if p.startsYield() {
+			return p.parseYieldStatement()
+		}
+		if p.startsDeclaration() {
+			return p.parseVariableDeclaration(p.curToken, nil)
+		}
+		return p.parseIdentifierStatement()
+	case tokens.INCREMENT:
+		return p.parseIncrementStatement()
+	case tokens.DECREMENT:
+		return p.parseDecrementStatement()
+	case tokens.BYTE_DT, tokens.SHORT_DT, tokens.INTEGER_DT, tokens.LONG_DT,
+		tokens.FLOAT_DT, tokens.DOUBLE_DT, tokens.CHARACTER_DT, tokens.BOOLEAN_DT:
+		return p.parseVariableDeclaration(p.curToken, nil)
+	case tokens.RETURN:
+		return p.parseReturnStatement()
+	case tokens.THROW:
+		return p.parseThrowStatement()
+	case tokens.TRY:
+		return p.parseTryStatement()
+	case tokens.CATCH, tokens.FINALLY:
+		p.misplacedClauseError(p.curToken, fmt.Sprintf("'%s' without 'try'", p.curToken.Literal))
+		return nil
+	case tokens.IF:
+		return p.parseIfStatement()
+	case tokens.SWITCH:
+		if e := p.parseSwitch(); e != nil {
+			return &ast.SwitchStatement{Token: e.Token, Value: e.Value, Cases: e.Cases}
+		}
+		return nil
+	case tokens.LBRACE:
+		return p.parseBlockStatement()
+	case tokens.CLASS, tokens.INTERFACE, tokens.ENUM, tokens.VOID:
+		return p.parseDeclarationStatement()
+	default:
+		if isModifier(p.curToken.Type) {
+			return p.parseDeclarationStatement()
+		}
+		return p.parseExpressionStatement()
+	}
+}
+
+// startsDeclaration reports whether the statement starting at the current
+// token, an identifier, declares variables: whether it is a type followed
+// by a name, as in `Foo x`, `Foo[] xs` or `List<String> xs`, rather than
+// an expression, as in `foo(x)`, `xs[0] = 1` or `a < b`. It looks ahead
+// without consuming any tokens.
+func (p *Parser) startsDeclaration() bool {
+	l := *p.l
+	tok := p.peekToken
+	for tok.Type == tokens.PERIOD {
+		if tok = l.NextToken(); tok.Type != tokens.IDENT {
+			return false
+		}
+		tok = l.NextToken()
+	}
+	if tok.Type == tokens.LT {
+		for depth := 0; ; {
+			switch tok.Type {
+			case tokens.LT:
+				depth++
+			case tokens.GT:
+				depth--
+			case tokens.COMMA, tokens.QUESTION, tokens.EXTENDS, tokens.SUPER, tokens.LSPAREN, tokens.RSPAREN:
+			default:
+				if !isTypeToken(tok.Type) {
+					return false
+				}
+			}
+			tok = l.NextToken()
+			if depth == 0 {
+				break
+			}
+		}
+	}
+	for tok.Type == tokens.LSPAREN {
+		if tok = l.NextToken(); tok.Type != tokens.RSPAREN {
+			return false
+		}
+		tok = l.NextToken()
+	}
+	// A reserved word used as the name is reported when it is declared.
+	return tok.Type == tokens.IDENT || tokens.IsKeyword(tok.Type) && tok.Type != tokens.INSTANCEOF
+}
+
+// parseVariableDeclaration parses a declaration of local variables with
+// modifiers, starting at the type. A name followed by '(' declares a
+// method instead.
+func (p *Parser) parseVariableDeclaration(first tokens.Token, modifiers []tokens.Token) ast.Statement {
+	var typ *ast.Type
+	inferred := p.curTokenIs(tokens.IDENT) && p.curToken.Literal == "var" && !p.peekTokenIs(tokens.LT)
+	if inferred {
+		typ = p.parseVarType()
+	} else {
+		typ = p.parseType()
+	}
+	if typ == nil || !p.expectIdentifier() {
+		return nil
+	}
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(tokens.LPAREN) {
+		if inferred {
+			p.restrictedNameError(typ.Token, "'var' is not allowed here").
+				WithNote("'var' is a restricted type name")
+			return nil
+		}
+		p.nextToken()
+		method := p.parseMethodRest(first, modifiers, typ, name)
+		if method == nil {
+			return nil
+		}
+		return &ast.ExpressionStatement{Token: first, Expression: method}
+	}
+
+	for _, m := range modifiers {
+		if m.Type != tokens.FINAL {
+			p.report(diagnostics.Errorf(ErrUnexpectedToken, diagnostics.TokenSpan(m, ""),
+				"modifier %s not allowed here", m.Literal))
+			return nil
+		}
+	}
+	decl := &ast.VariableDeclaration{Token: first, Modifiers: modifiers, Type: typ}
+	for {
+		declarator := &ast.Declarator{Name: name}
+		if p.peekTokenIs(tokens.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			if inferred && p.curTokenIs(tokens.LBRACE) {
+				p.restrictedNameError(name.Token, "cannot infer type for local variable %s", name.Value).
+					WithNote("array initializer needs an explicit target-type")
+				p.skipBraces()
+				p.skipSemicolon()
+				return nil
+			}
+			if declarator.Value = p.parseExpression(LOWEST); declarator.Value == nil {
+				return nil
+			}
+		}
+		decl.Declarators = append(decl.Declarators, declarator)
+
+		if !p.peekTokenIs(tokens.COMMA) {
+			break
+		}
+		p.nextToken()
+		if !p.expectIdentifier() {
+			return nil
+		}
+		if inferred {
+			p.restrictedNameError(p.curToken, "'var' is not allowed in a compound declaration")
+			return nil
+		}
+		name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+	if !p.expectPeek(tokens.SEMICOLON) {
+		return nil
+	}
+	return decl
+}
+
+func (p *Parser) parseIdentifierStatement() ast.Statement {
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	if p.peekTokenIs(tokens.INCREMENT) {
 		incrementStmt := &ast.IncrementStatement{Token: p.peekToken, Operand: ident, Side: "POSTFIX"}
+		p.nextToken()
+		p.skipSemicolon()
 		return incrementStmt
 	} else if p.peekTokenIs(tokens.DECREMENT) {
 		decrementStmt := &ast.DecrementStatement{Token: p.peekToken, Operand: ident, Side: "POSTFIX"}
+		p.nextToken()
+		p.skipSemicolon()
 		return decrementStmt
 	}
 
-	p.nextToken()
+	return p.parseExpressionStatement()
+}
 
-	return nil
+// skipBraces skips from the current token, a '{', to its matching '}'.
+func (p *Parser) skipBraces() {
+	for depth := 0; !p.curTokenIs(tokens.EOF); p.nextToken() {
+		if p.curTokenIs(tokens.LBRACE) {
+			depth++
+		} else if p.curTokenIs(tokens.RBRACE) {
+			if depth--; depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (p *Parser) skipSemicolon() {
+	if p.peekTokenIs(tokens.SEMICOLON) {
+		p.nextToken()
+	}
 }
 
 func (p *Parser) parseIncrementStatement() *ast.IncrementStatement {
-	if !p.peekTokenIs(tokens.IDENT) {
+	if !p.expectPeek(tokens.IDENT) {
 		return nil
 	}
-	p.nextToken()
 
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	incrementStmt := &ast.IncrementStatement{Token: p.curToken, Operand: ident, Side: "PREFIX"}
+	p.skipSemicolon()
 
 	return incrementStmt
 }
 
 func (p *Parser) parseDecrementStatement() *ast.DecrementStatement {
-	if !p.peekTokenIs(tokens.IDENT) {
+	if !p.expectPeek(tokens.IDENT) {
 		return nil
 	}
-	p.nextToken()
 
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
 	decrementStmt := &ast.DecrementStatement{Token: p.curToken, Operand: ident, Side: "PREFIX"}
+	p.skipSemicolon()
 
 	return decrementStmt
 }
 
-func (p *Parser) parseBooleanStatement() *ast.BooleanAssignmentStatement {
-	stmt := &ast.BooleanAssignmentStatement{Token: p.curToken}
+func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
+	stmt := &ast.ReturnStatement{Token: p.curToken}
 
-	if !p.expectPeek(tokens.IDENT) {
-		return nil
+	if p.peekTokenIs(tokens.SEMICOLON) {
+		p.nextToken()
+		return stmt
 	}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.nextToken()
+	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	if !p.expectPeek(tokens.ASSIGN) {
+	if !p.expectPeek(tokens.SEMICOLON) {
 		return nil
 	}
 
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
 	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if stmt.Value == nil {
+		return nil
+	}
 
-	for !p.curTokenIs(tokens.SEMICOLON) {
-		exp := p.parseExpression(LOWEST)
-		stmt.Value = exp
-		p.nextToken()
+	if !p.expectPeek(tokens.SEMICOLON) {
+		return nil
 	}
 
 	return stmt
 }
 
-func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
-	stmt := &ast.ReturnStatement{Token: p.curToken}
+// parseTryStatement parses a try block and the catch clauses and finally
+// block after it, at least one of which must be present.
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	stmt := &ast.TryStatement{Token: p.curToken}
 
-	p.nextToken()
+	if p.peekTokenIs(tokens.LPAREN) {
+		p.nextToken()
+		if stmt.Resources = p.parseResources(); stmt.Resources == nil {
+			return nil
+		}
+	}
+	if !p.expectPeek(tokens.LBRACE) {
+		return nil
+	}
+	if stmt.Block = p.parseBlockStatement(); stmt.Block == nil {
+		return nil
+	}
 
-	for !p.curTokenIs(tokens.SEMICOLON) {
-		exp := p.parseExpression(LOWEST)
-		stmt.ReturnValue = exp
+	for p.peekTokenIs(tokens.CATCH) {
 		p.nextToken()
+		clause := p.parseCatchClause()
+		if clause == nil {
+			return nil
+		}
+		stmt.Catches = append(stmt.Catches, clause)
+	}
+
+	if p.peekTokenIs(tokens.FINALLY) {
+		p.nextToken()
+		if !p.expectPeek(tokens.LBRACE) {
+			return nil
+		}
+		if stmt.Finally = p.parseBlockStatement(); stmt.Finally == nil {
+			return nil
+		}
 	}
 
+	if len(stmt.Resources) == 0 && len(stmt.Catches) == 0 && stmt.Finally == nil {
+		p.misplacedClauseError(stmt.Token, "'try' without 'catch', 'finally' or resource declarations")
+		return nil
+	}
 	return stmt
 }
 
-func (p *Parser) parseStringStatement() *ast.StringAssignmentStatement {
-	stmt := &ast.StringAssignmentStatement{Token: p.curToken}
+// parseResources parses the resources of a try-with-resources statement,
+// `(A a = ...; b)`, starting at the '('. The last resource may be
+// followed by a ';'. It returns nil on error.
+func (p *Parser) parseResources() []*ast.Resource {
+	open := p.curToken
+	resources := []*ast.Resource{}
+	for {
+		p.nextToken()
+		resource := p.parseResource()
+		if resource == nil {
+			return nil
+		}
+		resources = append(resources, resource)
 
-	if !p.expectPeek(tokens.IDENT) {
+		if p.peekTokenIs(tokens.SEMICOLON) {
+			p.nextToken()
+		}
+		if p.peekTokenIs(tokens.RPAREN) || !p.curTokenIs(tokens.SEMICOLON) {
+			break
+		}
+	}
+	if !p.expectClosing(tokens.RPAREN, open) {
 		return nil
 	}
+	return resources
+}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+// parseResource parses a resource declaration, `A a = new A()`, or a
+// variable that refers to a resource, such as `a` or `this.a`.
+func (p *Parser) parseResource() *ast.Resource {
+	if !p.curTokenIs(tokens.IDENT) || !(p.peekTokenIs(tokens.IDENT) || p.peekTokenIs(tokens.LSPAREN) || p.peekTokenIs(tokens.LT)) {
+		first := p.curToken
+		value := p.parseExpression(LOWEST)
+		switch value.(type) {
+		case nil:
+			return nil
+		case *ast.Identifier, *ast.MemberExpression:
+			return &ast.Resource{Value: value}
+		}
+		p.report(diagnostics.Errorf(ErrUnexpectedToken, diagnostics.TokenSpan(first, ""),
+			"the try-with-resources resource must either be a variable declaration or an expression denoting a reference to a final or effectively final variable"))
+		return nil
+	}
 
+	resource := &ast.Resource{Type: p.parseType()}
+	if resource.Type == nil || !p.expectIdentifier() {
+		return nil
+	}
+	resource.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	if !p.expectPeek(tokens.ASSIGN) {
 		return nil
 	}
-
 	p.nextToken()
-
-	for !p.curTokenIs(tokens.SEMICOLON) {
-		exp := p.parseExpression(LOWEST)
-		stmt.Value = exp
-		p.nextToken()
+	if resource.Value = p.parseExpression(LOWEST); resource.Value == nil {
+		return nil
 	}
-
-	return stmt
+	return resource
 }
 
-func (p *Parser) parseIntStatement() *ast.IntegerAssignmentStatement {
-	stmt := &ast.IntegerAssignmentStatement{Token: p.curToken}
+// parseCatchClause parses `catch (A | B e) { ... }`.
+func (p *Parser) parseCatchClause() *ast.CatchClause {
+	clause := &ast.CatchClause{Token: p.curToken}
 
-	if !p.expectPeek(tokens.IDENT) {
+	if !p.expectPeek(tokens.LPAREN) {
 		return nil
 	}
+	open := p.curToken
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	for {
+		p.nextToken()
+		typ := p.parseClassType()
+		if typ == nil {
+			return nil
+		}
+		clause.Types = append(clause.Types, typ)
 
-	if !p.expectPeek(tokens.ASSIGN) {
+		if !p.peekTokenIs(tokens.BAR) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectIdentifier() {
 		return nil
 	}
+	clause.Parameter = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	p.nextToken()
+	if !p.expectClosing(tokens.RPAREN, open) || !p.expectPeek(tokens.LBRACE) {
+		return nil
+	}
+	if clause.Body = p.parseBlockStatement(); clause.Body == nil {
+		return nil
+	}
+	return clause
+}
 
-	for !p.curTokenIs(tokens.SEMICOLON) {
-		exp := p.parseExpression(LOWEST)
-		stmt.Value = exp
-		p.nextToken()
+// expectIdentifier is expectPeek(tokens.IDENT) for names being declared,
+// with a clearer error when the name is a reserved word.
+func (p *Parser) expectIdentifier() bool {
+	if tokens.IsKeyword(p.peekToken.Type) {
+		p.reservedWordError(p.peekToken)
+		return false
 	}
+	return p.expectPeek(tokens.IDENT)
+}
 
-	return stmt
+func (p *Parser) checkIdentifier(tok tokens.Token) bool {
+	if tokens.IsKeyword(tok.Type) {
+		p.reservedWordError(tok)
+		return false
+	}
+	if tok.Type != tokens.IDENT {
+		p.expectedError(tok, "identifier")
+		return false
+	}
+	return true
+}
+
+// checkTypeName rejects the contextual keywords that are restricted type
+// names, e.g. `var` may name a variable but not a parameter or return type.
+func (p *Parser) checkTypeName(tok tokens.Token) bool {
+	if tok.Type == tokens.IDENT && (tok.Literal == "var" || tok.Literal == "yield" || tok.Literal == "record") {
+		p.restrictedNameError(tok, "'%s' is not allowed here", tok.Literal).
+			WithNote("'%s' is a restricted type name", tok.Literal)
+		return false
+	}
+	return true
+}
+
+func isTypeToken(t tokens.TokenType) bool {
+	switch t {
+	case tokens.IDENT, tokens.BYTE_DT, tokens.SHORT_DT, tokens.INTEGER_DT, tokens.LONG_DT,
+		tokens.FLOAT_DT, tokens.DOUBLE_DT, tokens.CHARACTER_DT, tokens.BOOLEAN_DT:
+		return true
+	}
+	return false
 }
 
 func (p *Parser) curTokenIs(t tokens.TokenType) bool {
@@ -540,6 +2178,18 @@ func (p *Parser) curTokenIs(t tokens.TokenType) bool {
 func (p *Parser) peekTokenIs(t tokens.TokenType) bool {
 	return p.peekToken.Type == t
 }
+
+// expectClosing is expectPeek for a closing delimiter, pointing out the
+// opening delimiter if it is missing.
+func (p *Parser) expectClosing(t tokens.TokenType, open tokens.Token) bool {
+	if p.peekTokenIs(t) {
+		p.nextToken()
+		return true
+	}
+	p.closingError(t, open)
+	return false
+}
+
 func (p *Parser) expectPeek(t tokens.TokenType) bool {
 	if p.peekTokenIs(t) {
 		p.nextToken()
@@ -5,98 +5,131 @@ import (
 	"java/ast"
 	"java/lexer"
 	"java/tokens"
+	"strings"
 	"testing"
 )
 
-func TestIntegerAssignmentStatement(t *testing.T) {
-	input := `
-		int x = 5;
-		int y = 2;
-		int num = 20;
-	`
-
-	l := lexer.New(input)
-	p := New(l)
-
-	program := p.ParseProgram()
-	checkParserErrors(t, p)
-	if program == nil {
-		t.Fatalf("ParseProgram() returned nil")
-	}
-
-	if len(program.Statements) != 3 {
-		t.Fatalf("program.Statements does not contain 3 statement. got=%d", len(program.Statements))
-	}
-
+func TestVariableDeclarations(t *testing.T) {
 	tests := []struct {
-		expectedIdentifier string
+		input    string
+		typ      string
+		names    []string
+		expected string
 	}{
-		{"x"},
-		{"y"},
-		{"num"},
+		{"int x = 5;", "int", []string{"x"}, "int x = 5;"},
+		{`String h = "hello";`, "String", []string{"h"}, "String h = hello;"},
+		{"boolean a = false;", "boolean", []string{"a"}, "boolean a = false;"},
+		{"double d;", "double", []string{"d"}, "double d;"},
+		{"int a = 1, b, c = 3;", "int", []string{"a", "b", "c"}, "int a = 1, b, c = 3;"},
+		{"final int x = 1;", "int", []string{"x"}, "final int x = 1;"},
+		{"Point p = new Point(1, 2);", "Point", []string{"p"}, "Point p = new Point(1, 2);"},
+		{"Point p;", "Point", []string{"p"}, "Point p;"},
+		{"int[] xs = null;", "int[]", []string{"xs"}, "int[] xs = null;"},
+		{"Point[][] grid;", "Point[][]", []string{"grid"}, "Point[][] grid;"},
+		{"Box<String> b = new Box<>();", "Box<String>", []string{"b"}, "Box<String> b = new Box<>();"},
+		{"Map<String, List<Integer>> m;", "Map<String, List<Integer>>", []string{"m"}, "Map<String, List<Integer>> m;"},
+		{"Box<? extends Number>[] boxes;", "Box<? extends Number>[]", []string{"boxes"}, "Box<? extends Number>[] boxes;"},
+		{"var x = 5;", "var", []string{"x"}, "var x = 5;"},
+		{`final var s = "a";`, "var", []string{"s"}, "final var s = a;"},
+		{"var var = 1;", "var", []string{"var"}, "var var = 1;"},
 	}
 
-	for i, tt := range tests {
-		stmt := program.Statements[i]
-		if !testIntegerAssignmentStatement(t, stmt, tt.expectedIdentifier) {
-			return
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		if len(program.Statements) != 1 {
+			t.Fatalf("%q: expected 1 statement, got %d", tt.input, len(program.Statements))
+		}
+		decl, ok := program.Statements[0].(*ast.VariableDeclaration)
+		if !ok {
+			t.Fatalf("%q: statement is not *ast.VariableDeclaration. got=%T", tt.input, program.Statements[0])
+		}
+		if decl.Type.String() != tt.typ {
+			t.Errorf("%q: wrong type. expected=%q, got=%q", tt.input, tt.typ, decl.Type.String())
+		}
+		if len(decl.Declarators) != len(tt.names) {
+			t.Fatalf("%q: expected %d declarators, got %d", tt.input, len(tt.names), len(decl.Declarators))
+		}
+		for i, name := range tt.names {
+			if decl.Declarators[i].Name.Value != name {
+				t.Errorf("%q: declarator %d is %q, expected %q", tt.input, i, decl.Declarators[i].Name.Value, name)
+			}
+		}
+		if decl.String() != tt.expected {
+			t.Errorf("%q: wrong String(). expected=%q, got=%q", tt.input, tt.expected, decl.String())
 		}
 	}
 }
 
-func TestStringAssignmentStatement(t *testing.T) {
-	input := `
-		String x = "Hello world";
-		String h = "hello";
-	`
-
-	l := lexer.New(input)
-	p := New(l)
-
-	program := p.ParseProgram()
-	checkParserErrors(t, p)
-
-	if program == nil {
-		t.Fatalf("ParseProgram() returned nil")
+// TestDeclarationOrExpression checks that statements starting with an
+// identifier are told apart from declarations.
+func TestDeclarationOrExpression(t *testing.T) {
+	tests := []struct {
+		input       string
+		declaration bool
+	}{
+		{"Foo x;", true},
+		{"Foo[] xs;", true},
+		{"List<String> xs;", true},
+		{"List<List<String>> xs;", true},
+		{"x = 1;", false},
+		{"xs[0] = 1;", false},
+		{"a < b;", false},
+		{"a < b == c > d;", false},
+		{"f(x);", false},
+		{"x++;", false},
+		{"p.x = 1;", false},
 	}
 
-	if len(program.Statements) != 2 {
-		t.Fatalf("program.Statements does not contain 2 statement. got=%d", len(program.Statements))
+	for _, tt := range tests {
+		program := parseProgram(t, tt.input)
+		_, ok := program.Statements[0].(*ast.VariableDeclaration)
+		if ok != tt.declaration {
+			t.Errorf("%q: declaration=%t, expected %t (%T)", tt.input, ok, tt.declaration, program.Statements[0])
+		}
 	}
+}
 
+func TestDeclarationErrors(t *testing.T) {
 	tests := []struct {
-		expectedIdentifier string
+		input   string
+		message string
 	}{
-		{"x"},
-		{"h"},
+		{"int a = 1, ;", "expected identifier, found ';'"},
+		{"int x = 1, 2;", "expected identifier, found integer literal"},
+		{"int x y;", "expected ';', found identifier 'y'"},
+		{"final Foo;", "expected identifier, found ';'"},
+		{"int[ xs;", "expected ']', found identifier 'xs'"},
+		{"public int x = 1;", "modifier public not allowed here"},
+		{"static final int x;", "modifier static not allowed here"},
+		{"var a = 1, b = 2;", "'var' is not allowed in a compound declaration"},
+		{"var[] xs = null;", "'var' is not allowed as an element type of an array"},
+		{"var xs = {1, 2};", "cannot infer type for local variable xs"},
+		{"var<String> x = null;", "'var' is not allowed here"},
+		{"public var f() { return 1; }", "'var' is not allowed here"},
+		{"class A { var x = 1; }", "'var' is not allowed here"},
 	}
 
-	for i, tt := range tests {
-		stmt := program.Statements[i]
-		if !testStringAssignmentStatement(t, stmt, tt.expectedIdentifier) {
-			return
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
 		}
 	}
 }
 
-func TestBooleanAssignmentStatement(t *testing.T) {
-	input := "boolean a = false;"
-
-	l := lexer.New(input)
-	p := New(l)
-
+func parseProgram(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := New(lexer.New(input))
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
-
-	if program == nil {
-		t.Fatalf("ParseProgram() returned nil")
-	}
-
-	if len(program.Statements) != 1 {
-		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
-	}
-
-	testBooleanStatement(t, program.Statements[0], "a")
+	return program
 }
 
 func TestReturnStatementExpression(t *testing.T) {
@@ -151,79 +184,6 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
-func testBooleanStatement(t *testing.T, s ast.Statement, name string) bool {
-	if s.TokenLiteral() != "boolean" {
-		t.Errorf("s.TokenLiteral not 'boolean'. got=%q", s.TokenLiteral())
-		return false
-	}
-
-	stmt, ok := s.(*ast.BooleanAssignmentStatement)
-	if !ok {
-		t.Errorf("s not *ast.BooleanAssignmentStatement. got=%T", s)
-		return false
-	}
-	if stmt.Name.Value != name {
-		t.Errorf("stmt.Name.Value not '%s'. got=%s", name, stmt.Name.Value)
-		return false
-	}
-	if stmt.Name.TokenLiteral() != name {
-		t.Errorf("stmt.Name.TokenLiteral() not '%s'. got=%s",
-			name, stmt.Name.TokenLiteral())
-		return false
-	}
-	return true
-
-}
-
-func testStringAssignmentStatement(t *testing.T, s ast.Statement, name string) bool {
-	if s.TokenLiteral() != "String" {
-		t.Errorf("s.TokenLiteral not 'String'. got=%q", s.TokenLiteral())
-		return false
-	}
-
-	stmt, ok := s.(*ast.StringAssignmentStatement)
-	if !ok {
-		t.Errorf("s not *ast.StringAssignmentStatement. got=%T", s)
-		return false
-	}
-	if stmt.Name.Value != name {
-		t.Errorf("stmt.Name.Value not '%s'. got=%s", name, stmt.Name.Value)
-		return false
-	}
-	if stmt.Name.TokenLiteral() != name {
-		t.Errorf("stmt.Name.TokenLiteral() not '%s'. got=%s",
-			name, stmt.Name.TokenLiteral())
-		return false
-	}
-	return true
-
-}
-
-func testIntegerAssignmentStatement(t *testing.T, s ast.Statement, name string) bool {
-
-	if s.TokenLiteral() != "int" {
-		t.Errorf("s.TokenLiteral not 'int'. got=%q", s.TokenLiteral())
-		return false
-	}
-
-	stmt, ok := s.(*ast.IntegerAssignmentStatement)
-	if !ok {
-		t.Errorf("s not *ast.IntegerAssignmentStatement. got=%T", s)
-		return false
-	}
-	if stmt.Name.Value != name {
-		t.Errorf("stmt.Name.Value not '%s'. got=%s", name, stmt.Name.Value)
-		return false
-	}
-	if stmt.Name.TokenLiteral() != name {
-		t.Errorf("stmt.Name.TokenLiteral() not '%s'. got=%s",
-			name, stmt.Name.TokenLiteral())
-		return false
-	}
-	return true
-
-}
-
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
@@ -239,16 +199,19 @@ func checkParserErrors(t *testing.T, p *Parser) {
 func TestString(t *testing.T) {
 	program := &ast.Program{
 		Statements: []ast.Statement{
-			&ast.IntegerAssignmentStatement{
+			&ast.VariableDeclaration{
 				Token: tokens.Token{Type: tokens.INTEGER_DT, Literal: "int"},
-				Name: &ast.Identifier{
-					Token: tokens.Token{Type: tokens.IDENT, Literal: "myVar"},
-					Value: "myVar",
-				},
-				Value: &ast.Identifier{
-					Token: tokens.Token{Type: tokens.IDENT, Literal: "anotherVar"},
-					Value: "anotherVar",
-				},
+				Type:  &ast.Type{Token: tokens.Token{Type: tokens.INTEGER_DT, Literal: "int"}, Name: "int"},
+				Declarators: []*ast.Declarator{{
+					Name: &ast.Identifier{
+						Token: tokens.Token{Type: tokens.IDENT, Literal: "myVar"},
+						Value: "myVar",
+					},
+					Value: &ast.Identifier{
+						Token: tokens.Token{Type: tokens.IDENT, Literal: "anotherVar"},
+						Value: "anotherVar",
+					},
+				}},
 			},
 		},
 	}
@@ -270,13 +233,13 @@ func TestIdentifierExpression(t *testing.T) {
 		t.Fatalf("program has not enough statements. got=%d",
 			len(program.Statements))
 	}
-	stmt, ok := program.Statements[0].(*ast.IntegerAssignmentStatement)
+	stmt, ok := program.Statements[0].(*ast.VariableDeclaration)
 
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.IntegerAssignmentStatement. got=%T",
+		t.Fatalf("program.Statements[0] is not ast.VariableDeclaration. got=%T",
 			program.Statements[0])
 	}
-	testIdentifier(t, stmt.Value, "y")
+	testIdentifier(t, stmt.Declarators[0].Value, "y")
 }
 
 func TestIntegerLiteralExpression(t *testing.T) {
@@ -292,14 +255,14 @@ func TestIntegerLiteralExpression(t *testing.T) {
 			len(program.Statements))
 	}
 
-	stmt, ok := program.Statements[0].(*ast.IntegerAssignmentStatement)
+	stmt, ok := program.Statements[0].(*ast.VariableDeclaration)
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.IntegerAssignmentStatement. got=%T",
+		t.Fatalf("program.Statements[0] is not ast.VariableDeclaration. got=%T",
 			program.Statements[0])
 	}
-	literal, ok := stmt.Value.(*ast.IntegerLiteral)
+	literal, ok := stmt.Declarators[0].Value.(*ast.IntegerLiteral)
 	if !ok {
-		t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Value)
+		t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Declarators[0].Value)
 	}
 	if literal.Value != 5 {
 		t.Errorf("literal.Value not %d. got=%d", 5, literal.Value)
@@ -322,15 +285,15 @@ func TestParsingPrefixExpressions(t *testing.T) {
 		t.Fatalf("program.Statements does not contain %d statements. got=%d\n",
 			1, len(program.Statements))
 	}
-	stmt, ok := program.Statements[0].(*ast.IntegerAssignmentStatement)
+	stmt, ok := program.Statements[0].(*ast.VariableDeclaration)
 
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.IntegerAssignmentStatement. got=%T",
+		t.Fatalf("program.Statements[0] is not ast.VariableDeclaration. got=%T",
 			program.Statements[0])
 	}
-	exp, ok := stmt.Value.(*ast.PrefixExpression)
+	exp, ok := stmt.Declarators[0].Value.(*ast.PrefixExpression)
 	if !ok {
-		t.Fatalf("stmt is not ast.PrefixExpression. got=%T", stmt.Value)
+		t.Fatalf("stmt is not ast.PrefixExpression. got=%T", stmt.Declarators[0].Value)
 	}
 	if exp.Operator != "-" {
 		t.Fatalf("exp.Operator is not '%s'. got=%s",
@@ -390,13 +353,13 @@ func TestParsingInfixExpressions(t *testing.T) {
 			t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
 		}
 
-		stmt, ok := program.Statements[0].(*ast.IntegerAssignmentStatement)
+		stmt, ok := program.Statements[0].(*ast.VariableDeclaration)
 		if !ok {
-			t.Fatalf("program.Statements[0] is not ast.IntegerAssignmentStatement. got=%T",
+			t.Fatalf("program.Statements[0] is not ast.VariableDeclaration. got=%T",
 				program.Statements[0])
 		}
 
-		exp, _ := stmt.Value.(*ast.InfixExpression)
+		exp, _ := stmt.Declarators[0].Value.(*ast.InfixExpression)
 		testInfixExpression(t, exp, tt.leftValue, tt.operator, tt.rightValue)
 	}
 }
@@ -569,7 +532,7 @@ func testBooleanLiteral(t *testing.T, exp ast.Expression, value bool) bool {
 	return true
 }
 
-func TestIfExpression(t *testing.T) {
+func TestIfStatement(t *testing.T) {
 	input := `if (x > y){ return x + y; }`
 
 	l := lexer.New(input)
@@ -580,43 +543,29 @@ func TestIfExpression(t *testing.T) {
 	if len(program.Statements) != 1 {
 		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
 	}
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-
+	stmt, ok := program.Statements[0].(*ast.IfStatement)
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+		t.Fatalf("program.Statements[0] is not ast.IfStatement. got=%T",
 			program.Statements[0])
 	}
 
-	exp, ok := stmt.Expression.(*ast.IfExpression)
-	if !ok {
-		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
-	}
-
-	if !testInfixExpression(t, exp.Condition, "x", ">", "y") {
+	if !testInfixExpression(t, stmt.Condition, "x", ">", "y") {
 		return
 	}
 
-	if len(exp.Consequence.Statements) != 1 {
-		t.Errorf("consequence is not 1 statements. got=%d\n", len(exp.Consequence.Statements))
-	}
-
-	consequence, ok := exp.Consequence.Statements[0].(*ast.ReturnStatement)
-	if !ok {
-		t.Fatalf("Statements[0] is not ast.ReturnStatement. got=%T", exp.Consequence.Statements[0])
-	}
-
-	infixExpression, _ := consequence.ReturnValue.(*ast.InfixExpression)
-
-	if !testInfixExpression(t, infixExpression, "x", "+", "y") {
+	consequence := testBlockStatement(t, stmt.Consequence, 1)
+	if consequence == nil {
 		return
 	}
 
-	if exp.Alternative != nil {
-		t.Errorf("exp.Alternative.Statements was not nil. got=%+v", exp.Alternative)
+	testReturnStatement(t, consequence.Statements[0], "x", "+", "y")
+
+	if stmt.Alternative != nil {
+		t.Errorf("stmt.Alternative was not nil. got=%+v", stmt.Alternative)
 	}
 }
 
-func TestIfElseExpression(t *testing.T) {
+func TestIfElseStatement(t *testing.T) {
 	input := `if (x > y) {
 	return x + y;
 	} else {
@@ -631,54 +580,92 @@ func TestIfElseExpression(t *testing.T) {
 	if len(program.Statements) != 1 {
 		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
 	}
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-
+	stmt, ok := program.Statements[0].(*ast.IfStatement)
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+		t.Fatalf("program.Statements[0] is not ast.IfStatement. got=%T",
 			program.Statements[0])
 	}
 
-	exp, ok := stmt.Expression.(*ast.IfExpression)
-	if !ok {
-		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+	if !testInfixExpression(t, stmt.Condition, "x", ">", "y") {
+		return
 	}
 
-	if !testInfixExpression(t, exp.Condition, "x", ">", "y") {
+	consequence := testBlockStatement(t, stmt.Consequence, 1)
+	if consequence == nil {
 		return
 	}
+	testReturnStatement(t, consequence.Statements[0], "x", "+", "y")
 
-	if len(exp.Consequence.Statements) != 1 {
-		t.Errorf("consequence is not 1 statements. got=%d\n", len(exp.Consequence.Statements))
+	alt := testBlockStatement(t, stmt.Alternative, 1)
+	if alt == nil {
+		return
 	}
+	testReturnStatement(t, alt.Statements[0], "x", "-", "y")
+}
 
-	consequence, ok := exp.Consequence.Statements[0].(*ast.ReturnStatement)
-	if !ok {
-		t.Fatalf("Statements[0] is not ast.ReturnStatement. got=%T", exp.Consequence.Statements[0])
+func TestIfElseIfStatement(t *testing.T) {
+	inputs := []string{
+		`if (x > y) {
+				return x + y;
+			  } else if (x < y) {
+				return x - y;
+			  } else {
+	 			return x / y;
+			  }`,
+		`if (x > y) { return x + y; } else  if (x < y) { return x - y; } else { return x / y; }`,
+		`if (x > y) { return x + y; } else /* comment */ if (x < y) { return x - y; } else { return x / y; }`,
+		"if (x > y) { return x + y; } else\n\n  if (x < y) { return x - y; } else // comment\n { return x / y; }",
 	}
 
-	infixExpression, _ := consequence.ReturnValue.(*ast.InfixExpression)
+	for _, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.IfStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.IfStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if !testInfixExpression(t, stmt.Condition, "x", ">", "y") {
+			return
+		}
 
-	if !testInfixExpression(t, infixExpression, "x", "+", "y") {
-		return
-	}
+		elseIf, ok := stmt.Alternative.(*ast.IfStatement)
+		if !ok {
+			t.Fatalf("stmt.Alternative is not ast.IfStatement. got=%T", stmt.Alternative)
+		}
 
-	alt := exp.Alternative
+		if !testInfixExpression(t, elseIf.Condition, "x", "<", "y") {
+			return
+		}
 
-	if len(alt.Statements) != 1 {
-		t.Fatalf("Else statements should only have 1 statement, got=%d\n", len(alt.Statements))
-	}
-	altInfixExpression, _ := alt.Statements[0].(*ast.ReturnStatement)
+		consequence := testBlockStatement(t, elseIf.Consequence, 1)
+		if consequence == nil {
+			return
+		}
+		testReturnStatement(t, consequence.Statements[0], "x", "-", "y")
 
-	if !testInfixExpression(t, altInfixExpression.ReturnValue, "x", "-", "y") {
-		return
+		alt := testBlockStatement(t, elseIf.Alternative, 1)
+		if alt == nil {
+			return
+		}
+		testReturnStatement(t, alt.Statements[0], "x", "/", "y")
 	}
 }
 
-func TestIfElseIfStatement(t *testing.T) {
+func TestIfElseStatement2(t *testing.T) {
 	input := `if (x > y) {
 				return x + y;
 			  } else if (x < y) {
 				return x - y;
+			  } else if (false) {
+			   return x * y;
 			  } else {
 	 			return x / y;
 			  }`
@@ -691,117 +678,105 @@ func TestIfElseIfStatement(t *testing.T) {
 	if len(program.Statements) != 1 {
 		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
 	}
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
 
+	stmt, ok := program.Statements[0].(*ast.IfStatement)
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
+		t.Fatalf("program.Statements[0] is not ast.IfStatement. got=%T",
 			program.Statements[0])
 	}
 
-	exp, ok := stmt.Expression.(*ast.IfExpression)
-	if !ok {
-		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
-	}
-
-	if !testInfixExpression(t, exp.Condition, "x", ">", "y") {
-		return
-	}
-
-	if len(exp.Consequence.Statements) != 1 {
-		t.Errorf("consequence is not 1 statements. got=%d\n", len(exp.Consequence.Statements))
+	elseIfs := 0
+	for {
+		next, ok := stmt.Alternative.(*ast.IfStatement)
+		if !ok {
+			break
+		}
+		elseIfs++
+		stmt = next
 	}
 
-	consequence, ok := exp.Consequence.Statements[0].(*ast.ReturnStatement)
-	if !ok {
-		t.Fatalf("Statements[0] is not ast.ReturnStatement. got=%T", exp.Consequence.Statements[0])
+	if elseIfs != 2 {
+		t.Fatalf("Should have 2 else if statements but got=%d\n", elseIfs)
 	}
 
-	infixExpression, _ := consequence.ReturnValue.(*ast.InfixExpression)
-
-	if !testInfixExpression(t, infixExpression, "x", "+", "y") {
+	if !testBooleanLiteral(t, stmt.Condition, false) {
 		return
 	}
 
-	alt := exp.Alternative
-
-	if len(alt.Statements) != 1 {
-		t.Fatalf("Else statements should only have 1 statement, got=%d\n", len(alt.Statements))
-	}
-	altInfixExpression, _ := alt.Statements[0].(*ast.ReturnStatement)
-
-	if !testInfixExpression(t, altInfixExpression.ReturnValue, "x", "/", "y") {
+	alt := testBlockStatement(t, stmt.Alternative, 1)
+	if alt == nil {
 		return
 	}
+	testReturnStatement(t, alt.Statements[0], "x", "/", "y")
 }
 
-func TestIfElseStatement2(t *testing.T) {
-	input := `if (x > y) {
-				return x + y;
-			  } else if (x < y) {
-				return x - y;
-			  } else if (false) {
-			   return x * y;
-			  } else {
-	 			return x / y;
-			  }`
+func TestIfWithoutBraces(t *testing.T) {
+	input := `
+		if (x) return;
+		if (x > y) return x; else if (x < y) return y; else return 0;
+		if (a) if (b) return a; else return b;
+	`
 
 	l := lexer.New(input)
 	p := New(l)
 	program := p.ParseProgram()
 	checkParserErrors(t, p)
 
-	if len(program.Statements) != 1 {
-		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 3, len(program.Statements))
 	}
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
 
+	first, ok := program.Statements[0].(*ast.IfStatement)
 	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T",
-			program.Statements[0])
-	}
-
-	exp, ok := stmt.Expression.(*ast.IfExpression)
-
-	elseIfs := exp.Branches
-
-	if len(elseIfs) != 2 {
-		t.Fatalf("Should have 2 Else If Expressions but got=%d\n", len(elseIfs))
+		t.Fatalf("program.Statements[0] is not ast.IfStatement. got=%T", program.Statements[0])
 	}
-
+	ret, ok := first.Consequence.(*ast.ReturnStatement)
 	if !ok {
-		t.Fatalf("stmt.Expression is not ast.IfExpression. got=%T", stmt.Expression)
+		t.Fatalf("first.Consequence is not ast.ReturnStatement. got=%T", first.Consequence)
 	}
-
-	if !testInfixExpression(t, exp.Condition, "x", ">", "y") {
-		return
+	if ret.ReturnValue != nil {
+		t.Errorf("ret.ReturnValue should be nil. got=%s", ret.ReturnValue)
 	}
 
-	if len(exp.Consequence.Statements) != 1 {
-		t.Errorf("consequence is not 1 statements. got=%d\n", len(exp.Consequence.Statements))
+	second := program.Statements[1].(*ast.IfStatement)
+	if second.String() != "if(x > y) return x;else if(x < y) return y;else return 0;" {
+		t.Errorf("second.String() wrong. got=%q", second.String())
 	}
 
-	consequence, ok := exp.Consequence.Statements[0].(*ast.ReturnStatement)
+	// The else belongs to the nearest if.
+	third := program.Statements[2].(*ast.IfStatement)
+	if third.Alternative != nil {
+		t.Errorf("outer if should not have an else branch. got=%s", third.Alternative)
+	}
+	inner, ok := third.Consequence.(*ast.IfStatement)
 	if !ok {
-		t.Fatalf("Statements[0] is not ast.ReturnStatement. got=%T", exp.Consequence.Statements[0])
+		t.Fatalf("third.Consequence is not ast.IfStatement. got=%T", third.Consequence)
 	}
-
-	infixExpression, _ := consequence.ReturnValue.(*ast.InfixExpression)
-
-	if !testInfixExpression(t, infixExpression, "x", "+", "y") {
-		return
+	if inner.Alternative == nil {
+		t.Errorf("inner if should have the else branch")
 	}
+}
 
-	alt := exp.Alternative
-
-	if len(alt.Statements) != 1 {
-		t.Fatalf("Else statements should only have 1 statement, got=%d\n", len(alt.Statements))
+func testBlockStatement(t *testing.T, s ast.Statement, length int) *ast.BlockStatement {
+	block, ok := s.(*ast.BlockStatement)
+	if !ok {
+		t.Errorf("s is not ast.BlockStatement. got=%T", s)
+		return nil
 	}
-	altInfixExpression, _ := alt.Statements[0].(*ast.ReturnStatement)
-
-	if !testInfixExpression(t, altInfixExpression.ReturnValue, "x", "/", "y") {
-		return
+	if len(block.Statements) != length {
+		t.Errorf("block does not contain %d statements. got=%d\n", length, len(block.Statements))
+		return nil
 	}
+	return block
+}
 
+func testReturnStatement(t *testing.T, s ast.Statement, left interface{}, operator string, right interface{}) bool {
+	returnStmt, ok := s.(*ast.ReturnStatement)
+	if !ok {
+		t.Errorf("s is not ast.ReturnStatement. got=%T", s)
+		return false
+	}
+	return testInfixExpression(t, returnStmt.ReturnValue, left, operator, right)
 }
 
 func TestFunctionParsing(t *testing.T) {
@@ -854,13 +829,27 @@ func testParameterExpression(t *testing.T, p ast.Parameter, datatype interface{}
 		return false
 	}
 
-	if p.DataType.Literal != datatype {
-		t.Fatalf("Data type for parameter should be %s. but was %s\n", p.DataType.Literal, datatype)
+	if p.DataType.String() != datatype {
+		t.Fatalf("Data type for parameter should be %s. but was %s\n", p.DataType.String(), datatype)
 		return false
 	}
 	return true
 }
 
+func TestFinalParameters(t *testing.T) {
+	program := parseProgram(t, "public int f(final int x, String y, final int... zs) { return x; }")
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+
+	for i, final := range []bool{true, false, true} {
+		if got := ast.HasModifier(function.Parameters[i].Modifiers, tokens.FINAL); got != final {
+			t.Errorf("parameter %d: final=%t, want %t", i, got, final)
+		}
+	}
+	if got := function.Parameters[0].String(); got != "final int x" {
+		t.Errorf("wrong String. expected=%q, got=%q", "final int x", got)
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 	l := lexer.New(input)
@@ -947,3 +936,833 @@ func testIncrementDecrementStatement(t *testing.T, operand string, operator stri
 	}
 	return true
 }
+
+func TestReservedWordAsIdentifier(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"int class = 5;", "'class' is a reserved word and cannot be used as an identifier"},
+		{"boolean while = true;", "'while' is a reserved word and cannot be used as an identifier"},
+		{`String null = "x";`, "'null' is a reserved word and cannot be used as an identifier"},
+		{"int _ = 1;", "'_' is a reserved word and cannot be used as an identifier"},
+		{"public void new() {}", "'new' is a reserved word and cannot be used as an identifier"},
+		{"public void f(int this) {}", "'this' is a reserved word and cannot be used as an identifier"},
+		{"public var f() {}", "'var' is not allowed here"},
+		{"yield(5);", "invalid use of a restricted identifier 'yield'"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected an error for %q", tt.input)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+	}
+}
+
+func TestContextualKeywordsAsIdentifiers(t *testing.T) {
+	input := `
+		int var = 1;
+		int record = 2;
+		String yield = "y";
+		boolean sealed = true;
+		int permits = var + record;
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 5 {
+		t.Fatalf("program.Statements does not contain 5 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[4].(*ast.VariableDeclaration)
+	if !ok {
+		t.Fatalf("program.Statements[4] is not ast.VariableDeclaration. got=%T", program.Statements[4])
+	}
+	testInfixExpression(t, stmt.Declarators[0].Value, "var", "+", "record")
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedError      string
+		expectedStatements []string
+	}{
+		{
+			`int x = 5;
+			int y = ;
+			int z = 10;`,
+			"expected expression, found ';'",
+			[]string{"int x = 5;", "int z = 10;"},
+		},
+		{
+			`int x = 5
+			int y = 6;`,
+			"expected ';', found 'int'",
+			[]string{"int y = 6;"},
+		},
+		{
+			`add(1, 2;
+			boolean b = true;`,
+			"expected ')', found ';'",
+			[]string{"boolean b = true;"},
+		},
+		{
+			`return x +
+			}
+			return 1;`,
+			"expected expression, found '}'",
+			[]string{"return 1;"},
+		},
+		{
+			`return 1 2 3 4 5
+			int a = 1;`,
+			"expected ';', found integer literal",
+			[]string{"int a = 1;"},
+		},
+		{
+			`return (1 + ;
+			return 2;`,
+			"expected expression, found ';'",
+			[]string{"return 2;"},
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected exactly 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errors[0])
+		}
+
+		if len(program.Statements) != len(tt.expectedStatements) {
+			t.Errorf("wrong number of statements for %q. expected=%d, got=%d", tt.input, len(tt.expectedStatements), len(program.Statements))
+			continue
+		}
+		for i, stmt := range program.Statements {
+			if stmt.String() != tt.expectedStatements[i] {
+				t.Errorf("wrong statement %d. expected=%q, got=%q", i, tt.expectedStatements[i], stmt.String())
+			}
+		}
+	}
+}
+
+func TestErrorRecoveryInsideBlocks(t *testing.T) {
+	input := `
+	public int f(int a) {
+		int b = a +;
+		return a;
+	}
+	if (x) {
+		return }
+	public int g() { return 2; }
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %q", len(errors), errors)
+	}
+
+	if len(program.Statements) != 3 {
+		t.Fatalf("program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	}
+
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if len(function.Body.Statements) != 1 {
+		t.Fatalf("function body should keep its valid statement. got=%d statements", len(function.Body.Statements))
+	}
+	if function.Body.Statements[0].String() != "return a;" {
+		t.Errorf("wrong statement in body. got=%q", function.Body.Statements[0].String())
+	}
+
+	ifStmt := program.Statements[1].(*ast.IfStatement)
+	if len(ifStmt.Consequence.(*ast.BlockStatement).Statements) != 0 {
+		t.Errorf("if block should be empty after dropping the bad return")
+	}
+
+	g := program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	if g.Name.Value != "g" {
+		t.Errorf("expected function g to be parsed. got=%s", g.Name.Value)
+	}
+}
+
+func TestUnterminatedInputDoesNotHang(t *testing.T) {
+	inputs := []string{
+		"return 5",
+		"int x = 5",
+		"public int f(int a",
+		"public int f(int a) { return a;",
+		"add(1, 2",
+		"if (x",
+		"(((",
+	}
+
+	for _, input := range inputs {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) != 1 {
+			t.Errorf("expected exactly 1 error for %q, got %d: %q", input, len(p.Errors()), p.Errors())
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		input     string
+		code      string
+		message   string
+		line      int
+		column    int
+		secondary int
+	}{
+		{"int x = 5\nint y = 6;", ErrUnexpectedToken, "expected ';', found 'int'", 1, 10, 0},
+		{"add(1, 2;", ErrUnexpectedToken, "expected ')', found ';'", 1, 9, 1},
+		{"int y = ;", ErrExpectedExpression, "expected expression, found ';'", 1, 9, 0},
+		{"return \"abc;", ErrIllegalToken, "unclosed string literal", 1, 8, 0},
+		{"int x = 99999999999999999999;", ErrIntegerTooLarge, "integer number too large: 99999999999999999999", 1, 9, 0},
+		{"int class = 1;", ErrReservedWord, "'class' is a reserved word and cannot be used as an identifier", 1, 5, 0},
+		{"if (x) {\n  return 1;\n", ErrUnexpectedEOF, "reached end of file while parsing", 3, 1, 1},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		diags := p.Diagnostics()
+		if len(diags) != 1 {
+			t.Errorf("expected 1 diagnostic for %q, got %d: %q", tt.input, len(diags), p.Errors())
+			continue
+		}
+		d := diags[0]
+		if d.Code != tt.code || d.Message != tt.message {
+			t.Errorf("wrong diagnostic for %q. expected=%s %q, got=%s %q", tt.input, tt.code, tt.message, d.Code, d.Message)
+		}
+		if d.Primary.Start.Line != tt.line || d.Primary.Start.Column != tt.column {
+			t.Errorf("wrong position for %q. expected=%d:%d, got=%d:%d", tt.input, tt.line, tt.column,
+				d.Primary.Start.Line, d.Primary.Start.Column)
+		}
+		if len(d.Secondary) != tt.secondary {
+			t.Errorf("wrong number of secondary spans for %q. expected=%d, got=%d", tt.input, tt.secondary, len(d.Secondary))
+		}
+	}
+}
+
+func TestClassDeclaration(t *testing.T) {
+	input := `
+public class Point {
+	private int x;
+	int y = 0;
+	String label;
+
+	Point() {}
+
+	public Point(int x, int y) {
+		this.x = x;
+		this.y = y;
+	}
+
+	{ label = "p"; }
+	static { count = 0; }
+
+	public int sum() {
+		return x + y;
+	}
+}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	class, ok := program.Statements[0].(*ast.ClassDeclaration)
+	if !ok {
+		t.Fatalf("stmt is not ast.ClassDeclaration. got=%T", program.Statements[0])
+	}
+	if class.Name.Value != "Point" || !ast.HasModifier(class.Modifiers, tokens.PUBLIC) {
+		t.Errorf("wrong class header. got=%q", class.String())
+	}
+
+	fields := []struct {
+		typ   string
+		name  string
+		value string
+	}{
+		{"int", "x", ""},
+		{"int", "y", "0"},
+		{"String", "label", ""},
+	}
+	if len(class.Fields) != len(fields) {
+		t.Fatalf("class.Fields has wrong length. want=%d, got=%d", len(fields), len(class.Fields))
+	}
+	for i, tt := range fields {
+		f := class.Fields[i]
+		if f.Type.String() != tt.typ || f.Name.Value != tt.name {
+			t.Errorf("field %d is wrong. want=%s %s, got=%s %s", i, tt.typ, tt.name, f.Type, f.Name)
+		}
+		if (f.Value == nil && tt.value != "") || (f.Value != nil && f.Value.String() != tt.value) {
+			t.Errorf("field %d has wrong initializer. want=%q, got=%v", i, tt.value, f.Value)
+		}
+	}
+	if !ast.HasModifier(class.Fields[0].Modifiers, tokens.PRIVATE) {
+		t.Errorf("field x should be private")
+	}
+
+	if len(class.Constructors) != 2 {
+		t.Fatalf("class.Constructors has wrong length. want=2, got=%d", len(class.Constructors))
+	}
+	ctor := class.Constructors[1]
+	if len(ctor.Parameters) != 2 || len(ctor.Body.Statements) != 2 {
+		t.Errorf("wrong constructor. got=%q", ctor.String())
+	}
+	if ctor.Body.Statements[0].String() != "this.x = x" {
+		t.Errorf("wrong constructor statement. got=%q", ctor.Body.Statements[0].String())
+	}
+
+	if len(class.Initializers) != 1 {
+		t.Errorf("class.Initializers has wrong length. want=1, got=%d", len(class.Initializers))
+	}
+	if len(class.StaticInitializers) != 1 {
+		t.Errorf("class.StaticInitializers has wrong length. want=1, got=%d", len(class.StaticInitializers))
+	}
+
+	if len(class.Methods) != 1 {
+		t.Fatalf("class.Methods has wrong length. want=1, got=%d", len(class.Methods))
+	}
+	method := class.Methods[0]
+	if method.Name.Value != "sum" || method.ReturnType.String() != "int" || method.Accessor.Type != tokens.PUBLIC {
+		t.Errorf("wrong method. got=%q", method.String())
+	}
+}
+
+func TestObjectExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"new Point(1, 2 + 3);", "new Point(1, (2 + 3))"},
+		{"new Point();", "new Point()"},
+		{"this.x = x;", "this.x = x"},
+		{"a.b.c;", "a.b.c"},
+		{"a.b == null;", "(a.b == null)"},
+		{"f(null, x);", "f(null, x)"},
+		{"a.b(c).d(e);", "a.b(c).d(e)"},
+		{"new Point(1, 2).sum();", "new Point(1, 2).sum()"},
+		{"-a.b * c;", "((-a.b) * c)"},
+		{"a = b = c + 1;", "a = b = (c + 1)"},
+		{"p.x = p.y == 2;", "p.x = (p.y == 2)"},
+		{"this.yield(1);", "this.yield(1)"},
+		{"super(a, b);", "super(a, b)"},
+		{"super.speak() + super.name;", "(super.speak() + super.name)"},
+		{"xs[i + 1] * 2.5;", "(xs[(i + 1)] * 2.5)"},
+		{"a.b[0] = c[1][2];", "a.b[0] = c[1][2]"},
+		{"public int sum(String label, int... xs) { return xs.length; }",
+			"public int sum(String label, int... xs) return xs.length;"},
+		{"public void run() throws IOException, InterruptedException {}",
+			"public void run() throws IOException, InterruptedException "},
+		{"f = x -> x * 2;", "f = (x) -> (x * 2)"},
+		{"f = (a, b) -> { return a + b; };", "f = (a, b) -> {return (a + b);}"},
+		{"r = () -> {};", "r = () -> {}"},
+		{"g(x -> y -> x + y);", "g((x) -> (y) -> (x + y))"},
+		{"f = (int a, final String b) -> a;", "f = (int a, final String b) -> a"},
+		{"(a + b) * 2;", "((a + b) * 2)"},
+		{"f = String::length;", "f = String::length"},
+		{"f = this::run;", "f = this::run"},
+		{"f = Point::new;", "f = Point::new"},
+		{"f = a.b::c;", "f = a.b::c"},
+	}
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	program := New(lexer.New("a.b(c);")).ParseProgram()
+	call := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	member, ok := call.Function.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("call.Function is not ast.MemberExpression. got=%T", call.Function)
+	}
+	testIdentifier(t, member.Object, "a")
+	testIdentifier(t, member.Property, "b")
+}
+
+func TestTryStatement(t *testing.T) {
+	input := `try {
+	risky();
+} catch (IllegalStateException | ArithmeticException e) {
+	throw new RuntimeException(e);
+} catch (Exception e) {
+} finally {
+	done();
+}`
+	program := New(lexer.New(input)).ParseProgram()
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("stmt is not ast.TryStatement. got=%T", program.Statements[0])
+	}
+	if len(stmt.Block.Statements) != 1 {
+		t.Errorf("try block has wrong number of statements. got=%d", len(stmt.Block.Statements))
+	}
+	if len(stmt.Catches) != 2 {
+		t.Fatalf("wrong number of catch clauses. got=%d", len(stmt.Catches))
+	}
+	multi := stmt.Catches[0]
+	if len(multi.Types) != 2 || multi.Types[0].Name != "IllegalStateException" || multi.Types[1].Name != "ArithmeticException" {
+		t.Errorf("wrong multi-catch types. got=%v", multi.Types)
+	}
+	if multi.Parameter.Value != "e" {
+		t.Errorf("wrong catch parameter. got=%q", multi.Parameter.Value)
+	}
+	throw, ok := multi.Body.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("catch body is not ast.ThrowStatement. got=%T", multi.Body.Statements[0])
+	}
+	if throw.Value.String() != "new RuntimeException(e)" {
+		t.Errorf("wrong thrown value. got=%q", throw.Value.String())
+	}
+	if stmt.Finally == nil || len(stmt.Finally.Statements) != 1 {
+		t.Errorf("wrong finally block. got=%v", stmt.Finally)
+	}
+
+	program = New(lexer.New("try { a(); } finally { b(); }")).ParseProgram()
+	if stmt := program.Statements[0].(*ast.TryStatement); len(stmt.Catches) != 0 || stmt.Finally == nil {
+		t.Errorf("wrong try statement without catch. got=%q", stmt.String())
+	}
+}
+
+func TestTryWithResources(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try (Reader r = new Reader()) { r.read(); }", "try (Reader r = new Reader()) {r.read()}"},
+		{"try (A a = open(1); B b = a.child();) {}", "try (A a = open(1); B b = a.child()) {}"},
+		{"try (r; this.w) {} catch (Exception e) {}", "try (r; this.w) {} catch (Exception e) {}"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+		if actual := program.Statements[0].String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Box<T> { T value; }", "class Box<T> { T value; }"},
+		{"class Node<T extends Comparable<T>> extends Base<T> implements Iterable<T> {}",
+			"class Node<T extends Comparable<T>> extends Base<T> implements Iterable<T> { }"},
+		{"class Pair<A, B extends Number & Comparable<B>> {}", "class Pair<A, B extends Number & Comparable<B>> { }"},
+		{"public static <T extends Comparable<T>> T max(T a, T b) { return a; }",
+			"public static <T extends Comparable<T>> T max(T a, T b) return a;"},
+		{"class A { <T> A(T t) {} public <K, V> Map<K, V> map(K k, V v) { return null; } }",
+			"class A { <T> A(T t)  public <K, V> Map<K, V> map(K k, V v) return null; }"},
+		// Each '>' closes one list of type arguments.
+		{"class A { Map<String, List<List<String>>> index; }", "class A { Map<String, List<List<String>>> index; }"},
+		{"class A { List<? extends Number>[] xs; List<? super T> ys; List<?> zs; }",
+			"class A { List<? extends Number>[] xs; List<? super T> ys; List<?> zs; }"},
+		{"new Box<>(1);", "new Box<>(1)"},
+		{"new Box<Pair<String, int[]>>(x);", "new Box<Pair<String, int[]>>(x)"},
+		{"Collections.<String>empty();", "Collections.<String>empty()"},
+		{"try (Box<String> b = open()) {}", "try (Box<String> b = open()) {}"},
+		// Outside of types, '<' and '>' are still comparisons.
+		{"a < b == c > d;", "((a < b) == (c > d))"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestClassErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"a + b = c;", "unexpected type: required variable, found value"},
+		{"class A { void f(int... xs, int y) {} }", "varargs parameter must be the last parameter"},
+		{"class A { void f() throws {} }", "expected class name, found '{'"},
+		{"try (new A()) {}",
+			"the try-with-resources resource must either be a variable declaration or an expression denoting a reference to a final or effectively final variable"},
+		{"try (A a) {}", "expected '=', found ')'"},
+		{"try (A a = b {}", "expected ')', found '{'"},
+		{"class A { int x = 1 }", "expected ';', found '}'"},
+		{"class A { return 1; }", "expected a field, method or constructor declaration, found 'return'"},
+		{"class A { void x; }", "expected '(', found ';'"},
+		{"class A { int f() {", "reached end of file while parsing"},
+		{"new int();", "'int' is a reserved word and cannot be used as an identifier"},
+		{"a.class;", "'class' is a reserved word and cannot be used as an identifier"},
+		{"class A extends int {}", "expected class name, found 'int'"},
+		{"return super;", "expected '.', found ';'"},
+		{"try { a(); }", "'try' without 'catch', 'finally' or resource declarations"},
+		{"catch (Exception e) {}", "'catch' without 'try'"},
+		{"try {} catch (int e) {}", "expected class name, found 'int'"},
+		{"try {} catch (A | e) {}", "expected identifier, found ')'"},
+		{"throw;", "expected expression, found ';'"},
+		{"class A { List<int> xs; }", "unexpected type: required reference, found int"},
+		{"class A<T extends int> {}", "expected class name, found 'int'"},
+		{"class A { List<> xs; }", "expected type, found '>'"},
+		{"class A { Map<String, List<String> xs; }", "expected '>', found identifier 'xs'"},
+		{"class A { <T> int x; }", "expected '(', found ';'"},
+		{"class A<> {}", "expected identifier, found '>'"},
+		{"enum A { X Y }", "expected ',', '}', or ';', found identifier 'Y'"},
+		{"switch (n) { 1 -> f(); }", "expected 'case', 'default', or '}', found integer literal"},
+		{"switch (n) { case 1: f(); }", "expected '->', found illegal character"},
+		{"record P(int x) extends Q {}", "expected '{', found 'extends'"},
+		{"b = o instanceof var x;", "'var' is not allowed here"},
+		{"b = o instanceof Point(int x;", "expected ')', found ';'"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestClassMemberErrorRecovery(t *testing.T) {
+	input := `
+class A {
+	int x = ;
+	int y;
+	void f(int) {}
+	int g() { return y; }
+}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %q", len(p.Errors()), p.Errors())
+	}
+	class := program.Statements[0].(*ast.ClassDeclaration)
+	if len(class.Fields) != 1 || class.Fields[0].Name.Value != "y" {
+		t.Errorf("expected field y to be kept. got=%q", class.String())
+	}
+	if len(class.Methods) != 1 || class.Methods[0].Name.Value != "g" {
+		t.Errorf("expected method g to be kept. got=%q", class.String())
+	}
+}
+
+func TestClassExtends(t *testing.T) {
+	input := `class Dog extends Animal { Dog() { super("rex"); } }`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	class := program.Statements[0].(*ast.ClassDeclaration)
+	if class.SuperClass == nil || class.SuperClass.String() != "Animal" {
+		t.Fatalf("class.SuperClass is wrong. got=%v", class.SuperClass)
+	}
+	call := class.Constructors[0].Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	if _, ok := call.Function.(*ast.SuperExpression); !ok {
+		t.Errorf("call.Function is not ast.SuperExpression. got=%T", call.Function)
+	}
+}
+
+func TestInterfaceDeclaration(t *testing.T) {
+	input := `
+interface Shape extends Named, Sized {
+	int area();
+	default String describe() { return "shape"; }
+	static int zero() { return 0; }
+}
+
+abstract class Base extends Object implements Shape, Named {
+	abstract int side();
+	public String describe() { return Shape.super.describe(); }
+}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	shape := program.Statements[0].(*ast.ClassDeclaration)
+	if !shape.IsInterface() || shape.SuperClass != nil || len(shape.Interfaces) != 2 {
+		t.Fatalf("wrong interface header. got=%q", shape.String())
+	}
+	if shape.Methods[0].Body != nil {
+		t.Errorf("abstract interface method should have no body")
+	}
+	if !ast.HasModifier(shape.Methods[1].Modifiers, tokens.DEFAULT) {
+		t.Errorf("describe should be a default method")
+	}
+
+	base := program.Statements[1].(*ast.ClassDeclaration)
+	if base.IsInterface() || base.SuperClass.String() != "Object" || len(base.Interfaces) != 2 {
+		t.Fatalf("wrong class header. got=%q", base.String())
+	}
+	if base.Methods[0].Body != nil || !ast.HasModifier(base.Methods[0].Modifiers, tokens.ABSTRACT) {
+		t.Errorf("wrong abstract method. got=%q", base.Methods[0].String())
+	}
+
+	ret := base.Methods[1].Body.Statements[0].(*ast.ReturnStatement)
+	call := ret.ReturnValue.(*ast.CallExpression)
+	super := call.Function.(*ast.MemberExpression).Object.(*ast.SuperExpression)
+	if super.Qualifier == nil || super.String() != "Shape.super" {
+		t.Errorf("wrong qualified super. got=%q", super.String())
+	}
+}
+
+func TestEnumDeclaration(t *testing.T) {
+	input := `
+enum Op implements Named {
+	PLUS("+") { int apply(int a, int b) { return a + b; } },
+	TIMES("*") { int apply(int a, int b) { return a * b; } };
+	private final String symbol;
+	Op(String symbol) { this.symbol = symbol; }
+	abstract int apply(int a, int b);
+}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	op := program.Statements[0].(*ast.ClassDeclaration)
+	if !op.IsEnum() || op.SuperClass.String() != "Enum<Op>" || len(op.Interfaces) != 1 {
+		t.Fatalf("wrong enum header. got=%q", op.String())
+	}
+	if len(op.Fields) != 3 {
+		t.Fatalf("op.Fields does not contain 3 fields. got=%d", len(op.Fields))
+	}
+	for i, name := range []string{"PLUS", "TIMES"} {
+		constant, ok := op.Fields[i].Value.(*ast.EnumConstant)
+		if !ok {
+			t.Fatalf("op.Fields[%d].Value is not ast.EnumConstant. got=%T", i, op.Fields[i].Value)
+		}
+		if constant.Name.Value != name || constant.Ordinal != i || len(constant.Arguments) != 1 {
+			t.Errorf("wrong enum constant. got=%q", constant.String())
+		}
+		if constant.Body == nil || constant.Body.Name.Value != fmt.Sprintf("Op$%d", i+1) || constant.Body.SuperClass.Name != "Op" {
+			t.Errorf("wrong enum constant body. got=%q", constant.String())
+		}
+		if !ast.HasModifier(op.Fields[i].Modifiers, tokens.STATIC) || !ast.HasModifier(op.Fields[i].Modifiers, tokens.FINAL) {
+			t.Errorf("enum constant %s should be static final", name)
+		}
+	}
+
+	var methods []string
+	for _, m := range op.Methods {
+		methods = append(methods, m.Name.Value)
+	}
+	if strings.Join(methods, " ") != "apply values valueOf" {
+		t.Errorf("wrong enum methods. got=%q", methods)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Color { RED, GREEN }", "enum Color { RED, GREEN; }"},
+		{"enum Color { RED, GREEN, }", "enum Color { RED, GREEN; }"},
+		{"enum Empty { ; int x; }", "enum Empty { ; int x; }"},
+		{"enum Size { SMALL(1), LARGE(2); Size(int n) {} }", "enum Size { SMALL(1), LARGE(2); Size(int n)  }"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"switch (c) { case RED, GREEN -> f(); default -> {} }",
+			"switch (c) { case RED, GREEN -> f(); default -> {} }"},
+		{"x = switch (n) { case 1 -> \"one\"; case 2 -> { yield \"two\"; } default -> throw new E(); };",
+			"x = switch (n) { case 1 -> one; case 2 -> {yield two;} default -> throw new E(); }"},
+		{"f(switch (s) { case \"a\" -> 1 + 2; default -> 0; } * 2);",
+			"f((switch (s) { case a -> (1 + 2); default -> 0; } * 2))"},
+		{"x = switch (n) { case 1 -> { int yield = 2; yield yield; } default -> 0; };",
+			"x = switch (n) { case 1 -> {int yield = 2;yield yield;} default -> 0; }"},
+		{"yield = 1; yield.f(); yield++;", "yield = 1yield.f()yield++"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestRecordDeclaration(t *testing.T) {
+	input := `
+record Point(int x, int y) implements Shape {
+	Point {
+		if (x < 0) { throw new IllegalArgumentException(); }
+	}
+	public int x() { return x; }
+}`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	point := program.Statements[0].(*ast.ClassDeclaration)
+	if !point.IsRecord() || !point.IsFinal() || point.SuperClass.Name != "Record" || len(point.Components) != 2 {
+		t.Fatalf("wrong record header. got=%q", point.String())
+	}
+	for i, name := range []string{"x", "y"} {
+		f := point.Fields[i]
+		if f.Name.Value != name || !ast.HasModifier(f.Modifiers, tokens.PRIVATE) || !ast.HasModifier(f.Modifiers, tokens.FINAL) {
+			t.Errorf("wrong field for component %s. got=%q", name, f.String())
+		}
+	}
+
+	var methods []string
+	for _, m := range point.Methods {
+		methods = append(methods, m.Name.Value)
+	}
+	if strings.Join(methods, " ") != "x y" {
+		t.Errorf("wrong record methods. got=%q", methods)
+	}
+	if point.Methods[0].Token.Type == tokens.RECORD || point.Methods[1].Token.Type != tokens.RECORD {
+		t.Errorf("only the accessor y() should be implicit")
+	}
+
+	if len(point.Constructors) != 1 {
+		t.Fatalf("point.Constructors does not contain 1 constructor. got=%d", len(point.Constructors))
+	}
+	ctor := point.Constructors[0]
+	if !ctor.Compact || len(ctor.Parameters) != 2 || len(ctor.Body.Statements) != 3 {
+		t.Errorf("wrong compact constructor. got=%q", ctor.String())
+	}
+	if assign := ctor.Body.Statements[2].String(); assign != "this.y = y" {
+		t.Errorf("wrong field assignment. got=%q", assign)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"record Empty() {}", "record Empty() { }"},
+		{"record Pair<A, B>(A a, B b) { static int n; }", "record Pair<A, B>(A a, B b) { static int n; }"},
+		{"public sealed interface Shape permits Circle, Square {}", "public sealed interface Shape permits Circle, Square { }"},
+		{"non-sealed class Square extends Shape {}", "non-sealed class Square extends Shape { }"},
+		{"sealed abstract class Expr {}", "sealed abstract class Expr { }"},
+		{"int record = 1; sealed = 2; non - sealed;", "int record = 1;sealed = 2(non - sealed)"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"b = o instanceof String;", "b = (o instanceof String)"},
+		{"b = o instanceof final String s;", "b = (o instanceof final String s)"},
+		{"b = !(o instanceof Point(int x, var y)) == c;", "b = ((!(o instanceof Point(int x, var y))) == c)"},
+		{"b = o instanceof Line(Point(var x1, var y1), Point end);", "b = (o instanceof Line(Point(var x1, var y1), Point end))"},
+		{"switch (o) { case null -> f(); case Point(int x, int y) when x > y -> g(); case String s -> h(s); default -> {} }",
+			"switch (o) { case null -> f(); case Point(int x, int y) when (x > y) -> g(); case String s -> h(s); default -> {} }"},
+		{"x = switch (o) { case Integer i when i > when -> i; case RED -> 0; default -> 1; };",
+			"x = switch (o) { case Integer i when (i > when) -> i; case RED -> 0; default -> 1; }"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestNestedClasses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"class Outer { int x; class Inner { int f() { return Outer.this.x; } } static class Nested {} interface I {} enum E { A } record R(int a) {} }",
+			"class Outer { int x; class Inner { int f() return Outer.this.x; } static class Nested { } interface I { } enum E { A; } record R(int a) { } }"},
+		{"Outer.Inner i = o.new Inner(1);", "Outer.Inner i = o.new Inner(1);"},
+		{"x = a.b.new C().d;", "x = a.b.new C().d"},
+		{"Runnable r = new Runnable() { public void run() {} };", "Runnable r = new Runnable() { public void run()  };"},
+		{"Comparator<String> c = new Comparator<>() { public int compare(String a, String b) { return 0; } };",
+			"Comparator<String> c = new Comparator<>() { public int compare(String a, String b) return 0; };"},
+	}
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
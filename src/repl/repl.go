@@ -4,15 +4,20 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"java/diagnostics"
 	"java/evaluator"
 	"java/lexer"
+	"java/object"
 	"java/parser"
+	"java/typecheck"
 )
 
 const PROMPT = ">> "
 
 func Start(in io.Reader, out io.Writer) {
 	scanner := bufio.NewScanner(in)
+	env := object.NewEnvironment()
+	checker := typecheck.New()
 	for {
 		fmt.Fprintf(out, PROMPT)
 		scanned := scanner.Scan()
@@ -27,20 +32,24 @@ func Start(in io.Reader, out io.Writer) {
 		p := parser.New(l)
 		program := p.ParseProgram()
 
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+		if len(p.Diagnostics()) != 0 {
+			printParserErrors(out, p.Diagnostics(), diagnostics.NewSource("<stdin>", line))
+			continue
+		}
+		if diags := checker.Check(program); len(diags) != 0 {
+			printParserErrors(out, diags, diagnostics.NewSource("<stdin>", line))
 			continue
 		}
 
-		evaluated := evaluator.Eval(program)
+		evaluated := evaluator.Eval(program, env)
 		if evaluated != nil {
 			io.WriteString(out, evaluated.Inspect())
 			io.WriteString(out, "\n")
 		}
 	}
 }
-func printParserErrors(out io.Writer, errors []string) {
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+func printParserErrors(out io.Writer, diags []*diagnostics.Diagnostic, src *diagnostics.Source) {
+	for _, d := range diags {
+		io.WriteString(out, diagnostics.Render(d, src))
 	}
 }
@@ -9,6 +9,7 @@ const (
 	// Identifiers + literals
 	IDENT  = "IDENT" // add, foobar, x, y, ...
 	INT    = "INT"
+	DOUBLE = "DOUBLE"
 	STRING = "STRING"
 
 	// Operators
@@ -21,6 +22,13 @@ const (
 	DECREMENT = "--"
 	SLASH     = "/"
 	PERIOD    = "."
+	ELLIPSIS  = "..."
+	BAR       = "|"
+	AMPERSAND = "&"
+	QUESTION  = "?"
+	ARROW     = "->"
+
+	DOUBLE_COLON = "::"
 
 	LT = "<"
 	GT = ">"
@@ -41,54 +49,165 @@ const (
 	QUOTATION = "\""
 
 	// Keywords
-	TRUE    = "TRUE"
-	FALSE   = "FALSE"
-	IF      = "IF"
-	ELSE    = "ELSE"
-	ELSE_IF = "ELSE IF"
-	RETURN  = "RETURN"
-	CLASS   = "CLASS"
-	STATIC  = "STATIC"
+	TRUE         = "TRUE"
+	FALSE        = "FALSE"
+	NULL         = "NULL"
+	IF           = "IF"
+	ELSE         = "ELSE"
+	RETURN       = "RETURN"
+	CLASS        = "CLASS"
+	INTERFACE    = "INTERFACE"
+	ENUM         = "ENUM"
+	EXTENDS      = "EXTENDS"
+	IMPLEMENTS   = "IMPLEMENTS"
+	NEW          = "NEW"
+	THIS         = "THIS"
+	SUPER        = "SUPER"
+	INSTANCEOF   = "INSTANCEOF"
+	WHILE        = "WHILE"
+	DO           = "DO"
+	FOR          = "FOR"
+	BREAK        = "BREAK"
+	CONTINUE     = "CONTINUE"
+	SWITCH       = "SWITCH"
+	CASE         = "CASE"
+	DEFAULT      = "DEFAULT"
+	TRY          = "TRY"
+	CATCH        = "CATCH"
+	FINALLY      = "FINALLY"
+	THROW        = "THROW"
+	THROWS       = "THROWS"
+	ASSERT       = "ASSERT"
+	PACKAGE      = "PACKAGE"
+	IMPORT       = "IMPORT"
+	UNDERSCORE   = "_"
+	CONST        = "CONST" // reserved, but unused
+	GOTO         = "GOTO"  // reserved, but unused
+	STATIC       = "STATIC"
+	FINAL        = "FINAL"
+	ABSTRACT     = "ABSTRACT"
+	NATIVE       = "NATIVE"
+	SYNCHRONIZED = "SYNCHRONIZED"
+	TRANSIENT    = "TRANSIENT"
+	VOLATILE     = "VOLATILE"
+	STRICTFP     = "STRICTFP"
 
 	// Access modifiers
-	PUBLIC  = "PUBLIC"
-	PRIVATE = "PRIVATE"
+	PUBLIC    = "PUBLIC"
+	PRIVATE   = "PRIVATE"
+	PROTECTED = "PROTECTED"
 
 	// return type
 	VOID = "VOID"
 
+	// Contextual keywords, which the lexer gives as identifiers. The parser
+	// gives them these types where they have their special meaning.
+	RECORD     = "RECORD"
+	SEALED     = "SEALED"
+	NON_SEALED = "NON_SEALED"
+
 	// Data types
+	BYTE_DT      = "byte"
+	SHORT_DT     = "short"
 	INTEGER_DT   = "int"
-	STRING_DT    = "String"
+	LONG_DT      = "long"
+	FLOAT_DT     = "float"
+	DOUBLE_DT    = "double"
 	CHARACTER_DT = "char"
 	BOOLEAN_DT   = "boolean"
-
-	// External System library
-	SYSTEM  = "System"
-	OUT     = "OUT"
-	PRINTLN = "PRINTLN"
 )
 
 var keywords = map[string]TokenType{
-	"class":   CLASS,
-	"true":    TRUE,
-	"false":   FALSE,
-	"else":    ELSE,
-	"public":  PUBLIC,
-	"private": PRIVATE,
-	"static":  STATIC,
-	"void":    VOID,
-	"System":  SYSTEM,
-	"out":     OUT,
-	"println": PRINTLN,
-	"int":     INTEGER_DT,
-	"String":  STRING_DT,
-	"return":  RETURN,
-	"boolean": BOOLEAN_DT,
-	"if":      IF,
-	"else if": ELSE_IF,
+	"abstract":     ABSTRACT,
+	"assert":       ASSERT,
+	"boolean":      BOOLEAN_DT,
+	"break":        BREAK,
+	"byte":         BYTE_DT,
+	"case":         CASE,
+	"catch":        CATCH,
+	"char":         CHARACTER_DT,
+	"class":        CLASS,
+	"const":        CONST,
+	"continue":     CONTINUE,
+	"default":      DEFAULT,
+	"do":           DO,
+	"double":       DOUBLE_DT,
+	"else":         ELSE,
+	"enum":         ENUM,
+	"extends":      EXTENDS,
+	"final":        FINAL,
+	"finally":      FINALLY,
+	"float":        FLOAT_DT,
+	"for":          FOR,
+	"goto":         GOTO,
+	"if":           IF,
+	"implements":   IMPLEMENTS,
+	"import":       IMPORT,
+	"instanceof":   INSTANCEOF,
+	"int":          INTEGER_DT,
+	"interface":    INTERFACE,
+	"long":         LONG_DT,
+	"native":       NATIVE,
+	"new":          NEW,
+	"package":      PACKAGE,
+	"private":      PRIVATE,
+	"protected":    PROTECTED,
+	"public":       PUBLIC,
+	"return":       RETURN,
+	"short":        SHORT_DT,
+	"static":       STATIC,
+	"strictfp":     STRICTFP,
+	"super":        SUPER,
+	"switch":       SWITCH,
+	"synchronized": SYNCHRONIZED,
+	"this":         THIS,
+	"throw":        THROW,
+	"throws":       THROWS,
+	"transient":    TRANSIENT,
+	"try":          TRY,
+	"void":         VOID,
+	"volatile":     VOLATILE,
+	"while":        WHILE,
+	"_":            UNDERSCORE,
+
+	// Literals
+	"true":  TRUE,
+	"false": FALSE,
+	"null":  NULL,
+}
+
+// Contextual keywords are lexed as identifiers and only take on a special
+// meaning in particular places, so `int record = 1;` remains valid.
+var contextualKeywords = map[string]bool{
+	"var":     true,
+	"record":  true,
+	"yield":   true,
+	"sealed":  true,
+	"permits": true,
+	"when":    true,
 }
 
+// IsKeyword reports whether t is the type of a reserved word, i.e. a token
+// that may never be used as an identifier.
+func IsKeyword(t TokenType) bool {
+	_, ok := reserved[t]
+	return ok
+}
+
+// IsContextualKeyword reports whether the identifier s has a special
+// meaning in some contexts.
+func IsContextualKeyword(s string) bool {
+	return contextualKeywords[s]
+}
+
+var reserved = func() map[TokenType]string {
+	m := make(map[TokenType]string, len(keywords))
+	for literal, t := range keywords {
+		m[t] = literal
+	}
+	return m
+}()
+
 func LookupIdentifier(s string) TokenType {
 	if tok, ok := keywords[s]; ok {
 		return tok
@@ -99,4 +218,34 @@ func LookupIdentifier(s string) TokenType {
 type Token struct {
 	Type    TokenType
 	Literal string
+	Start   Position // position of the first character
+	End     Position // position just past the last character
+}
+
+// Position is a location in the source text.
+type Position struct {
+	Offset int `json:"offset"` // byte offset, starting at 0
+	Line   int `json:"line"`   // starting at 1
+	Column int `json:"column"` // in characters, starting at 1
+}
+
+var descriptions = map[TokenType]string{
+	ILLEGAL: "illegal character",
+	EOF:     "end of file",
+	IDENT:   "identifier",
+	INT:     "integer literal",
+	DOUBLE:  "floating-point literal",
+	STRING:  "string literal",
+}
+
+// Describe returns a human readable name for a token type, e.g. "')'" for
+// RPAREN, "'class'" for CLASS and "identifier" for IDENT.
+func Describe(t TokenType) string {
+	if d, ok := descriptions[t]; ok {
+		return d
+	}
+	if literal, ok := reserved[t]; ok {
+		return "'" + literal + "'"
+	}
+	return "'" + string(t) + "'"
 }
@@ -0,0 +1,77 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/tokens"
+)
+
+// checkEnumConstant checks the arguments of an enum constant, which it
+// passes to a constructor of its enum, the class being checked.
+func (c *Checker) checkEnumConstant(e *ast.EnumConstant) *Type {
+	args := c.checkArguments(e.Arguments)
+	var candidates []method
+	e.Signature, candidates = c.checkConstructorCall(e.Token, classType(c.class), e.Arguments, args)
+	c.checkFunctionArguments(e.Arguments, candidates)
+	return classType(c.class)
+}
+
+// checkEnumConstructor checks a constructor of an enum, which is private
+// whether it says so or not. Only the enum constants call it, and it never
+// calls the constructor of Enum itself.
+func (c *Checker) checkEnumConstructor(ctor *ast.ConstructorDeclaration) {
+	for _, m := range ctor.Modifiers {
+		if m.Type == tokens.PUBLIC || m.Type == tokens.PROTECTED {
+			c.errorf(ErrIllegalModifier, ctor.Name.Token, "modifier %s not allowed here", m.Literal)
+		}
+	}
+	if super, ok := explicitConstructorCall(ctor).(*ast.SuperExpression); ok {
+		c.errorf(ErrEnumConstructor, super.Token, "call to super not allowed in enum constructor")
+	}
+}
+
+// enumConstants returns the declarations of the constants of enum.
+func enumConstants(enum *Class) []*ast.EnumConstant {
+	var constants []*ast.EnumConstant
+	for _, f := range enum.Decl.Fields {
+		if constant, ok := f.Value.(*ast.EnumConstant); ok {
+			constants = append(constants, constant)
+		}
+	}
+	return constants
+}
+
+// constantBodies returns the class bodies of the constants of class, if
+// it is an enum.
+func constantBodies(class *Class) []*ast.ClassDeclaration {
+	var bodies []*ast.ClassDeclaration
+	for _, constant := range enumConstants(class) {
+		if constant.Body != nil {
+			bodies = append(bodies, constant.Body)
+		}
+	}
+	return bodies
+}
+
+// hasConstantBodies reports whether the enum has constants and all of
+// them have a class body, so that the enum may have abstract methods.
+func hasConstantBodies(enum *Class) bool {
+	n := len(enumConstants(enum))
+	return n > 0 && len(constantBodies(enum)) == n
+}
+
+// enumType returns the enum t is, or nil if it is not one.
+func enumType(t *Type) *Class {
+	if t == nil || t.Class == nil || t.Dimensions > 0 || !t.Class.Decl.IsEnum() {
+		return nil
+	}
+	return t.Class
+}
+
+// outermost returns the top level class that class is in, itself if it is
+// one.
+func outermost(class *Class) *Class {
+	for class != nil && class.enclosing() != nil {
+		class = class.enclosing()
+	}
+	return class
+}
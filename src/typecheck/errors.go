@@ -0,0 +1,62 @@
+package typecheck
+
+import (
+	"java/diagnostics"
+	"java/tokens"
+)
+
+// Diagnostic codes reported by the type checker.
+const (
+	ErrCannotFindSymbol      = "E0101"
+	ErrCyclicInheritance     = "E0102"
+	ErrBadSupertype          = "E0103"
+	ErrAbstractInstantiation = "E0104"
+	ErrMissingImplementation = "E0105"
+	ErrConflictingDefaults   = "E0106"
+	ErrMethodBody            = "E0107"
+	ErrDuplicateClass        = "E0108"
+	ErrIllegalModifier       = "E0109"
+	ErrStaticContext         = "E0110"
+	ErrPrivateAccess         = "E0111"
+	ErrWeakerAccess          = "E0112"
+	ErrAmbiguousCall         = "E0113"
+	ErrInapplicable          = "E0114"
+	ErrDuplicateMethod       = "E0115"
+	ErrIncompatibleTypes     = "E0116"
+	ErrMultiCatch            = "E0117"
+	ErrAlreadyCaught         = "E0118"
+	ErrUnreportedException   = "E0119"
+	ErrNeverThrown           = "E0120"
+	ErrOverriddenThrows      = "E0121"
+	ErrBadOperand            = "E0122"
+	ErrMissingReturn         = "E0123"
+	ErrDereference           = "E0124"
+	ErrVoidValue             = "E0125"
+	ErrCannotInfer           = "E0126"
+	ErrUnassigned            = "E0127"
+	ErrFinalAssignment       = "E0128"
+	ErrUnreachable           = "E0129"
+	ErrUnexpectedFunction    = "E0130"
+	ErrNotEffectivelyFinal   = "E0131"
+	ErrEnumConstructor       = "E0132"
+	ErrDuplicateLabel        = "E0133"
+	ErrNotExhaustive         = "E0134"
+	ErrBadYield              = "E0135"
+	ErrMissingYield          = "E0136"
+	ErrRecordMember          = "E0137"
+	ErrSealed                = "E0138"
+	ErrBadPattern            = "E0139"
+	ErrDominatedLabel        = "E0140"
+	ErrNotEnclosing          = "E0141"
+	ErrRecursiveConstructor  = "E0142"
+	ErrConstructorCall       = "E0143"
+	ErrBadOverride           = "E0144"
+	ErrStaticInterfaceCall   = "E0145"
+	ErrTypeArgumentBounds    = "E0146"
+)
+
+func (c *Checker) errorf(code string, tok tokens.Token, format string, a ...interface{}) *diagnostics.Diagnostic {
+	d := diagnostics.Errorf(code, diagnostics.TokenSpan(tok, ""), format, a...)
+	c.diagnostics = append(c.diagnostics, d)
+	return d
+}
@@ -0,0 +1,439 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/diagnostics"
+	"java/tokens"
+)
+
+// handler is a try statement with catch clauses, seen from its try block.
+type handler struct {
+	types  []*Class // the exceptions its catch clauses name
+	thrown []*Class // the exceptions thrown in the block that it may catch
+}
+
+func (c *Checker) throwableType() *Type {
+	return classType(c.classes["Throwable"])
+}
+
+// isChecked reports whether the exception class is a checked exception,
+// which is any Throwable that is not a RuntimeException or an Error.
+func (c *Checker) isChecked(class *Class) bool {
+	return !class.isSubtypeOf(c.classes["RuntimeException"]) && !class.isSubtypeOf(c.classes["Error"])
+}
+
+// catchesUnchecked reports whether class is a superclass of the unchecked
+// exceptions, so that a catch clause for it always may catch something.
+func (c *Checker) catchesUnchecked(class *Class) bool {
+	return c.classes["RuntimeException"].isSubtypeOf(class) || c.classes["Error"].isSubtypeOf(class)
+}
+
+// thrown records that the code at tok throws exception. It is an error if
+// the exception is checked and neither caught by an enclosing try
+// statement nor declared by the method. what is added to the message. It
+// returns the error reported, if any.
+func (c *Checker) thrown(tok tokens.Token, exception *Class, what string) *diagnostics.Diagnostic {
+	for i := len(c.handlers) - 1; i >= 0; i-- {
+		h := c.handlers[i]
+		caught, related := false, false
+		for _, t := range h.types {
+			caught = caught || exception.isSubtypeOf(t)
+			related = related || exception.isSubtypeOf(t) || t.isSubtypeOf(exception)
+		}
+		if related {
+			h.thrown = append(h.thrown, exception)
+		}
+		if caught {
+			return nil
+		}
+	}
+
+	if c.throwAny || !c.isChecked(exception) {
+		return nil
+	}
+	for _, t := range c.throws {
+		if exception.isSubtypeOf(t) {
+			return nil
+		}
+	}
+	return c.errorf(ErrUnreportedException, tok, "unreported exception %s%s; must be caught or declared to be thrown",
+		exception.Name, what)
+}
+
+// checkCallThrows records the exceptions that a call of m at tok throws.
+func (c *Checker) checkCallThrows(tok tokens.Token, m method, what string) {
+	for _, t := range m.throws {
+		if class := c.classOf(t); class != nil {
+			c.thrown(tok, class, what)
+		}
+	}
+}
+
+// checkThrowsClause checks that the types in a throws clause are
+// exceptions, and returns them.
+func (c *Checker) checkThrowsClause(types []*ast.Type) []*Class {
+	var classes []*Class
+	for _, t := range types {
+		if class := c.checkExceptionType(t); class != nil {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// initializerThrows returns the checked exceptions the instance
+// initializers of class may throw: those declared by every constructor.
+// A class without constructors may not throw any.
+func (c *Checker) initializerThrows(class *Class) []*Class {
+	ctors := class.Decl.Constructors
+	if len(ctors) == 0 {
+		return nil
+	}
+	var result []*Class
+	for _, t := range ctors[0].Throws {
+		exception := c.classOf(t)
+		if exception == nil {
+			continue
+		}
+		common := true
+		for _, ctor := range ctors[1:] {
+			declared := false
+			for _, other := range ctor.Throws {
+				if super := c.classOf(other); super != nil && exception.isSubtypeOf(super) {
+					declared = true
+				}
+			}
+			common = common && declared
+		}
+		if common {
+			result = append(result, exception)
+		}
+	}
+	return result
+}
+
+// checkImplicitSuperCall checks the call of the superclass constructor
+// without arguments that a constructor of class without this(...) or
+// super(...) implicitly begins with, and records the exceptions it throws.
+func (c *Checker) checkImplicitSuperCall(class *Class, tok tokens.Token, what string) {
+	if class.Super == nil {
+		return
+	}
+	if len(withArity(constructorsOf(class.Super), 0)) == 0 {
+		c.errorf(ErrInapplicable, tok, "constructor %s in class %s cannot be applied to given types", class.Super.Name, class.Super.Name)
+		return
+	}
+	for _, ctor := range constructorsOf(class.Super) {
+		if len(ctor.params) == 0 {
+			c.checkCallThrows(tok, ctor, what)
+		}
+	}
+}
+
+// explicitConstructorCall returns the this or super of the this(...) or
+// super(...) the body of ctor begins with, or nil if it does not.
+func explicitConstructorCall(ctor *ast.ConstructorDeclaration) ast.Expression {
+	if call := explicitCall(ctor); call != nil {
+		return call.Function
+	}
+	return nil
+}
+
+// explicitCall returns the this(...) or super(...) call the body of ctor
+// begins with, or nil if it does not.
+func explicitCall(ctor *ast.ConstructorDeclaration) *ast.CallExpression {
+	if len(ctor.Body.Statements) == 0 {
+		return nil
+	}
+	stmt, ok := ctor.Body.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		return nil
+	}
+	switch call.Function.(type) {
+	case *ast.ThisExpression, *ast.SuperExpression:
+		return call
+	}
+	return nil
+}
+
+// checkOverrideThrows reports a method that declares a checked exception
+// that a method it overrides does not.
+func (c *Checker) checkOverrideThrows(class *Class, m *ast.FunctionLiteral) {
+	if isStatic(m.Modifiers) {
+		return
+	}
+	sig := signature(m)
+	for _, t := range supertypes(class)[1:] {
+		for _, sm := range t.Decl.Methods {
+			if signatureIn(class, t, sm) != sig || isStatic(sm.Modifiers) || ast.HasModifier(sm.Modifiers, tokens.PRIVATE) {
+				continue
+			}
+			for _, thrown := range m.Throws {
+				exception := c.classOf(thrown)
+				if exception == nil || !c.isChecked(exception) || c.declares(sm, exception) {
+					continue
+				}
+				c.errorf(ErrOverriddenThrows, m.Name.Token,
+					"%s in %s cannot override %s in %s; overridden method does not throw %s",
+					sig, class.Name, signature(sm), t.Name, exception.Name)
+				return
+			}
+		}
+	}
+}
+
+// declares reports whether the throws clause of m covers exception.
+func (c *Checker) declares(m *ast.FunctionLiteral, exception *Class) bool {
+	for _, t := range m.Throws {
+		if class := c.classOf(t); class != nil && exception.isSubtypeOf(class) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkThrow checks a throw statement. Rethrowing the parameter of a catch
+// clause throws only the exceptions the clause may have caught.
+func (c *Checker) checkThrow(s *ast.ThrowStatement) {
+	t := c.checkExpression(s.Value)
+	if t == nil || t.Name == "<null>" {
+		return
+	}
+	if !isSubtype(t, c.throwableType()) {
+		c.errorf(ErrIncompatibleTypes, tokenOf(s.Value), "incompatible types: %s cannot be converted to Throwable", t)
+		return
+	}
+	if caught, ok := c.caught[t]; ok {
+		for _, exception := range caught {
+			c.thrown(s.Token, exception, "")
+		}
+		return
+	}
+	if t.Class != nil {
+		c.thrown(s.Token, t.Class, "")
+	}
+}
+
+// checkTry checks a try statement. Each catch clause must name subclasses
+// of Throwable that are not caught by an earlier clause, and the
+// alternatives of a multi-catch clause must be unrelated. A clause may
+// only catch a checked exception that the try block can throw.
+func (c *Checker) checkTry(s *ast.TryStatement) {
+	var caught []*Class
+	clauses := make([][]*Class, len(s.Catches))
+	for i, clause := range s.Catches {
+		var alternatives []*Class
+		for _, t := range clause.Types {
+			class := c.checkExceptionType(t)
+			if class == nil {
+				continue
+			}
+			for _, other := range caught {
+				if class.isSubtypeOf(other) {
+					c.errorf(ErrAlreadyCaught, t.Token, "exception %s has already been caught", class.Name)
+					break
+				}
+			}
+			for _, other := range alternatives {
+				sub, super := class, other
+				if other.isSubtypeOf(class) {
+					sub, super = other, class
+				} else if !class.isSubtypeOf(other) {
+					continue
+				}
+				c.errorf(ErrMultiCatch, t.Token, "Alternatives in a multi-catch statement cannot be related by subclassing").
+					WithNote("Alternative %s is a subclass of alternative %s", sub.Name, super.Name)
+			}
+			alternatives = append(alternatives, class)
+		}
+		caught = append(caught, alternatives...)
+		clauses[i] = alternatives
+	}
+
+	h := &handler{types: caught}
+	c.handlers = append(c.handlers, h)
+	before := c.flow.copy()
+	assigned := map[*ast.Identifier]bool{}
+	c.assignments = append(c.assignments, assigned)
+	outer := c.scope
+	c.scope = newScope(outer)
+	for _, r := range s.Resources {
+		c.checkResource(r)
+	}
+	c.checkStatement(s.Block)
+	c.scope = outer
+	c.handlers = c.handlers[:len(c.handlers)-1]
+
+	// A catch clause may run after any part of the block, so the variables
+	// the block assigns are neither definitely assigned nor definitely
+	// unassigned in it.
+	after := c.flow
+	inBlock := make([]*ast.Identifier, 0, len(assigned))
+	for v := range assigned {
+		inBlock = append(inBlock, v)
+	}
+
+	for i, clause := range s.Catches {
+		for j, class := range clauses[i] {
+			if c.isChecked(class) && !c.catchesUnchecked(class) && !mayBeThrown(h.thrown, class) {
+				c.errorf(ErrNeverThrown, clause.Types[j].Token,
+					"exception %s is never thrown in body of corresponding try statement", class.Name)
+			}
+		}
+
+		outer := c.scope
+		c.scope = newScope(outer)
+		var t *Type
+		if len(clauses[i]) > 0 {
+			t = classType(commonSuperclass(clauses[i]))
+			c.caught[t] = rethrown(h.thrown, clauses[i])
+		}
+		v := c.scope.declare(clause.Parameter, t)
+		if len(clause.Types) > 1 {
+			v.final, v.kind = true, "multi-catch parameter"
+		}
+		c.flow = before.copy()
+		for _, name := range inBlock {
+			c.flow.assigned[name] = true
+		}
+		c.checkStatement(clause.Body)
+		after.join(c.flow)
+		c.scope = outer
+	}
+	c.assignments = c.assignments[:len(c.assignments)-1]
+
+	// The finally block, which may run after any part of the block and the
+	// catch clauses, assigns the variables it assigns after all of them.
+	if s.Finally != nil {
+		c.flow = before.copy()
+		for name := range assigned {
+			c.flow.assigned[name] = true
+		}
+		c.checkStatement(s.Finally)
+		for name := range after.unassigned {
+			if !c.flow.unassigned[name] {
+				delete(after.unassigned, name)
+			}
+		}
+		for name := range c.flow.assigned {
+			after.assigned[name] = true
+		}
+	}
+	c.flow = after
+}
+
+// checkResource checks a resource of a try-with-resources statement,
+// which must be AutoCloseable, and declares its variable. The exceptions
+// its close method throws are thrown by the statement.
+func (c *Checker) checkResource(r *ast.Resource) {
+	if r.Type != nil {
+		// The variable is in scope in its own initializer.
+		c.scope.declare(r.Name, nil)
+	}
+	t := c.checkExpression(r.Value)
+	tok := tokenOf(r.Value)
+	if r.Type != nil {
+		declared := c.typeOf(r.Type)
+		if declared.Class == nil && !isPrimitive(r.Type.Name) && !isLibraryType(r.Type.Name) {
+			c.lookupClass(r.Type)
+			declared = nil
+		} else if t != nil && !isConvertible(t, declared) {
+			c.errorf(ErrIncompatibleTypes, tok, "incompatible types: %s cannot be converted to %s", t, declared)
+		}
+		v := c.scope.declare(r.Name, declared)
+		v.final, v.kind = true, "auto-closeable resource"
+		t, tok = declared, r.Name.Token
+	}
+	if t == nil {
+		return
+	}
+
+	closeable := classType(c.classes["AutoCloseable"])
+	if !isSubtype(t, closeable) {
+		c.errorf(ErrIncompatibleTypes, tok, "incompatible types: try-with-resources not applicable to variable type").
+			WithNote("%s cannot be converted to AutoCloseable", t)
+		return
+	}
+	for _, m := range withArity(findMethods(t.Class, "close"), 0) {
+		for _, thrown := range m.throws {
+			exception := c.classOf(thrown)
+			if exception == nil {
+				continue
+			}
+			if d := c.thrown(tok, exception, ""); d != nil {
+				d.WithNote("exception thrown from implicit call to close() on resource variable %s", tok.Literal)
+			}
+		}
+		break
+	}
+}
+
+// mayBeThrown reports whether one of the exceptions thrown may be an
+// instance of class.
+func mayBeThrown(thrown []*Class, class *Class) bool {
+	for _, t := range thrown {
+		if t.isSubtypeOf(class) || class.isSubtypeOf(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// rethrown returns the exceptions that rethrowing the parameter of a catch
+// clause for types throws: those thrown in the try block that the clause
+// catches, or the types themselves for exceptions that may or may not be
+// instances of them.
+func rethrown(thrown []*Class, types []*Class) []*Class {
+	var result []*Class
+	for _, exception := range thrown {
+		for _, t := range types {
+			switch {
+			case exception.isSubtypeOf(t):
+				result = append(result, exception)
+			case t.isSubtypeOf(exception):
+				result = append(result, t)
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return result
+}
+
+// checkExceptionType returns the class named by the type t of a catch or
+// throws clause, or nil if there is none or it is not a Throwable.
+func (c *Checker) checkExceptionType(t *ast.Type) *Class {
+	if isLibraryType(t.Name) || t.Dimensions > 0 || isPrimitive(t.Name) {
+		c.errorf(ErrIncompatibleTypes, t.Token, "incompatible types: %s cannot be converted to Throwable", t)
+		return nil
+	}
+	class := c.lookupClass(t)
+	if class == nil {
+		return nil
+	}
+	if !class.isSubtypeOf(c.classes["Throwable"]) {
+		c.errorf(ErrIncompatibleTypes, t.Token, "incompatible types: %s cannot be converted to Throwable", class.Name)
+		return nil
+	}
+	return class
+}
+
+// commonSuperclass is the most specific class all of classes extend, the
+// type of the parameter of a multi-catch clause.
+func commonSuperclass(classes []*Class) *Class {
+	for super := classes[0]; super != nil; super = super.Super {
+		common := true
+		for _, class := range classes {
+			common = common && class.isSubtypeOf(super)
+		}
+		if common {
+			return super
+		}
+	}
+	return nil
+}
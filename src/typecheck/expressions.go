@@ -0,0 +1,1355 @@
+package typecheck
+
+import (
+	"fmt"
+	"java/ast"
+	"java/diagnostics"
+	"java/tokens"
+	"strings"
+)
+
+// scope holds the local variables and parameters visible in a block, and
+// the local classes declared there. The scope of the parameters of a
+// lambda expression is marked, as the lambda captures the variables of the
+// scopes outside it, and so is the scope of the body of a class, where its
+// members are in scope and shadow the variables outside it.
+type scope struct {
+	vars    map[string]*variable
+	classes map[string]*Class
+	outer   *scope
+	lambda  bool
+	class   *Class
+}
+
+// variable is a local variable or parameter.
+type variable struct {
+	name  *ast.Identifier // where it is declared
+	typ   *Type
+	final bool
+	blank bool // final, but declared without an initializer
+	// kind is what javac calls a variable that may never be assigned,
+	// such as "final parameter", and empty for other variables.
+	kind string
+
+	// A variable declared without an initializer is uninitialized. It is
+	// reassigned once it is assigned where it may already have a value,
+	// which makes it not effectively final. captures are the uses of it
+	// by lambda expressions and inner classes so far.
+	uninitialized bool
+	reassigned    bool
+	captures      []capture
+}
+
+// capture is a use at tok of a local variable declared outside of the
+// lambda expression or inner class it is in, which by describes.
+type capture struct {
+	tok tokens.Token
+	by  string
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{vars: make(map[string]*variable), outer: outer}
+}
+
+func (s *scope) declare(name *ast.Identifier, t *Type) *variable {
+	v := &variable{name: name, typ: t}
+	s.vars[name.Value] = v
+	return v
+}
+
+// lookup finds the variable name, or returns nil if it is not in scope
+// or a field of a class the scope is in shadows it.
+func (s *scope) lookup(name string) *variable {
+	for ; s != nil; s = s.outer {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+		if s.class != nil {
+			if field, _ := findField(s.class, name); field != nil {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkStatement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		c.checkExpression(s.Expression)
+	case *ast.BlockStatement:
+		outer := c.scope
+		c.scope = newScope(outer)
+		c.checkStatements(s.Statements)
+		c.scope = outer
+	case *ast.IfStatement:
+		s.Condition = c.checkAssignedValue(s.Condition, booleanType)
+		whenTrue, whenFalse := bindings(s.Condition, true), bindings(s.Condition, false)
+		before := c.flow.copy()
+		c.checkBound(s.Consequence, whenTrue)
+		after := c.flow
+		c.flow = before
+		if s.Alternative != nil {
+			c.checkBound(s.Alternative, whenFalse)
+		}
+		c.flow.join(after)
+		// The variables of the patterns that match where one branch cannot
+		// complete normally are in scope after the if statement.
+		consequence := canCompleteNormally(s.Consequence)
+		alternative := s.Alternative == nil || canCompleteNormally(s.Alternative)
+		if !consequence && alternative {
+			c.declareBindings(whenFalse)
+		} else if consequence && !alternative {
+			c.declareBindings(whenTrue)
+		}
+	case *ast.ReturnStatement:
+		c.checkReturn(s)
+		if c.initializer && !c.static {
+			c.checkFieldsAssigned(s.Token)
+		}
+		c.flow.unreachable()
+	case *ast.ClassDeclaration:
+		c.checkLocalClass(s)
+	case *ast.VariableDeclaration:
+		c.checkDeclaration(s)
+	case *ast.IncrementStatement:
+		c.checkStep(s.Token, s.Operand)
+	case *ast.DecrementStatement:
+		c.checkStep(s.Token, s.Operand)
+	case *ast.ThrowStatement:
+		c.checkThrow(s)
+		c.flow.unreachable()
+	case *ast.TryStatement:
+		c.checkTry(s)
+	case *ast.SwitchStatement:
+		c.checkSwitchStatement(s)
+	case *ast.YieldStatement:
+		c.checkYield(s)
+		c.flow.unreachable()
+	}
+}
+
+// checkDeclaration checks a declaration of local variables and declares
+// them. Each variable is in scope in its own initializer.
+func (c *Checker) checkDeclaration(s *ast.VariableDeclaration) {
+	final := ast.HasModifier(s.Modifiers, tokens.FINAL)
+	if isInferred(s.Type) {
+		c.checkInferredDeclaration(s.Declarators[0], final)
+		return
+	}
+	t := c.typeOf(s.Type)
+	if !isKnown(t) {
+		c.lookupClass(s.Type)
+		t = nil
+	} else {
+		c.checkTypeArguments(s.Type, c.typeParams)
+	}
+	for _, d := range s.Declarators {
+		c.declareLocal(d, t, final)
+		if d.Value != nil {
+			d.Value = c.checkAssignedValue(d.Value, t)
+			c.flow.assign(d.Name)
+		}
+	}
+}
+
+// declareLocal declares the variable of d, which is unassigned until its
+// initializer, if any, has been checked. The variables declared outside of
+// any class without an initializer start with the default value of their
+// type, as in jshell.
+func (c *Checker) declareLocal(d *ast.Declarator, t *Type, final bool) *variable {
+	v := c.scope.declare(d.Name, t)
+	v.final, v.blank, v.uninitialized = final, final && d.Value == nil, d.Value == nil
+	if c.scope != c.globals || d.Value != nil {
+		c.flow.unassigned[d.Name] = true
+	}
+	return v
+}
+
+// checkInferredDeclaration declares a `var` variable with the type of its
+// initializer, which must have one.
+func (c *Checker) checkInferredDeclaration(d *ast.Declarator, final bool) {
+	v := c.declareLocal(d, nil, final)
+	if d.Value == nil {
+		c.errorf(ErrCannotInfer, d.Name.Token, "cannot infer type for local variable %s", d.Name.Value).
+			WithNote("cannot use 'var' on variable without initializer")
+		delete(c.flow.unassigned, d.Name)
+		return
+	}
+	if isFunction(d.Value) {
+		what := "lambda expression"
+		if _, ok := d.Value.(*ast.MethodReference); ok {
+			what = "method reference"
+		}
+		c.errorf(ErrCannotInfer, d.Name.Token, "cannot infer type for local variable %s", d.Name.Value).
+			WithNote("%s needs an explicit target-type", what)
+		c.checkAssignedValue(d.Value, nil)
+		c.flow.assign(d.Name)
+		return
+	}
+	t := c.checkExpression(d.Value)
+	switch {
+	case t == nullType:
+		c.errorf(ErrCannotInfer, d.Name.Token, "cannot infer type for local variable %s", d.Name.Value).
+			WithNote("variable initializer is 'null'")
+		t = nil
+	case isVoid(t):
+		c.errorf(ErrCannotInfer, d.Name.Token, "cannot infer type for local variable %s", d.Name.Value).
+			WithNote("variable initializer is 'void'")
+		t = nil
+	}
+	v.typ = t
+	c.flow.assign(d.Name)
+}
+
+// checkReturn checks that a return statement returns a value exactly when
+// the method it is in has a result, and that the value can be assigned to
+// the result type.
+func (c *Checker) checkReturn(s *ast.ReturnStatement) {
+	if c.yield != nil {
+		c.errorf(ErrBadYield, s.Token, "attempt to return out of a switch expression")
+		if s.ReturnValue != nil {
+			c.checkExpression(s.ReturnValue)
+		}
+		return
+	}
+	if s.ReturnValue == nil {
+		if c.result != nil && !isVoid(c.result) {
+			c.errorf(ErrIncompatibleTypes, s.Token, "incompatible types: missing return value")
+		}
+		return
+	}
+	if isVoid(c.result) {
+		c.checkExpression(s.ReturnValue)
+		c.errorf(ErrIncompatibleTypes, tokenOf(s.ReturnValue), "incompatible types: unexpected return value")
+		return
+	}
+	if c.lambda {
+		c.checkLambdaResult(s.ReturnValue)
+		return
+	}
+	s.ReturnValue = c.checkAssignedValue(s.ReturnValue, c.result)
+}
+
+// checkStep checks the operand of ++ or --, which must be a numeric
+// variable.
+func (c *Checker) checkStep(op tokens.Token, operand *ast.Identifier) {
+	t := c.checkName(operand)
+	if isKnown(t) && numericType(t, t) == nil {
+		c.errorf(ErrBadOperand, op, "bad operand type %s for unary operator '%s'", t, op.Literal)
+		return
+	}
+	c.checkTarget(operand)
+}
+
+// checkAssignable reports a value of type t, computed by e, that cannot be
+// assigned to a variable of type target, in the words javac uses.
+func (c *Checker) checkAssignable(e ast.Expression, t, target *Type) {
+	if problem := assignmentProblem(e, t, target); problem != "" {
+		c.errorf(ErrIncompatibleTypes, tokenOf(e), "incompatible types: %s", problem)
+	}
+}
+
+// assignmentProblem explains why a value of type t, computed by e, cannot
+// be assigned to a variable of type target, or returns "" if it can.
+func assignmentProblem(e ast.Expression, t, target *Type) string {
+	if !isKnown(t) || !isKnown(target) || (!isVoid(t) && isConvertible(t, target)) {
+		return ""
+	}
+	if t.Name == "int" && isConstant(e) && isNarrowable(target) {
+		return ""
+	}
+	if t.isNumeric() && target.isNumeric() {
+		return fmt.Sprintf("possible lossy conversion from %s to %s", t, target)
+	}
+	return fmt.Sprintf("%s cannot be converted to %s", t, target)
+}
+
+// unboxed is e, whose value of type t is converted to the primitive type
+// target, with the unboxing that takes made explicit: a call of the
+// intValue() method of an Integer, or the like method of another box
+// class, as JLS 5.1.8 defines it. The evaluator then throws a
+// NullPointerException if the value is null.
+func unboxed(e ast.Expression, t, target *Type) ast.Expression {
+	if t == nil || target == nil || !target.isPrimitive() {
+		return e
+	}
+	primitive := unbox(t)
+	if primitive == nil {
+		return e
+	}
+	tok := tokenOf(e)
+	return &ast.CallExpression{
+		Token: tok,
+		Function: &ast.MemberExpression{
+			Token:    tok,
+			Object:   e,
+			Property: &ast.Identifier{Token: tok, Value: primitive.Name + "Value"},
+			Receiver: qualifiedName(t),
+		},
+		Signature: "()",
+		Receiver:  qualifiedName(t),
+	}
+}
+
+// isConstant reports whether e is an integer constant, which may be
+// assigned to a byte, short or char variable if it fits.
+func isConstant(e ast.Expression) bool {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		return true
+	case *ast.PrefixExpression:
+		return e.Operator == "-" && isConstant(e.Right)
+	}
+	return false
+}
+
+func isNarrowable(t *Type) bool {
+	return t.Dimensions == 0 && (t.Name == "byte" || t.Name == "short" || t.Name == "char")
+}
+
+// checkExpression checks e and returns its static type, or nil if the
+// type is not known.
+func (c *Checker) checkExpression(e ast.Expression) *Type {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		return intType
+	case *ast.DoubleLiteral:
+		return doubleType
+	case *ast.StringLiteral:
+		return stringType
+	case *ast.Boolean:
+		return booleanType
+	case *ast.NullLiteral:
+		return nullType
+	case *ast.Identifier:
+		return c.checkName(e)
+	case *ast.ThisExpression:
+		return c.checkThis(e)
+	case *ast.SuperExpression:
+		return c.checkSuper(e)
+	case *ast.PrefixExpression:
+		return c.checkPrefix(e)
+	case *ast.InfixExpression:
+		return c.checkInfix(e)
+	case *ast.AssignmentExpression:
+		if _, ok := e.Target.(*ast.IndexExpression); ok {
+			t := c.checkExpression(e.Target)
+			e.Value = c.checkAssignedValue(e.Value, t)
+			return t
+		}
+		if isFunction(e.Value) {
+			t := c.checkTarget(e.Target)
+			c.checkAssignedValue(e.Value, t)
+			return t
+		}
+		// The variable is assigned after the value is computed.
+		v := c.checkExpression(e.Value)
+		t := c.checkTarget(e.Target)
+		c.checkAssignable(e.Value, v, t)
+		e.Value = unboxed(e.Value, v, t)
+		return t
+	case *ast.MemberExpression:
+		return c.checkFieldAccess(e)
+	case *ast.IndexExpression:
+		t := c.checkValue(e.Left)
+		index := c.checkValue(e.Index)
+		c.checkAssignable(e.Index, index, intType)
+		e.Index = unboxed(e.Index, index, intType)
+		if isKnown(t) && t.Dimensions == 0 {
+			c.errorf(ErrDereference, tokenOf(e.Left), "array required, but %s found", t)
+		} else if t != nil && t.Dimensions > 0 {
+			e.Array = t.String()
+			return t.element()
+		}
+	case *ast.CallExpression:
+		return c.checkCall(e)
+	case *ast.FunctionLiteral:
+		restore := c.save()
+		c.enter(nil, true, e.TypeParameters, e.Parameters)
+		c.result = c.typeOf(e.ReturnType)
+		c.throws = c.checkThrowsClause(e.Throws)
+		if e.Body != nil {
+			c.checkStatement(e.Body)
+			c.checkMissingReturn(e)
+		}
+		restore()
+	case *ast.NewExpression:
+		return c.checkNewExpression(e, nil)
+	case *ast.LambdaExpression:
+		c.errorf(ErrUnexpectedFunction, e.Token, "lambda expression not expected here")
+		c.checkLambda(e, nil)
+	case *ast.MethodReference:
+		c.errorf(ErrUnexpectedFunction, tokenOf(e), "method reference not expected here")
+		c.checkMethodReference(e, nil)
+	case *ast.SwitchExpression:
+		return c.checkSwitchExpression(e, nil)
+	case *ast.EnumConstant:
+		return c.checkEnumConstant(e)
+	case *ast.InstanceofExpression:
+		return c.checkInstanceof(e)
+	}
+	return nil
+}
+
+// checkValue checks an expression whose value is used, as an operand or
+// an argument, where a call of a void method is not allowed.
+func (c *Checker) checkValue(e ast.Expression) *Type {
+	t := c.checkExpression(e)
+	if isVoid(t) {
+		c.errorf(ErrVoidValue, tokenOf(e), "'void' type not allowed here")
+		return nil
+	}
+	return t
+}
+
+func (c *Checker) checkPrefix(e *ast.PrefixExpression) *Type {
+	t := c.checkValue(e.Right)
+	var result *Type
+	switch e.Operator {
+	case "!":
+		if isBoolean(t) {
+			result = booleanType
+		}
+	case "-":
+		result = numericType(t, t)
+	default:
+		return nil
+	}
+	if result == nil && isKnown(t) {
+		c.errorf(ErrBadOperand, e.Token, "bad operand type %s for unary operator '%s'", t, e.Operator)
+	}
+	e.Right = unboxed(e.Right, t, result)
+	if e.Operator == "!" {
+		return booleanType
+	}
+	return result
+}
+
+func (c *Checker) checkInfix(e *ast.InfixExpression) *Type {
+	l, r := c.checkValue(e.Left), c.checkValue(e.Right)
+	var result *Type
+	switch e.Operator {
+	case "+":
+		if isString(l) || isString(r) {
+			return stringType
+		}
+		result = numericType(l, r)
+	case "-", "*", "/":
+		result = numericType(l, r)
+	case "<", ">":
+		if numericType(l, r) != nil {
+			result = booleanType
+		}
+	case "==", "!=":
+		if numericType(l, r) != nil || isBoolean(l) && isBoolean(r) {
+			result = booleanType
+		} else if isKnown(l) && isKnown(r) && !l.isPrimitive() && !r.isPrimitive() {
+			if !isCastable(l, r) {
+				c.errorf(ErrIncompatibleTypes, e.Token, "incomparable types: %s and %s", l, r)
+			}
+			return booleanType
+		}
+	default:
+		return nil
+	}
+	if result == nil && isKnown(l) && isKnown(r) {
+		c.errorf(ErrBadOperand, e.Token, "bad operand types for binary operator '%s'", e.Operator).
+			WithNote("first type:  %s", l).
+			WithNote("second type: %s", r)
+	}
+	// The operands are unboxed, unless == compares two references.
+	if result != nil && (l.isPrimitive() || r.isPrimitive() || e.Operator != "==" && e.Operator != "!=") {
+		e.Left, e.Right = unboxed(e.Left, l, result), unboxed(e.Right, r, result)
+	}
+	switch e.Operator {
+	case "<", ">", "==", "!=":
+		return booleanType
+	}
+	return result
+}
+
+func isString(t *Type) bool {
+	return t != nil && t.String() == "String"
+}
+
+// checkName checks a read of a variable referenced by its simple name,
+// which must have been assigned a value.
+func (c *Checker) checkName(name *ast.Identifier) *Type {
+	t, decl := c.checkVariable(name)
+	if decl != nil {
+		c.checkRead(name.Token, decl)
+	}
+	if v := c.scope.lookup(name.Value); v != nil {
+		c.checkCapture(name.Token, v, false)
+	}
+	return t
+}
+
+// checkTarget checks the variable an assignment, ++ or -- assigns, a
+// simple name or a field access, and returns its type.
+func (c *Checker) checkTarget(e ast.Expression) *Type {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		t, decl := c.checkVariable(e)
+		if v := c.scope.lookup(e.Value); v != nil {
+			if c.captures(v) != "" {
+				c.checkCapture(e.Token, v, true)
+			} else {
+				c.checkAssign(e.Token, v)
+			}
+		} else if decl != nil {
+			field, owner, _ := c.findVariable(e.Value)
+			c.checkFieldAssign(e.Token, field, owner, true)
+		}
+		return t
+	case *ast.MemberExpression:
+		t, field, owner := c.fieldAccess(e)
+		if field != nil {
+			c.checkFieldAssign(e.Property.Token, field, owner, isThis(e.Object))
+		}
+		return t
+	}
+	return c.checkExpression(e)
+}
+
+// checkVariable checks a variable referenced by its simple name: a local
+// variable or parameter, or else a field of the classes the code is in. It
+// returns the type of the variable and the name in its declaration.
+func (c *Checker) checkVariable(name *ast.Identifier) (*Type, *ast.Identifier) {
+	if v := c.scope.lookup(name.Value); v != nil {
+		return v.typ, v.name
+	}
+	field, owner, static := c.findVariable(name.Value)
+	if field == nil {
+		d := c.errorf(ErrCannotFindSymbol, name.Token, "cannot find symbol: variable %s", name.Value)
+		if c.class != nil {
+			d.WithNote("location: %s %s", c.class.kind(), c.class.Name)
+		}
+		return nil, nil
+	}
+	if static && !isStaticField(owner, field) {
+		c.errorf(ErrStaticContext, name.Token,
+			"non-static variable %s cannot be referenced from a static context", name.Value)
+		return nil, nil
+	}
+	c.checkAccess(name.Token, field.Modifiers, owner, name.Value)
+	if owner != c.class {
+		bound := c.bindings(classType(c.class), owner)
+		return c.memberType(field.Type, typeParameters(owner, false, nil), bound), field.Name
+	}
+	return c.typeOf(field.Type), field.Name
+}
+
+// findVariable finds the field name in the innermost class the code is in
+// that has one, declared there or inherited, and returns it with the class
+// declaring it and whether the code is in a static context with respect
+// to that class.
+func (c *Checker) findVariable(name string) (*ast.FieldDeclaration, *Class, bool) {
+	for _, in := range c.enclosingClasses() {
+		if field, owner := findField(in.class, name); field != nil {
+			return field, owner, in.static
+		}
+	}
+	return nil, nil, false
+}
+
+// enclosingClass is a class the code being checked is in, and whether the
+// code is in a static context with respect to it, without an instance of
+// it to use.
+type enclosingClass struct {
+	class  *Class
+	static bool
+}
+
+// enclosingClasses returns the classes the code being checked is in,
+// innermost first. Code in a class without an enclosing instance is in a
+// static context with respect to the classes around it.
+func (c *Checker) enclosingClasses() []enclosingClass {
+	var classes []enclosingClass
+	static := c.static
+	for s := c.scope; s != nil; s = s.outer {
+		if s.class != nil {
+			classes = append(classes, enclosingClass{s.class, static})
+			static = static || s.class.static
+		}
+	}
+	return classes
+}
+
+// checkThis checks this, or Outer.this, which refers to the instance of
+// the class Outer that the code is in, and returns its type.
+func (c *Checker) checkThis(e *ast.ThisExpression) *Type {
+	class := c.class
+	if e.Qualifier != nil {
+		if class = c.resolveClass(e.Qualifier.Value); class == nil {
+			c.errorf(ErrCannotFindSymbol, e.Qualifier.Token, "cannot find symbol: class %s", e.Qualifier.Value)
+			return nil
+		}
+	}
+	for _, in := range c.enclosingClasses() {
+		if in.class != class {
+			continue
+		}
+		if in.static {
+			c.errorf(ErrStaticContext, e.Token, "non-static variable this cannot be referenced from a static context")
+			return nil
+		}
+		return classType(class)
+	}
+	if class == nil {
+		c.errorf(ErrStaticContext, e.Token, "non-static variable this cannot be referenced from a static context")
+		return nil
+	}
+	c.errorf(ErrNotEnclosing, e.Qualifier.Token, "not an enclosing class: %s", class.Decl.Name.Value)
+	return nil
+}
+
+// isThis reports whether e is this, unqualified.
+func isThis(e ast.Expression) bool {
+	this, ok := e.(*ast.ThisExpression)
+	return ok && this.Qualifier == nil
+}
+
+// checkSuper returns the type of super: the superclass of the class the
+// code is in, which is Object for a class that does not extend another.
+func (c *Checker) checkSuper(e *ast.SuperExpression) *Type {
+	if c.static {
+		c.errorf(ErrStaticContext, e.Token, "non-static variable super cannot be referenced from a static context")
+		return nil
+	}
+	var class *Class
+	switch {
+	case e.Qualifier != nil:
+		class = c.resolveClass(e.Qualifier.Value)
+	case c.class == nil:
+	case c.class.Super == nil:
+		return &Type{Name: "Object"}
+	default:
+		class = c.class.Super
+	}
+	if class == nil {
+		return nil
+	}
+	return classType(class)
+}
+
+func (c *Checker) checkFieldAccess(e *ast.MemberExpression) *Type {
+	t, field, _ := c.fieldAccess(e)
+	if isThis(e.Object) && field != nil {
+		c.checkRead(e.Property.Token, field.Name)
+	}
+	return t
+}
+
+// fieldAccess checks a field access and returns the type of the field,
+// along with its declaration and the class declaring it, if it is not the
+// length of an array.
+func (c *Checker) fieldAccess(e *ast.MemberExpression) (*Type, *ast.FieldDeclaration, *Class) {
+	receiver, static := c.checkReceiver(e.Object)
+	if receiver == nil {
+		return nil, nil, nil
+	}
+	e.Receiver = qualifiedName(receiver)
+	if receiver.Dimensions > 0 && e.Property.Value == "length" {
+		return intType, nil, nil
+	}
+	if !isKnown(receiver) {
+		return nil, nil, nil
+	}
+	var field *ast.FieldDeclaration
+	var owner *Class
+	if receiver.Class != nil && receiver.Dimensions == 0 {
+		field, owner = findField(receiver.Class, e.Property.Value)
+	}
+	if field == nil {
+		c.errorf(ErrCannotFindSymbol, e.Property.Token, "cannot find symbol: variable %s", e.Property.Value).
+			WithNote("location: %s", location(e.Object, receiver, static))
+		return nil, nil, nil
+	}
+	if static && !isStaticField(owner, field) {
+		c.errorf(ErrStaticContext, e.Property.Token,
+			"non-static variable %s cannot be referenced from a static context", e.Property.Value)
+		return nil, nil, nil
+	}
+	c.checkAccess(e.Property.Token, field.Modifiers, owner, e.Property.Value)
+	return c.memberType(field.Type, typeParameters(owner, false, nil), c.bindings(receiver, owner)), field, owner
+}
+
+// checkArguments checks the arguments of a call and returns their types.
+// Lambda expressions and method references are left for
+// checkFunctionArguments, and their types are not known.
+func (c *Checker) checkArguments(args []ast.Expression) []*Type {
+	types := make([]*Type, len(args))
+	for i, a := range args {
+		if !isFunction(a) {
+			types[i] = c.checkValue(a)
+		}
+	}
+	return types
+}
+
+func (c *Checker) checkCall(e *ast.CallExpression) *Type {
+	t, candidates := c.checkMethodCall(e)
+	c.checkFunctionArguments(e.Arguments, candidates)
+	return t
+}
+
+// checkMethodCall checks a method call and returns its type, along with
+// the methods it may call.
+func (c *Checker) checkMethodCall(e *ast.CallExpression) (*Type, []method) {
+	args := c.checkArguments(e.Arguments)
+
+	var candidates []method
+	var static bool // whether the method is called through a class name
+	var name *ast.Identifier
+	switch function := e.Function.(type) {
+	case *ast.Identifier:
+		// The method is one of the innermost class the code is in that
+		// has methods of that name.
+		name = function
+		if c.class != nil {
+			e.Receiver = qualifiedName(classType(c.class))
+		}
+		static = c.static
+		for _, in := range c.enclosingClasses() {
+			if candidates = c.through(classType(in.class), findMethods(in.class, name.Value)); len(candidates) > 0 {
+				e.Receiver = qualifiedName(classType(in.class))
+				static = in.static
+				break
+			}
+		}
+		if len(candidates) == 0 {
+			for _, f := range c.functions[name.Value] {
+				candidates = append(candidates, methodOf(f, nil))
+			}
+			candidates = c.through(nil, candidates)
+		}
+	case *ast.MemberExpression:
+		var receiver *Type
+		receiver, static = c.checkReceiver(function.Object)
+		if receiver == nil {
+			return nil, nil
+		}
+		e.Receiver = qualifiedName(receiver)
+		if !isKnown(receiver) {
+			return nil, nil
+		}
+		name = function.Property
+		switch {
+		case receiver.Dimensions > 0:
+			// The evaluator implements no methods of arrays.
+		case receiver.Class != nil:
+			candidates = c.through(receiver, findMethods(receiver.Class, name.Value))
+			if function.TypeArguments != nil {
+				candidates = c.withTypeArguments(candidates, function.TypeArguments)
+			}
+		default:
+			candidates = findValueMethods(receiver, name.Value)
+		}
+		if len(candidates) == 0 && receiver.Class != nil && receiver.Dimensions == 0 {
+			if owner := staticInterfaceMethodOwner(receiver.Class, name.Value); owner != nil {
+				c.errorf(ErrStaticInterfaceCall, name.Token, "illegal static interface method call").
+					WithNote("the receiver expression should be replaced with the type qualifier '%s'", owner.Name)
+				return nil, nil
+			}
+		}
+		if len(candidates) == 0 {
+			c.cannotFindMethod(name, args).WithNote("location: %s", location(function.Object, receiver, static))
+			return nil, nil
+		}
+	case *ast.ThisExpression, *ast.SuperExpression:
+		// An explicit constructor call, this(...) or super(...).
+		if e != c.explicitCall {
+			c.errorf(ErrConstructorCall, tokenOf(function), "call to %s must be first statement in constructor", function.String())
+			return nil, nil
+		}
+		t := c.checkExpression(function)
+		if t != nil && t.Class == nil && len(args) > 0 {
+			// Object has only the constructor without parameters.
+			c.errorf(ErrInapplicable, tokenOf(function), "constructor Object in class Object cannot be applied to given types")
+		}
+		if t != nil {
+			if _, ok := function.(*ast.SuperExpression); ok && c.class.Decl.SuperClass != nil {
+				// The superclass with the type arguments the class gives it.
+				t = c.typeOf(c.class.Decl.SuperClass)
+			}
+			e.Signature, candidates = c.checkConstructorCall(tokenOf(function), t, e.Arguments, args)
+		}
+		return nil, candidates
+	default:
+		c.checkExpression(e.Function)
+		return nil, nil
+	}
+	if len(candidates) == 0 {
+		d := c.cannotFindMethod(name, args)
+		if c.class != nil {
+			d.WithNote("location: %s %s", c.class.kind(), c.class.Name)
+		}
+		return nil, nil
+	}
+
+	if known(args) {
+		m, ok := c.resolve(name.Token, name.Value, candidates, args)
+		if !ok {
+			return nil, candidates
+		}
+		// An override may declare the type arguments its class gives a
+		// generic supertype in place of its type variables, as the
+		// compareTo(Point) of a Comparable<Point> does for compareTo(T).
+		// The evaluator selects such a method by the arguments instead.
+		if !m.hasClassTypeVariables() {
+			e.Signature = parameterList(m.params)
+		}
+		c.unboxArguments(e.Arguments, m, args)
+		candidates = []method{c.infer(m, args)}
+	} else if candidates = withArity(candidates, len(args)); len(candidates) == 0 {
+		return nil, nil
+	}
+
+	if static && !anyMethod(candidates, isStaticMethod) {
+		c.errorf(ErrStaticContext, name.Token, "non-static method %s cannot be referenced from a static context",
+			candidates[0].signature())
+		return nil, candidates
+	}
+	if !anyMethod(candidates, func(m method) bool { return c.accessible(m.modifiers, m.owner) }) {
+		c.errorf(ErrPrivateAccess, name.Token, "%s has private access in %s", candidates[0].signature(), candidates[0].owner.Name)
+		return nil, candidates
+	}
+	if len(candidates) == 1 {
+		c.checkCallThrows(name.Token, candidates[0], "")
+	}
+	if t := candidates[0].returnType; t.Name != "void" {
+		return c.memberType(t, candidates[0].typeParams, candidates[0].bound), candidates
+	}
+	return voidType, candidates
+}
+
+// cannotFindMethod reports a call of a method name that is not declared,
+// with arguments of types args, e.g. "cannot find symbol: method f(int)".
+func (c *Checker) cannotFindMethod(name *ast.Identifier, args []*Type) *diagnostics.Diagnostic {
+	types := []string{}
+	for _, a := range args {
+		if a == nil {
+			types = append(types, "?")
+		} else {
+			types = append(types, a.String())
+		}
+	}
+	return c.errorf(ErrCannotFindSymbol, name.Token, "cannot find symbol: method %s(%s)", name.Value, strings.Join(types, ","))
+}
+
+// location describes where a member of receiver, accessed through e, is
+// looked up, as javac does in the note of a "cannot find symbol" error:
+// "class A" when e names a class, or else e.g. "variable a of type A".
+func location(e ast.Expression, receiver *Type, static bool) string {
+	if static && receiver.Class != nil {
+		return receiver.Class.kind() + " " + receiver.Name
+	}
+	if static {
+		return "class " + receiver.Name
+	}
+	if name, ok := e.(*ast.Identifier); ok {
+		return "variable " + name.Value + " of type " + receiver.String()
+	}
+	if receiver.Class != nil && receiver.Dimensions == 0 {
+		return receiver.Class.kind() + " " + receiver.Name
+	}
+	return "class " + receiver.String()
+}
+
+// checkNewExpression checks the creation of an object, whose type is
+// expected to be target, if that is known.
+func (c *Checker) checkNewExpression(e *ast.NewExpression, target *Type) *Type {
+	var outer *Type
+	if e.Outer != nil {
+		outer = c.checkValue(e.Outer)
+	}
+	args := c.checkArguments(e.Arguments)
+	var class *Class
+	switch {
+	case e.Outer != nil:
+		if class = c.innerClass(e, outer); class == nil {
+			return nil
+		}
+	case e.Body != nil && e.Type.Name == "Object":
+		// An anonymous subclass of Object, which the checker does not
+		// declare.
+	default:
+		if class = c.lookupClass(e.Type); class == nil {
+			return nil
+		}
+		if class.Decl.IsEnum() {
+			c.errorf(ErrAbstractInstantiation, e.Type.Token, "enum classes may not be instantiated")
+			return nil
+		}
+		if class.isAbstract() && e.Body == nil {
+			c.errorf(ErrAbstractInstantiation, e.Type.Token, "%s is abstract; cannot be instantiated", class.Name)
+			return nil
+		}
+		if !c.hasEnclosingInstance(class) {
+			c.errorf(ErrStaticContext, e.Token, "non-static variable this cannot be referenced from a static context")
+			return nil
+		}
+	}
+	if e.Body != nil {
+		return c.checkAnonymousClass(e, class, args)
+	}
+	created := classType(class)
+	switch {
+	case e.Type.Arguments == nil:
+	case len(e.Type.Arguments) > 0:
+		c.checkTypeArguments(e.Type, c.typeParams)
+		created.Arguments = c.typeOf(e.Type).Arguments
+	case target != nil && target.Class == class && target.Dimensions == 0:
+		// The diamond takes the type arguments of the type expected.
+		created.Arguments = target.Arguments
+	default:
+		// The type arguments inferred from the arguments are not known.
+		created.Arguments = make([]*Type, len(class.Decl.TypeParameters))
+	}
+	var candidates []method
+	e.Signature, candidates = c.checkConstructorCall(e.Type.Token, created, e.Arguments, args)
+	c.checkFunctionArguments(e.Arguments, candidates)
+	return created
+}
+
+// innerClass returns the inner class that `outer.new Inner()` creates an
+// instance of, a member class of the type of outer.
+func (c *Checker) innerClass(e *ast.NewExpression, outer *Type) *Class {
+	if !isKnown(outer) {
+		return nil
+	}
+	if outer.isPrimitive() || outer.Class == nil || outer.Dimensions > 0 {
+		c.errorf(ErrDereference, tokenOf(e.Outer), "%s cannot be dereferenced", outer)
+		return nil
+	}
+	class := memberClass(outer.Class, e.Type.Name)
+	switch {
+	case class == nil:
+		c.errorf(ErrCannotFindSymbol, e.Type.Token, "cannot find symbol: class %s", e.Type.Name).
+			WithNote("location: %s %s", outer.Class.kind(), outer.Class.Name)
+	case class.static:
+		c.errorf(ErrNotEnclosing, e.Type.Token, "qualified new of static class")
+	case class.isAbstract() && e.Body == nil:
+		c.errorf(ErrAbstractInstantiation, e.Type.Token, "%s is abstract; cannot be instantiated", class.Name)
+	default:
+		return class
+	}
+	return nil
+}
+
+// hasEnclosingInstance reports whether the code being checked can create
+// an instance of class without naming the instance of its outer class it
+// belongs to, if it is an inner member class: the code must be in an
+// instance of the outer class, or of a subclass of it.
+func (c *Checker) hasEnclosingInstance(class *Class) bool {
+	if class.static || class.Outer == nil {
+		return true
+	}
+	for _, in := range c.enclosingClasses() {
+		if in.class.isSubtypeOf(class.Outer) {
+			return !in.static
+		}
+	}
+	return false
+}
+
+// checkAnonymousClass checks `new T(...) { ... }`, which declares a class
+// that extends T, or implements it if T is an interface, and creates an
+// instance of it. Its constructor passes the arguments on to that of its
+// superclass, and it is in scope of the local variables where it is
+// declared. The class is Object if super is nil.
+func (c *Checker) checkAnonymousClass(e *ast.NewExpression, super *Class, args []*Type) *Type {
+	decl := *e.Body
+	t := *e.Type
+	if super != nil && t.Arguments != nil && len(t.Arguments) == 0 {
+		t.Arguments = inferTypeArguments(super, e.Body)
+	}
+	switch {
+	case super == nil:
+	case super.isInterface():
+		decl.Interfaces = []*ast.Type{&t}
+		if len(args) > 0 {
+			c.errorf(ErrBadSupertype, e.Type.Token, "anonymous class implements interface; cannot have arguments")
+		}
+	default:
+		decl.SuperClass = &t
+		var candidates []method
+		e.Signature, candidates = c.checkConstructorCall(e.Type.Token, c.typeOf(&t), e.Arguments, args)
+		c.checkFunctionArguments(e.Arguments, candidates)
+	}
+	anonymous := &Class{Name: "<anonymous " + e.Type.Name + ">", Decl: &decl, scope: c.scope, static: c.static, anonymous: true}
+	c.declare([]*Class{anonymous})
+	return classType(anonymous)
+}
+
+// inferTypeArguments infers the type arguments of super, the generic
+// supertype of an anonymous class declared with the diamond, from the
+// methods of its body that override those of super: an override has the
+// type argument in place of each type variable. A type variable no method
+// uses stands for Object.
+func inferTypeArguments(super *Class, body *ast.ClassDeclaration) []*ast.Type {
+	inferred := map[string]*ast.Type{}
+	infer := func(t, arg *ast.Type) {
+		if tv := typeVariable(t, super.Decl.TypeParameters); tv != nil && t.Dimensions <= arg.Dimensions {
+			a := *arg
+			a.Dimensions -= t.Dimensions
+			inferred[tv.Name.Value] = &a
+		}
+	}
+	for _, sm := range super.Decl.Methods {
+		for _, m := range body.Methods {
+			if m.Name.Value != sm.Name.Value || len(m.Parameters) != len(sm.Parameters) {
+				continue
+			}
+			for i, p := range sm.Parameters {
+				infer(p.DataType, m.Parameters[i].DataType)
+			}
+			if sm.ReturnType != nil && m.ReturnType != nil {
+				infer(sm.ReturnType, m.ReturnType)
+			}
+		}
+	}
+	var args []*ast.Type
+	for _, tp := range super.Decl.TypeParameters {
+		arg, ok := inferred[tp.Name.Value]
+		if !ok {
+			arg = &ast.Type{Token: tp.Name.Token, Name: "Object"}
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// checkLocalClass declares and checks a class declared in a block, which is
+// in scope in the rest of the block.
+func (c *Checker) checkLocalClass(decl *ast.ClassDeclaration) {
+	if _, ok := c.scope.classes[decl.Name.Value]; ok {
+		c.errorf(ErrDuplicateClass, decl.Name.Token, "duplicate class: %s", decl.Name.Value)
+		return
+	}
+	class := &Class{Name: decl.Name.Value, Decl: decl, scope: c.scope,
+		static: c.static || decl.IsInterface() || decl.IsEnum() || decl.IsRecord()}
+	if c.scope.classes == nil {
+		c.scope.classes = map[string]*Class{}
+	}
+	c.scope.classes[class.Name] = class
+	c.declare([]*Class{class})
+}
+
+// checkConstructorCall resolves a call of a constructor of the class of
+// t, by new, this(...) or super(...), and returns the signature of the
+// constructor chosen, if the call could be resolved, and the constructors
+// it may call. The type arguments of t are those of the object created.
+// The arguments exprs are of types args.
+func (c *Checker) checkConstructorCall(tok tokens.Token, t *Type, exprs []ast.Expression, args []*Type) (string, []method) {
+	if t == nil || t.Class == nil {
+		return "", nil
+	}
+	class := t.Class
+	candidates := c.through(t, constructorsOf(class))
+	var sig string
+	if known(args) {
+		m, ok := c.resolve(tok, "constructor "+class.Name, candidates, args)
+		if !ok {
+			return "", candidates
+		}
+		sig = parameterList(m.params)
+		c.unboxArguments(exprs, m, args)
+		candidates = []method{m}
+	} else if candidates = withArity(candidates, len(args)); len(candidates) == 0 {
+		return "", nil
+	}
+
+	if !anyMethod(candidates, func(m method) bool { return c.accessible(m.modifiers, class) }) {
+		c.errorf(ErrPrivateAccess, tok, "%s has private access in %s", candidates[0].signature(), class.Name)
+	} else if len(candidates) == 1 {
+		c.checkCallThrows(tok, candidates[0], "")
+	}
+	return sig, candidates
+}
+
+func known(types []*Type) bool {
+	for _, t := range types {
+		if t == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// checkReceiver checks the object of a field access or method call. It
+// also reports whether it names a class, as in Counter.increment(),
+// rather than being a value.
+func (c *Checker) checkReceiver(e ast.Expression) (*Type, bool) {
+	if class := c.className(e); class != nil {
+		return classType(class), true
+	}
+	t := c.checkExpression(e)
+	if t != nil && (t.isPrimitive() || isVoid(t) || t.Name == "<null>") {
+		c.errorf(ErrDereference, tokenOf(e), "%s cannot be dereferenced", t)
+		return nil, false
+	}
+	return t, false
+}
+
+// className returns the class e names, if it is a receiver such as
+// Counter in Counter.increment(), or Outer.Inner, rather than a value.
+func (c *Checker) className(e ast.Expression) *Class {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		if !c.isVariable(e.Value) {
+			return c.resolveClass(e.Value)
+		}
+	case *ast.MemberExpression:
+		if outer := c.className(e.Object); outer != nil {
+			if field, _ := findField(outer, e.Property.Value); field == nil {
+				return memberClass(outer, e.Property.Value)
+			}
+		}
+	}
+	return nil
+}
+
+// isVariable reports whether name is a local variable, a parameter or a
+// field in the current context, which takes precedence over a class of
+// the same name.
+func (c *Checker) isVariable(name string) bool {
+	if c.scope.lookup(name) != nil {
+		return true
+	}
+	field, _, _ := c.findVariable(name)
+	return field != nil
+}
+
+// checkAccess reports a use of a member of owner, named what in the
+// message, that is private to another class.
+func (c *Checker) checkAccess(tok tokens.Token, modifiers []tokens.Token, owner *Class, what string) {
+	if !c.accessible(modifiers, owner) {
+		c.errorf(ErrPrivateAccess, tok, "%s has private access in %s", what, owner.Name)
+	}
+}
+
+// accessible reports whether a member of owner with modifiers can be used
+// in the current context. All classes of a program belong to the same,
+// unnamed, package, so only private members are inaccessible, outside of
+// the class declaring them and the classes in it. Methods declared
+// outside of any class, with a nil owner, are accessible everywhere.
+func (c *Checker) accessible(modifiers []tokens.Token, owner *Class) bool {
+	return owner == nil || !ast.HasModifier(modifiers, tokens.PRIVATE) || outermost(c.class) == outermost(owner)
+}
+
+// checkOverride reports a method that overrides or hides a method of a
+// supertype that it may not: a final method, an instance method by a
+// static one or the other way round, a more accessible method, or one
+// whose result type its own cannot stand for.
+func (c *Checker) checkOverride(class *Class, m *ast.FunctionLiteral) {
+	static := isStatic(m.Modifiers)
+	c.enter(class, static, typeParameters(class, static, m.TypeParameters), nil)
+	sig := signature(m)
+	for _, t := range supertypes(class)[1:] {
+		for _, sm := range t.Decl.Methods {
+			if signatureIn(class, t, sm) != sig || ast.HasModifier(sm.Modifiers, tokens.PRIVATE) {
+				continue
+			}
+			if t.isInterface() && isStatic(sm.Modifiers) {
+				// The static methods of an interface are not inherited.
+				continue
+			}
+			code, reason := ErrBadOverride, ""
+			switch {
+			case isStatic(sm.Modifiers) && !static:
+				reason = "overridden method is static"
+			case static && !isStatic(sm.Modifiers):
+				reason = "overriding method is static"
+			case ast.HasModifier(sm.Modifiers, tokens.FINAL) && static:
+				reason = "overridden method is static final"
+			case ast.HasModifier(sm.Modifiers, tokens.FINAL):
+				reason = "overridden method is final"
+			case access(class, m) < access(t, sm):
+				code = ErrWeakerAccess
+				reason = "attempting to assign weaker access privileges; was " + accessNames[access(t, sm)]
+			default:
+				result, overridden := c.typeOf(m.ReturnType), c.overriddenResult(class, t, sm)
+				if !isKnown(result) || !isKnown(overridden) || isReturnSubstitutable(result, overridden) {
+					continue
+				}
+				code = ErrIncompatibleTypes
+				reason = fmt.Sprintf("return type %s is not compatible with %s", result, overridden)
+			}
+			c.errorf(code, m.Name.Token, "%s in %s %s %s in %s; %s",
+				sig, class.Name, overrideVerb(class, t, static && isStatic(sm.Modifiers)), signature(sm), t.Name, reason)
+			return
+		}
+	}
+}
+
+// overrideVerb is how javac words a method of class overriding, or if
+// hides, hiding, one of its supertype t.
+func overrideVerb(class, t *Class, hides bool) string {
+	switch {
+	case hides:
+		return "cannot hide"
+	case t.isInterface() && class.isInterface():
+		return "clashes with"
+	case t.isInterface():
+		return "cannot implement"
+	}
+	return "cannot override"
+}
+
+// overriddenResult is the result type of the method m of the supertype t
+// of class, with the type arguments class gives t in place of the type
+// parameters of t.
+func (c *Checker) overriddenResult(class, t *Class, m *ast.FunctionLiteral) *Type {
+	result := m.ReturnType
+	if typeVariable(result, m.TypeParameters) == nil {
+		result = substitute(result, typeArguments(class, t))
+	}
+	return c.typeOf(result)
+}
+
+// isReturnSubstitutable reports whether a method with the result type s
+// may override one with the result type t: a primitive type or void must
+// be the same, and a reference type may be a subtype (JLS 8.4.8.3).
+func isReturnSubstitutable(s, t *Type) bool {
+	if t.isPrimitive() || isVoid(t) || s.isPrimitive() || isVoid(s) {
+		return s.String() == t.String()
+	}
+	return isSubtype(s, t)
+}
+
+// Access levels, from least to most accessible.
+const (
+	privateAccess = iota
+	packageAccess
+	protectedAccess
+	publicAccess
+)
+
+var accessNames = []string{"private", "package", "protected", "public"}
+
+// access is the access level of the method m declared in owner. Interface
+// methods are public unless they are private.
+func access(owner *Class, m *ast.FunctionLiteral) int {
+	switch {
+	case ast.HasModifier(m.Modifiers, tokens.PRIVATE):
+		return privateAccess
+	case ast.HasModifier(m.Modifiers, tokens.PUBLIC) || owner.isInterface():
+		return publicAccess
+	case ast.HasModifier(m.Modifiers, tokens.PROTECTED):
+		return protectedAccess
+	}
+	return packageAccess
+}
+
+// findField finds the field name of class, declared there or inherited,
+// and the class or interface declaring it.
+func findField(class *Class, name string) (*ast.FieldDeclaration, *Class) {
+	for _, t := range supertypes(class) {
+		for _, f := range t.Decl.Fields {
+			if f.Name.Value == name {
+				return f, t
+			}
+		}
+	}
+	return nil, nil
+}
+
+func anyMethod(methods []method, f func(method) bool) bool {
+	for _, m := range methods {
+		if f(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStaticMethod reports whether m can be called without a receiver, as
+// static methods and methods declared outside of any class can.
+func isStaticMethod(m method) bool {
+	return m.owner == nil && !m.library || isStatic(m.modifiers)
+}
+
+func isStatic(modifiers []tokens.Token) bool {
+	return ast.HasModifier(modifiers, tokens.STATIC)
+}
+
+// isStaticField reports whether f, declared in owner, is static. The
+// fields of an interface are implicitly static.
+func isStaticField(owner *Class, f *ast.FieldDeclaration) bool {
+	return owner.isInterface() || isStatic(f.Modifiers)
+}
+
+// isFinalField reports whether f, declared in owner, is final. The fields
+// of an interface are implicitly final.
+func isFinalField(owner *Class, f *ast.FieldDeclaration) bool {
+	return owner.isInterface() || ast.HasModifier(f.Modifiers, tokens.FINAL)
+}
+
+// parameterList is the parameter types of a method or constructor as
+// javac prints them, e.g. "(int,String...)".
+func parameterList(params []*ast.Parameter) string {
+	types := []string{}
+	for _, p := range params {
+		types = append(types, p.TypeString())
+	}
+	return "(" + strings.Join(types, ",") + ")"
+}
+
+// tokenOf is the token a diagnostic about e points at: its first token,
+// or for an operation the operator.
+func tokenOf(e ast.Expression) tokens.Token {
+	switch e := e.(type) {
+	case *ast.ThisExpression:
+		return e.Token
+	case *ast.SuperExpression:
+		return e.Token
+	case *ast.Identifier:
+		return e.Token
+	case *ast.NewExpression:
+		return e.Token
+	case *ast.StringLiteral:
+		return e.Token
+	case *ast.IntegerLiteral:
+		return e.Token
+	case *ast.DoubleLiteral:
+		return e.Token
+	case *ast.Boolean:
+		return e.Token
+	case *ast.NullLiteral:
+		return e.Token
+	case *ast.PrefixExpression:
+		return e.Token
+	case *ast.InfixExpression:
+		return e.Token
+	case *ast.MemberExpression:
+		return e.Property.Token
+	case *ast.CallExpression:
+		return tokenOf(e.Function)
+	case *ast.IndexExpression:
+		return tokenOf(e.Left)
+	case *ast.AssignmentExpression:
+		return e.Token
+	case *ast.LambdaExpression:
+		return e.Token
+	case *ast.MethodReference:
+		return tokenOf(e.Target)
+	case *ast.SwitchExpression:
+		return e.Token
+	case *ast.EnumConstant:
+		return e.Token
+	case *ast.InstanceofExpression:
+		return e.Token
+	case *ast.TypePattern:
+		return e.Type.Token
+	case *ast.RecordPattern:
+		return e.Type.Token
+	case *ast.FunctionLiteral:
+		return e.Name.Token
+	case *ast.Parameter:
+		return e.ParameterName.Token
+	}
+	return tokens.Token{}
+}
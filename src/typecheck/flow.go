@@ -0,0 +1,237 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/tokens"
+)
+
+// canCompleteNormally reports whether executing s may reach the statement
+// after it, as JLS 14.22 defines it: a return or throw statement cannot,
+// and neither can a statement all of whose paths end in one.
+func canCompleteNormally(s ast.Statement) bool {
+	switch s := s.(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement, *ast.YieldStatement:
+		return false
+	case *ast.BlockStatement:
+		for _, stmt := range s.Statements {
+			if !canCompleteNormally(stmt) {
+				return false
+			}
+		}
+	case *ast.IfStatement:
+		// An if statement without else can always complete normally, even
+		// if its condition is the constant true.
+		return s.Alternative == nil || canCompleteNormally(s.Consequence) || canCompleteNormally(s.Alternative)
+	case *ast.TryStatement:
+		if s.Finally != nil && !canCompleteNormally(s.Finally) {
+			return false
+		}
+		if canCompleteNormally(s.Block) {
+			return true
+		}
+		for _, clause := range s.Catches {
+			if canCompleteNormally(clause.Body) {
+				return true
+			}
+		}
+		return false
+	case *ast.SwitchStatement:
+		// Without a default case, no case may run at all, unless the
+		// switch has patterns and so covers every value.
+		if defaultCase(s.Cases) == nil && !isEnhanced(s.Cases) {
+			return true
+		}
+		for _, sc := range s.Cases {
+			if body, ok := sc.Body.(ast.Statement); !ok || canCompleteNormally(body) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// checkMissingReturn reports a method with a result whose body can
+// complete normally, which would leave the method without a value to
+// return.
+func (c *Checker) checkMissingReturn(m *ast.FunctionLiteral) {
+	if m.Body == nil || m.ReturnType == nil || m.ReturnType.String() == "void" {
+		return
+	}
+	if canCompleteNormally(m.Body) {
+		c.errorf(ErrMissingReturn, m.Name.Token, "missing return statement")
+	}
+}
+
+// flow is the definite assignment state at a point in the code, as JLS
+// chapter 16 defines it. Variables are identified by the name in their
+// declaration. A point that cannot be reached has an empty state, since
+// there every variable is both definitely assigned and definitely
+// unassigned.
+type flow struct {
+	unassigned map[*ast.Identifier]bool // not definitely assigned
+	assigned   map[*ast.Identifier]bool // final, and not definitely unassigned
+}
+
+func newFlow() *flow {
+	return &flow{unassigned: map[*ast.Identifier]bool{}, assigned: map[*ast.Identifier]bool{}}
+}
+
+func (f *flow) copy() *flow {
+	g := newFlow()
+	g.join(f)
+	return g
+}
+
+// join merges into f the state at the end of another path to the same
+// point: a variable is definitely assigned there only if it is along
+// both paths.
+func (f *flow) join(other *flow) {
+	for v := range other.unassigned {
+		f.unassigned[v] = true
+	}
+	for v := range other.assigned {
+		f.assigned[v] = true
+	}
+}
+
+// unreachable makes f the state after a statement that cannot complete
+// normally.
+func (f *flow) unreachable() {
+	f.unassigned = map[*ast.Identifier]bool{}
+	f.assigned = map[*ast.Identifier]bool{}
+}
+
+// assign records an assignment of the variable declared as name.
+func (f *flow) assign(name *ast.Identifier) {
+	delete(f.unassigned, name)
+	f.assigned[name] = true
+}
+
+// assign records an assignment of the variable declared as name, both in
+// the flow and for the try statements the code is in.
+func (c *Checker) assign(name *ast.Identifier) {
+	c.flow.assign(name)
+	for _, assigned := range c.assignments {
+		assigned[name] = true
+	}
+}
+
+// checkStatements checks the statements of a block. Each must be
+// reachable: javac rejects a statement after one that cannot complete
+// normally, such as a return statement.
+func (c *Checker) checkStatements(stmts []ast.Statement) {
+	reported := false
+	for i, s := range stmts {
+		if i > 0 && !reported && !canCompleteNormally(stmts[i-1]) {
+			c.errorf(ErrUnreachable, statementToken(s), "unreachable statement")
+			reported = true
+		}
+		c.checkStatement(s)
+	}
+}
+
+// checkRead reports a read of a variable, declared as name, that may not
+// have been assigned a value yet.
+func (c *Checker) checkRead(tok tokens.Token, name *ast.Identifier) {
+	if c.flow.unassigned[name] {
+		c.errorf(ErrUnassigned, tok, "variable %s might not have been initialized", name.Value)
+		// Report each variable once.
+		delete(c.flow.unassigned, name)
+	}
+}
+
+// checkAssign checks an assignment at tok of the local variable or
+// parameter v, which may only be assigned once if it is final.
+func (c *Checker) checkAssign(tok tokens.Token, v *variable) {
+	switch {
+	case v.kind != "":
+		c.errorf(ErrFinalAssignment, tok, "%s %s may not be assigned", v.kind, v.name.Value)
+	case v.final && !v.blank:
+		c.errorf(ErrFinalAssignment, tok, "cannot assign a value to final variable %s", v.name.Value)
+	case v.final && c.flow.assigned[v.name]:
+		c.errorf(ErrFinalAssignment, tok, "variable %s might already have been assigned", v.name.Value)
+	}
+	if !v.uninitialized || c.flow.assigned[v.name] {
+		c.reassigned(v)
+	}
+	c.assign(v.name)
+}
+
+// checkFieldAssign checks an assignment at tok of the field f of owner,
+// which is direct if it is by the simple name of the field or through
+// this. A final field may only be assigned if it has no initializer, and
+// then once, directly, by the constructors or initializers of its class.
+func (c *Checker) checkFieldAssign(tok tokens.Token, f *ast.FieldDeclaration, owner *Class, direct bool) {
+	if !isFinalField(owner, f) {
+		return
+	}
+	if f.Value != nil || !direct || owner != c.class || !c.initializer || isStaticField(owner, f) != c.static {
+		c.errorf(ErrFinalAssignment, tok, "cannot assign a value to final variable %s", f.Name.Value)
+		return
+	}
+	if c.flow.assigned[f.Name] {
+		c.errorf(ErrFinalAssignment, tok, "variable %s might already have been assigned", f.Name.Value)
+	}
+	c.assign(f.Name)
+}
+
+// blankFinals returns the final fields of class without an initializer,
+// the static ones or the instance ones, which its initializers and
+// constructors must assign.
+func blankFinals(class *Class, static bool) []*ast.FieldDeclaration {
+	var fields []*ast.FieldDeclaration
+	for _, f := range class.Decl.Fields {
+		if isFinalField(class, f) && f.Value == nil && isStaticField(class, f) == static {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// checkFieldsAssigned reports, at tok, the blank final instance fields of
+// the class that a constructor may complete without assigning.
+func (c *Checker) checkFieldsAssigned(tok tokens.Token) {
+	for _, f := range blankFinals(c.class, false) {
+		if c.flow.unassigned[f.Name] {
+			c.errorf(ErrUnassigned, tok, "variable %s might not have been initialized", f.Name.Value)
+		}
+	}
+}
+
+// statementToken is the token a diagnostic about s points at, its first.
+func statementToken(s ast.Statement) tokens.Token {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		return s.Token
+	case *ast.BlockStatement:
+		return s.Token
+	case *ast.IfStatement:
+		return s.Token
+	case *ast.VariableDeclaration:
+		return s.Token
+	case *ast.ReturnStatement:
+		return s.Token
+	case *ast.ThrowStatement:
+		return s.Token
+	case *ast.TryStatement:
+		return s.Token
+	case *ast.SwitchStatement:
+		return s.Token
+	case *ast.YieldStatement:
+		return s.Token
+	case *ast.ClassDeclaration:
+		return s.Token
+	case *ast.IncrementStatement:
+		if s.Side == "POSTFIX" {
+			return s.Operand.Token
+		}
+		return s.Token
+	case *ast.DecrementStatement:
+		if s.Side == "POSTFIX" {
+			return s.Operand.Token
+		}
+		return s.Token
+	}
+	return tokens.Token{}
+}
@@ -0,0 +1,368 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/tokens"
+)
+
+// isFunction reports whether e is a lambda expression or a method
+// reference, whose type is the functional interface its context expects.
+func isFunction(e ast.Expression) bool {
+	switch e.(type) {
+	case *ast.LambdaExpression, *ast.MethodReference:
+		return true
+	}
+	return false
+}
+
+// checkAssignedValue checks e, whose value is assigned to a variable of
+// type target or passed for a parameter of that type. It returns e, with
+// the unboxing the assignment takes made explicit.
+func (c *Checker) checkAssignedValue(e ast.Expression, target *Type) ast.Expression {
+	switch v := e.(type) {
+	case *ast.LambdaExpression:
+		c.checkLambda(v, target)
+	case *ast.MethodReference:
+		c.checkMethodReference(v, target)
+	case *ast.SwitchExpression:
+		c.checkSwitchExpression(v, target)
+	case *ast.NewExpression:
+		c.checkAssignable(e, c.checkNewExpression(v, target), target)
+	default:
+		t := c.checkExpression(e)
+		c.checkAssignable(e, t, target)
+		return unboxed(e, t, target)
+	}
+	return e
+}
+
+// checkFunctionArguments checks the lambda expressions and method
+// references among the arguments of a call, which take their types from
+// the parameters of the method the call selects. They are only known once
+// a single candidate is left.
+func (c *Checker) checkFunctionArguments(args []ast.Expression, candidates []method) {
+	candidates = withArity(candidates, len(args))
+	for i, a := range args {
+		if !isFunction(a) {
+			continue
+		}
+		var target *Type
+		if len(candidates) == 1 {
+			m := candidates[0]
+			target = c.argumentType(m, i, ast.IsVariadic(m.params) && len(args) != len(m.params))
+		}
+		c.checkAssignedValue(a, target)
+	}
+}
+
+// abstractMethods returns the abstract methods of the interface class, of
+// which a functional interface has exactly one. The methods of Object an
+// interface redeclares do not count, nor do those that a more specific
+// interface gives a default.
+func abstractMethods(class *Class) []method {
+	var methods []method
+	var signatures []string
+	for _, t := range interfacesOf(class) {
+		for _, m := range t.Decl.Methods {
+			sig := signatureIn(class, t, m)
+			if !isAbstractMethod(t, m) || contains(signatures, sig) || isObjectMethod(sig) || hasDefault(class, sig) {
+				continue
+			}
+			methods = append(methods, methodOf(m, t))
+			signatures = append(signatures, sig)
+		}
+	}
+	return methods
+}
+
+func isObjectMethod(sig string) bool {
+	for _, m := range objectMethods {
+		if m.signature() == sig {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDefault(class *Class, sig string) bool {
+	for _, im := range inheritedInterfaceMethods(class, sig) {
+		if im.method.Body != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// functionalMethod returns the method that a lambda expression or method
+// reference at tok implements, the abstract method of its target type. It
+// reports a target that is not a functional interface, and returns false
+// if the target is not known either.
+func (c *Checker) functionalMethod(tok tokens.Token, target *Type) (method, bool) {
+	if !isKnown(target) {
+		return method{}, false
+	}
+	var methods []method
+	if target.Class != nil && target.Dimensions == 0 && target.Class.isInterface() {
+		if methods = abstractMethods(target.Class); len(methods) == 1 {
+			return c.through(target, methods)[0], true
+		}
+	}
+	d := c.errorf(ErrIncompatibleTypes, tok, "incompatible types: %s is not a functional interface", target)
+	switch {
+	case target.Class == nil || target.Dimensions > 0 || !target.Class.isInterface():
+	case len(methods) == 0:
+		d.WithNote("no abstract method found in interface %s", target.Class.Name)
+	default:
+		d.WithNote("multiple non-overriding abstract methods found in interface %s", target.Class.Name)
+	}
+	return method{}, false
+}
+
+// functionalType is the type of a parameter or the result of the
+// functional method m, declared as t, with the type arguments of the
+// target type in place of the type variables of its interface.
+func (c *Checker) functionalType(t *ast.Type, m method) *Type {
+	return c.memberType(t, m.typeParams, m.bound)
+}
+
+// checkLambda checks a lambda expression that implements the functional
+// interface target, or an unknown one if target is nil. Its body is
+// checked as the body of a method with the parameters of the lambda, in
+// the scope the lambda is in.
+func (c *Checker) checkLambda(e *ast.LambdaExpression, target *Type) {
+	m, ok := c.functionalMethod(e.Token, target)
+	if ok && len(m.params) != len(e.Parameters) {
+		c.errorf(ErrIncompatibleTypes, e.Token, "incompatible types: incompatible parameter types in lambda expression")
+		ok = false
+	}
+	if ok {
+		e.Interface = target.Name
+	}
+
+	restore := c.save()
+	c.scope = newScope(c.scope)
+	c.scope.lambda = true
+	c.flow = c.flow.copy()
+	c.lambda, c.initializer, c.assignments, c.handlers, c.yield = true, false, nil, nil, nil
+	c.result, c.throws, c.throwAny = nil, nil, !ok
+	if ok {
+		c.result = c.functionalType(m.returnType, m)
+		c.throws = c.thrownBy(m)
+	}
+	for i, p := range e.Parameters {
+		var t *Type
+		if ok {
+			t = c.functionalType(m.params[i].DataType, m)
+		}
+		if p.DataType != nil {
+			declared := c.parameterType(p, c.typeParams)
+			if isKnown(t) && isKnown(declared) && t.String() != declared.String() && ok {
+				c.errorf(ErrIncompatibleTypes, e.Token, "incompatible types: incompatible parameter types in lambda expression")
+				ok = false
+			}
+			t = declared
+		}
+		v := c.scope.declare(p.ParameterName, t)
+		if ast.HasModifier(p.Modifiers, tokens.FINAL) {
+			v.final, v.kind = true, "final parameter"
+		}
+	}
+
+	switch body := e.Body.(type) {
+	case *ast.BlockStatement:
+		c.checkStatement(body)
+		if c.result != nil && !isVoid(c.result) && canCompleteNormally(body) {
+			c.errorf(ErrIncompatibleTypes, e.Token, "incompatible types: bad return type in lambda expression").
+				WithNote("missing return value")
+		}
+	case ast.Expression:
+		if !isVoid(c.result) {
+			c.checkLambdaResult(body)
+			break
+		}
+		// The value of the expression is discarded, so it must be one that
+		// may be used as a statement.
+		t := c.checkExpression(body)
+		switch body.(type) {
+		case *ast.CallExpression, *ast.AssignmentExpression, *ast.NewExpression:
+		default:
+			d := c.errorf(ErrIncompatibleTypes, tokenOf(body), "incompatible types: bad return type in lambda expression")
+			if t != nil {
+				d.WithNote("%s cannot be converted to void", t)
+			}
+		}
+	}
+	restore()
+}
+
+// checkLambdaResult checks a value that the body of a lambda expression
+// returns, which must be assignable to the result type of its method.
+func (c *Checker) checkLambdaResult(e ast.Expression) {
+	if isFunction(e) {
+		c.checkAssignedValue(e, c.result)
+		return
+	}
+	if problem := assignmentProblem(e, c.checkExpression(e), c.result); problem != "" {
+		c.errorf(ErrIncompatibleTypes, tokenOf(e), "incompatible types: bad return type in lambda expression").
+			WithNote("%s", problem)
+	}
+}
+
+// thrownBy returns the checked exceptions that the method m declares.
+func (c *Checker) thrownBy(m method) []*Class {
+	var classes []*Class
+	for _, t := range m.throws {
+		if class := c.classOf(t); class != nil {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+// checkMethodReference checks a method reference that implements the
+// functional interface target. It must refer to a method or constructor
+// that can be called with the arguments of the functional method, where
+// an instance method referred to through a class name is called on the
+// first argument.
+func (c *Checker) checkMethodReference(e *ast.MethodReference, target *Type) {
+	m, ok := c.functionalMethod(tokenOf(e), target)
+	var receiver *Type
+	var static bool
+	if name, isName := e.Target.(*ast.Identifier); isName && !c.isVariable(name.Value) && c.resolveClass(name.Value) == nil && isLibraryType(name.Value) {
+		// Library types such as String are not declared.
+		receiver, static = &Type{Name: name.Value}, true
+	} else {
+		receiver, static = c.checkReceiver(e.Target)
+	}
+	if !ok {
+		return
+	}
+	e.Interface = target.Name
+	if !isKnown(receiver) || receiver.Dimensions > 0 || receiver.Class == nil && e.Name.Value == "new" {
+		return
+	}
+
+	n := len(m.params)
+	var candidates []method
+	if e.Name.Value == "new" {
+		class := receiver.Class
+		if class.isAbstract() {
+			c.errorf(ErrAbstractInstantiation, tokenOf(e), "%s is abstract; cannot be instantiated", class.Name)
+			return
+		}
+		if candidates = withArity(constructorsOf(class), n); len(candidates) == 0 {
+			c.errorf(ErrInapplicable, tokenOf(e), "incompatible types: invalid constructor reference").
+				WithNote("constructor %s in class %s cannot be applied to given types", class.Name, class.Name)
+			return
+		}
+	} else {
+		var methods []method
+		if receiver.Class != nil {
+			methods = findMethods(receiver.Class, e.Name.Value)
+		} else {
+			methods = findValueMethods(receiver, e.Name.Value)
+		}
+		if !static {
+			candidates = withArity(methods, n)
+		} else {
+			for _, r := range withArity(methods, n) {
+				if isStaticMethod(r) {
+					candidates = append(candidates, r)
+				}
+			}
+			for _, r := range withArity(methods, n-1) {
+				if !isStaticMethod(r) {
+					candidates = append(candidates, r)
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			c.errorf(ErrCannotFindSymbol, e.Name.Token, "invalid method reference").
+				WithNote("cannot find symbol: method %s", e.Name.Value).
+				WithNote("location: %s", location(e.Target, receiver, static))
+			return
+		}
+	}
+	if !anyMethod(candidates, func(r method) bool { return c.accessible(r.modifiers, r.owner) }) {
+		c.errorf(ErrPrivateAccess, e.Name.Token, "%s has private access in %s", candidates[0].signature(), candidates[0].owner.Name)
+		return
+	}
+	if len(candidates) > 1 {
+		return
+	}
+
+	r := candidates[0]
+	if result := c.functionalType(m.returnType, m); !r.isConstructor() && r.returnType.Name == "void" && result != nil && !isVoid(result) {
+		c.errorf(ErrIncompatibleTypes, tokenOf(e), "incompatible types: bad return type in method reference").
+			WithNote("void cannot be converted to %s", result)
+	}
+	declared := c.thrownBy(m)
+	for _, exception := range c.thrownBy(r) {
+		if c.isChecked(exception) && !anyClass(declared, exception.isSubtypeOf) {
+			c.errorf(ErrUnreportedException, tokenOf(e), "incompatible thrown types %s in functional expression", exception.Name)
+		}
+	}
+}
+
+func anyClass(classes []*Class, f func(*Class) bool) bool {
+	for _, class := range classes {
+		if f(class) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCapture checks a use at tok of the local variable v in a lambda
+// body or inner class that is declared outside of it. They may only read
+// such a variable if it is final or effectively final, never assigned
+// after it is initialized, which a later assignment may still reveal.
+func (c *Checker) checkCapture(tok tokens.Token, v *variable, write bool) {
+	by := c.captures(v)
+	if by == "" {
+		return
+	}
+	if write || v.reassigned {
+		c.notEffectivelyFinal(capture{tok, by})
+		return
+	}
+	v.captures = append(v.captures, capture{tok, by})
+}
+
+// captures describes the innermost lambda expression or inner class that
+// the code being checked is in and that captures v, or is empty if there
+// is none. The variables declared outside of any class are not captured,
+// as in jshell, where they are fields.
+func (c *Checker) captures(v *variable) string {
+	if c.globals.vars[v.name.Value] == v {
+		return ""
+	}
+	by := ""
+	for s := c.scope; s != nil; s = s.outer {
+		switch {
+		case s.vars[v.name.Value] == v:
+			return by
+		case by != "":
+		case s.lambda:
+			by = "a lambda expression"
+		case s.class != nil:
+			by = "an inner class"
+		}
+	}
+	return ""
+}
+
+func (c *Checker) notEffectivelyFinal(use capture) {
+	c.errorf(ErrNotEffectivelyFinal, use.tok, "local variables referenced from %s must be final or effectively final", use.by)
+}
+
+// reassigned records that v is assigned after it is initialized, so it is
+// not effectively final, and reports the lambda expressions and inner
+// classes that captured it before.
+func (c *Checker) reassigned(v *variable) {
+	v.reassigned = true
+	for _, use := range v.captures {
+		c.notEffectivelyFinal(use)
+	}
+	v.captures = nil
+}
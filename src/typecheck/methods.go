@@ -0,0 +1,595 @@
+package typecheck
+
+import (
+	"fmt"
+	"java/ast"
+	"java/tokens"
+	"strings"
+)
+
+// method is a method or constructor a call may select.
+type method struct {
+	name       string
+	params     []*ast.Parameter
+	modifiers  []tokens.Token
+	returnType *ast.Type // nil for constructors
+	owner      *Class    // nil for methods declared outside of any class
+	throws     []*ast.Type
+	typeParams []*ast.TypeParameter // those in scope in the declaration
+	library    bool                 // an instance method of a library class with no declaration
+
+	// bound maps the type parameters of owner to the types the receiver
+	// of a call gives them, as bindings does.
+	bound map[string]*Type
+}
+
+func methodOf(m *ast.FunctionLiteral, owner *Class) method {
+	typeParams := typeParameters(owner, isStatic(m.Modifiers), m.TypeParameters)
+	return method{name: m.Name.Value, params: m.Parameters, modifiers: m.Modifiers, returnType: m.ReturnType,
+		owner: owner, throws: m.Throws, typeParams: typeParams}
+}
+
+func constructorOf(ctor *ast.ConstructorDeclaration, owner *Class) method {
+	typeParams := typeParameters(owner, false, ctor.TypeParameters)
+	return method{name: owner.Name, params: ctor.Parameters, modifiers: ctor.Modifiers, owner: owner,
+		throws: ctor.Throws, typeParams: typeParams}
+}
+
+func (m method) isConstructor() bool { return m.returnType == nil }
+
+// hasClassTypeVariables reports whether the parameter types of m name
+// type parameters of the class declaring it.
+func (m method) hasClassTypeVariables() bool {
+	if m.owner == nil {
+		return false
+	}
+	for _, p := range m.params {
+		if typeVariable(p.DataType, m.owner.Decl.TypeParameters) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (m method) signature() string { return m.name + parameterList(m.params) }
+
+// describe is how javac refers to m in the notes of an error, e.g.
+// "method A.f(int)".
+func (m method) describe() string {
+	if m.owner == nil {
+		return kindOf(m) + " " + m.signature()
+	}
+	return kindOf(m) + " " + m.owner.Name + "." + m.signature()
+}
+
+// objectMethods are the methods of Object that every class and interface
+// has, unless it declares them. They are not declared by a library class.
+var objectMethods = []method{
+	libraryMethod("equals", "boolean", "Object"),
+	libraryMethod("hashCode", "int"),
+	libraryMethod("toString", "String"),
+}
+
+// valueMethods are the methods of String and the box classes other than
+// those of Object, which the evaluator implements for their values.
+var valueMethods = map[string][]method{
+	"String": {
+		libraryMethod("length", "int"),
+		libraryMethod("isEmpty", "boolean"),
+		libraryMethod("compareTo", "int", "String"),
+	},
+	"Integer": {libraryMethod("intValue", "int"), libraryMethod("compareTo", "int", "Integer")},
+	"Double":  {libraryMethod("doubleValue", "double"), libraryMethod("compareTo", "int", "Double")},
+	"Boolean": {libraryMethod("booleanValue", "boolean"), libraryMethod("compareTo", "int", "Boolean")},
+}
+
+func libraryMethod(name, result string, params ...string) method {
+	m := method{name: name, returnType: &ast.Type{Name: result}, modifiers: []tokens.Token{{Type: tokens.PUBLIC, Literal: "public"}}, library: true}
+	for i, p := range params {
+		m.params = append(m.params, &ast.Parameter{
+			DataType:      &ast.Type{Name: p},
+			ParameterName: &ast.Identifier{Value: fmt.Sprintf("arg%d", i)},
+		})
+	}
+	return m
+}
+
+// findValueMethods finds the methods name of t, String, a box class or
+// Object, which are not declared by a library class.
+func findValueMethods(t *Type, name string) []method {
+	var methods []method
+	for _, declared := range [][]method{valueMethods[t.Name], objectMethods} {
+		for _, m := range declared {
+			if m.name == name {
+				methods = append(methods, m)
+			}
+		}
+	}
+	return methods
+}
+
+// findMethods finds the methods name of class, declared there or
+// inherited, including those of Object. A method overridden in a subtype
+// is not inherited.
+func findMethods(class *Class, name string) []method {
+	var methods []method
+	var signatures []string
+	for _, t := range supertypes(class) {
+		for _, m := range t.Decl.Methods {
+			if t != class && t.isInterface() && isStatic(m.Modifiers) {
+				// The static methods of an interface are not inherited.
+				continue
+			}
+			if m.Name.Value == name {
+				methods = append(methods, methodOf(m, t))
+				signatures = append(signatures, signatureIn(class, t, m))
+			}
+		}
+	}
+
+	var inherited []method
+	for i, m := range methods {
+		overridden := false
+		for j, other := range methods {
+			if other.owner != m.owner && other.owner.isSubtypeOf(m.owner) && signatures[j] == signatures[i] {
+				overridden = true
+			}
+		}
+		if !overridden {
+			inherited = append(inherited, m)
+		}
+	}
+	for _, m := range objectMethods {
+		if m.name == name && !contains(signatures, m.signature()) {
+			inherited = append(inherited, m)
+		}
+	}
+	return inherited
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+func constructorsOf(class *Class) []method {
+	var ctors []method
+	for _, ctor := range class.Decl.Constructors {
+		ctors = append(ctors, constructorOf(ctor, class))
+	}
+	if len(ctors) == 0 && !class.isInterface() {
+		// The default constructor.
+		ctors = append(ctors, method{name: class.Name, modifiers: class.Decl.Modifiers, owner: class})
+	}
+	return ctors
+}
+
+// staticInterfaceMethodOwner returns a superinterface of class that
+// declares a static method name, which class does not inherit, if any.
+func staticInterfaceMethodOwner(class *Class, name string) *Class {
+	for _, t := range supertypes(class)[1:] {
+		for _, m := range t.Decl.Methods {
+			if t.isInterface() && isStatic(m.Modifiers) && m.Name.Value == name {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// withArity returns the methods that can be called with n arguments.
+func withArity(methods []method, n int) []method {
+	var result []method
+	for _, m := range methods {
+		if len(m.params) == n || (ast.IsVariadic(m.params) && n >= len(m.params)-1) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// The phases of overload resolution, as in JLS 15.12.2.
+const (
+	strictPhase   = iota // subtyping and primitive widening only
+	loosePhase           // also boxing and unboxing
+	variadicPhase        // also variable arity
+)
+
+// resolve chooses the method a call with arguments of types args selects
+// among candidates, the way javac does: it finds the methods applicable
+// in the earliest phase possible and picks the most specific of them. It
+// reports an error at tok if there is no such method or if the choice is
+// ambiguous. The call is not resolved if the type of an argument is not
+// known. call describes the call in messages, e.g. "f" or "constructor A".
+func (c *Checker) resolve(tok tokens.Token, call string, candidates []method, args []*Type) (method, bool) {
+	for _, a := range args {
+		if a == nil {
+			return method{}, false
+		}
+	}
+
+	for phase := strictPhase; phase <= variadicPhase; phase++ {
+		var applicable []method
+		for _, m := range candidates {
+			if c.isApplicable(m, args, phase) {
+				applicable = append(applicable, m)
+			}
+		}
+		if len(applicable) == 0 {
+			continue
+		}
+
+		var best []method
+		for _, m := range applicable {
+			mostSpecific := true
+			for _, other := range applicable {
+				if !c.isMoreSpecific(m, other, len(args), phase) {
+					mostSpecific = false
+				}
+			}
+			if mostSpecific {
+				best = append(best, m)
+			}
+		}
+		if len(best) == 1 {
+			return best[0], true
+		}
+		if len(best) == 0 {
+			best = applicable
+		}
+		c.errorf(ErrAmbiguousCall, tok, "reference to %s is ambiguous", strings.TrimPrefix(call, "constructor ")).
+			WithNote("both %s and %s match", best[0].declaredIn(), best[1].declaredIn())
+		return method{}, false
+	}
+
+	c.inapplicableError(tok, call, candidates, args)
+	return method{}, false
+}
+
+func (c *Checker) isApplicable(m method, args []*Type, phase int) bool {
+	if phase == variadicPhase {
+		if !ast.IsVariadic(m.params) || len(args) < len(m.params)-1 {
+			return false
+		}
+		for i, a := range args {
+			if !isConvertible(a, c.argumentType(m, i, true)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if len(m.params) != len(args) {
+		return false
+	}
+	for i, a := range args {
+		t := c.parameterOf(m, i)
+		if (phase == strictPhase && !isSubtype(a, t)) || (phase == loosePhase && !isConvertible(a, t)) {
+			return false
+		}
+	}
+	return true
+}
+
+// argumentType is the type of argument i of a call of m. A call with
+// variable arity passes the trailing arguments as array elements.
+func (c *Checker) argumentType(m method, i int, variadic bool) *Type {
+	last := len(m.params) - 1
+	if !variadic || i < last {
+		return c.parameterOf(m, i)
+	}
+	return c.typeWith(m.params[last].DataType, m.typeParams, m.bound)
+}
+
+// parameterOf is the type of parameter i of m, with the types the receiver
+// of the call gives the type parameters of its class.
+func (c *Checker) parameterOf(m method, i int) *Type {
+	t := c.typeWith(m.params[i].DataType, m.typeParams, m.bound)
+	if m.params[i].Variadic {
+		t.Dimensions++
+	}
+	return t
+}
+
+// through returns methods, called on receiver, with the types receiver
+// gives the type parameters of the classes declaring them. The type
+// arguments of generic methods are not known until the call gives or
+// infers them.
+func (c *Checker) through(receiver *Type, methods []method) []method {
+	for i, m := range methods {
+		bound := c.bindings(receiver, m.owner)
+		if bound == nil {
+			bound = map[string]*Type{}
+		}
+		for _, tp := range m.typeParams {
+			if _, ok := bound[tp.Name.Value]; !ok {
+				bound[tp.Name.Value] = nil
+			}
+		}
+		methods[i].bound = bound
+	}
+	return methods
+}
+
+// ownTypeParameters returns the type parameters m declares itself, which
+// are those of a generic method or constructor.
+func (m method) ownTypeParameters() []*ast.TypeParameter {
+	var own []*ast.TypeParameter
+	for _, tp := range m.typeParams {
+		if m.owner == nil || typeParameterIndex(m.owner.Decl.TypeParameters, tp) < 0 {
+			own = append(own, tp)
+		}
+	}
+	return own
+}
+
+func typeParameterIndex(params []*ast.TypeParameter, tp *ast.TypeParameter) int {
+	for i, p := range params {
+		if p == tp {
+			return i
+		}
+	}
+	return -1
+}
+
+// withTypeArguments returns methods, called with the explicit type
+// arguments args, as in U.<Integer>id(x), with their type parameters
+// bound to them. Those of a method that declares a different number of
+// type parameters are ignored.
+func (c *Checker) withTypeArguments(methods []method, args []*ast.Type) []method {
+	for i, m := range methods {
+		own := m.ownTypeParameters()
+		if len(own) != len(args) {
+			continue
+		}
+		bound := map[string]*Type{}
+		for name, t := range m.bound {
+			bound[name] = t
+		}
+		for j, tp := range own {
+			bound[tp.Name.Value] = c.typeOf(args[j])
+		}
+		methods[i].bound = bound
+	}
+	return methods
+}
+
+// infer returns m, called with arguments of types args, with the type
+// parameters of the generic method that the call does not give explicitly
+// bound to the least upper bound of the types of the arguments passed for
+// them, boxed. A type parameter only null or no argument is passed for is
+// left not known: javac infers it from the target of the call.
+func (c *Checker) infer(m method, args []*Type) method {
+	own := m.ownTypeParameters()
+	if len(own) == 0 {
+		return m
+	}
+	inferred := map[string][]*Type{}
+	variadic := c.isVariadicCall(m, args)
+	last := len(m.params) - 1
+	for i, a := range args {
+		if variadic && i >= last {
+			c.collect(m.params[last].DataType, 0, a, own, m.bound, inferred)
+		} else if m.params[i].Variadic {
+			c.collect(m.params[i].DataType, 1, a, own, m.bound, inferred)
+		} else {
+			c.collect(m.params[i].DataType, 0, a, own, m.bound, inferred)
+		}
+	}
+
+	bound := map[string]*Type{}
+	for name, t := range m.bound {
+		bound[name] = t
+	}
+	for _, tp := range own {
+		if bound[tp.Name.Value] == nil {
+			bound[tp.Name.Value] = lub(inferred[tp.Name.Value])
+		}
+	}
+	m.bound = bound
+	return m
+}
+
+// collect adds to inferred the types an argument of type a, passed for a
+// parameter declared as p with dims more dimensions, gives the type
+// parameters own that bound does not bind, e.g. String to T for a String[]
+// passed for a T[], or for a Box<String> passed for a Box<T>.
+func (c *Checker) collect(p *ast.Type, dims int, a *Type, own []*ast.TypeParameter, bound map[string]*Type, inferred map[string][]*Type) {
+	dims += p.Dimensions
+	if a == nil || a.Name == "<null>" || a.Dimensions < dims {
+		return
+	}
+	if tp := typeVariable(p, own); tp != nil {
+		if bound[tp.Name.Value] != nil {
+			return
+		}
+		t := &Type{Name: a.Name, Dimensions: a.Dimensions - dims, Class: a.Class, Arguments: a.Arguments}
+		if t.isPrimitive() {
+			if dims > 0 {
+				// An int[] is not a T[].
+				return
+			}
+			t = &Type{Name: boxes[t.Name]}
+		}
+		inferred[tp.Name.Value] = append(inferred[tp.Name.Value], t)
+		return
+	}
+
+	class := c.classOf(p)
+	if len(p.Arguments) == 0 || class == nil || a.Class == nil || a.Dimensions != dims {
+		return
+	}
+	given := c.bindings(&Type{Name: a.Name, Class: a.Class, Arguments: a.Arguments}, class)
+	for i, arg := range p.Arguments {
+		if i >= len(class.Decl.TypeParameters) {
+			break
+		}
+		t := given[class.Decl.TypeParameters[i].Name.Value]
+		if arg.Name == "?" {
+			if arg.Bound == nil || arg.Super {
+				continue
+			}
+			arg = arg.Bound
+		}
+		c.collect(arg, 0, t, own, bound, inferred)
+	}
+}
+
+// lub is the least upper bound of types, the first of the supertypes of
+// the first of them that the others are subtypes of, or nil if types is
+// empty.
+func lub(types []*Type) *Type {
+	if len(types) == 0 {
+		return nil
+	}
+	first := types[0]
+	candidates := []*Type{first}
+	switch {
+	case first.Dimensions > 0:
+	case first.Class != nil:
+		for _, super := range supertypes(first.Class)[1:] {
+			candidates = append(candidates, classType(super))
+		}
+	default:
+		for _, super := range librarySupertypes[first.Name] {
+			candidates = append(candidates, &Type{Name: super})
+		}
+	}
+	for _, candidate := range candidates {
+		upper := true
+		for _, t := range types[1:] {
+			if !isSubtype(t, candidate) {
+				upper = false
+			}
+		}
+		if upper {
+			return candidate
+		}
+	}
+	return &Type{Name: "Object"}
+}
+
+// isMoreSpecific reports whether m is at least as specific as other for a
+// call with n arguments: if each parameter type of m is a subtype of the
+// corresponding one of other.
+func (c *Checker) isMoreSpecific(m, other method, n int, phase int) bool {
+	variadic := phase == variadicPhase
+	if !variadic {
+		n = len(m.params)
+	}
+	for i := 0; i < n; i++ {
+		if !isSubtype(c.argumentType(m, i, variadic), c.argumentType(other, i, variadic)) {
+			return false
+		}
+	}
+	return true
+}
+
+// inapplicableError reports a call that no candidate can be called with,
+// with notes explaining why, as javac does.
+func (c *Checker) inapplicableError(tok tokens.Token, call string, candidates []method, args []*Type) {
+	found := []string{}
+	for _, a := range args {
+		found = append(found, a.String())
+	}
+
+	if len(candidates) > 1 {
+		kind := "method"
+		name := call
+		if strings.HasPrefix(call, "constructor ") {
+			kind, name = "constructor", strings.TrimPrefix(call, "constructor ")
+		}
+		d := c.errorf(ErrInapplicable, tok, "no suitable %s found for %s(%s)", kind, name, strings.Join(found, ","))
+		for _, m := range candidates {
+			d.WithNote("%s is not applicable (%s)", m.describe(), c.mismatch(m, args))
+		}
+		return
+	}
+
+	m := candidates[0]
+	message := fmt.Sprintf("%s %s cannot be applied to given types", kindOf(m), m.name)
+	if m.owner != nil {
+		message = fmt.Sprintf("%s %s in %s %s cannot be applied to given types", kindOf(m), m.name, m.owner.kind(), m.owner.Name)
+	}
+	required := parameterList(m.params)
+	if len(m.params) == 0 {
+		required = "()"
+	}
+	c.errorf(ErrInapplicable, tok, "%s", message).
+		WithNote("required: %s", strings.Trim(required, "()")).
+		WithNote("found: %s", strings.Join(found, ",")).
+		WithNote("reason: %s", c.mismatch(m, args))
+}
+
+// mismatch explains why m cannot be called with arguments of types args.
+func (c *Checker) mismatch(m method, args []*Type) string {
+	last := len(m.params) - 1
+	variadic := c.isVariadicCall(m, args)
+	if !variadic && len(m.params) != len(args) || variadic && len(args) < last {
+		return "actual and formal argument lists differ in length"
+	}
+	for i, a := range args {
+		if t := c.argumentType(m, i, variadic); !isConvertible(a, t) {
+			return fmt.Sprintf("argument mismatch; %s cannot be converted to %s", a, t)
+		}
+	}
+	return "argument mismatch"
+}
+
+// isVariadicCall reports whether a call of m with arguments of types args
+// passes its variable arity parameter as a list of elements rather than
+// as an array.
+func (c *Checker) isVariadicCall(m method, args []*Type) bool {
+	last := len(m.params) - 1
+	return ast.IsVariadic(m.params) &&
+		(len(args) != len(m.params) || !isConvertible(args[last], c.parameterOf(m, last)))
+}
+
+// unboxArguments makes explicit the unboxing of the arguments exprs, of
+// types args, that a call of m passes for parameters of primitive types.
+func (c *Checker) unboxArguments(exprs []ast.Expression, m method, args []*Type) {
+	variadic := c.isVariadicCall(m, args)
+	for i, a := range args {
+		exprs[i] = unboxed(exprs[i], a, c.argumentType(m, i, variadic))
+	}
+}
+
+func kindOf(m method) string {
+	if m.isConstructor() {
+		return "constructor"
+	}
+	return "method"
+}
+
+// declaredIn describes m along with where it is declared, e.g.
+// "method f(int) in A".
+func (m method) declaredIn() string {
+	if m.owner == nil {
+		return kindOf(m) + " " + m.signature()
+	}
+	return kindOf(m) + " " + m.signature() + " in " + m.owner.Name
+}
+
+// checkDuplicates reports methods and constructors of class declared more
+// than once with the same signature.
+func (c *Checker) checkDuplicates(class *Class) {
+	seen := map[string]bool{}
+	for _, m := range class.Decl.Methods {
+		sig := signature(m)
+		if seen[sig] {
+			c.errorf(ErrDuplicateMethod, m.Name.Token, "method %s is already defined in %s %s", sig, class.kind(), class.Name)
+		}
+		seen[sig] = true
+	}
+	for _, ctor := range class.Decl.Constructors {
+		sig := class.Name + parameterList(ctor.Parameters)
+		if seen[sig] {
+			c.errorf(ErrDuplicateMethod, ctor.Name.Token, "constructor %s is already defined in %s %s", sig, class.kind(), class.Name)
+		}
+		seen[sig] = true
+	}
+}
@@ -0,0 +1,207 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/tokens"
+	"strings"
+)
+
+// checkInstanceof checks `e instanceof Pattern`, whose operand must have
+// a reference type that the type of the pattern may be an instance of.
+func (c *Checker) checkInstanceof(e *ast.InstanceofExpression) *Type {
+	t := c.checkValue(e.Left)
+	if isKnown(t) && t.isPrimitive() {
+		c.errorf(ErrIncompatibleTypes, tokenOf(e.Left), "unexpected type").
+			WithNote("required: reference").
+			WithNote("found:    %s", t)
+		t = nil
+	}
+	c.checkPattern(e.Pattern, t)
+	return booleanType
+}
+
+// checkPattern checks a pattern that is matched against values of type t,
+// which is nil if it is not known, and records the type of the values it
+// matches. A type pattern with the type var matches any value of type t.
+func (c *Checker) checkPattern(pattern ast.Expression, t *Type) {
+	switch p := pattern.(type) {
+	case *ast.TypePattern:
+		if isInferred(p.Type) {
+			c.patterns[p] = t
+			return
+		}
+		pt := c.typeOf(p.Type)
+		if !isKnown(pt) {
+			c.lookupClass(p.Type)
+			return
+		}
+		c.checkCastable(p.Type.Token, t, pt)
+		c.patterns[p] = pt
+	case *ast.RecordPattern:
+		record := c.lookupClass(p.Type)
+		switch {
+		case record == nil:
+		case !record.Decl.IsRecord():
+			c.errorf(ErrBadPattern, p.Type.Token, "deconstruction patterns can only be applied to records, %s is not a record", record.Name)
+		case len(p.Patterns) != len(record.Decl.Components):
+			c.errorf(ErrBadPattern, p.Type.Token, "incorrect number of nested patterns").
+				WithNote("required: %s", componentTypes(record)).
+				WithNote("found: %s", patternTypes(p.Patterns))
+		default:
+			c.checkCastable(p.Type.Token, t, classType(record))
+			c.patterns[p] = classType(record)
+			for i, nested := range p.Patterns {
+				c.checkPattern(nested, c.componentType(record, i))
+			}
+			return
+		}
+		for _, nested := range p.Patterns {
+			c.checkPattern(nested, nil)
+		}
+	}
+}
+
+// checkCastable checks that a value of type s may match a pattern of type
+// t. A primitive type only matches itself.
+func (c *Checker) checkCastable(tok tokens.Token, s, t *Type) {
+	if !isKnown(s) || !isKnown(t) {
+		return
+	}
+	if s.isPrimitive() || t.isPrimitive() {
+		if s.String() == t.String() {
+			return
+		}
+	} else if isCastable(s, t) {
+		return
+	}
+	c.errorf(ErrIncompatibleTypes, tok, "incompatible types: %s cannot be converted to %s", s, t)
+}
+
+// componentTypes lists the types of the components of record, as in
+// "int,String".
+func componentTypes(record *Class) string {
+	var types []string
+	for _, p := range record.Decl.Components {
+		types = append(types, p.TypeString())
+	}
+	return strings.Join(types, ",")
+}
+
+func patternTypes(patterns []ast.Expression) string {
+	var types []string
+	for _, p := range patterns {
+		switch p := p.(type) {
+		case *ast.TypePattern:
+			types = append(types, p.Type.String())
+		case *ast.RecordPattern:
+			types = append(types, p.Type.String())
+		}
+	}
+	return strings.Join(types, ",")
+}
+
+// exhausts reports whether the pattern matches every value of type t but
+// null, so that a switch with a case for it covers them all. A record
+// pattern does if each of its nested patterns exhausts the type of its
+// component.
+func (c *Checker) exhausts(pattern ast.Expression, t *Type) bool {
+	switch p := pattern.(type) {
+	case *ast.TypePattern:
+		return isInferred(p.Type) || isKnown(t) && isSubtype(t, c.patterns[p])
+	case *ast.RecordPattern:
+		record := c.patterns[p]
+		if record == nil || !isSubtype(t, record) {
+			return false
+		}
+		for i, nested := range p.Patterns {
+			component := record.Class.Decl.Components[i]
+			if !c.exhausts(nested, c.parameterType(component, record.Class.Decl.TypeParameters)) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// bindings returns the patterns whose variables are in scope where e is
+// whenTrue: those of `x instanceof P` where it is true, and those of e
+// where `!e` is false.
+func bindings(e ast.Expression, whenTrue bool) []ast.Expression {
+	switch e := e.(type) {
+	case *ast.InstanceofExpression:
+		if whenTrue {
+			return []ast.Expression{e.Pattern}
+		}
+	case *ast.PrefixExpression:
+		if e.Operator == "!" {
+			return bindings(e.Right, !whenTrue)
+		}
+	}
+	return nil
+}
+
+// declareBindings declares the pattern variables of patterns in the
+// current scope. Other expressions among them, such as the constant
+// labels of a case, declare none.
+func (c *Checker) declareBindings(patterns []ast.Expression) {
+	for _, p := range patterns {
+		switch p := p.(type) {
+		case *ast.TypePattern:
+			if p.Name != nil {
+				v := c.scope.declare(p.Name, c.patterns[p])
+				v.final = ast.HasModifier(p.Modifiers, tokens.FINAL)
+			}
+		case *ast.RecordPattern:
+			c.declareBindings(p.Patterns)
+		}
+	}
+}
+
+// checkBound checks s in a scope with the pattern variables of patterns.
+func (c *Checker) checkBound(s ast.Statement, patterns []ast.Expression) {
+	outer := c.scope
+	c.scope = newScope(outer)
+	c.declareBindings(patterns)
+	c.checkStatement(s)
+	c.scope = outer
+}
+
+// enterCase enters the scope of the body of a case, where the pattern
+// variables of its label are in scope, and checks its guard. The guard
+// may declare more variables that are in scope in the body. It returns a
+// function that leaves the scope.
+func (c *Checker) enterCase(sc *ast.SwitchCase) func() {
+	outer := c.scope
+	c.scope = newScope(outer)
+	c.declareBindings(sc.Labels)
+	if sc.Guard != nil {
+		guard := c.checkValue(sc.Guard)
+		c.checkAssignable(sc.Guard, guard, booleanType)
+		sc.Guard = unboxed(sc.Guard, guard, booleanType)
+		c.declareBindings(bindings(sc.Guard, true))
+	}
+	return func() { c.scope = outer }
+}
+
+// isEnhanced reports whether a switch has a pattern or null label, which
+// makes it a switch that must cover all values of its selector.
+func isEnhanced(cases []*ast.SwitchCase) bool {
+	for _, sc := range cases {
+		for _, label := range sc.Labels {
+			if _, ok := label.(*ast.NullLiteral); ok || ast.IsPattern(label) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasPattern(sc *ast.SwitchCase) bool {
+	for _, label := range sc.Labels {
+		if ast.IsPattern(label) {
+			return true
+		}
+	}
+	return false
+}
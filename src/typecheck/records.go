@@ -0,0 +1,157 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/tokens"
+	"sort"
+)
+
+// checkRecord checks the members a record declares. Its only instance
+// fields are those of its components, which the canonical constructor
+// assigns, so it has no instance initializers, and any other constructor
+// must start by calling another one. An accessor it declares itself must
+// be public and return the type of its component.
+func (c *Checker) checkRecord(class *Class) {
+	for _, f := range class.Decl.Fields {
+		if f.Token.Type != tokens.RECORD && !ast.HasModifier(f.Modifiers, tokens.STATIC) {
+			c.errorf(ErrRecordMember, f.Name.Token, "field declaration must be static").
+				WithNote("(consider replacing field with record component)")
+		}
+	}
+	for _, b := range class.Decl.Initializers {
+		c.errorf(ErrRecordMember, b.Token, "instance initializers not allowed in records")
+	}
+	for _, ctor := range class.Decl.Constructors {
+		call := explicitConstructorCall(ctor)
+		switch {
+		case ctor.Compact:
+		case !isCanonical(ctor, class):
+			if _, ok := call.(*ast.ThisExpression); !ok {
+				c.errorf(ErrRecordMember, ctor.Name.Token, "constructor is not canonical, so its first statement must invoke another constructor of class %s", class.Name)
+			}
+		case call != nil:
+			c.errorf(ErrRecordMember, ctor.Name.Token, "invalid canonical constructor in record %s", class.Name).
+				WithNote("(canonical constructor must not contain explicit constructor invocation)")
+		}
+	}
+	for _, m := range class.Decl.Methods {
+		component := componentNamed(class, m.Name.Value)
+		if m.Token.Type == tokens.RECORD || component == nil || len(m.Parameters) > 0 {
+			continue
+		}
+		if !ast.HasModifier(m.Modifiers, tokens.PUBLIC) {
+			c.errorf(ErrRecordMember, m.Name.Token, "invalid accessor method in record %s", class.Name).
+				WithNote("(accessor method must be public)")
+		} else if m.ReturnType.String() != component.TypeString() {
+			c.errorf(ErrRecordMember, m.Name.Token, "invalid accessor method in record %s", class.Name).
+				WithNote("(return type of accessor method %s() must match the type of record component %s)", m.Name.Value, m.Name.Value)
+		}
+	}
+}
+
+// isCanonical reports whether ctor is the canonical constructor of the
+// record class, whose parameters have the types of its components.
+func isCanonical(ctor *ast.ConstructorDeclaration, class *Class) bool {
+	components := class.Decl.Components
+	if len(ctor.Parameters) != len(components) {
+		return false
+	}
+	for i, p := range ctor.Parameters {
+		if p.TypeString() != components[i].TypeString() {
+			return false
+		}
+	}
+	return true
+}
+
+// componentNamed returns the component name of the record class, or nil
+// if it has none.
+func componentNamed(class *Class, name string) *ast.Parameter {
+	for _, p := range class.Decl.Components {
+		if p.ParameterName.Value == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// componentType is the type of the component i of the record class, or
+// nil if it is a type variable, which stands for a type that is not known.
+func (c *Checker) componentType(record *Class, i int) *Type {
+	component := record.Decl.Components[i]
+	if typeVariable(component.DataType, record.Decl.TypeParameters) != nil {
+		return nil
+	}
+	return c.parameterType(component, record.Decl.TypeParameters)
+}
+
+// checkSealed checks the place of class in a sealed hierarchy. A class
+// may only extend a sealed class or interface that permits it, and must
+// then say whether it is final, sealed or non-sealed itself; records and
+// enums are implicitly final. The classes a sealed class permits must
+// extend it.
+func (c *Checker) checkSealed(class *Class) {
+	decl := class.Decl
+	extendsSealed := false
+	for _, t := range append([]*ast.Type{decl.SuperClass}, decl.Interfaces...) {
+		if t == nil || directSupertype(class, t.Name) == nil {
+			continue
+		}
+		super := directSupertype(class, t.Name)
+		if !super.Decl.IsSealed() {
+			continue
+		}
+		extendsSealed = true
+		if !anyClass(c.permitted(super), func(k *Class) bool { return k == class }) {
+			c.errorf(ErrSealed, t.Token, "class is not allowed to extend sealed class: %s (as it is not listed in its 'permits' clause)", super.Name)
+		}
+	}
+
+	nonSealed := ast.HasModifier(decl.Modifiers, tokens.NON_SEALED)
+	switch {
+	case extendsSealed && !decl.IsFinal() && !decl.IsSealed() && !nonSealed:
+		if class.isInterface() {
+			c.errorf(ErrSealed, decl.Name.Token, "sealed or non-sealed modifiers expected")
+		} else {
+			c.errorf(ErrSealed, decl.Name.Token, "sealed, non-sealed or final modifiers expected")
+		}
+	case nonSealed && !extendsSealed:
+		c.errorf(ErrSealed, decl.Name.Token, "non-sealed modifier not allowed here").
+			WithNote("(%s %s does not have any sealed supertypes)", class.kind(), class.Name)
+	}
+
+	if !decl.IsSealed() {
+		return
+	}
+	if len(decl.Permits) == 0 && len(c.permitted(class)) == 0 {
+		c.errorf(ErrSealed, decl.Name.Token, "sealed class must have subclasses")
+	}
+	for _, t := range decl.Permits {
+		if sub := c.lookupClass(t); sub != nil && directSupertype(sub, class.Name) != class {
+			c.errorf(ErrSealed, t.Token, "invalid permits clause").
+				WithNote("(subclass %s must extend sealed class)", sub.Name)
+		}
+	}
+}
+
+// permitted returns the classes that the sealed class permits to extend
+// it: those in its permits clause or, without one, those that do extend
+// it.
+func (c *Checker) permitted(sealed *Class) []*Class {
+	var classes []*Class
+	if len(sealed.Decl.Permits) > 0 {
+		for _, t := range sealed.Decl.Permits {
+			if class := c.classOf(t); class != nil {
+				classes = append(classes, class)
+			}
+		}
+		return classes
+	}
+	for _, class := range c.classes {
+		if directSupertype(class, sealed.Name) == sealed {
+			classes = append(classes, class)
+		}
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i].Name < classes[j].Name })
+	return classes
+}
@@ -0,0 +1,303 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/tokens"
+)
+
+// switchExpression collects what the cases of a switch expression yield:
+// their values, whose types make the type of the expression, and the
+// definite assignment state after each. In an assignment context, the
+// values are checked against the type of the target instead.
+type switchExpression struct {
+	target *Type
+	types  []*Type
+	flow   *flow
+}
+
+// checkSwitchStatement checks a switch statement. One with a pattern or
+// null label must have a case for every value of its selector, as a
+// switch expression must.
+func (c *Checker) checkSwitchStatement(s *ast.SwitchStatement) {
+	selector := c.checkSwitchLabels(s.Value, s.Cases)
+	enhanced := isEnhanced(s.Cases)
+	if enhanced && !c.exhaustive(selector, s.Cases) {
+		c.errorf(ErrNotExhaustive, s.Token, "the switch statement does not cover all possible input values")
+	}
+	before := c.flow
+	after := newFlow()
+	for _, sc := range s.Cases {
+		c.flow = before.copy()
+		leave := c.enterCase(sc)
+		switch body := sc.Body.(type) {
+		case ast.Statement:
+			c.checkStatement(body)
+		case ast.Expression:
+			c.checkExpression(body)
+		}
+		leave()
+		after.join(c.flow)
+	}
+	// Without a default case, no case may run at all.
+	if defaultCase(s.Cases) == nil && !enhanced {
+		after.join(before)
+	}
+	c.flow = after
+}
+
+// checkSwitchExpression checks a switch expression, whose value is
+// assigned to a variable of type target, if it is not nil. It must have a
+// value for every value of its selector. Each case either has a value or
+// throws.
+func (c *Checker) checkSwitchExpression(e *ast.SwitchExpression, target *Type) *Type {
+	selector := c.checkSwitchLabels(e.Value, e.Cases)
+	if !c.exhaustive(selector, e.Cases) {
+		c.errorf(ErrNotExhaustive, e.Token, "the switch expression does not cover all possible input values")
+	}
+
+	outer := c.yield
+	sw := &switchExpression{target: target, flow: newFlow()}
+	before := c.flow
+	for _, sc := range e.Cases {
+		c.flow = before.copy()
+		c.yield = sw
+		leave := c.enterCase(sc)
+		switch body := sc.Body.(type) {
+		case *ast.BlockStatement:
+			c.checkStatement(body)
+			if canCompleteNormally(body) {
+				c.errorf(ErrMissingYield, sc.Token, "switch rule completes without providing a value").
+					WithNote("(switch rules in switch expressions must either provide a value or throw)")
+			}
+		case ast.Statement:
+			c.checkStatement(body)
+		case ast.Expression:
+			c.checkSwitchValue(body)
+			sw.flow.join(c.flow)
+		}
+		leave()
+	}
+	c.yield = outer
+	c.flow = sw.flow
+	return sw.typ()
+}
+
+// checkYield checks a yield statement, which gives the value of the switch
+// expression whose case it is in.
+func (c *Checker) checkYield(s *ast.YieldStatement) {
+	if c.yield == nil {
+		c.errorf(ErrBadYield, s.Token, "yield outside of switch expression")
+		c.checkExpression(s.Value)
+		return
+	}
+	c.checkSwitchValue(s.Value)
+	c.yield.flow.join(c.flow)
+}
+
+// checkSwitchValue checks e, a value of the switch expression being
+// checked.
+func (c *Checker) checkSwitchValue(e ast.Expression) {
+	sw := c.yield
+	switch {
+	case sw.target == nil:
+		sw.types = append(sw.types, c.checkValue(e))
+	case isFunction(e):
+		c.checkAssignedValue(e, sw.target)
+	default:
+		if problem := assignmentProblem(e, c.checkValue(e), sw.target); problem != "" {
+			c.errorf(ErrIncompatibleTypes, tokenOf(e), "incompatible types: bad type in switch expression").
+				WithNote("%s", problem)
+		}
+	}
+}
+
+// typ is the type of a switch expression, the type its values have in
+// common: the numeric type they are all promoted to, or else the type all
+// the others can be assigned to. It is nil if there is none.
+func (sw *switchExpression) typ() *Type {
+	if sw.target != nil {
+		return sw.target
+	}
+	var result *Type
+	for _, t := range sw.types {
+		switch {
+		case !isKnown(t):
+			return nil
+		case t == nullType:
+		case result == nil:
+			result = t
+		case result.String() == t.String():
+		case numericType(result, t) != nil:
+			result = numericType(result, t)
+		case isConvertible(t, result):
+		case isConvertible(result, t):
+			result = t
+		default:
+			return nil
+		}
+	}
+	return result
+}
+
+// checkSwitchLabels checks the selector of a switch and the labels of its
+// cases, and returns the type of the selector. The labels of a switch on
+// an enum are the simple names of its constants, and those of any other
+// switch are values that can be assigned to the type of the selector, or
+// null. A case with a pattern has no other label, and only such a case
+// has a guard. No label may be one that an earlier case without a guard
+// already matches. No two cases have the same label, and at most one is
+// the default case.
+func (c *Checker) checkSwitchLabels(value ast.Expression, cases []*ast.SwitchCase) *Type {
+	t := c.checkValue(value)
+	enum := enumType(t)
+	seen := map[string]bool{}
+	defaults := 0
+	var dominating []*Type
+	for _, sc := range cases {
+		if len(sc.Labels) == 0 {
+			if defaults++; defaults > 1 {
+				c.errorf(ErrDuplicateLabel, sc.Token, "duplicate default label")
+			}
+		}
+		pattern := hasPattern(sc)
+		if sc.Guard != nil && !pattern {
+			c.errorf(ErrBadPattern, tokenOf(sc.Guard), "guards are only allowed for case with a pattern")
+		}
+		if pattern && len(sc.Labels) > 1 {
+			c.errorf(ErrBadPattern, tokenOf(sc.Labels[1]), "illegal fall-through from a pattern")
+		}
+		for _, label := range sc.Labels {
+			var l *Type
+			_, null := label.(*ast.NullLiteral)
+			switch {
+			case ast.IsPattern(label):
+				c.checkPattern(label, t)
+				l = c.patterns[label]
+			case enum != nil && !null:
+				name, ok := label.(*ast.Identifier)
+				if !ok {
+					c.errorf(ErrIncompatibleTypes, tokenOf(label), "an enum switch case label must be the unqualified name of an enumeration constant")
+					continue
+				}
+				if constantNamed(enum, name.Value) == nil {
+					c.errorf(ErrCannotFindSymbol, name.Token, "cannot find symbol: variable %s", name.Value).
+						WithNote("location: %s %s", enum.kind(), enum.Name)
+					continue
+				}
+				l = classType(enum)
+			default:
+				if l = c.checkValue(label); assignmentProblem(label, l, t) != "" {
+					c.errorf(ErrIncompatibleTypes, tokenOf(label), "constant label of type %s is not compatible with switch selector type %s", l, t)
+				}
+			}
+			if !null && dominated(l, dominating) {
+				c.errorf(ErrDominatedLabel, tokenOf(label), "this case label is dominated by a preceding case label")
+			}
+			if ast.IsPattern(label) {
+				continue
+			}
+			if seen[label.String()] {
+				c.errorf(ErrDuplicateLabel, tokenOf(label), "duplicate case label")
+			}
+			seen[label.String()] = true
+		}
+		if sc.Guard == nil {
+			for _, label := range sc.Labels {
+				if p, ok := label.(*ast.TypePattern); ok && c.patterns[p] != nil {
+					dominating = append(dominating, c.patterns[p])
+				}
+			}
+		}
+	}
+	return t
+}
+
+// dominated reports whether a label matching values of type t comes after
+// a type pattern without a guard that matches them all, one of the types
+// dominating. The value of a constant label is boxed.
+func dominated(t *Type, dominating []*Type) bool {
+	if !isKnown(t) {
+		return false
+	}
+	if t.isPrimitive() {
+		t = &Type{Name: boxes[t.Name]}
+	}
+	for _, d := range dominating {
+		if isSubtype(t, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// exhaustive reports whether cases cover every value of type t: if one of
+// them is the default case or has a pattern without a guard that exhausts
+// t, if t is an enum with a case for each constant, or if t is an abstract
+// sealed class or interface and each class it permits is covered.
+func (c *Checker) exhaustive(t *Type, cases []*ast.SwitchCase) bool {
+	return defaultCase(cases) != nil || c.covers(t, cases)
+}
+
+func (c *Checker) covers(t *Type, cases []*ast.SwitchCase) bool {
+	for _, sc := range cases {
+		if sc.Guard != nil {
+			continue
+		}
+		for _, label := range sc.Labels {
+			if ast.IsPattern(label) && c.exhausts(label, t) {
+				return true
+			}
+		}
+	}
+	if enum := enumType(t); enum != nil {
+		return coversEnum(enum, cases)
+	}
+	if t == nil || t.Class == nil || t.Dimensions > 0 || !t.Class.Decl.IsSealed() {
+		return false
+	}
+	if !t.Class.isInterface() && !ast.HasModifier(t.Class.Decl.Modifiers, tokens.ABSTRACT) {
+		return false
+	}
+	for _, class := range c.permitted(t.Class) {
+		if !c.covers(classType(class), cases) {
+			return false
+		}
+	}
+	return true
+}
+
+func defaultCase(cases []*ast.SwitchCase) *ast.SwitchCase {
+	for _, sc := range cases {
+		if len(sc.Labels) == 0 {
+			return sc
+		}
+	}
+	return nil
+}
+
+// coversEnum reports whether cases have a label for every constant of
+// enum.
+func coversEnum(enum *Class, cases []*ast.SwitchCase) bool {
+	labels := map[string]bool{}
+	for _, sc := range cases {
+		for _, label := range sc.Labels {
+			labels[label.String()] = true
+		}
+	}
+	for _, constant := range enumConstants(enum) {
+		if !labels[constant.Name.Value] {
+			return false
+		}
+	}
+	return true
+}
+
+// constantNamed returns the constant name of enum, or nil if it has none.
+func constantNamed(enum *Class, name string) *ast.EnumConstant {
+	for _, constant := range enumConstants(enum) {
+		if constant.Name.Value == name {
+			return constant
+		}
+	}
+	return nil
+}
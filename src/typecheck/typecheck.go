@@ -0,0 +1,982 @@
+// Package typecheck checks a parsed program for the errors javac reports
+// at compile time, so they are found before the program runs.
+package typecheck
+
+import (
+	"java/ast"
+	"java/diagnostics"
+	"java/lang"
+	"java/tokens"
+	"strings"
+)
+
+// Class is what the checker knows about a class or interface. A member
+// class has the class it is declared in as its Outer class, and is named
+// after it, as in "Outer.Inner". So does the class of the body of an enum
+// constant, with the enum. Classes maps the names of the member classes
+// to them.
+type Class struct {
+	Name       string
+	Decl       *ast.ClassDeclaration
+	Super      *Class
+	Interfaces []*Class
+	Outer      *Class
+	Classes    map[string]*Class
+
+	// A local or anonymous class has the scope it is declared in, whose
+	// variables are in scope in its body too. A static class has no
+	// enclosing instance: a static member class, a member interface, enum
+	// or record, or a class declared in a static context.
+	scope     *scope
+	static    bool
+	anonymous bool
+}
+
+func (c *Class) isInterface() bool { return c.Decl.IsInterface() }
+
+// enclosing returns the class c is declared in, if any.
+func (c *Class) enclosing() *Class {
+	if c.Outer != nil {
+		return c.Outer
+	}
+	for s := c.scope; s != nil; s = s.outer {
+		if s.class != nil {
+			return s.class
+		}
+	}
+	return nil
+}
+
+func (c *Class) isAbstract() bool {
+	return c.isInterface() || ast.HasModifier(c.Decl.Modifiers, tokens.ABSTRACT)
+}
+
+// kind is "class" or "interface", as used in messages.
+func (c *Class) kind() string { return c.Decl.TokenLiteral() }
+
+// isSubtypeOf reports whether c is other or extends or implements it,
+// directly or indirectly.
+func (c *Class) isSubtypeOf(other *Class) bool {
+	if c == other {
+		return true
+	}
+	if c.Super != nil && c.Super.isSubtypeOf(other) {
+		return true
+	}
+	for _, i := range c.Interfaces {
+		if i.isSubtypeOf(other) {
+			return true
+		}
+	}
+	return false
+}
+
+type Checker struct {
+	classes     map[string]*Class
+	functions   map[string][]*ast.FunctionLiteral // methods declared outside of any class
+	diagnostics []*diagnostics.Diagnostic
+
+	// The variables declared by statements outside of any class, which
+	// are in scope in the methods declared there, as in jshell.
+	globals *scope
+
+	// The context of the code being checked: the class it is in, if any,
+	// whether it is in a static context, the type parameters and local
+	// variables in scope, and the result type of the method it is in, or
+	// nil outside of any method. Code in an initializer or constructor
+	// may assign the blank final fields of its class. In the body of a
+	// lambda expression, the result is that of the method it implements.
+	// explicitCall is the this(...) or super(...) call the constructor
+	// being checked begins with, if any.
+	class        *Class
+	static       bool
+	typeParams   []*ast.TypeParameter
+	scope        *scope
+	result       *Type
+	initializer  bool
+	lambda       bool
+	explicitCall *ast.CallExpression
+
+	// flow is the definite assignment state of the code being checked,
+	// and assignments the variables assigned in each try statement it is
+	// in, innermost last.
+	flow        *flow
+	assignments []map[*ast.Identifier]bool
+
+	// The checked exceptions the code may throw: those its method
+	// declares, or any at all outside of any class, as in jshell. The
+	// handlers are the try statements the code is in, innermost last.
+	throws   []*Class
+	throwAny bool
+	handlers []*handler
+
+	// caught maps the type of each catch parameter to the exceptions
+	// rethrowing the parameter throws.
+	caught map[*Type][]*Class
+
+	// yield is the switch expression whose cases the code is in, if any.
+	yield *switchExpression
+
+	// patterns maps each type pattern and record pattern to the type of
+	// the values it matches, the type of the variable a type pattern binds.
+	patterns map[ast.Expression]*Type
+
+	// resolved maps the types in the declarations of the members of
+	// classes to the classes they name, resolved where they are written.
+	resolved map[*ast.Type]*Class
+}
+
+// New returns a checker that knows the library classes.
+func New() *Checker {
+	c := &Checker{
+		classes:   make(map[string]*Class),
+		functions: make(map[string][]*ast.FunctionLiteral),
+		caught:    make(map[*Type][]*Class),
+		patterns:  make(map[ast.Expression]*Type),
+		resolved:  make(map[*ast.Type]*Class),
+		globals:   newScope(nil),
+	}
+	var stmts []ast.Statement
+	for _, decl := range lang.Classes() {
+		stmts = append(stmts, decl)
+	}
+	c.declareClasses(stmts)
+	return c
+}
+
+// Check checks program and returns the errors found. The classes and
+// methods of a program without errors stay known to the checker, so that
+// a REPL can check its input one line at a time.
+func (c *Checker) Check(program *ast.Program) []*diagnostics.Diagnostic {
+	c.diagnostics = []*diagnostics.Diagnostic{}
+
+	functions := make(map[string][]*ast.FunctionLiteral, len(c.functions))
+	for name, fns := range c.functions {
+		functions[name] = fns
+	}
+	classes := make(map[string]*Class, len(c.classes))
+	for name, class := range c.classes {
+		classes[name] = class
+	}
+	globals := make(map[string]*variable, len(c.globals.vars))
+	for name, v := range c.globals.vars {
+		globals[name] = v
+	}
+	c.declareFunctions(program.Statements)
+	c.declareClasses(program.Statements)
+
+	// Statements outside of any class have no this.
+	c.enter(nil, true, nil, nil)
+	c.scope = c.globals
+	c.throwAny = true
+	for _, s := range program.Statements {
+		if _, ok := s.(*ast.ClassDeclaration); !ok {
+			c.checkStatement(s)
+		}
+	}
+
+	if len(c.diagnostics) > 0 {
+		c.classes = classes
+		c.functions = functions
+		c.globals.vars = globals
+	}
+	return c.diagnostics
+}
+
+// declareFunctions adds the methods declared outside of any class among
+// stmts. A method replaces one declared earlier with the same signature.
+func (c *Checker) declareFunctions(stmts []ast.Statement) {
+	for _, s := range stmts {
+		es, ok := s.(*ast.ExpressionStatement)
+		if !ok {
+			continue
+		}
+		fn, ok := es.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			continue
+		}
+		var overloads []*ast.FunctionLiteral
+		for _, other := range c.functions[fn.Name.Value] {
+			if signature(other) != signature(fn) {
+				overloads = append(overloads, other)
+			}
+		}
+		c.functions[fn.Name.Value] = append(overloads, fn)
+	}
+}
+
+// declareClasses adds the classes declared by stmts to the class table,
+// then declares and checks them.
+func (c *Checker) declareClasses(stmts []ast.Statement) []*Class {
+	var classes []*Class
+	declared := map[string]bool{}
+	for _, s := range stmts {
+		decl, ok := s.(*ast.ClassDeclaration)
+		if !ok {
+			continue
+		}
+		if declared[decl.Name.Value] {
+			c.errorf(ErrDuplicateClass, decl.Name.Token, "duplicate class: %s", decl.Name.Value)
+			continue
+		}
+		declared[decl.Name.Value] = true
+		class := &Class{Name: decl.Name.Value, Decl: decl}
+		c.classes[class.Name] = class
+		classes = append(classes, class)
+	}
+	c.declare(classes)
+	return classes
+}
+
+// declare declares the member classes of classes and resolves the
+// supertypes of them all, then checks them once they are all known.
+func (c *Checker) declare(classes []*Class) {
+	restore := c.save()
+	defer restore()
+	classes = c.declareMembers(classes)
+	for _, class := range classes {
+		c.scope = c.declarationScope(class)
+		c.resolveSupertypes(class)
+	}
+	for _, class := range classes {
+		c.checkCycles(class)
+	}
+	for _, class := range classes {
+		c.scope = c.bodyScope(class)
+		c.checkSealed(class)
+		c.resolveTypes(class)
+	}
+	for _, class := range classes {
+		c.checkClass(class)
+	}
+}
+
+// declareMembers declares the member classes of classes, and theirs in
+// turn, and returns them all, each class before its members. Those of a
+// class that is not local are in the class table too.
+func (c *Checker) declareMembers(classes []*Class) []*Class {
+	var all []*Class
+	for _, class := range classes {
+		all = append(all, class)
+		class.Classes = map[string]*Class{}
+		var members []*Class
+		for _, decl := range class.Decl.Classes {
+			if _, ok := class.Classes[decl.Name.Value]; ok {
+				c.errorf(ErrDuplicateClass, decl.Name.Token, "duplicate class: %s", decl.Name.Value)
+				continue
+			}
+			member := &Class{Name: class.Name + "." + decl.Name.Value, Decl: decl, Outer: class,
+				static: isStatic(decl.Modifiers) || decl.IsInterface() || decl.IsEnum() || decl.IsRecord() || class.isInterface()}
+			class.Classes[decl.Name.Value] = member
+			if c.classes[class.Name] == class {
+				c.classes[member.Name] = member
+			}
+			members = append(members, member)
+		}
+		all = append(all, c.declareMembers(members)...)
+	}
+	return all
+}
+
+// resolveTypes resolves the types in the declarations of the members of
+// class in the current context, its body, so that they name the same
+// classes wherever the members are used. It reports the classes named in
+// the types of fields, parameters and results that are not declared.
+func (c *Checker) resolveTypes(class *Class) {
+	resolve := func(types ...*ast.Type) {
+		for _, t := range types {
+			if t != nil {
+				c.resolved[t] = c.resolveClass(t.Name)
+			}
+		}
+	}
+	// The type parameters of class and of the classes and the method it
+	// is declared in are in scope in its body.
+	inScope := append([]*ast.TypeParameter{}, c.typeParams...)
+	for k := class; k != nil; k = k.enclosing() {
+		inScope = append(inScope, k.Decl.TypeParameters...)
+	}
+	// The canonical constructor of a record shares the types of its
+	// components.
+	checked := map[*ast.Type]bool{}
+	declared := func(t *ast.Type, typeParams []*ast.TypeParameter) {
+		resolve(t)
+		if !checked[t] {
+			checked[t] = true
+			c.checkTypeNames(t, append(typeParams, inScope...))
+			c.checkTypeArguments(t, append(typeParams, inScope...))
+		}
+	}
+	parameters := func(params []*ast.Parameter, typeParams []*ast.TypeParameter) {
+		for _, p := range params {
+			declared(p.DataType, typeParams)
+		}
+		for _, tp := range typeParams {
+			resolve(tp.Bounds...)
+		}
+	}
+	decl := class.Decl
+	parameters(decl.Components, decl.TypeParameters)
+	resolve(decl.Permits...)
+	// The fields and accessors of the components of a record have their
+	// types, which are checked with them.
+	for _, f := range decl.Fields {
+		if f.Token.Type == tokens.RECORD {
+			resolve(f.Type)
+		} else {
+			declared(f.Type, nil)
+		}
+	}
+	for _, m := range decl.Methods {
+		if m.Token.Type == tokens.RECORD {
+			resolve(m.ReturnType)
+		} else {
+			declared(m.ReturnType, m.TypeParameters)
+		}
+		resolve(m.Throws...)
+		parameters(m.Parameters, m.TypeParameters)
+	}
+	for _, ctor := range decl.Constructors {
+		resolve(ctor.Throws...)
+		parameters(ctor.Parameters, ctor.TypeParameters)
+	}
+}
+
+// checkTypeNames reports the classes that the type t, or a type argument
+// of it, names but that are not declared. The type parameters typeParams
+// are in scope where t is written.
+func (c *Checker) checkTypeNames(t *ast.Type, typeParams []*ast.TypeParameter) {
+	if t == nil {
+		return
+	}
+	if t.Name == "?" {
+		c.checkTypeNames(t.Bound, typeParams)
+		return
+	}
+	if !isPrimitive(t.Name) && t.Name != "void" && !isLibraryType(t.Name) && typeVariable(t, typeParams) == nil {
+		c.lookupClass(t)
+	}
+	for _, a := range t.Arguments {
+		c.checkTypeNames(a, typeParams)
+	}
+}
+
+// checkTypeArguments reports the type arguments in t, written where the
+// type parameters typeParams are in scope, that are not within the bounds
+// of the type parameters of the generic class they are given to, as the
+// String of a Box<String> for a Box<T extends Number>. Wildcards and type
+// variables are not checked.
+func (c *Checker) checkTypeArguments(t *ast.Type, typeParams []*ast.TypeParameter) {
+	if t == nil {
+		return
+	}
+	for _, a := range t.Arguments {
+		if a.Name == "?" {
+			c.checkTypeArguments(a.Bound, typeParams)
+		} else {
+			c.checkTypeArguments(a, typeParams)
+		}
+	}
+	class := c.classOf(t)
+	if class == nil || len(t.Arguments) != len(class.Decl.TypeParameters) {
+		return
+	}
+	args := c.typeIn(t, typeParams).Arguments
+	given := map[string]*Type{}
+	for i, tp := range class.Decl.TypeParameters {
+		given[tp.Name.Value] = args[i]
+	}
+	for i, tp := range class.Decl.TypeParameters {
+		a := t.Arguments[i]
+		if a.Name == "?" || typeVariable(a, typeParams) != nil || !isKnown(args[i]) {
+			continue
+		}
+		for _, b := range tp.Bounds {
+			if bound := c.typeWith(b, class.Decl.TypeParameters, given); !isSubtype(args[i], bound) {
+				c.errorf(ErrTypeArgumentBounds, a.Token, "type argument %s is not within bounds of type-variable %s",
+					args[i], tp.Name.Value)
+				break
+			}
+		}
+	}
+}
+
+func (c *Checker) resolveSupertypes(class *Class) {
+	if t := class.Decl.SuperClass; t != nil && t.Name != "Object" {
+		super := c.lookupClass(t)
+		switch {
+		case super == nil:
+		case super.isInterface():
+			c.errorf(ErrBadSupertype, t.Token, "no interface expected here")
+		case super.Decl.IsFinal():
+			// An enum is implicitly final, but for the bodies of its
+			// constants.
+			c.errorf(ErrBadSupertype, t.Token, "cannot inherit from final %s", super.Name)
+		case super == c.classes["Enum"] && !class.Decl.IsEnum():
+			c.errorf(ErrBadSupertype, t.Token, "classes cannot directly extend java.lang.Enum")
+		default:
+			class.Super = super
+		}
+	}
+
+	for _, t := range class.Decl.Interfaces {
+		i := c.lookupClass(t)
+		switch {
+		case i == nil:
+		case !i.isInterface():
+			c.errorf(ErrBadSupertype, t.Token, "interface expected here")
+		default:
+			class.Interfaces = append(class.Interfaces, i)
+		}
+	}
+}
+
+// checkCycles reports a class that is its own supertype, and breaks the
+// cycle so that later checks can walk the hierarchy.
+func (c *Checker) checkCycles(class *Class) {
+	cyclic := class.Super != nil && class.Super.isSubtypeOf(class)
+	for _, i := range class.Interfaces {
+		cyclic = cyclic || i.isSubtypeOf(class)
+	}
+	if cyclic {
+		c.errorf(ErrCyclicInheritance, class.Decl.Name.Token, "cyclic inheritance involving %s", class.Name)
+		class.Super = nil
+		class.Interfaces = nil
+	}
+}
+
+func (c *Checker) lookupClass(t *ast.Type) *Class {
+	if class := c.classOf(t); class != nil {
+		return class
+	}
+	c.errorf(ErrCannotFindSymbol, t.Token, "cannot find symbol: class %s", t.Name)
+	return nil
+}
+
+// classOf returns the class that t names, if it is declared, resolved
+// where t is written.
+func (c *Checker) classOf(t *ast.Type) *Class {
+	if class, ok := c.resolved[t]; ok {
+		return class
+	}
+	return c.resolveClass(t.Name)
+}
+
+// resolveClass returns the class name names in the current context, or
+// nil if there is none. A simple name names a local class, or else a
+// member class, declared or inherited, of the classes the code is in,
+// innermost first, or else a top level class. A qualified name, as in
+// Outer.Inner, names a member class of the class its qualifier names.
+func (c *Checker) resolveClass(name string) *Class {
+	simple, rest, qualified := strings.Cut(name, ".")
+	class := c.classes[simple]
+	for s := c.scope; s != nil; s = s.outer {
+		if local, ok := s.classes[simple]; ok {
+			class = local
+			break
+		}
+		if s.class != nil {
+			if member := memberClass(s.class, simple); member != nil {
+				class = member
+				break
+			}
+		}
+	}
+	for qualified && class != nil {
+		simple, rest, qualified = strings.Cut(rest, ".")
+		class = memberClass(class, simple)
+	}
+	return class
+}
+
+// memberClass returns the member class name of class, declared there or
+// inherited.
+func memberClass(class *Class, name string) *Class {
+	for _, t := range supertypes(class) {
+		if member, ok := t.Classes[name]; ok {
+			return member
+		}
+	}
+	return nil
+}
+
+// bodyScope returns the scope of the body of class, where its members are
+// in scope, and what is in scope where it is declared.
+func (c *Checker) bodyScope(class *Class) *scope {
+	s := newScope(c.declarationScope(class))
+	s.class = class
+	return s
+}
+
+// declarationScope returns the scope class is declared in: the body of its
+// outer class, the scope of a local or anonymous class, or none for a top
+// level class.
+func (c *Checker) declarationScope(class *Class) *scope {
+	switch {
+	case class.scope != nil:
+		return class.scope
+	case class.Outer != nil:
+		return c.bodyScope(class.Outer)
+	}
+	return nil
+}
+
+func (c *Checker) checkClass(class *Class) {
+	c.checkDuplicates(class)
+	if class.Decl.IsRecord() {
+		c.checkRecord(class)
+	}
+	for _, m := range class.Decl.Methods {
+		c.checkMethodBody(class, m)
+		c.checkOverride(class, m)
+	}
+	c.checkImplemented(class)
+	c.checkDefaults(class)
+
+	for _, f := range class.Decl.Fields {
+		if f.Value != nil {
+			static := isStaticField(class, f)
+			c.enter(class, static, typeParameters(class, static, nil), nil)
+			f.Value = c.checkAssignedValue(f.Value, c.typeOf(f.Type))
+		}
+	}
+	// The static initializers must assign the blank final static fields,
+	// and the instance initializers and then each constructor the blank
+	// final instance fields.
+	statics := newFlow()
+	for _, f := range blankFinals(class, true) {
+		statics.unassigned[f.Name] = true
+	}
+	for _, b := range class.Decl.StaticInitializers {
+		c.enter(class, true, nil, nil)
+		c.result = voidType
+		c.initializer, c.flow = true, statics
+		c.checkStatement(b)
+		statics = c.flow
+	}
+	instance := newFlow()
+	for _, f := range blankFinals(class, false) {
+		instance.unassigned[f.Name] = true
+	}
+	for _, b := range class.Decl.Initializers {
+		c.enter(class, false, class.Decl.TypeParameters, nil)
+		c.result = voidType
+		c.throws = c.initializerThrows(class)
+		c.initializer, c.flow = true, instance
+		c.checkStatement(b)
+		instance = c.flow
+	}
+	for _, ctor := range class.Decl.Constructors {
+		c.enter(class, false, typeParameters(class, false, ctor.TypeParameters), ctor.Parameters)
+		c.result = voidType
+		c.throws = c.checkThrowsClause(ctor.Throws)
+		c.initializer, c.flow = true, instance.copy()
+		c.explicitCall = explicitCall(ctor)
+		if class.Decl.IsEnum() {
+			c.checkEnumConstructor(ctor)
+		}
+		switch explicitConstructorCall(ctor).(type) {
+		case nil:
+			c.checkImplicitSuperCall(class, ctor.Name.Token, "")
+		case *ast.ThisExpression:
+			// The other constructor assigns the fields.
+			for _, f := range blankFinals(class, false) {
+				c.flow.assign(f.Name)
+			}
+		}
+		c.checkStatement(ctor.Body)
+		c.checkFieldsAssigned(ctor.Name.Token)
+	}
+	c.checkRecursiveConstructors(class)
+	var unassigned []*ast.FieldDeclaration
+	if len(class.Decl.Constructors) == 0 {
+		// The constructor of an anonymous class is checked where it is
+		// instantiated.
+		c.enter(class, false, nil, nil)
+		if !class.anonymous {
+			c.checkImplicitSuperCall(class, class.Decl.Name.Token, " in default constructor")
+		}
+		unassigned = blankFinals(class, false)
+	}
+	for _, f := range append(unassigned, blankFinals(class, true)...) {
+		if instance.unassigned[f.Name] || statics.unassigned[f.Name] {
+			c.errorf(ErrUnassigned, f.Name.Token, "variable %s not initialized in the default constructor", f.Name.Value)
+		}
+	}
+	for _, m := range class.Decl.Methods {
+		static := isStatic(m.Modifiers)
+		c.enter(class, static, typeParameters(class, static, m.TypeParameters), m.Parameters)
+		c.result = c.typeOf(m.ReturnType)
+		c.throws = c.checkThrowsClause(m.Throws)
+		c.checkOverrideThrows(class, m)
+		if m.Body != nil {
+			c.checkStatement(m.Body)
+			c.checkMissingReturn(m)
+		}
+	}
+	for _, body := range constantBodies(class) {
+		c.checkClass(&Class{Name: body.Name.Value, Decl: body, Super: class, Outer: class, static: true, anonymous: true})
+	}
+}
+
+// checkRecursiveConstructors reports, once for each cycle, constructors
+// of class that end up invoking themselves through this(...).
+func (c *Checker) checkRecursiveConstructors(class *Class) {
+	ctors := class.Decl.Constructors
+	next := map[*ast.ConstructorDeclaration]*ast.ConstructorDeclaration{}
+	for _, ctor := range ctors {
+		call := explicitCall(ctor)
+		if call == nil || class.Decl.IsRecord() && (ctor.Compact || isCanonical(ctor, class)) {
+			// The canonical constructor of a record may not call another.
+			continue
+		}
+		if _, ok := call.Function.(*ast.ThisExpression); !ok {
+			continue
+		}
+		for _, other := range ctors {
+			if parameterList(other.Parameters) == call.Signature {
+				next[ctor] = other
+			}
+		}
+	}
+	reported := map[*ast.ConstructorDeclaration]bool{}
+	for _, ctor := range ctors {
+		d := next[ctor]
+		for i := 0; d != nil && d != ctor && i < len(ctors); i++ {
+			d = next[d]
+		}
+		if d != ctor || reported[ctor] {
+			continue
+		}
+		c.errorf(ErrRecursiveConstructor, ctor.Name.Token, "recursive constructor invocation")
+		for reported[d] = true; next[d] != ctor; d = next[d] {
+			reported[next[d]] = true
+		}
+	}
+}
+
+// enter makes a member of class the context of the code being checked,
+// with typeParams and params in scope, inside the body of class. Outside
+// of any class, the global variables are in scope instead.
+func (c *Checker) enter(class *Class, static bool, typeParams []*ast.TypeParameter, params []*ast.Parameter) {
+	outer := c.globals
+	if class != nil {
+		outer = c.bodyScope(class)
+	}
+	c.class, c.static, c.typeParams, c.scope, c.result = class, static, typeParams, newScope(outer), nil
+	c.initializer, c.lambda, c.flow, c.assignments = false, false, newFlow(), nil
+	c.throws, c.throwAny, c.handlers, c.yield = nil, false, nil, nil
+	c.explicitCall = nil
+	for _, p := range params {
+		v := c.scope.declare(p.ParameterName, c.parameterType(p, typeParams))
+		if ast.HasModifier(p.Modifiers, tokens.FINAL) {
+			v.final, v.kind = true, "final parameter"
+		}
+	}
+}
+
+// save returns a function that restores the context of the code being
+// checked to what it is now.
+func (c *Checker) save() func() {
+	class, static, typeParams, scope, result := c.class, c.static, c.typeParams, c.scope, c.result
+	initializer, lambda, flow, assignments := c.initializer, c.lambda, c.flow, c.assignments
+	throws, throwAny, handlers, yield := c.throws, c.throwAny, c.handlers, c.yield
+	explicitCall := c.explicitCall
+	return func() {
+		c.class, c.static, c.typeParams, c.scope, c.result = class, static, typeParams, scope, result
+		c.initializer, c.lambda, c.flow, c.assignments = initializer, lambda, flow, assignments
+		c.throws, c.throwAny, c.handlers, c.yield = throws, throwAny, handlers, yield
+		c.explicitCall = explicitCall
+	}
+}
+
+// checkMethodBody checks that m has a body exactly when it should: interface
+// methods have one only if they are default, static or private, and class
+// methods unless they are abstract or native.
+func (c *Checker) checkMethodBody(class *Class, m *ast.FunctionLiteral) {
+	isDefault := ast.HasModifier(m.Modifiers, tokens.DEFAULT)
+	if class.isInterface() {
+		hasBody := isDefault || ast.HasModifier(m.Modifiers, tokens.STATIC) || ast.HasModifier(m.Modifiers, tokens.PRIVATE)
+		if m.Body != nil && !hasBody {
+			c.errorf(ErrMethodBody, m.Name.Token, "interface abstract methods cannot have body")
+		} else if m.Body == nil && hasBody {
+			c.errorf(ErrMethodBody, m.Name.Token, "missing method body, or declare abstract")
+		}
+		return
+	}
+
+	if isDefault {
+		c.errorf(ErrIllegalModifier, m.Name.Token, "modifier default not allowed here")
+	}
+	isAbstract := ast.HasModifier(m.Modifiers, tokens.ABSTRACT)
+	if m.Body != nil && isAbstract {
+		c.errorf(ErrMethodBody, m.Name.Token, "abstract methods cannot have a body")
+	} else if m.Body == nil && !isAbstract && !ast.HasModifier(m.Modifiers, tokens.NATIVE) {
+		c.errorf(ErrMethodBody, m.Name.Token, "missing method body, or declare abstract")
+	}
+}
+
+// checkImplemented reports a concrete class that neither declares nor
+// inherits an implementation of each abstract method of its supertypes.
+func (c *Checker) checkImplemented(class *Class) {
+	if class.isAbstract() {
+		return
+	}
+	// The bodies of the constants implement the methods of an enum.
+	if class.Decl.IsEnum() && hasConstantBodies(class) {
+		return
+	}
+	for _, t := range supertypes(class) {
+		for _, m := range t.Decl.Methods {
+			if !isAbstractMethod(t, m) {
+				continue
+			}
+			sig := signatureIn(class, t, m)
+			if !c.isImplemented(class, sig) {
+				c.errorf(ErrMissingImplementation, class.Decl.Name.Token,
+					"%s is not abstract and does not override abstract method %s in %s", class.Name, sig, t.Name)
+				return
+			}
+		}
+	}
+}
+
+func (c *Checker) isImplemented(class *Class, sig string) bool {
+	for k := class; k != nil; k = k.Super {
+		for _, m := range k.Decl.Methods {
+			if signatureIn(class, k, m) == sig && m.Body != nil {
+				return true
+			}
+		}
+	}
+	for _, im := range inheritedInterfaceMethods(class, sig) {
+		if im.method.Body != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDefaults applies the rules for default methods inherited along
+// several paths: a method declared in a class wins, then a method declared
+// in a more specific interface wins. Otherwise, inheriting a default along
+// with another declaration of the same method is an error, which the class
+// must resolve by overriding the method.
+func (c *Checker) checkDefaults(class *Class) {
+	reported := map[string]bool{}
+	for _, i := range interfacesOf(class) {
+		for _, m := range i.Decl.Methods {
+			sig := signatureIn(class, i, m)
+			if reported[sig] || !isInherited(m) || declaredInClass(class, sig) {
+				continue
+			}
+			methods := inheritedInterfaceMethods(class, sig)
+			if len(methods) < 2 {
+				continue
+			}
+
+			defaults := 0
+			for _, im := range methods {
+				if im.method.Body != nil {
+					defaults++
+				}
+			}
+			if defaults == 0 {
+				continue
+			}
+			reported[sig] = true
+
+			a, b := methods[0].owner.Name, methods[1].owner.Name
+			what := "unrelated defaults"
+			if defaults < len(methods) {
+				what = "abstract and default"
+			}
+			c.errorf(ErrConflictingDefaults, class.Decl.Name.Token,
+				"types %s and %s are incompatible; %s %s inherits %s for %s from types %s and %s",
+				a, b, class.kind(), class.Name, what, sig, a, b).
+				WithHint("override %s in %s", sig, class.Name)
+		}
+	}
+}
+
+// declaredInClass reports whether class, if it is not an interface, or one
+// of its superclasses declares a method with signature sig.
+func declaredInClass(class *Class, sig string) bool {
+	if class.isInterface() {
+		return false
+	}
+	for k := class; k != nil; k = k.Super {
+		for _, m := range k.Decl.Methods {
+			if signatureIn(class, k, m) == sig {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type interfaceMethod struct {
+	method *ast.FunctionLiteral
+	owner  *Class
+}
+
+// inheritedInterfaceMethods returns the declarations of the instance
+// method sig in the interfaces of class that are not overridden by a
+// declaration in a more specific interface.
+func inheritedInterfaceMethods(class *Class, sig string) []interfaceMethod {
+	var candidates []interfaceMethod
+	for _, i := range interfacesOf(class) {
+		for _, m := range i.Decl.Methods {
+			if signatureIn(class, i, m) == sig && isInherited(m) {
+				candidates = append(candidates, interfaceMethod{m, i})
+			}
+		}
+	}
+
+	var result []interfaceMethod
+	for _, c := range candidates {
+		overridden := false
+		for _, other := range candidates {
+			if other.owner != c.owner && other.owner.isSubtypeOf(c.owner) {
+				overridden = true
+			}
+		}
+		if !overridden {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// supertypes returns class, its superclasses and then all of the
+// interfaces it implements.
+func supertypes(class *Class) []*Class {
+	var result []*Class
+	for k := class; k != nil; k = k.Super {
+		if !k.isInterface() {
+			result = append(result, k)
+		}
+	}
+	return append(result, interfacesOf(class)...)
+}
+
+// interfacesOf returns the interfaces class implements, directly or
+// indirectly, including class itself if it is an interface.
+func interfacesOf(class *Class) []*Class {
+	var result []*Class
+	seen := map[*Class]bool{}
+	var visit func(k *Class)
+	visit = func(k *Class) {
+		if k == nil || seen[k] {
+			return
+		}
+		seen[k] = true
+		if k.isInterface() {
+			result = append(result, k)
+		}
+		visit(k.Super)
+		for _, i := range k.Interfaces {
+			visit(i)
+		}
+	}
+	visit(class)
+	return result
+}
+
+// isInherited reports whether the interface method m is inherited by the
+// classes implementing the interface, which static and private methods
+// are not.
+func isInherited(m *ast.FunctionLiteral) bool {
+	return !ast.HasModifier(m.Modifiers, tokens.STATIC) && !ast.HasModifier(m.Modifiers, tokens.PRIVATE)
+}
+
+// isAbstractMethod reports whether m, declared in owner, is abstract.
+// Interface methods without a body are implicitly abstract.
+func isAbstractMethod(owner *Class, m *ast.FunctionLiteral) bool {
+	if owner.isInterface() {
+		return m.Body == nil && !ast.HasModifier(m.Modifiers, tokens.STATIC)
+	}
+	return ast.HasModifier(m.Modifiers, tokens.ABSTRACT)
+}
+
+// signature is the name and parameter types of m as javac prints them,
+// e.g. "move(int,int)".
+func signature(m *ast.FunctionLiteral) string {
+	return m.Name.Value + parameterList(m.Parameters)
+}
+
+// signatureIn is the signature of the method m of the supertype t of
+// class as class inherits it, with the type arguments class gives t in
+// place of the type parameters of t, e.g. "compareTo(Point)" for the
+// compareTo(T) of Comparable in a class implementing Comparable<Point>.
+func signatureIn(class, t *Class, m *ast.FunctionLiteral) string {
+	args := typeArguments(class, t)
+	var params []*ast.Parameter
+	for _, p := range m.Parameters {
+		param := *p
+		if typeVariable(p.DataType, m.TypeParameters) == nil {
+			param.DataType = substitute(p.DataType, args)
+		}
+		params = append(params, &param)
+	}
+	return m.Name.Value + parameterList(params)
+}
+
+// typeArguments maps the type parameters of t, a supertype of class, to
+// the type arguments class gives them, directly or through its other
+// supertypes. A raw supertype, as in `implements Comparable`, gives none.
+func typeArguments(class, t *Class) map[string]*ast.Type {
+	if class == t {
+		args := map[string]*ast.Type{}
+		for _, tp := range t.Decl.TypeParameters {
+			args[tp.Name.Value] = &ast.Type{Token: tp.Name.Token, Name: tp.Name.Value}
+		}
+		return args
+	}
+
+	declared := class.Decl.Interfaces
+	if class.Decl.SuperClass != nil {
+		declared = append([]*ast.Type{class.Decl.SuperClass}, declared...)
+	}
+	for _, st := range declared {
+		super := directSupertype(class, st.Name)
+		if super == nil || !super.isSubtypeOf(t) {
+			continue
+		}
+		given := map[string]*ast.Type{}
+		for i, tp := range super.Decl.TypeParameters {
+			if i < len(st.Arguments) {
+				given[tp.Name.Value] = st.Arguments[i]
+			}
+		}
+		args := typeArguments(super, t)
+		for name, arg := range args {
+			args[name] = substitute(arg, given)
+		}
+		return args
+	}
+	return nil
+}
+
+// directSupertype returns the direct supertype of class named name, which
+// may leave out the classes a member class is declared in.
+func directSupertype(class *Class, name string) *Class {
+	for _, t := range append([]*Class{class.Super}, class.Interfaces...) {
+		if t != nil && (t.Name == name || strings.HasSuffix(t.Name, "."+name)) {
+			return t
+		}
+	}
+	return nil
+}
+
+// substitute is t with the type arguments args in place of the type
+// variables they are given for.
+func substitute(t *ast.Type, args map[string]*ast.Type) *ast.Type {
+	arg, ok := args[t.Name]
+	if !ok {
+		return t
+	}
+	substituted := *arg
+	substituted.Dimensions += t.Dimensions
+	return &substituted
+}
@@ -0,0 +1,1251 @@
+package typecheck
+
+import (
+	"java/lexer"
+	"java/parser"
+	"testing"
+)
+
+func check(t *testing.T, c *Checker, input string) []string {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %q", input, p.Errors())
+	}
+
+	var messages []string
+	for _, d := range c.Check(program) {
+		messages = append(messages, d.Message)
+	}
+	return messages
+}
+
+func TestValidHierarchies(t *testing.T) {
+	inputs := []string{
+		`interface Shape { int area(); }
+		 abstract class Base implements Shape { abstract int side(); }
+		 class Square extends Base {
+		 	int side() { return 1; }
+		 	public int area() { return 1; }
+		 }
+		 new Square();`,
+		// An implementation inherited from a superclass counts.
+		`interface Shape { int area(); }
+		 class Base { public int area() { return 0; } }
+		 class Square extends Base implements Shape {}`,
+		// So does a default method.
+		`interface Shape { int area(); }
+		 interface Zero extends Shape { default int area() { return 0; } }
+		 class Point implements Zero {}
+		 new Point();`,
+		// The default of the more specific interface wins.
+		`interface Named { default String name() { return "a"; } }
+		 interface Loud extends Named { default String name() { return "b"; } }
+		 class C implements Loud, Named {}`,
+		// Overriding resolves a conflict between defaults.
+		`interface A { default int f() { return 1; } }
+		 interface B { default int f() { return 2; } }
+		 class C implements A, B { public int f() { return A.super.f(); } }`,
+		// Static and private interface methods are not inherited.
+		`interface A { static int f() { return 1; } private int g() { return 1; } }
+		 interface B { default int f() { return 2; } default int g() { return 2; } }
+		 class C implements A, B {}`,
+		`class Node extends Object { native int hash(); }`,
+		// Private members are accessible anywhere in their class, also
+		// through other instances of it.
+		`class Point {
+			private int x;
+			private Point(int x) { this.x = x; }
+			private int x() { return x; }
+			static Point origin() { return new Point(0); }
+			boolean same(Point other) { return other.x == x(); }
+			boolean equals(Point other) { return other.x() == this.x; }
+		 }`,
+		// Protected and package-private members are accessible in the
+		// unnamed package.
+		`class A { protected int x; int y; protected int f() { return x; } }
+		 class B { int g(A a) { return a.x + a.y + a.f(); } }`,
+		// Locals and parameters shadow instance fields in static methods.
+		`class A { int x; static int f(int x) { return x; } static int g() { int x = 1; return x; } }`,
+		`class A { static int count; static int f() { return count; } static int g() { return f(); } }`,
+		`class A { protected void f() {} }
+		 class B extends A { public void f() {} }`,
+		`interface I { void f(); }
+		 class A implements I { public void f() {} }`,
+		`class A { private void f() {} }
+		 class B extends A { void f() {} }`,
+		`class Counter {
+			static int count;
+			static { count = 1; }
+			static int increment() { return count = count + 1; }
+		 }
+		 Counter.increment();
+		 Counter.count = Counter.count + 1;`,
+		`interface Limits { int MAX = 10; static int max() { return MAX; } }
+		 class A implements Limits { static int f() { return MAX + Limits.max(); } }`,
+		// A call selects the override, not the method it overrides.
+		`interface I { void f(int x); }
+		 abstract class B implements I { public void f(int x) {} }
+		 class C extends B {}
+		 new C().f(1);`,
+		// A variable hides a class with the same name.
+		`class A { int x; }
+		 class B { A A; int f() { return A.x; } }`,
+		// A static interface method is called through its interface.
+		`interface I { static int s() { return 1; } default int d() { return s(); } }
+		 class C implements I { int f() { return I.s() + d(); } }`,
+		// An override may return a subtype of the result of the method it
+		// overrides.
+		`class A { Object f() { return null; } }
+		 class B extends A { String f() { return "s"; } }
+		 interface Source<T> { T get(); }
+		 class Text implements Source<String> { public String get() { return "t"; } }
+		 class Box<T> implements Source<T> { T value; public T get() { return value; } }`,
+		// A static method hides one of the superclass, and the static
+		// methods of an interface are not inherited.
+		`class A { static int f() { return 1; } }
+		 class B extends A { static int f() { return 2; } }
+		 interface I { static int g() { return 1; } }
+		 class C implements I { int g() { return 2; } }`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestClassErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"class A extends B {}", "cannot find symbol: class B"},
+		{"class A {}\nclass A {}", "duplicate class: A"},
+		{"class A extends B {}\nclass B extends A {}", "cyclic inheritance involving A"},
+		{"interface I extends I {}", "cyclic inheritance involving I"},
+		{"final class A {}\nclass B extends A {}", "cannot inherit from final A"},
+		{"interface I {}\nclass A extends I {}", "no interface expected here"},
+		{"class B {}\nclass A implements B {}", "interface expected here"},
+		{"class B {}\ninterface I extends B {}", "interface expected here"},
+		{"abstract class A {}\nnew A();", "A is abstract; cannot be instantiated"},
+		{"interface I {}\nclass A { I make() { return new I(); } }", "I is abstract; cannot be instantiated"},
+		{"new Missing();", "cannot find symbol: class Missing"},
+		{"class U { Foo x; int f() { return x.bar(); } }", "cannot find symbol: class Foo"},
+		{"class U { static int f(Foo x) { return 1; } }", "cannot find symbol: class Foo"},
+		{"class U { Foo f() { return null; } }", "cannot find symbol: class Foo"},
+		{"class Box<T> {}\nclass U { Box<Foo> b; }", "cannot find symbol: class Foo"},
+		{"record P(Foo x) {}", "cannot find symbol: class Foo"},
+		{"record P(Foo... xs) {}", "cannot find symbol: class Foo"},
+		{"interface Shape { int area(); }\nclass Square implements Shape {}",
+			"Square is not abstract and does not override abstract method area() in Shape"},
+		{"abstract class A { abstract void f(int x, String y); }\nclass B extends A {}",
+			"B is not abstract and does not override abstract method f(int,String) in A"},
+		{"class A { abstract void f(); }", "A is not abstract and does not override abstract method f() in A"},
+		{"class A { void f(); }", "missing method body, or declare abstract"},
+		{"abstract class A { abstract void f() {} }", "abstract methods cannot have a body"},
+		{"interface I { void f() {} }", "interface abstract methods cannot have body"},
+		{"interface I { default void f(); }", "missing method body, or declare abstract"},
+		{"class A { default void f() {} }", "modifier default not allowed here"},
+		{"interface A { default int f() { return 1; } }\ninterface B { default int f() { return 2; } }\nclass C implements A, B {}",
+			"types A and B are incompatible; class C inherits unrelated defaults for f() from types A and B"},
+		{"interface A { default int f() { return 1; } }\ninterface B { int f(); }\ninterface C extends A, B {}",
+			"types A and B are incompatible; interface C inherits abstract and default for f() from types A and B"},
+		{"class A { private int x; }\nclass B { int f(A a) { return a.x; } }", "x has private access in A"},
+		{"class A { private int x; }\nclass B { void f(A a) { a.x = 1; } }", "x has private access in A"},
+		{"class A { private int x; }\nclass B extends A { int f() { return x; } }", "x has private access in A"},
+		{"class A { private int f(int x) { return x; } }\nclass B { int g(A a) { return a.f(1); } }",
+			"f(int) has private access in A"},
+		{"class A { private int f() { return 1; } }\nclass B extends A { int g() { return f(); } }",
+			"f() has private access in A"},
+		{"class A { private A(int x) {} }\nnew A(1);", "A(int) has private access in A"},
+		{"class A { private A() {} }\nclass B extends A { B() { super(); } }", "A() has private access in A"},
+		{"class A { int x; static int f() { return x; } }",
+			"non-static variable x cannot be referenced from a static context"},
+		{"class A { int x; static void f() { x = 1; } }",
+			"non-static variable x cannot be referenced from a static context"},
+		{"class A { int x; static int y = x; }",
+			"non-static variable x cannot be referenced from a static context"},
+		{"class A { int f(int a) { return a; } static int g() { return f(1); } }",
+			"non-static method f(int) cannot be referenced from a static context"},
+		{"class A { static A f() { return this; } }",
+			"non-static variable this cannot be referenced from a static context"},
+		{"class A { int x; }\nclass B extends A { static int f() { return super.x; } }",
+			"non-static variable super cannot be referenced from a static context"},
+		{"this;", "non-static variable this cannot be referenced from a static context"},
+		{"class A { int x; static { x = 1; } }",
+			"non-static variable x cannot be referenced from a static context"},
+		{"class A { int x; }\nA.x = 1;", "non-static variable x cannot be referenced from a static context"},
+		{"class A { void f() {} }\nA.f();", "non-static method f() cannot be referenced from a static context"},
+		{"class A { private static int count; }\nA.count;", "count has private access in A"},
+		{"interface I { static int s() { return 1; } }\nclass C implements I {}\nnew C().s();",
+			"illegal static interface method call"},
+		{"interface I { static int s() { return 1; } }\nclass C implements I {}\nC.s();",
+			"illegal static interface method call"},
+		{"interface I { static int s() { return 1; } }\ninterface J extends I {}\nJ.s();",
+			"illegal static interface method call"},
+		{"interface I { static int s() { return 1; } }\nclass C implements I { int f() { return s(); } }",
+			"cannot find symbol: method s()"},
+		{"class A { void f(int a, double b) {} void f(double a, int b) {} }\nnew A().f(1, 2);",
+			"reference to f is ambiguous"},
+		{"class A { void f(Integer a, int b) {} void f(int a, Integer b) {} }\nnew A().f(1, 2);",
+			"reference to f is ambiguous"},
+		{"class A { void f(int a) {} }\nnew A().f(\"s\");", "method f in class A cannot be applied to given types"},
+		{"class A { void f(int a) {} }\nnew A().f(1, 2);", "method f in class A cannot be applied to given types"},
+		{"class A { void f(int a) {} void f(String s) {} }\nnew A().f(true);", "no suitable method found for f(boolean)"},
+		{"class A { void f(String s) {} void f(A a) {} }\nnew A().f(null);", "reference to f is ambiguous"},
+		{"class A { void f(int a) {} }\nnew A().f(null);", "method f in class A cannot be applied to given types"},
+		{"int x = null;", "incompatible types: <null> cannot be converted to int"},
+		{"class A { A(int x) {} }\nclass B extends A {}", "constructor A in class A cannot be applied to given types"},
+		{"class A { A(int x) {} }\nclass B extends A { B() {} }", "constructor A in class A cannot be applied to given types"},
+		{"class A { A(int x) {} }\nnew A(\"s\");", "constructor A in class A cannot be applied to given types"},
+		{"class A {}\nnew A(1);", "constructor A in class A cannot be applied to given types"},
+		{"class A { A() { this(); } }", "recursive constructor invocation"},
+		{"class A { A() { this(1); } A(int x) { this(); } }", "recursive constructor invocation"},
+		{"class A { A() { int x = 1; super(); } }", "call to super must be first statement in constructor"},
+		{"class A { A() {} void f() { this(); } }", "call to this must be first statement in constructor"},
+		{"class A { void f() { super.f(); } }", "cannot find symbol: method f()"},
+		{"class A { A() { super(1); } }", "constructor Object in class Object cannot be applied to given types"},
+		{"class A { void f(int a) {} int f(int b) { return b; } }", "method f(int) is already defined in class A"},
+		{"class A { A(int... a) {} A(int... b) {} }", "constructor A(int...) is already defined in class A"},
+		{"public int f(int... xs) { return 1; }\nf(1, \"s\");", "method f cannot be applied to given types"},
+		{"class A { public void f() {} }\nclass B extends A { void f() {} }",
+			"f() in B cannot override f() in A; attempting to assign weaker access privileges; was public"},
+		{"class A { protected void f(int x) {} }\nclass B extends A { private void f(int x) {} }",
+			"f(int) in B cannot override f(int) in A; attempting to assign weaker access privileges; was protected"},
+		{"class A { int f() { return 1; } }\nclass B extends A { String f() { return \"s\"; } }",
+			"f() in B cannot override f() in A; return type String is not compatible with int"},
+		{"class A { void f() {} }\nclass B extends A { int f() { return 1; } }",
+			"f() in B cannot override f() in A; return type int is not compatible with void"},
+		{"class A { Object f() { return null; } }\nclass B extends A { int f() { return 1; } }",
+			"f() in B cannot override f() in A; return type int is not compatible with Object"},
+		{"interface I { int f(); }\nclass A implements I { public String f() { return \"s\"; } }",
+			"f() in A cannot implement f() in I; return type String is not compatible with int"},
+		{"interface I { Integer f(); }\ninterface J extends I { String f(); }",
+			"f() in J clashes with f() in I; return type String is not compatible with Integer"},
+		{"interface Source<T> { T get(); }\nclass Text implements Source<String> { public Integer get() { return 1; } }",
+			"get() in Text cannot implement get() in Source; return type Integer is not compatible with String"},
+		{"class A { final int f() { return 1; } }\nclass B extends A { int f() { return 2; } }",
+			"f() in B cannot override f() in A; overridden method is final"},
+		{"class A { static int f() { return 1; } }\nclass B extends A { int f() { return 2; } }",
+			"f() in B cannot override f() in A; overridden method is static"},
+		{"class A { int f() { return 1; } }\nclass B extends A { static int f() { return 2; } }",
+			"f() in B cannot override f() in A; overriding method is static"},
+		{"class A { static final int f() { return 1; } }\nclass B extends A { static int f() { return 2; } }",
+			"f() in B cannot hide f() in A; overridden method is static final"},
+		{"class A { public static int f() { return 1; } }\nclass B extends A { static int f() { return 2; } }",
+			"f() in B cannot hide f() in A; attempting to assign weaker access privileges; was public"},
+		{"interface I { void f(); }\nclass A implements I { public void g() {} void f() {} }",
+			"f() in A cannot implement f() in I; attempting to assign weaker access privileges; was public"},
+		{"public <T extends Comparable<T>> T max(T a, T b) { return a; }\nclass A {}\nmax(new A(), new A());",
+			"method max cannot be applied to given types"},
+		{"class Box<T> { void put(T t) {} }\nnew Box<String>().put(1, 2);",
+			"method put in class Box cannot be applied to given types"},
+		{"class Box<T> { void put(T t) {} }\nnew Box<String>().put(1);",
+			"method put in class Box cannot be applied to given types"},
+		{"class Box<T> { T value; Box(T value) {} }\nBox<String> b = new Box<>(3);",
+			"constructor Box in class Box cannot be applied to given types"},
+		{"class Box<T> { T get() { return null; } }\nBox<String> b = new Box<>();\nint n = b.get();",
+			"incompatible types: String cannot be converted to int"},
+		{"class Box<T> { T value; }\nBox<String> b = new Box<String>();\nint n = b.value;",
+			"incompatible types: String cannot be converted to int"},
+		{"class Box<T> { T get() { return null; } }\nclass Ints extends Box<Integer> {}\nString s = new Ints().get();",
+			"incompatible types: Integer cannot be converted to String"},
+		{"class Box<T> { T get() { return null; } }\nBox b = new Box<String>();\nString s = b.get();",
+			"incompatible types: Object cannot be converted to String"},
+		{"class U { static <T> T id(T a) { return a; } }\nString s = U.id(1);",
+			"incompatible types: Integer cannot be converted to String"},
+		{"class U { static <T> T id(T a) { return a; } }\nU.id(1).length();",
+			"cannot find symbol: method length()"},
+		{"class U { static <T extends Comparable<T>> T max(T a, T b) { return a; } }\nint n = U.max(\"a\", \"b\");",
+			"incompatible types: String cannot be converted to int"},
+		{"class U { static <T> T first(T... ts) { return ts[0]; } }\nString s = U.first(1, 2);",
+			"incompatible types: Integer cannot be converted to String"},
+		{"class Box<T> { T value; }\nclass U { static <T> T get(Box<T> box) { return box.value; } }\nString s = U.get(new Box<Integer>());",
+			"incompatible types: Integer cannot be converted to String"},
+		{"class U { static <T> T id(T a) { return a; } }\nU.<Integer>id(\"x\");",
+			"method id in class U cannot be applied to given types"},
+		{"class U { static <T> T id(T a) { return a; } }\nString s = U.<Integer>id(null);",
+			"incompatible types: Integer cannot be converted to String"},
+		{"class Box<T extends Number> {}\nBox<String> b = null;",
+			"type argument String is not within bounds of type-variable T"},
+		{"class Box<T extends Number> {}\nclass A { Box<Box<Integer>> b; }",
+			"type argument Box is not within bounds of type-variable T"},
+		{"class Box<T extends Number> {}\nclass A { void f(Box<? extends Box<String>> b) {} }",
+			"type argument String is not within bounds of type-variable T"},
+		{"class Box<T extends Number> {}\nObject o = new Box<String>();",
+			"type argument String is not within bounds of type-variable T"},
+		{"class Sorted<T extends Comparable<T>> {}\nclass A {}\nSorted<A> s = null;",
+			"type argument A is not within bounds of type-variable T"},
+		{"class Version implements Comparable<Version> {}",
+			"Version is not abstract and does not override abstract method compareTo(Version) in Comparable"},
+		{"interface Sink<T> { void put(T t); }\ninterface Text extends Sink<String> {}\nclass Out implements Text { public void put(Integer i) {} }",
+			"Out is not abstract and does not override abstract method put(String) in Sink"},
+		{"class Version implements Comparable<Version> { int compareTo(Version other) { return 0; } }",
+			"compareTo(Version) in Version cannot implement compareTo(T) in Comparable; attempting to assign weaker access privileges; was public"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestGenerics(t *testing.T) {
+	inputs := []string{
+		`class Box<T> {
+			private T value;
+			Box(T value) { this.value = value; }
+			T get() { return value; }
+			<R> R with(R other) { return other; }
+		 }
+		 class Use { String f(Box<String> box) { String s = box.get(); return box.<String>with(s); } }
+		 new Box<>("a");
+		 new Box<Box<Integer>>(new Box<>(1));`,
+		// Type variables are erased to their first bound.
+		`public <T extends Comparable<T>> T max(T a, T b) {
+			if (a.compareTo(b) > 0) {
+				return a;
+			}
+			return b;
+		 }
+		 max(1, 2);
+		 max("a", "b");`,
+		`class Pair<A, B extends A> { A first; B second; Pair(A a, B b) { first = a; second = b; } }
+		 new Pair<Object, String>("a", "b");`,
+		`public int count(Box<? extends Number> box, Box<? super Integer>... boxes) { return boxes.length; }
+		 class Box<T> {}`,
+		// An implementation of a generic supertype method takes the type
+		// arguments in place of its type variables.
+		`abstract class Base<T> { abstract void take(T t); }
+		 class Strings extends Base<String> { void take(String s) {} }`,
+		`interface Sink<T> { void put(T t); }
+		 interface Text extends Sink<String> {}
+		 class Out implements Text { public void put(String s) {} }`,
+		`abstract class Ordered<T> implements Comparable<T> { public int compareTo(T other) { return 0; } }
+		 class Version extends Ordered<Version> {}
+		 new Version().compareTo(new Version());`,
+		// Members take the type arguments of the type they are accessed
+		// through.
+		`class Box<T> {
+			T value;
+			Box(T value) { this.value = value; }
+			T get() { return value; }
+		 }
+		 class Ints extends Box<Integer> { Ints() { super(1); } }
+		 Box<String> b = new Box<>("a");
+		 int n = b.get().length() + b.value.length() + new Ints().get();
+		 Box<Box<Integer>> nested = new Box<>(new Box<>(1));
+		 Box<? extends Number> number = new Box<Integer>(2);
+		 double d = number.get().hashCode() + nested.get().get();`,
+		// Calls infer the type arguments of generic methods from their
+		// arguments, or from their target where the arguments do not.
+		`class U {
+			static <T> T id(T a) { return a; }
+			static <T> T first(T... ts) { return ts[0]; }
+			static <T> T make() { return null; }
+		 }
+		 class A {}
+		 class B extends A {}
+		 class C extends A {}
+		 int n = U.id(1) + U.id("a").length() + U.first(1, 2);
+		 A a = U.first(new B(), new C());
+		 Comparable c = U.first(1, "a");
+		 String s = U.id(null);
+		 String t = U.make();
+		 Integer i = U.<Integer>id(null);`,
+		// Type arguments are within the bounds of their type variables.
+		`class Box<T extends Number> {}
+		 class Sorted<T extends Comparable<T>> {}
+		 class Holder<S extends Integer> { Box<S> box; Box<? super Integer> any; }
+		 Box<Integer> b = new Box<Integer>();
+		 Box<Double> d = null;
+		 Sorted<String> s = new Sorted<>();`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestWellTyped(t *testing.T) {
+	inputs := []string{
+		`int x = 1; String s = "a" + x; boolean b = x < 2; b = s == null;`,
+		`class A {
+			byte b = 1;
+			short s = -2;
+			double d = 1;
+			Integer boxed = 3;
+			int unboxed = boxed + 1;
+			int f(boolean c) {
+				if (c) {
+					return 1;
+				} else {
+					return 2;
+				}
+			}
+			int g() { throw new IllegalStateException(); }
+			int h() { try { return f(true); } finally {} }
+			void i() { return; }
+			boolean same(A other) { return other.equals(this) == (hashCode() == other.hashCode()); }
+			String show() { return toString(); }
+		 }`,
+		// An interface may be implemented by a subclass of any class that
+		// is not final.
+		`interface I {}
+		 class A {}
+		 class B { boolean f(I i, A a) { return i == a; } }`,
+		`int x = 1;
+		 public int next() { return x + 1; }
+		 next();`,
+		`class A {}
+		 class B extends A {}
+		 A a = new B(), b;
+		 b = a;
+		 final Comparable<String> c = "c";
+		 Object[] os = null;`,
+		`class A { int x; }
+		 var a = new A();
+		 final var n = a.x + 1;
+		 double d = n;
+		 var var = 1;
+		 int i = var;`,
+		`String s = "s";
+		 Integer i = 1;
+		 Object o = s;
+		 int n = s.length() + s.compareTo("t") + i.intValue() + i.compareTo(2) + o.hashCode();
+		 boolean b = s.isEmpty() == o.equals(i);
+		 String t = i.toString();
+		 Function<String, Integer> length = String::length;`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`int x = "hello";`, "incompatible types: String cannot be converted to int"},
+		{`int x = 1.5;`, "incompatible types: possible lossy conversion from double to int"},
+		{`String s = 1;`, "incompatible types: int cannot be converted to String"},
+		{`boolean b = 1;`, "incompatible types: int cannot be converted to boolean"},
+		{`int x = 1; x = true;`, "incompatible types: boolean cannot be converted to int"},
+		{"class A { Double d = 1; }", "incompatible types: int cannot be converted to Double"},
+		{"class A { int f() { return \"s\"; } }", "incompatible types: String cannot be converted to int"},
+		{"class A { void f() { return 1; } }", "incompatible types: unexpected return value"},
+		{"class A { A() { return 1; } }", "incompatible types: unexpected return value"},
+		{"class A { int f() { return; } }", "incompatible types: missing return value"},
+		{"class A { int f() { if (true) { return 1; } } }", "missing return statement"},
+		{"class A { int f() { try { return 1; } catch (RuntimeException e) {} } }", "missing return statement"},
+		{"public int f() {}", "missing return statement"},
+		{"if (1) {}", "incompatible types: int cannot be converted to boolean"},
+		{`"a" - 1;`, "bad operand types for binary operator '-'"},
+		{`1 < true;`, "bad operand types for binary operator '<'"},
+		{`"a" == 1;`, "bad operand types for binary operator '=='"},
+		{"!1;", "bad operand type int for unary operator '!'"},
+		{"-true;", "bad operand type boolean for unary operator '-'"},
+		{"class A { String s; void f() { s++; } }", "bad operand type String for unary operator '++'"},
+		{"class A {}\nclass B {}\nnew A() == new B();", "incomparable types: A and B"},
+		{"y + 1;", "cannot find symbol: variable y"},
+		{"class A { int f() { return y; } }", "cannot find symbol: variable y"},
+		{"class A { int x; }\nclass B { int f(A a) { return a.y; } }", "cannot find symbol: variable y"},
+		{"f(1);", "cannot find symbol: method f(int)"},
+		{"class A { void f() { g(1, \"s\"); } }", "cannot find symbol: method g(int,String)"},
+		{"class A {}\nnew A().f();", "cannot find symbol: method f()"},
+		{"int x = 1; x.foo();", "int cannot be dereferenced"},
+		{`String s = ""; s.nonexistent(1, 2);`, "cannot find symbol: method nonexistent(int,int)"},
+		{`String s = ""; String t = s.length();`, "incompatible types: int cannot be converted to String"},
+		{`Integer i = 1; i.length();`, "cannot find symbol: method length()"},
+		{`String s = ""; s.value;`, "cannot find symbol: variable value"},
+		{`int[] xs = null; xs.size();`, "cannot find symbol: method size()"},
+		{"null.toString();", "<null> cannot be dereferenced"},
+		{"int x = 1; x[0];", "array required, but int found"},
+		{"class A { int[] xs; int f() { return xs[1.5]; } }", "incompatible types: possible lossy conversion from double to int"},
+		{"class A { void f() {} int g() { return f(); } }", "incompatible types: void cannot be converted to int"},
+		{"class A { void f() {} String g() { return \"\" + f(); } }", "'void' type not allowed here"},
+		{"class A { void f() {} void g() { f().x; } }", "void cannot be dereferenced"},
+		{"Missing m = null;", "cannot find symbol: class Missing"},
+		{"class A {}\nA a = \"a\";", "incompatible types: String cannot be converted to A"},
+		{"int a = 1, b = \"b\";", "incompatible types: String cannot be converted to int"},
+		{"var x;", "cannot infer type for local variable x"},
+		{"var x = null;", "cannot infer type for local variable x"},
+		{"class A { void f() {} void g() { var x = f(); } }", "cannot infer type for local variable x"},
+		{"var x = 1; x = 2.5;", "incompatible types: possible lossy conversion from double to int"},
+		{"var s = \"s\"; int n = s;", "incompatible types: String cannot be converted to int"},
+		{"class A {}\nvar a = new A(); a.x;", "cannot find symbol: variable x"},
+		{"class A {}\nclass B {}\nA a; B b = a;", "incompatible types: A cannot be converted to B"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestErrorSpans(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+		line    int
+		column  int
+		end     int
+	}{
+		{"int x = null;", "incompatible types: <null> cannot be converted to int", 1, 9, 13},
+		{"class A { int f() { return null; } }", "incompatible types: <null> cannot be converted to int", 1, 28, 32},
+		{"if (null) {}", "incompatible types: <null> cannot be converted to boolean", 1, 5, 9},
+		{"int x;\nString s = x = 3;", "incompatible types: int cannot be converted to String", 2, 14, 15},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors for %q: %q", tt.input, p.Errors())
+		}
+		diagnostics := New().Check(program)
+		if len(diagnostics) != 1 {
+			t.Errorf("expected 1 error for %q, got %d", tt.input, len(diagnostics))
+			continue
+		}
+		d := diagnostics[0]
+		if d.Message != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, d.Message)
+		}
+		start, end := d.Primary.Start, d.Primary.End
+		if start.Line != tt.line || start.Column != tt.column || end.Line != tt.line || end.Column != tt.end {
+			t.Errorf("wrong span for %q. expected=%d:%d-%d, got=%d:%d-%d:%d",
+				tt.input, tt.line, tt.column, tt.end, start.Line, start.Column, end.Line, end.Column)
+		}
+	}
+}
+
+func TestVariablesPersistAcrossChecks(t *testing.T) {
+	c := New()
+	if errors := check(t, c, "int x = 1;"); len(errors) != 0 {
+		t.Fatalf("unexpected errors: %q", errors)
+	}
+	if errors := check(t, c, "String y = \"a\"; x = y;"); len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %q", errors)
+	}
+	// y was declared by a program with errors, so it is unknown.
+	if errors := check(t, c, "x + 1;\ny;"); len(errors) != 1 || errors[0] != "cannot find symbol: variable y" {
+		t.Errorf("expected only y to be unknown, got %q", errors)
+	}
+}
+
+func TestDefiniteAssignment(t *testing.T) {
+	inputs := []string{
+		`public int f(boolean b) {
+			int x;
+			if (b) {
+				x = 1;
+			} else {
+				x = 2;
+			}
+			return x;
+		 }`,
+		`public int f(boolean b) {
+			final int x;
+			if (b) {
+				return 0;
+			} else {
+				x = 2;
+			}
+			return x;
+		 }`,
+		`public int f() {
+			int x;
+			try {
+				x = 1;
+			} catch (RuntimeException e) {
+				x = 2;
+			}
+			return x;
+		 }`,
+		`public int f() { int x; try {} finally { x = 1; } return x; }`,
+		`public void f(final int x, int y) { y = x; }`,
+		`class A {
+			final int x;
+			final int y;
+			static final int Z;
+			static { Z = 1; }
+			{ y = 2; }
+			A(int x) { this.x = x; }
+			A() { this(Z); }
+		 }`,
+		// jshell gives a variable declared without an initializer the
+		// default value of its type.
+		`int x; x = x + 1;`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestDefiniteAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"public int f() { int x; return x; }", "variable x might not have been initialized"},
+		{"public int f(boolean b) { int x; if (b) { x = 1; } return x; }", "variable x might not have been initialized"},
+		{"public int f() { int x = x + 1; return x; }", "variable x might not have been initialized"},
+		{"public void f() { int x; x++; }", "variable x might not have been initialized"},
+		{"{ int x; int y = x; }", "variable x might not have been initialized"},
+		{"public int f() { int x; try { x = 1; } catch (RuntimeException e) {} return x; }",
+			"variable x might not have been initialized"},
+		{"public void f() { final int x = 1; x = 2; }", "cannot assign a value to final variable x"},
+		{"public void f() { final int x = 1; x++; }", "cannot assign a value to final variable x"},
+		{"public void f(boolean b) { final int x; if (b) { x = 1; } x = 2; }", "variable x might already have been assigned"},
+		{"public void f() { final int x; try { x = 1; } catch (RuntimeException e) { x = 2; } }",
+			"variable x might already have been assigned"},
+		{"public void f(final int x) { x = 2; }", "final parameter x may not be assigned"},
+		{"public void f() { try {} catch (IllegalStateException | IllegalArgumentException e) { e = null; } }",
+			"multi-catch parameter e may not be assigned"},
+		{"class R implements AutoCloseable { public void close() {} }\npublic void f() { try (R r = new R()) { r = null; } }",
+			"auto-closeable resource r may not be assigned"},
+		{"class A { final int x; }", "variable x not initialized in the default constructor"},
+		{"class A { static final int X; }", "variable X not initialized in the default constructor"},
+		{"class A { final int x; A() {} }", "variable x might not have been initialized"},
+		{"class A { final int x; A(boolean b) { if (b) { return; } x = 1; } }", "variable x might not have been initialized"},
+		{"class A { final int x; A() { int y = this.x; x = 1; } }", "variable x might not have been initialized"},
+		{"class A { final int x; A() { x = 1; x = 2; } }", "variable x might already have been assigned"},
+		{"class A { final int x = 1; void f() { x = 2; } }", "cannot assign a value to final variable x"},
+		{"class A { final int x; A() { x = 1; } void f() { this.x = 2; } }", "cannot assign a value to final variable x"},
+		{"class A { final int x; A(A a) { a.x = 1; x = 2; } }", "cannot assign a value to final variable x"},
+		{"interface I { int X = 1; }\nI.X = 2;", "cannot assign a value to final variable X"},
+		{"public void f() { return; int y = 1; }", "unreachable statement"},
+		{"public int f(boolean b) { if (b) { return 1; } else { throw new RuntimeException(); } f(b); }",
+			"unreachable statement"},
+		{"public void f() { try { return; } finally {} f(); }", "unreachable statement"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestLambdas(t *testing.T) {
+	inputs := []string{
+		`Function<Integer, Integer> f = x -> x * 2;`,
+		`BiFunction<Integer, Integer, Integer> add = (a, b) -> { return a + b; };`,
+		`Runnable r = () -> {};`,
+		`interface Op { double apply(int a); }
+		 Op half = a -> a / 2.0;
+		 Op same = (int a) -> a;`,
+		`interface Shape { double area(); default double twice() { return 2 * area(); } }
+		 Shape s = () -> 1;`,
+		`interface Named { String name(); boolean equals(Object o); }
+		 Named n = () -> "n";`,
+		`public int f(final int a, int b) {
+			int c = a + b;
+			Supplier<Integer> s = () -> a + b + c;
+			return s.get();
+		 }`,
+		`public void f() { Runnable r = () -> { int x = 1; x = 2; }; }`,
+		`int n = 1;
+		 Runnable r = () -> { n = 2; };`,
+		`class Point {
+			int x;
+			Point(int x) { this.x = x; }
+			int getX() { return x; }
+			static int twice(int x) { return 2 * x; }
+			Supplier<Integer> getter() { return this::getX; }
+		 }
+		 Function<Integer, Point> make = Point::new;
+		 Function<Point, Integer> getX = Point::getX;
+		 Function<Integer, Integer> twice = Point::twice;
+		 Function<String, Integer> length = String::length;`,
+		`public void apply(Function<Integer, Integer> f) {}
+		 public void apply(Function<Integer, Integer> f, int x) {}
+		 apply(x -> x + 1);`,
+		`class Task { void run() throws Exception {} }
+		 interface Action { void act() throws Exception; }
+		 Action a = new Task()::run;`,
+		// The parameters and result of a lambda expression take the type
+		// arguments of its functional interface.
+		`Function<String, Integer> length = s -> s.length();
+		 Supplier<String> text = () -> "text";
+		 int n = length.apply(text.get()) + text.get().length();
+		 public <T> void each(Function<T, String> f) {}
+		 each(x -> x.toString());`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestLambdaErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"Object o = () -> 1;", "incompatible types: Object is not a functional interface"},
+		{"interface I {}\nI i = () -> 1;", "incompatible types: I is not a functional interface"},
+		{"interface I { void a(); void b(); }\nI i = () -> {};", "incompatible types: I is not a functional interface"},
+		{"int x = (() -> 1) + 1;", "lambda expression not expected here"},
+		{"var f = x -> x;", "cannot infer type for local variable f"},
+		{"var f = String::length;", "cannot infer type for local variable f"},
+		{"Function<Integer, Integer> f = (a, b) -> a;", "incompatible types: incompatible parameter types in lambda expression"},
+		{"interface Op { int apply(int a); }\nOp op = (String s) -> 1;",
+			"incompatible types: incompatible parameter types in lambda expression"},
+		{"interface Op { int apply(int a); }\nOp op = a -> \"a\";", "incompatible types: bad return type in lambda expression"},
+		{"interface Op { int apply(int a); }\nOp op = a -> { };", "incompatible types: bad return type in lambda expression"},
+		{"Runnable r = () -> 5;", "incompatible types: bad return type in lambda expression"},
+		{"Function<String, Integer> f = s -> s;", "incompatible types: bad return type in lambda expression"},
+		{"Supplier<String> s = () -> { return 5; };", "incompatible types: bad return type in lambda expression"},
+		{"Function<Integer, String> f = (String s) -> s;",
+			"incompatible types: incompatible parameter types in lambda expression"},
+		{"Function<Integer, Integer> f = x -> x.length();", "cannot find symbol: method length()"},
+		{"Runnable r = () -> { return 5; };", "incompatible types: unexpected return value"},
+		{"public void f() { int n = 1; n = 2; Runnable r = () -> { int m = n; }; }",
+			"local variables referenced from a lambda expression must be final or effectively final"},
+		{"public void f() { int n = 1; Runnable r = () -> { int m = n; }; n++; }",
+			"local variables referenced from a lambda expression must be final or effectively final"},
+		{"public void f() { int n = 1; Runnable r = () -> { n = 2; }; }",
+			"local variables referenced from a lambda expression must be final or effectively final"},
+		{"Runnable r = () -> { throw new Exception(); };", "unreported exception Exception; must be caught or declared to be thrown"},
+		{"class A { int f() { return 1; } }\nSupplier<Integer> s = A::g;", "invalid method reference"},
+		{"class A { private void f() {} }\nRunnable r = new A()::f;", "f() has private access in A"},
+		{"abstract class A {}\nSupplier<A> s = A::new;", "A is abstract; cannot be instantiated"},
+		{"class A { A(int x) {} }\nSupplier<A> s = A::new;", "incompatible types: invalid constructor reference"},
+		{"interface Op { int apply(); }\nclass A { static void f() {} }\nOp op = A::f;",
+			"incompatible types: bad return type in method reference"},
+		{"class A { void f() throws Exception {} }\nRunnable r = new A()::f;",
+			"incompatible thrown types Exception in functional expression"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	inputs := []string{
+		`enum Color { RED, GREEN, BLUE }
+		 Color c = Color.valueOf("RED");
+		 Color[] all = Color.values();
+		 int n = c.ordinal() + c.compareTo(Color.BLUE);
+		 String s = c.name() + c;`,
+		`enum Size {
+			SMALL(1), LARGE(10);
+			private final int weight;
+			Size(int weight) { this.weight = weight; }
+			int weight() { return weight; }
+		 }
+		 int w = Size.LARGE.weight();`,
+		`enum Op {
+			PLUS { int apply(int a, int b) { return a + b; } },
+			TIMES { int apply(int a, int b) { return a * b; } };
+			abstract int apply(int a, int b);
+		 }
+		 int x = Op.PLUS.apply(1, 2);`,
+		`interface Named { String label(); }
+		 enum Planet implements Named {
+			EARTH;
+			private String secret() { return "blue"; }
+			public String label() { return secret(); }
+		 }
+		 Named n = Planet.EARTH;
+		 Comparable<Planet> p = Planet.EARTH;`,
+		`enum Color { RED, GREEN, BLUE }
+		 public String name(Color c) {
+			switch (c) {
+				case RED -> { return "red"; }
+				case GREEN, BLUE -> { return "other"; }
+				default -> throw new IllegalStateException();
+			}
+		 }
+		 public int code(Color c) {
+			return switch (c) {
+				case RED -> 1;
+				case GREEN -> { int g = 2; yield g; }
+				case BLUE -> throw new IllegalStateException();
+			};
+		 }`,
+		`int n = 2;
+		 String s;
+		 switch (n) {
+			case 1 -> s = "one";
+			default -> s = "many";
+		 }
+		 String t = s;
+		 double d = switch (s) { case "one" -> 1; default -> 2.5; };
+		 Object o = switch (n) { case 1 -> "one"; default -> null; };`,
+		`public int f(int n) {
+			final int x;
+			int y = switch (n) {
+				case 1 -> { x = 1; yield 1; }
+				default -> { x = 2; yield 2; }
+			};
+			return x + y;
+		 }`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestEnumErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"enum Color { RED }\nColor c = new Color();", "enum classes may not be instantiated"},
+		{"enum Color { RED }\nclass Dark extends Color {}", "cannot inherit from final Color"},
+		{"class E extends Enum<E> {}", "classes cannot directly extend java.lang.Enum"},
+		{"enum Color { RED; public Color() {} }", "modifier public not allowed here"},
+		{"enum Color { RED; Color() { super(); } }", "call to super not allowed in enum constructor"},
+		{"enum Size { SMALL(\"s\"); Size(int n) {} }", "constructor Size in enum Size cannot be applied to given types"},
+		{"enum Op { PLUS { int apply() { return 1; } }, MINUS; abstract int apply(); }",
+			"Op is not abstract and does not override abstract method apply() in Op"},
+		{"enum Color { RED }\nColor c = Color.BLUE;", "cannot find symbol: variable BLUE"},
+		{"enum Color { RED, GREEN }\nint n = switch (Color.RED) { case RED -> 1; };",
+			"the switch expression does not cover all possible input values"},
+		{"int n = switch (1) { case 1 -> 1; };", "the switch expression does not cover all possible input values"},
+		{"enum Color { RED }\nswitch (Color.RED) { case BLUE -> {} }", "cannot find symbol: variable BLUE"},
+		{"enum Color { RED }\nswitch (Color.RED) { case Color.RED -> {} }",
+			"an enum switch case label must be the unqualified name of an enumeration constant"},
+		{"switch (1) { case 1 -> {} case 1 -> {} }", "duplicate case label"},
+		{"switch (1) { default -> {} default -> {} }", "duplicate default label"},
+		{"switch (1) { case \"a\" -> {} }", "constant label of type String is not compatible with switch selector type int"},
+		{"int n = switch (1) { case 1 -> { } default -> 0; };", "switch rule completes without providing a value"},
+		{"yield 1;", "yield outside of switch expression"},
+		{"public int f() { return switch (1) { default -> { return 1; } }; }", "attempt to return out of a switch expression"},
+		{"int n = switch (1) { case 1 -> \"a\"; default -> 2; };", "incompatible types: bad type in switch expression"},
+		{"public int f(int n) { switch (n) { case 1 -> { return 1; } } }", "missing return statement"},
+		{"public int f(int n) { int x; switch (n) { case 1 -> x = 1; } return x; }", "variable x might not have been initialized"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestRecordsAndPatterns(t *testing.T) {
+	inputs := []string{
+		`record Point(int x, int y) {
+			Point {
+				if (x < 0) { throw new IllegalArgumentException(); }
+			}
+			Point(int x) { this(x, 0); }
+			static Point origin() { return new Point(0, 0); }
+			int sum() { return x + y; }
+		 }
+		 Point p = new Point(1, 2);
+		 int n = p.x() + p.y() + p.sum() + p.hashCode();
+		 String s = p.toString();
+		 boolean b = p.equals(Point.origin());`,
+		`record Pair<A, B>(A first, B second) {
+			public A first() { return first; }
+		 }
+		 Pair<String, Integer> p = new Pair<>("a", 1);`,
+		`sealed interface Shape permits Circle, Square {}
+		 record Circle(int r) implements Shape {}
+		 non-sealed class Square implements Shape {}
+		 class Tile extends Square {}
+		 public int area(Shape s) {
+			return switch (s) {
+				case Circle c when c.r() == 0 -> 0;
+				case Circle(int r) -> 3 * r * r;
+				case Square q -> 1;
+			};
+		 }`,
+		`sealed abstract class Expr {}
+		 final class Num extends Expr { int n; }
+		 sealed class Neg extends Expr {}
+		 final class DoubleNeg extends Neg {}
+		 public int eval(Expr e) {
+			switch (e) {
+				case Num n -> { return n.n; }
+				case Neg n -> { return 0; }
+			}
+		 }`,
+		`Object o = "text";
+		 if (o instanceof String s) { int n = s.length(); }
+		 if (!(o instanceof Integer i)) { int n = 0; } else { int n = i + 1; }
+		 if (!(o instanceof String s)) { throw new IllegalStateException(); }
+		 int n = s.length();`,
+		`record Box(Object value) {}
+		 Object o = new Box("a");
+		 String s = switch (o) {
+			case null -> "null";
+			case Box(String t) -> t;
+			case Box(var v) when v instanceof Integer i -> "" + (i + 1);
+			case Box b -> "box";
+			default -> "other";
+		 };
+		 switch (o) {
+			case Integer i -> {}
+			case Object x -> {}
+		 }`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestRecordAndPatternErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"record P(int x) { int y; }", "field declaration must be static"},
+		{"record P(int x) { { } }", "instance initializers not allowed in records"},
+		{"record P(int x) { P(String s) { this.x = 0; } }",
+			"constructor is not canonical, so its first statement must invoke another constructor of class P"},
+		{"record P(int x) { P(int x) { this(); } P() { this(0); } }", "invalid canonical constructor in record P"},
+		{"record P(int x) { int x() { return x; } }", "invalid accessor method in record P"},
+		{"record P(int x) { public long x() { return x; } }", "invalid accessor method in record P"},
+		{"record P(int x) {}\nclass Q extends P {}", "cannot inherit from final P"},
+		{"sealed interface S permits A {}\nfinal class A implements S {}\nfinal class B implements S {}",
+			"class is not allowed to extend sealed class: S (as it is not listed in its 'permits' clause)"},
+		{"sealed class S {}\nclass A extends S {}", "sealed, non-sealed or final modifiers expected"},
+		{"sealed interface S {}\ninterface I extends S {}", "sealed or non-sealed modifiers expected"},
+		{"non-sealed class A {}", "non-sealed modifier not allowed here"},
+		{"sealed class A {}", "sealed class must have subclasses"},
+		{"sealed class A permits B {}\nfinal class B {}", "invalid permits clause"},
+		{"int n = 1;\nboolean b = n instanceof Integer;", "unexpected type"},
+		{"Integer n = 1;\nboolean b = n instanceof String s;", "incompatible types: Integer cannot be converted to String"},
+		{"class A {}\nObject o = null;\nboolean b = o instanceof A(int x);",
+			"deconstruction patterns can only be applied to records, A is not a record"},
+		{"record P(int x, int y) {}\nObject o = null;\nboolean b = o instanceof P(int x);", "incorrect number of nested patterns"},
+		{"record P(int x) {}\nObject o = null;\nboolean b = o instanceof P(String s);", "incompatible types: int cannot be converted to String"},
+		{"Object o = null;\nif (o instanceof String s) {} else { int n = s.length(); }", "cannot find symbol: variable s"},
+		{"Object o = null;\nif (o instanceof final String s) { s = \"\"; }", "cannot assign a value to final variable s"},
+		{"Object o = null;\nswitch (o) { case Integer i -> {} case String s -> {} }",
+			"the switch statement does not cover all possible input values"},
+		{"sealed interface S permits A, B {}\nfinal class A implements S {}\nfinal class B implements S {}\nS s = null;\nint n = switch (s) { case A a -> 1; };",
+			"the switch expression does not cover all possible input values"},
+		{"interface I {}\nclass A implements I {}\nObject o = null;\nswitch (o) { case I i -> {} case A a -> {} default -> {} }",
+			"this case label is dominated by a preceding case label"},
+		{"Integer n = 1;\nswitch (n) { case Integer i -> {} case 1 -> {} }", "this case label is dominated by a preceding case label"},
+		{"switch (1) { case 1 when true -> {} default -> {} }", "guards are only allowed for case with a pattern"},
+		{"Object o = null;\nswitch (o) { case Integer i, String s -> {} default -> {} }", "illegal fall-through from a pattern"},
+		{"Object o = null;\nswitch (o) { case String s when s -> {} default -> {} }",
+			"incompatible types: String cannot be converted to boolean"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestNestedClasses(t *testing.T) {
+	inputs := []string{
+		`class Outer {
+			private int x = 1;
+			static int count;
+			class Inner {
+				int y = x + count;
+				int outer() { return Outer.this.x + y; }
+				void bump() { x++; helper(); }
+			}
+			static class Nested { int get() { return count; } }
+			interface Visitor { int visit(Inner i); }
+			void helper() {}
+			Inner make() { return new Inner(); }
+		 }
+		 Outer o = new Outer();
+		 Outer.Inner i = o.new Inner();
+		 Outer.Nested n = new Outer.Nested();
+		 Outer.Visitor v = in -> in.outer();
+		 int k = i.outer() + n.get() + v.visit(o.make());`,
+		// A local class and an anonymous class capture effectively final
+		// local variables.
+		`interface Counter { int next(); }
+		 class A {
+			int base = 10;
+			Counter counter(int start) {
+				int step = 2;
+				class Local implements Counter {
+					int n = start;
+					public int next() { n = n + step; return n + base; }
+				}
+				return new Local();
+			}
+			Counter anonymous(int start) {
+				return new Counter() {
+					int n = start;
+					public int next() { n = n + 1; return n + base; }
+				};
+			}
+		 }`,
+		`Comparator<String> byLength = new Comparator<>() {
+			public int compare(String a, String b) { return a.length() - b.length(); }
+		 };
+		 Object o = new Object() { public String toString() { return "o"; } };
+		 Runnable r = new Runnable() { public void run() {} };`,
+		// A field of a class shadows a local variable outside of it.
+		`class A {
+			int f() {
+				int v = 1;
+				v++;
+				class L { int v = 3; int g() { v++; return v; } }
+				return new L().g();
+			}
+		 }`,
+		`sealed interface Shape permits Shapes.Circle, Shapes.Square {}
+		 class Shapes {
+			record Circle(int r) implements Shape {}
+			record Square(int s) implements Shape {}
+			static int area(Shape s) {
+				return switch (s) {
+					case Circle c -> 3 * c.r() * c.r();
+					case Square q -> q.s() * q.s();
+				};
+			}
+		 }`,
+		`abstract class Base { abstract int f(); Base(int n) {} }
+		 Base b = new Base(1) { int f() { return 1; } };`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestNestedClassErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"class Outer { class Inner {} }\nnew Outer.Inner();",
+			"non-static variable this cannot be referenced from a static context"},
+		{"class Outer { class Inner {} static void f() { new Inner(); } }",
+			"non-static variable this cannot be referenced from a static context"},
+		{"class Outer { int x; static class Nested { int f() { return x; } } }",
+			"non-static variable x cannot be referenced from a static context"},
+		{"class Outer { static class Nested { Object f() { return Outer.this; } } }",
+			"non-static variable this cannot be referenced from a static context"},
+		{"class Outer { static class Nested {} void f() { Object o = Nested.this; } }",
+			"not an enclosing class: Nested"},
+		{"class Outer { static class Nested {} }\nOuter o = new Outer();\no.new Nested();",
+			"qualified new of static class"},
+		{"class Outer {}\nOuter o = new Outer();\no.new Missing();", "cannot find symbol: class Missing"},
+		{"class Outer { class Inner {} }\nnew Inner();", "cannot find symbol: class Inner"},
+		{"class A { void f() { int n = 0; n++; class L { int g() { return n; } } } }",
+			"local variables referenced from an inner class must be final or effectively final"},
+		{"class A { void f() { int n = 0; Runnable r = new Runnable() { public void run() { int m = n; } }; n = 1; } }",
+			"local variables referenced from an inner class must be final or effectively final"},
+		{"class A { void f() { int n = 0; Runnable r = new Runnable() { public void run() { n = 1; } }; } }",
+			"local variables referenced from an inner class must be final or effectively final"},
+		{"Runnable r = new Runnable() {};",
+			"<anonymous Runnable> is not abstract and does not override abstract method run() in Runnable"},
+		{"Runnable r = new Runnable(1) { public void run() {} };",
+			"anonymous class implements interface; cannot have arguments"},
+		{"class A { void f() { class L {} class L {} } }", "duplicate class: L"},
+		{"class A { void f() { class L {} } void g() { new L(); } }", "cannot find symbol: class L"},
+		{"class Outer { private int x; }\nclass Other { class Inner { int f(Outer o) { return o.x; } } }",
+			"x has private access in Outer"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
+
+func TestClassesPersistAcrossChecks(t *testing.T) {
+	c := New()
+	if errors := check(t, c, "abstract class A {}"); len(errors) != 0 {
+		t.Fatalf("unexpected errors: %q", errors)
+	}
+	if errors := check(t, c, "class B extends A {}\nclass C extends Missing {}"); len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %q", errors)
+	}
+	// B was in a program with errors, so it was never declared.
+	if errors := check(t, c, "new B();"); len(errors) != 1 || errors[0] != "cannot find symbol: class B" {
+		t.Errorf("expected B to be unknown, got %q", errors)
+	}
+	if errors := check(t, c, "new A();"); len(errors) != 1 || errors[0] != "A is abstract; cannot be instantiated" {
+		t.Errorf("expected A to be known, got %q", errors)
+	}
+	// So are the member classes of a class.
+	if errors := check(t, c, "class Outer { static class Nested {} }"); len(errors) != 0 {
+		t.Fatalf("unexpected errors: %q", errors)
+	}
+	if errors := check(t, c, "Outer.Nested n = new Outer.Nested();"); len(errors) != 0 {
+		t.Errorf("expected Outer.Nested to be known, got %q", errors)
+	}
+}
+
+func TestLibraryClasses(t *testing.T) {
+	c := New()
+	if len(c.diagnostics) != 0 {
+		t.Fatalf("errors in the library classes: %s", c.diagnostics[0].Message)
+	}
+	// A class of a program with errors does not replace a library class.
+	if errors := check(t, c, "class Exception extends Missing {}"); len(errors) != 1 {
+		t.Fatalf("expected 1 error, got %q", errors)
+	}
+	if errors := check(t, c, "class E extends Exception { E() { super(\"e\"); } }"); len(errors) != 0 {
+		t.Errorf("unexpected errors: %q", errors)
+	}
+}
+
+func TestExceptions(t *testing.T) {
+	inputs := []string{
+		`class Failure extends RuntimeException { Failure(String s) { super(s); } }
+		 try { throw new Failure("x"); }
+		 catch (IllegalStateException | Failure e) { e.getMessage(); }
+		 catch (Exception e) { throw new Error(e); }
+		 finally {}`,
+		`try { 1 / 0; } finally {}`,
+		`class Checked extends Exception {}
+		 class A {
+			A() throws Checked {}
+			void f() throws Exception { throw new Checked(); }
+			void g() throws Checked {
+				try { f(); } catch (Checked e) { throw e; } catch (Exception e) {}
+			}
+			void h() throws Checked {
+				try { g(); } catch (Exception e) { throw e; }
+			}
+		 }
+		 class B extends A {
+			B() throws Exception {}
+			void f() throws Checked {}
+		 }
+		 throw new Checked();`,
+		`class R implements AutoCloseable { public void close() {} }
+		 class A {
+			R r = new R();
+			void f() {
+				try (R a = new R(); R b = a) {}
+				try (r; this.r) {}
+			}
+			void g() throws Exception {
+				try (AutoCloseable c = new R()) {}
+			}
+		 }`,
+	}
+
+	for _, input := range inputs {
+		if errors := check(t, New(), input); len(errors) != 0 {
+			t.Errorf("unexpected errors for %q: %q", input, errors)
+		}
+	}
+}
+
+func TestExceptionErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{`throw "error";`, "incompatible types: String cannot be converted to Throwable"},
+		{"class A {}\nthrow new A();", "incompatible types: A cannot be converted to Throwable"},
+		{"class A {}\ntry {} catch (A e) {}", "incompatible types: A cannot be converted to Throwable"},
+		{"try {} catch (String e) {}", "incompatible types: String cannot be converted to Throwable"},
+		{"try {} catch (Missing e) {}", "cannot find symbol: class Missing"},
+		{"try {} catch (Exception | RuntimeException e) {}",
+			"Alternatives in a multi-catch statement cannot be related by subclassing"},
+		{"try {} catch (ArithmeticException | RuntimeException e) {}",
+			"Alternatives in a multi-catch statement cannot be related by subclassing"},
+		{"try {} catch (Exception e) {} catch (ArithmeticException e) {}",
+			"exception ArithmeticException has already been caught"},
+		{"class E extends Exception {}\nclass A { void f() { throw new E(); } }",
+			"unreported exception E; must be caught or declared to be thrown"},
+		{"class E extends Exception {}\nclass A { void f() throws E {} void g() { f(); } }",
+			"unreported exception E; must be caught or declared to be thrown"},
+		{"class E extends Exception {}\nclass A { A() throws E {} }\nclass B { void f() { new A(); } }",
+			"unreported exception E; must be caught or declared to be thrown"},
+		{"class E extends Exception {}\nclass A { A() throws E {} }\nclass B extends A {}",
+			"unreported exception E in default constructor; must be caught or declared to be thrown"},
+		{"class E extends Exception {}\nclass A { void f() throws Exception { try { throw new E(); } catch (E e) { throw new Exception(); } } void g() { f(); } }",
+			"unreported exception Exception; must be caught or declared to be thrown"},
+		{"class E extends Exception {}\nclass A { void f() { try {} catch (E e) {} } }",
+			"exception E is never thrown in body of corresponding try statement"},
+		{"class A { void f() throws String {} }", "incompatible types: String cannot be converted to Throwable"},
+		{"class R implements AutoCloseable { public void close() throws Exception {} }\nclass A { void f() { try (R r = new R()) {} } }",
+			"unreported exception Exception; must be caught or declared to be thrown"},
+		{"class R {}\ntry (R r = new R()) {}", "incompatible types: try-with-resources not applicable to variable type"},
+		{`try (String s = "") {}`, "incompatible types: try-with-resources not applicable to variable type"},
+		{"class R implements AutoCloseable { public void close() {} }\nclass S {}\ntry (R r = new S()) {}",
+			"incompatible types: S cannot be converted to R"},
+		{"try (Missing m = m) {}", "cannot find symbol: class Missing"},
+		{"class A { void f() {} }\nclass B extends A { void f() throws Exception {} }",
+			"f() in B cannot override f() in A; overridden method does not throw Exception"},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, New(), tt.input)
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %q", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0] != tt.message {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.message, errors[0])
+		}
+	}
+}
@@ -0,0 +1,415 @@
+package typecheck
+
+import (
+	"java/ast"
+	"java/lang"
+	"java/tokens"
+	"strings"
+)
+
+// Type is the static type of an expression or a variable. Checks that
+// need the type of an expression are skipped where it is not known, which
+// the checker represents as a nil *Type.
+type Type struct {
+	Name       string // a primitive type, a class or interface, or "<null>"
+	Dimensions int
+	Class      *Class // the class named, if it is declared in the program
+
+	// Arguments are the type arguments of a parameterized type, as in
+	// Box<String>, and nil for a raw or non-generic type. An argument is
+	// nil if it is not known, as for a wildcard.
+	Arguments []*Type
+}
+
+var (
+	intType     = &Type{Name: "int"}
+	doubleType  = &Type{Name: "double"}
+	booleanType = &Type{Name: "boolean"}
+	stringType  = &Type{Name: "String"}
+	nullType    = &Type{Name: "<null>"}
+	voidType    = &Type{Name: "void"} // the result of a call of a void method
+)
+
+func (t *Type) String() string {
+	return t.Name + strings.Repeat("[]", t.Dimensions)
+}
+
+func (t *Type) isPrimitive() bool {
+	return t.Dimensions == 0 && isPrimitive(t.Name)
+}
+
+func (t *Type) isNumeric() bool {
+	return t.isPrimitive() && t.Name != "boolean"
+}
+
+// isKnown reports whether t is a type the checker knows: a primitive
+// type, a class of the program or of the library, null or void. Checks
+// involving other types, which name classes that are not declared, are
+// skipped.
+func isKnown(t *Type) bool {
+	return t != nil && (isPrimitive(t.Name) || t.Class != nil || isLibraryType(t.Name) ||
+		t.Name == "<null>" || t.Name == "void")
+}
+
+// isInferred reports whether t is the `var` of a local variable whose type
+// is inferred from its initializer.
+func isInferred(t *ast.Type) bool {
+	return t.Token.Type == tokens.IDENT && t.Name == "var"
+}
+
+func isVoid(t *Type) bool {
+	return t != nil && t.Name == "void"
+}
+
+// isBoolean reports whether t is boolean or its box class.
+func isBoolean(t *Type) bool {
+	return t != nil && t.Dimensions == 0 && (t.Name == "boolean" || t.Name == "Boolean")
+}
+
+// element is the type of the elements of the array type t.
+func (t *Type) element() *Type {
+	return &Type{Name: t.Name, Dimensions: t.Dimensions - 1, Class: t.Class, Arguments: t.Arguments}
+}
+
+// qualifiedName is t with the package of a library class, as the JVM
+// names it in messages, e.g. "java.lang.Throwable[]", or "Outer$Inner"
+// for a member class. The JVM leaves String and Object unqualified.
+func qualifiedName(t *Type) string {
+	if unbox(&Type{Name: t.Name}) != nil {
+		return "java.lang." + t.String()
+	}
+	if t.Class != nil {
+		if pkg := lang.Package(t.Class.Decl); pkg != "" {
+			return pkg + "." + t.String()
+		}
+		if t.Class.Outer != nil && t.Class.Outer.Classes[t.Class.Decl.Name.Value] == t.Class {
+			return strings.ReplaceAll(t.Name, ".", "$") + strings.Repeat("[]", t.Dimensions)
+		}
+	}
+	return t.String()
+}
+
+func classType(class *Class) *Type {
+	return &Type{Name: class.Name, Class: class}
+}
+
+// typeOf is the type written as t in a declaration in the code being
+// checked.
+func (c *Checker) typeOf(t *ast.Type) *Type {
+	return c.typeIn(t, c.typeParams)
+}
+
+// typeIn is the type written as t in a declaration where the type
+// parameters params are in scope. Type variables are erased: each stands
+// for its first bound, or Object if it has none.
+func (c *Checker) typeIn(t *ast.Type, params []*ast.TypeParameter) *Type {
+	return c.typeWith(t, params, nil)
+}
+
+// typeWith is the type written as t where the type parameters params are
+// in scope, with the types bound gives some of them in their place. The
+// others are erased, as are those bound gives nil, unless they are type
+// arguments, which are then not known.
+func (c *Checker) typeWith(t *ast.Type, params []*ast.TypeParameter, bound map[string]*Type) *Type {
+	if t == nil {
+		return nil
+	}
+	if tp := typeVariable(t, params); tp != nil {
+		if b := bound[tp.Name.Value]; b != nil {
+			return &Type{Name: b.Name, Dimensions: b.Dimensions + t.Dimensions, Class: b.Class, Arguments: b.Arguments}
+		}
+		erased := &Type{Name: "Object"}
+		if len(tp.Bounds) > 0 {
+			// A bound may be another type variable, but not this one.
+			erased = c.typeWith(tp.Bounds[0], without(params, tp), bound)
+			erased.Arguments = nil
+		}
+		erased.Dimensions += t.Dimensions
+		return erased
+	}
+	var args []*Type
+	if t.Arguments != nil {
+		args = []*Type{}
+	}
+	for _, a := range t.Arguments {
+		if tp := typeVariable(a, params); tp != nil && a.Dimensions == 0 {
+			if b, ok := bound[tp.Name.Value]; ok && b == nil {
+				// The type the variable stands for is not known.
+				args = append(args, nil)
+				continue
+			}
+		}
+		switch {
+		case a.Name != "?":
+			args = append(args, c.typeWith(a, params, bound))
+		case a.Bound != nil && !a.Super:
+			// A value of Box<? extends Number> holds a Number.
+			args = append(args, c.typeWith(a.Bound, params, bound))
+		default:
+			args = append(args, nil)
+		}
+	}
+	if class := c.classOf(t); class != nil {
+		return &Type{Name: class.Name, Dimensions: t.Dimensions, Class: class, Arguments: args}
+	}
+	return &Type{Name: t.Name, Dimensions: t.Dimensions, Arguments: args}
+}
+
+// bindings maps the type parameters of owner, a supertype of the class of
+// receiver, to the types receiver gives them, e.g. T to String for the
+// Box<T> of a Box<String>. A raw receiver gives them their erasures, and
+// an argument that is not known gives nil.
+func (c *Checker) bindings(receiver *Type, owner *Class) map[string]*Type {
+	if receiver == nil || receiver.Class == nil || owner == nil || receiver.Dimensions > 0 {
+		return nil
+	}
+	params := receiver.Class.Decl.TypeParameters
+	given := map[string]*Type{}
+	for i, tp := range params {
+		if receiver.Arguments == nil {
+			given[tp.Name.Value] = c.typeIn(&ast.Type{Name: tp.Name.Value}, params)
+		} else if i < len(receiver.Arguments) {
+			given[tp.Name.Value] = receiver.Arguments[i]
+		}
+	}
+	bound := map[string]*Type{}
+	for name, arg := range typeArguments(receiver.Class, owner) {
+		if tp := typeVariable(arg, params); tp != nil && arg.Dimensions == 0 {
+			bound[name] = given[tp.Name.Value]
+		} else {
+			bound[name] = c.typeWith(arg, params, given)
+		}
+	}
+	return bound
+}
+
+// typeVariable returns the type parameter among params that t names, if
+// any.
+func typeVariable(t *ast.Type, params []*ast.TypeParameter) *ast.TypeParameter {
+	for _, tp := range params {
+		if tp.Name.Value == t.Name {
+			return tp
+		}
+	}
+	return nil
+}
+
+func without(params []*ast.TypeParameter, tp *ast.TypeParameter) []*ast.TypeParameter {
+	var result []*ast.TypeParameter
+	for _, p := range params {
+		if p != tp {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// typeParameters returns the type parameters in scope in a generic
+// method or constructor with the type parameters own, declared in class.
+// Those of the class are not in scope in static methods.
+func typeParameters(class *Class, static bool, own []*ast.TypeParameter) []*ast.TypeParameter {
+	params := append([]*ast.TypeParameter{}, own...)
+	if class != nil && !static {
+		params = append(params, class.Decl.TypeParameters...)
+	}
+	return params
+}
+
+// memberType is the type of a field or method result declared as t,
+// where the type parameters params are in scope, accessed through a
+// receiver that gives the type parameters of its class the types bound
+// maps them to. The type a type variable stands for is not known if
+// bound maps it to nil, as for a type argument of a generic method that
+// could not be inferred.
+func (c *Checker) memberType(t *ast.Type, params []*ast.TypeParameter, bound map[string]*Type) *Type {
+	if tp := typeVariable(t, params); tp != nil && bound[tp.Name.Value] == nil {
+		return nil
+	}
+	return c.typeWith(t, params, bound)
+}
+
+// parameterType is the type of param inside a method with the type
+// parameters typeParams, which is an array for a variable arity parameter.
+func (c *Checker) parameterType(param *ast.Parameter, typeParams []*ast.TypeParameter) *Type {
+	t := c.typeIn(param.DataType, typeParams)
+	if param.Variadic {
+		t.Dimensions++
+	}
+	return t
+}
+
+func isPrimitive(name string) bool {
+	_, ok := boxes[name]
+	return ok
+}
+
+// boxes maps each primitive type to the class its values are boxed in.
+var boxes = map[string]string{
+	"byte":    "Byte",
+	"short":   "Short",
+	"char":    "Character",
+	"int":     "Integer",
+	"long":    "Long",
+	"float":   "Float",
+	"double":  "Double",
+	"boolean": "Boolean",
+}
+
+// widenings lists the primitive types each primitive type widens to.
+var widenings = map[string][]string{
+	"byte":  {"short", "int", "long", "float", "double"},
+	"short": {"int", "long", "float", "double"},
+	"char":  {"int", "long", "float", "double"},
+	"int":   {"long", "float", "double"},
+	"long":  {"float", "double"},
+	"float": {"double"},
+}
+
+// librarySupertypes lists the direct supertypes of the library classes the
+// checker knows about, other than Object.
+var librarySupertypes = map[string][]string{
+	"Byte":      {"Number", "Comparable"},
+	"Short":     {"Number", "Comparable"},
+	"Integer":   {"Number", "Comparable"},
+	"Long":      {"Number", "Comparable"},
+	"Float":     {"Number", "Comparable"},
+	"Double":    {"Number", "Comparable"},
+	"Character": {"Comparable"},
+	"Boolean":   {"Comparable"},
+	"String":    {"CharSequence", "Comparable"},
+}
+
+// isLibraryType reports whether name is Object or one of the library
+// classes in librarySupertypes.
+func isLibraryType(name string) bool {
+	_, ok := librarySupertypes[name]
+	return ok || name == "Object"
+}
+
+// isSubtype reports whether a value of type s can be used where a t is
+// expected without boxing or unboxing: if t is s or a supertype of s, or,
+// for primitive types, if s widens to t.
+func isSubtype(s, t *Type) bool {
+	if s == nil || t == nil {
+		return false
+	}
+	if s.String() == t.String() {
+		return true
+	}
+	if s.isPrimitive() || t.isPrimitive() {
+		if !s.isPrimitive() || !t.isPrimitive() {
+			return false
+		}
+		for _, w := range widenings[s.Name] {
+			if w == t.Name {
+				return true
+			}
+		}
+		return false
+	}
+
+	if s.Name == "<null>" || (t.Name == "Object" && t.Dimensions == 0) {
+		return true
+	}
+	if s.Dimensions > 0 || t.Dimensions > 0 {
+		return s.Dimensions == t.Dimensions && !isPrimitive(s.Name) && !isPrimitive(t.Name) &&
+			isSubtype(s.element(), t.element())
+	}
+	if s.Class != nil && t.Class != nil {
+		return s.Class.isSubtypeOf(t.Class)
+	}
+	for _, super := range librarySupertypes[s.Name] {
+		if isSubtype(&Type{Name: super}, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// isConvertible reports whether a value of type s can be passed where a t
+// is expected, allowing boxing and unboxing.
+func isConvertible(s, t *Type) bool {
+	if isSubtype(s, t) {
+		return true
+	}
+	if s == nil || t == nil {
+		return false
+	}
+	if s.isPrimitive() && !t.isPrimitive() {
+		return isSubtype(&Type{Name: boxes[s.Name]}, t)
+	}
+	if !s.isPrimitive() && t.isPrimitive() {
+		if unboxed := unbox(s); unboxed != nil {
+			return isSubtype(unboxed, t)
+		}
+	}
+	return false
+}
+
+// isCastable reports whether a value of the reference type s may be an
+// instance of the reference type t, so that the two can be compared with
+// ==: if one is a subtype of the other, or if one is an interface that a
+// subclass of the other may implement.
+func isCastable(s, t *Type) bool {
+	if isSubtype(s, t) || isSubtype(t, s) {
+		return true
+	}
+	if s.Dimensions > 0 || t.Dimensions > 0 {
+		return false
+	}
+	return isInterfaceType(s) && !isFinalType(t) || isInterfaceType(t) && !isFinalType(s)
+}
+
+func isInterfaceType(t *Type) bool {
+	if t.Class != nil {
+		return t.Class.isInterface()
+	}
+	return t.Name == "CharSequence"
+}
+
+// isFinalType reports whether t is a final class. String and the box
+// classes are.
+func isFinalType(t *Type) bool {
+	if t.Class != nil {
+		return t.Class.Decl.IsFinal()
+	}
+	_, ok := librarySupertypes[t.Name]
+	return ok
+}
+
+// unbox is the primitive type of the values of the box class t, or nil if
+// t is not a box class.
+func unbox(t *Type) *Type {
+	if t.Dimensions > 0 {
+		return nil
+	}
+	for primitive, box := range boxes {
+		if box == t.Name {
+			return &Type{Name: primitive}
+		}
+	}
+	return nil
+}
+
+// numericType is the type of an arithmetic operation on operands of types
+// l and r after binary numeric promotion, or nil if either is not numeric.
+func numericType(l, r *Type) *Type {
+	if l == nil || r == nil {
+		return nil
+	}
+	if u := unbox(l); u != nil {
+		l = u
+	}
+	if u := unbox(r); u != nil {
+		r = u
+	}
+	if !l.isNumeric() || !r.isNumeric() {
+		return nil
+	}
+	for _, name := range []string{"double", "float", "long"} {
+		if l.Name == name || r.Name == name {
+			return &Type{Name: name}
+		}
+	}
+	return intType
+}